@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+const adminerSiteName = "adminer"
+
+// adminerAsset is the single-file release with every driver and language
+var adminerAsset = regexp.MustCompile(`^adminer-[0-9.]+\.php$`)
+
+func adminerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "adminer",
+		Short: "Host Adminer at adminer.test",
+		Long:  `Adminer serves a single-file database UI as adminer.test using the default PHP version.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "on",
+		Short: "Download Adminer and serve it at adminer.test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminerOn()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Stop serving Adminer and remove it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminerOff()
+		},
+	})
+
+	return cmd
+}
+
+func runAdminerOn() error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	toolDir := filepath.Join(paths.Tools, "adminer")
+
+	fmt.Println("📥 Downloading Adminer...")
+	download, err := services.GitHubAsset("vrana/adminer", adminerAsset)
+	if err == nil {
+		err = services.DownloadVerified(download, filepath.Join(toolDir, "index.php"), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download Adminer: %w", err)
+	}
+	if err := services.ChownToInvokingUser(toolDir); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+
+	site := config.Site{
+		Name: adminerSiteName,
+		Path: toolDir,
+		Type: "link",
+	}
+	if err := registerSite(site, cfg); err != nil {
+		return err
+	}
+
+	fmt.Println("\n✅ Adminer is ready!")
+	fmt.Printf("   URL: %s\n", siteURL(&site, cfg))
+	fmt.Printf("   PHP: %s\n", cfg.DefaultPHP)
+
+	return nil
+}
+
+func runAdminerOff() error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	toolDir := filepath.Join(paths.Tools, "adminer")
+
+	if err := unregisterSite(adminerSiteName, toolDir); err != nil {
+		return err
+	}
+
+	if err := oplog.RemoveAll(toolDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", toolDir, err)
+	}
+
+	fmt.Println("\n✅ Adminer removed")
+	return nil
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest is a declarative description of a machine's sites, read by
+// `phppark apply`:
+//
+//	park:
+//	  - path: ~/Sites
+//	    php: "8.3"
+//	sites:
+//	  - name: api
+//	    path: ./api
+//	    php: "8.2"
+//	    secure: true
+//	    env:
+//	      APP_ENV: local
+//	  - name: vite
+//	    proxy: http://127.0.0.1:5173
+//	services:
+//	  - redis
+//	  - name: meilisearch
+//	    proxy: true
+type manifest struct {
+	Park     []manifestPark    `yaml:"park"`
+	Sites    []manifestSite    `yaml:"sites"`
+	Services []manifestService `yaml:"services"`
+}
+
+// manifestPark is a parked directory
+type manifestPark struct {
+	Path     string   `yaml:"path"`
+	PHP      string   `yaml:"php"`
+	Secure   bool     `yaml:"secure"`
+	Template string   `yaml:"template"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// manifestSite is a linked site
+type manifestSite struct {
+	Name     string            `yaml:"name"`
+	Path     string            `yaml:"path"` // Relative to the manifest (default: its directory)
+	PHP      string            `yaml:"php"`
+	Template string            `yaml:"template"`
+	Secure   bool              `yaml:"secure"`
+	Proxy    string            `yaml:"proxy"`
+	Env      map[string]string `yaml:"env"`
+}
+
+// manifestService is a managed service, given by name or as a mapping
+type manifestService struct {
+	Name  string `yaml:"name"`
+	Proxy bool   `yaml:"proxy"`
+}
+
+func (s *manifestService) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Name = node.Value
+		return nil
+	}
+	type plain manifestService
+	return node.Decode((*plain)(s))
+}
+
+// applyOptions holds flags for the apply command
+type applyOptions struct {
+	prune  bool // Unlink sites the manifest doesn't mention
+	dryRun bool // Only show what would change
+}
+
+// applyStep is one change apply makes
+type applyStep struct {
+	desc string
+	run  func() error
+}
+
+func applyCmd() *cobra.Command {
+	var opts applyOptions
+
+	cmd := &cobra.Command{
+		Use:   "apply <manifest>",
+		Short: "Converge sites to a manifest (e.g., phppark.yaml)",
+		Long: `Apply reads a manifest of parked directories, linked sites (with their PHP
+version, template, HTTPS, environment or proxy) and services, and makes the
+machine match it: missing sites are created, sites that differ are updated
+(or relinked, if their path or proxy changed) and missing services are
+installed. With --prune, linked and parked sites the manifest doesn't mention
+are unlinked. Paths are relative to the manifest, so it can be committed to
+a repository.
+
+  park:
+    - path: ~/Sites
+      php: "8.3"
+  sites:
+    - name: api
+      path: ./api
+      php: "8.2"
+      secure: true
+      env:
+        APP_ENV: local
+    - name: vite
+      proxy: http://127.0.0.1:5173
+  services:
+    - redis
+    - name: meilisearch
+      proxy: true`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.prune, "prune", false, "Unlink sites the manifest doesn't mention")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show what would change without changing anything")
+
+	return cmd
+}
+
+func runApply(file string, opts applyOptions) error {
+	m, err := loadManifest(file)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	steps := planApply(m, sites, cfg, paths, opts.prune)
+
+	if len(steps) == 0 {
+		fmt.Println("✅ Already matches the manifest")
+		return nil
+	}
+
+	if opts.dryRun {
+		fmt.Printf("📋 %d change(s) to apply:\n", len(steps))
+		for _, step := range steps {
+			fmt.Printf("   • %s\n", step.desc)
+		}
+		return nil
+	}
+
+	var failed []string
+	for _, step := range steps {
+		fmt.Printf("▶️  %s\n", step.desc)
+		if err := step.run(); err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			failed = append(failed, step.desc)
+		}
+		fmt.Println()
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("%d of %d change(s) failed: %s", len(failed), len(steps), strings.Join(failed, "; "))
+		if len(failed) < len(steps) {
+			return partialFailure(err)
+		}
+		return err
+	}
+
+	fmt.Printf("✅ Applied %d change(s)\n", len(steps))
+	return nil
+}
+
+// loadManifest reads and checks a manifest, resolving its paths
+func loadManifest(file string) (*manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	resolve := func(path string) (string, error) {
+		if path == "" {
+			return dir, nil
+		}
+		if !filepath.IsAbs(path) && path != "~" && !strings.HasPrefix(path, "~/") {
+			path = filepath.Join(dir, path)
+		}
+		return resolveSiteDir(path)
+	}
+
+	for i := range m.Park {
+		park := &m.Park[i]
+		if park.Path == "" {
+			return nil, fmt.Errorf("park entry %d has no path", i+1)
+		}
+		if park.Path, err = resolve(park.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range m.Sites {
+		site := &m.Sites[i]
+		if site.Name == "" {
+			return nil, fmt.Errorf("site entry %d has no name", i+1)
+		}
+		if seen[site.Name] {
+			return nil, fmt.Errorf("site '%s' is listed twice", site.Name)
+		}
+		seen[site.Name] = true
+
+		if site.Path, err = resolve(site.Path); err != nil {
+			return nil, err
+		}
+		if site.Template != "" {
+			if err := validateTemplate(site.Template); err != nil {
+				return nil, fmt.Errorf("site '%s': %w", site.Name, err)
+			}
+		}
+		for name, value := range site.Env {
+			if err := nginx.ValidateEnv(name, value); err != nil {
+				return nil, fmt.Errorf("site '%s': %w", site.Name, err)
+			}
+		}
+		site.Proxy = strings.TrimSuffix(site.Proxy, "/")
+		if site.PHP != "" {
+			site.PHP = php.FormatVersion(site.PHP)
+		}
+	}
+
+	for _, svc := range m.Services {
+		if _, ok := services.GetService(svc.Name); !ok {
+			return nil, i18n.Errorf(i18n.UnknownService, svc.Name)
+		}
+	}
+
+	return &m, nil
+}
+
+// planApply works out the steps that turn the registry into the manifest
+func planApply(m *manifest, sites *config.SiteRegistry, cfg *config.Config, paths *config.Paths, prune bool) []applyStep {
+	var steps []applyStep
+
+	// Services first, so sites can proxy to them
+	for _, svc := range m.Services {
+		managed, _ := services.GetService(svc.Name)
+		missingProxy := false
+		for _, p := range managed.Proxies {
+			if svc.Proxy && sites.FindSite(p.Site) == nil {
+				missingProxy = true
+			}
+		}
+		if managed.IsRunning() && !missingProxy {
+			continue
+		}
+		proxy := svc.Proxy
+		steps = append(steps, applyStep{
+			desc: "install service " + managed.Name,
+			run:  func() error { return installService(managed, proxy) },
+		})
+	}
+
+	parked := make(map[string]bool)
+	for _, park := range m.Park {
+		parked[park.Path] = true
+		if !hasNewSubdirs(park.Path, park.Exclude, sites) {
+			continue
+		}
+		opts := parkOptions{php: park.PHP, secure: park.Secure, template: park.Template, exclude: park.Exclude}
+		path := park.Path
+		steps = append(steps, applyStep{
+			desc: "park " + path,
+			run:  func() error { return runPark(path, opts) },
+		})
+	}
+
+	declared := make(map[string]bool)
+	for _, want := range m.Sites {
+		declared[want.Name] = true
+		steps = append(steps, planSite(want, sites.FindSite(want.Name), cfg)...)
+	}
+
+	if prune {
+		for _, site := range sites.ListSites() {
+			name := site.Name
+			switch {
+			case declared[name]:
+				continue
+			case strings.HasPrefix(site.Path, paths.Home+string(filepath.Separator)):
+				// Services and tools PHPark manages itself
+				continue
+			case site.Type == "park" && parked[site.ParkedIn]:
+				continue
+			case site.Type != "park" && site.Type != "link":
+				continue
+			}
+			steps = append(steps, applyStep{
+				desc: "unlink " + name + " (not in the manifest)",
+				run:  func() error { return runUnlink(name) },
+			})
+		}
+	}
+
+	return steps
+}
+
+// planSite returns the steps that bring one site in line with the manifest
+func planSite(want manifestSite, have *config.Site, cfg *config.Config) []applyStep {
+	link := applyStep{
+		desc: "link " + want.Name,
+		run: func() error {
+			opts := linkOptions{path: want.Path, php: want.PHP, secure: want.Secure, proxy: want.Proxy, template: want.Template}
+			if err := runLink(want.Name, opts); err != nil {
+				return err
+			}
+			if len(want.Env) == 0 {
+				return nil
+			}
+			return updateManifestSite(want.Name, cfg, func(site *config.Site) {
+				site.Env = maps.Clone(want.Env)
+			})
+		},
+	}
+
+	if have == nil {
+		return []applyStep{link}
+	}
+
+	// A new path or upstream means a different site: start over
+	if have.Type != "link" || have.Path != want.Path || have.Proxy != want.Proxy || have.Builtin || have.Octane {
+		link.desc = "relink " + want.Name + " (path or proxy changed)"
+		run := link.run
+		link.run = func() error {
+			if err := runUnlink(want.Name); err != nil {
+				return err
+			}
+			return run()
+		}
+		return []applyStep{link}
+	}
+
+	var steps []applyStep
+	var changes []string
+	if have.PHPVersion != want.PHP {
+		changes = append(changes, "php "+valueOr(want.PHP, "default"))
+	}
+	if have.Template != want.Template {
+		changes = append(changes, "template "+valueOr(want.Template, "detected"))
+	}
+	if !maps.Equal(have.Env, want.Env) {
+		changes = append(changes, "env")
+	}
+	if len(changes) > 0 {
+		steps = append(steps, applyStep{
+			desc: fmt.Sprintf("update %s (%s)", want.Name, strings.Join(changes, ", ")),
+			run: func() error {
+				phpVersion := want.PHP
+				if phpVersion != "" {
+					var err error
+					if phpVersion, err = ensurePHPVersion(phpVersion, cfg, true); err != nil {
+						return err
+					}
+				}
+				return updateManifestSite(want.Name, cfg, func(site *config.Site) {
+					site.PHPVersion = phpVersion
+					site.Template = want.Template
+					site.Env = maps.Clone(want.Env)
+				})
+			},
+		})
+	}
+
+	if want.Secure && !have.Secured {
+		steps = append(steps, applyStep{
+			desc: "secure " + want.Name,
+			run:  func() error { return runSecure(want.Name, false) },
+		})
+	} else if !want.Secure && have.Secured && !cfg.UseHTTPS {
+		steps = append(steps, applyStep{
+			desc: "unsecure " + want.Name,
+			run:  func() error { return runUnsecure(want.Name) },
+		})
+	}
+
+	return steps
+}
+
+// updateManifestSite changes a site's record and redeploys its config
+func updateManifestSite(name string, cfg *config.Config, change func(site *config.Site)) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(name)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, name)
+	}
+
+	change(site)
+	if len(site.Env) == 0 {
+		site.Env = nil
+	}
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	return generateNginxConfig(site, cfg)
+}
+
+// hasNewSubdirs reports whether parking a directory would add any sites
+func hasNewSubdirs(dir string, exclude []string, sites *config.SiteRegistry) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Let park report it
+		return true
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() && name[0] != '.' && matchExclude(name, exclude) == "" && sites.FindSite(name) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// valueOr returns value, or fallback when it's empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// auditFinding is one risky state `phppark audit` found
+type auditFinding struct {
+	risk    bool   // A real exposure, rather than something to be aware of
+	subject string // What it's about, e.g., a site or a file
+	problem string
+	fix     string
+}
+
+func auditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "audit",
+		Short: "Flag risky states for a security review",
+		Long: `Audit looks for states that expose more than a development machine should:
+a home directory readable by every user (what older PHPark versions' chmod
+left behind), sites served from a project root that holds a .env, sites
+listening on every interface while nothing is opened to the LAN, private keys
+other users can read, and HTTPS sites whose certificate is missing or expired.
+The home directories checked are the ones sites are registered under, and
+yours (the one behind sudo, if any). It changes nothing; each finding comes
+with the command that fixes it. Exits non-zero if anything was found.`,
+		Args: cobra.NoArgs,
+		// Findings are a result, not a usage mistake
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit()
+		},
+	}
+}
+
+func runAudit() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🔍 Auditing PHPark...")
+	fmt.Println()
+
+	var findings []auditFinding
+	findings = append(findings, auditHome(sites.ListSites())...)
+	findings = append(findings, auditDocRoots(sites.ListSites(), cfg)...)
+	findings = append(findings, auditListen(sites.ListSites(), cfg, paths)...)
+	findings = append(findings, auditKeys(paths)...)
+	findings = append(findings, auditCertificates(sites.ListSites(), cfg, paths)...)
+
+	if len(findings) == 0 {
+		fmt.Println("✅ Nothing to flag")
+		return nil
+	}
+
+	risks := 0
+	for _, f := range findings {
+		icon := "⚠️ "
+		if f.risk {
+			icon = "❌"
+			risks++
+		}
+		fmt.Printf("%s %s: %s\n", icon, f.subject, f.problem)
+		if f.fix != "" {
+			fmt.Printf("   Fix: %s\n", f.fix)
+		}
+	}
+
+	fmt.Printf("\n📋 %d finding(s), %d of them risks\n", len(findings), risks)
+	return fmt.Errorf("%d finding(s)", len(findings))
+}
+
+// auditHome flags a home directory other users can list: the invoking
+// user's, and each site owner's that the site lives under. The web server
+// only needs to traverse them (o+x), which PHPark now limits itself to.
+func auditHome(sites []config.Site) []auditFinding {
+	var homes []string
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		if u, err := user.Lookup(name); err == nil {
+			homes = append(homes, u.HomeDir)
+		}
+	} else if home, err := os.UserHomeDir(); err == nil {
+		homes = append(homes, home)
+	}
+	for _, site := range sites {
+		owner := pathOwner(site.Path)
+		if owner == nil || owner.HomeDir == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(owner.HomeDir, site.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			homes = append(homes, owner.HomeDir)
+		}
+	}
+
+	var findings []auditFinding
+	seen := make(map[string]bool)
+	for _, home := range homes {
+		home = filepath.Clean(home)
+		if seen[home] {
+			continue
+		}
+		seen[home] = true
+
+		info, err := os.Stat(home)
+		if err != nil || info.Mode().Perm()&0004 == 0 {
+			continue
+		}
+		findings = append(findings, auditFinding{
+			risk:    true,
+			subject: home,
+			problem: fmt.Sprintf("readable by every user (%#o)", info.Mode().Perm()),
+			fix:     "chmod o-r " + home + " (sites keep working: the web server only needs o+x)",
+		})
+	}
+	return findings
+}
+
+// auditDocRoots flags sites served from their project root when it holds a
+// .env: everything next to it (composer.json, storage/, vendor/) is served,
+// and so is the .env itself under a template that doesn't deny dotfiles
+func auditDocRoots(sites []config.Site, cfg *config.Config) []auditFinding {
+	var findings []auditFinding
+	for i := range sites {
+		site := &sites[i]
+		if site.Proxy != "" {
+			continue
+		}
+
+		root := siteDocumentRoot(site, cfg)
+		if templateRoot := nginx.TemplateDocumentRoot(siteTemplate(site), site.Path); templateRoot != "" && site.DocRoot == "" {
+			root = templateRoot
+		}
+		if filepath.Clean(root) != filepath.Clean(site.Path) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(site.Path, ".env")); err != nil {
+			continue
+		}
+
+		findings = append(findings, auditFinding{
+			risk:    true,
+			subject: site.Name + "." + cfg.SiteDomain(),
+			problem: "served from its project root, which holds a .env",
+			fix:     fmt.Sprintf("phppark docroot %s <dir> (e.g., public)", site.Name),
+		})
+	}
+	return findings
+}
+
+// listenAllPattern matches nginx listen directives without an address,
+// which bind every interface
+var listenAllPattern = regexp.MustCompile(`(?m)^\s*listen\s+(?:0\.0\.0\.0:|\[::\]:)?(\d+)\b`)
+
+// auditListen flags sites nginx serves on every interface while nothing is
+// opened to the LAN (lan enabled, or dnsmasq answering on a LAN address),
+// since anyone on the network can reach them with a Host header
+func auditListen(sites []config.Site, cfg *config.Config, paths *config.Paths) []auditFinding {
+	if lanDNS(cfg) {
+		return nil
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil || server.Name() != "nginx" || cfg.Backend == "docker" {
+		return nil
+	}
+
+	var exposed []string
+	for _, site := range sites {
+		if site.Builtin {
+			continue
+		}
+		content, err := os.ReadFile(server.ConfigPath(paths, site.Name))
+		if err != nil {
+			continue
+		}
+		if listenAllPattern.Match(content) {
+			exposed = append(exposed, site.Name+"."+cfg.SiteDomain())
+		}
+	}
+	if len(exposed) == 0 {
+		return nil
+	}
+	sort.Strings(exposed)
+
+	subject := exposed[0]
+	if len(exposed) > 1 {
+		subject = fmt.Sprintf("%d sites (%s, ...)", len(exposed), exposed[0])
+	}
+	return []auditFinding{{
+		subject: subject,
+		problem: "nginx listens on 0.0.0.0 (every interface): reachable from the LAN although no LAN access is set up",
+		fix:     "block ports 80 and 443 from the network (e.g., sudo ufw deny 80,443/tcp) unless you mean to share them",
+	}}
+}
+
+// lanDNS reports whether sites are opened to other machines: lan is
+// enabled, or dnsmasq is set to answer on a LAN address
+func lanDNS(cfg *config.Config) bool {
+	if cfg.LANDomain() != "" {
+		return true
+	}
+	for _, addr := range cfg.DNS.ListenAddress {
+		if ip := net.ParseIP(addr); ip != nil && !ip.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+// auditKeys flags private keys other users can read, a key directory
+// they can list, and keys still kept beside their certificates
+func auditKeys(paths *config.Paths) []auditFinding {
+	var findings []auditFinding
+
+	keyDir := filepath.Join(paths.Certificates, ssl.KeyDirName)
+	if info, err := os.Stat(keyDir); err == nil && info.Mode().Perm()&0077 != 0 {
+		findings = append(findings, auditFinding{
+			risk:    true,
+			subject: keyDir,
+			problem: fmt.Sprintf("private key directory open to others (%#o)", info.Mode().Perm()),
+			fix:     "chmod 700 " + keyDir,
+		})
+	}
+
+	// Keys that moved leave a link behind; only files are still to move
+	var legacy []string
+	candidates, _ := filepath.Glob(filepath.Join(paths.Certificates, "*.key"))
+	for _, key := range candidates {
+		if info, err := os.Lstat(key); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		legacy = append(legacy, key)
+		findings = append(findings, auditFinding{
+			subject: key,
+			problem: "private key kept beside the certificates rather than in " + ssl.KeyDirName + "/",
+			fix:     "sudo phppark rebuild (moves keys into " + keyDir + ")",
+		})
+	}
+
+	keys, _ := filepath.Glob(filepath.Join(keyDir, "*.key"))
+	for _, key := range append(legacy, keys...) {
+		info, err := os.Stat(key)
+		if err != nil || info.Mode().Perm()&0077 == 0 {
+			continue
+		}
+		findings = append(findings, auditFinding{
+			risk:    true,
+			subject: key,
+			problem: fmt.Sprintf("private key readable by others (%#o)", info.Mode().Perm()),
+			fix:     "chmod 600 " + key,
+		})
+	}
+	return findings
+}
+
+// auditCertificates flags HTTPS sites whose certificate is missing or
+// expired. Self-signed ones aren't flagged: that's what PHPark issues.
+func auditCertificates(sites []config.Site, cfg *config.Config, paths *config.Paths) []auditFinding {
+	var findings []auditFinding
+	for _, site := range sites {
+		if !site.Secured {
+			continue
+		}
+		hostname := site.Name + "." + cfg.SiteDomain()
+
+		expiry, err := ssl.CertificateExpiry(site.Name, paths.Certificates)
+		if err != nil {
+			findings = append(findings, auditFinding{
+				risk:    true,
+				subject: hostname,
+				problem: "served over HTTPS without a readable certificate",
+				fix:     "phppark secure " + site.Name,
+			})
+			continue
+		}
+
+		if time.Now().After(expiry) {
+			findings = append(findings, auditFinding{
+				risk:    true,
+				subject: hostname,
+				problem: "certificate expired on " + expiry.Format("2006-01-02"),
+				fix:     "phppark renew " + site.Name,
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/health"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// benchOptions holds flags for the bench command
+type benchOptions struct {
+	requests    int
+	concurrency int
+	path        string
+	useDNS      bool
+	timeout     time.Duration
+}
+
+func benchCmd() *cobra.Command {
+	var opts benchOptions
+
+	cmd := &cobra.Command{
+		Use:   "bench <site>",
+		Short: "Load-test a site and report latency percentiles",
+		Long: `Bench sends --requests GET requests to a site, --concurrency at a time over
+keepalive connections, and reports throughput, latency percentiles, status
+codes and errors. Like 'phppark test' it goes to 127.0.0.1 with the site's Host
+header (HTTPS for secured sites) unless --dns is given.
+
+Run it before and after a change — opcache settings, PHP-FPM pool sizes,
+micro-caching — to see what the change bought. Redirects are not followed, so
+bench the path the site actually serves.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(args[0], opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.requests, "requests", "n", 500, "Total requests to send")
+	cmd.Flags().IntVarP(&opts.concurrency, "concurrency", "c", 20, "Requests in flight at once")
+	cmd.Flags().StringVar(&opts.path, "path", "/", "Path to request")
+	cmd.Flags().BoolVar(&opts.useDNS, "dns", false, "Resolve the site's hostname through DNS instead of 127.0.0.1")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 30*time.Second, "Timeout per request")
+
+	return cmd
+}
+
+func runBench(siteName string, opts benchOptions) error {
+	if opts.requests < 1 {
+		return fmt.Errorf("--requests must be at least 1")
+	}
+	if opts.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	opts.concurrency = min(opts.concurrency, opts.requests)
+
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	url := siteURL(site, cfg) + "/" + strings.TrimPrefix(opts.path, "/")
+
+	fmt.Printf("🏋️  Benchmarking %s (%d requests, %d concurrent)...\n\n", url, opts.requests, opts.concurrency)
+
+	result := health.Bench(health.Target{URL: url, Local: !opts.useDNS}, health.BenchOptions{
+		Requests:    opts.requests,
+		Concurrency: opts.concurrency,
+		Timeout:     opts.timeout,
+	})
+
+	fmt.Println("=== Throughput ===")
+	fmt.Printf("   %.1f req/s over %s\n", result.RequestsPerSecond(), result.Elapsed.Round(time.Millisecond))
+
+	if len(result.Latencies) > 0 {
+		fmt.Println("\n=== Latency ===")
+		for _, p := range []float64{50, 90, 95, 99} {
+			fmt.Printf("   p%-3.0f %8s\n", p, formatMillis(result.Percentile(p)))
+		}
+		fmt.Printf("   max  %8s\n", formatMillis(result.Latencies[len(result.Latencies)-1]))
+	}
+
+	if len(result.StatusCodes) > 0 {
+		codes := make([]int, 0, len(result.StatusCodes))
+		for code := range result.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+
+		fmt.Println("\n=== Status codes ===")
+		for _, code := range codes {
+			icon := "✅"
+			switch {
+			case code >= 500:
+				icon = "❌"
+			case code >= 400:
+				icon = "⚠️ "
+			case code >= 300:
+				icon = "↪️ "
+			}
+			fmt.Printf("   %s %d  %6d\n", icon, code, result.StatusCodes[code])
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		messages := make([]string, 0, len(result.Errors))
+		for message := range result.Errors {
+			messages = append(messages, message)
+		}
+		sort.Slice(messages, func(i, j int) bool { return result.Errors[messages[i]] > result.Errors[messages[j]] })
+
+		fmt.Println("\n=== Errors ===")
+		for _, message := range messages {
+			fmt.Printf("   ❌ %6d  %s\n", result.Errors[message], message)
+		}
+	}
+
+	if failed := result.Failed(); failed > 0 {
+		fmt.Printf("\n⚠️  %d of %d request(s) failed\n", failed, result.Requests)
+	} else {
+		fmt.Printf("\n✅ All %d request(s) succeeded\n", result.Requests)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/dns"
+)
+
+// builtinProcessName is the supervised `php -S` process for builtin sites
+const builtinProcessName = "server"
+
+// builtinPortStart is the first port assigned to builtin sites
+const builtinPortStart = 8100
+
+// assignPort returns the first port from builtinPortStart that no site is
+// registered on and nothing is currently listening on
+func assignPort(sites *config.SiteRegistry) (int, error) {
+	used := make(map[int]bool)
+	for _, site := range sites.ListSites() {
+		if site.Port != 0 {
+			used[site.Port] = true
+		}
+	}
+
+	for port := builtinPortStart; port <= 65535; port++ {
+		if used[port] {
+			continue
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port between %d and 65535 for the built-in server", builtinPortStart)
+}
+
+// builtinCommand returns the `php -S` command serving a site's document root.
+// Without a router script PHP falls back to index.php, which suits front
+// controllers.
+func builtinCommand(site *config.Site, cfg *config.Config) string {
+	docRoot := siteDocumentRoot(site, cfg)
+	return fmt.Sprintf("php -S 127.0.0.1:%d -t %s", site.Port, shellJoin([]string{docRoot}))
+}
+
+// builtinURL returns the port-based URL a builtin site is served at
+func builtinURL(site *config.Site, domain string) string {
+	return fmt.Sprintf("http://%s.%s:%d", site.Name, domain, site.Port)
+}
+
+// mapBuiltinHost makes a builtin site's hostname resolve when dnsmasq isn't
+// configured: an /etc/hosts entry as root, otherwise a hint to use 127.0.0.1
+func mapBuiltinHost(site *config.Site, cfg *config.Config) {
+	if configured, _ := dnsConfigured(cfg); configured {
+		return
+	}
+
+	hostname := site.Name + "." + cfg.SiteDomain()
+	if os.Geteuid() != 0 {
+		fmt.Printf("   💡 %s won't resolve without dnsmasq, use http://127.0.0.1:%d\n", hostname, site.Port)
+		return
+	}
+
+	if err := dns.AddHostsEntry(hostname); err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not map %s: %v\n", hostname, err)
+	} else {
+		fmt.Printf("   ✅ Mapped %s in /etc/hosts\n", hostname)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+func cleanCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove nginx configs left behind by removed sites",
+		Long: `Clean finds the nginx configs PHPark generated (tagged with a "Generated by
+PHPark" first line) for sites no longer in the registry, e.g., ones removed
+by hand from sites.json, and removes them after confirmation. Configs PHPark
+didn't generate are never touched. Configs from before the tag was added get
+it on the next 'phppark rebuild'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClean(force)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+
+	return cmd
+}
+
+func runClean(force bool) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+	if server.Name() != "nginx" || cfg.Backend == "docker" {
+		return fmt.Errorf("clean only handles the native nginx web server")
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	orphans := orphanedConfigs(sites.ListSites(), paths)
+	if len(orphans) == 0 {
+		fmt.Println("✅ No stale nginx configs")
+		return nil
+	}
+
+	fmt.Printf("🧹 Found %d stale nginx config(s):\n", len(orphans))
+	for _, orphan := range orphans {
+		fmt.Printf("   • %s (%s)\n", orphan.path, orphan.site)
+	}
+
+	if !force {
+		fmt.Printf("\n   Remove them? (y/N): ")
+
+		var response string
+		fmt.Scanln(&response)
+
+		if response != "y" && response != "Y" && response != "yes" {
+			fmt.Println(i18n.T(i18n.Cancelled))
+			return nil
+		}
+	}
+	fmt.Println()
+
+	return removeOrphanedConfigs(orphans, paths)
+}
+
+// removeOrphanedConfigs deletes stale configs, then tests and reloads nginx
+// if any of them were deployed
+func removeOrphanedConfigs(orphans []orphanedConfig, paths *config.Paths) error {
+	removedDeployed := false
+	var failed []string
+	for _, orphan := range orphans {
+		if err := oplog.Remove(orphan.path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("   ❌ %s: %v\n", orphan.path, err)
+			failed = append(failed, orphan.path)
+			continue
+		}
+		fmt.Printf("   🗑️  Removed %s\n", orphan.path)
+		if !strings.HasPrefix(orphan.path, paths.Nginx+string(filepath.Separator)) {
+			removedDeployed = true
+		}
+	}
+
+	if removedDeployed {
+		if err := services.TestNginxConfig(); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+			printConfigTestDetail(err, "   ")
+		} else if err := services.ReloadNginx(); err != nil {
+			fmt.Printf("⚠️  Warning: Could not reload nginx: %v\n", err)
+		} else {
+			fmt.Println("\n✅ Reloaded nginx")
+		}
+	}
+
+	if len(failed) > 0 {
+		err := i18n.Errorf(i18n.RemoveTrySudo, strings.Join(failed, ", "))
+		if len(failed) < len(orphans) {
+			return partialFailure(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// orphanedConfig is a generated config whose site is gone
+type orphanedConfig struct {
+	path string
+	site string // The site named in its Generated by PHPark tag
+}
+
+// orphanedConfigs finds the configs PHPark generated, deployed or in
+// ~/.phppark/nginx, for sites that aren't registered. In multi-user mode
+// only this user's deployed configs are considered.
+func orphanedConfigs(sites []config.Site, paths *config.Paths) []orphanedConfig {
+	deployed := make(map[string]bool)
+	local := make(map[string]bool)
+	for _, site := range sites {
+		if !site.Builtin {
+			// Both carry the shared name in their Generated by PHPark tag
+			deployed[sharedName(site.Name)] = true
+			local[sharedName(site.Name)] = true
+		}
+	}
+
+	var orphans []orphanedConfig
+	check := func(files []string, known map[string]bool, mine func(string) bool) {
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			site := nginx.GeneratedFor(content)
+			if site != "" && !known[site] && mine(site) {
+				orphans = append(orphans, orphanedConfig{path: file, site: site})
+			}
+		}
+	}
+
+	check(services.NginxSiteConfigs(), deployed, func(site string) bool {
+		return userNamespace == "" || strings.HasSuffix(site, "."+userNamespace)
+	})
+
+	localFiles, _ := filepath.Glob(filepath.Join(paths.Nginx, "*.conf"))
+	check(localFiles, local, func(string) bool { return true })
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].path < orphans[j].path })
+	return orphans
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/php"
+)
+
+// completePHPVersions completes installed PHP versions, from the detector
+// cache so a keypress doesn't run every PHP binary
+func completePHPVersions(toComplete string) []string {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return nil
+	}
+
+	versions, err := php.DetectPHPVersionsCached(paths.PHPCache)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, v := range versions {
+		if strings.HasPrefix(v.Version, toComplete) {
+			matches = append(matches, v.Version)
+		}
+	}
+	return matches
+}
+
+// completeSiteNames completes registered site names
+func completeSiteNames(toComplete string) []string {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, site := range sites.ListSites() {
+		if strings.HasPrefix(site.Name, toComplete) {
+			matches = append(matches, site.Name)
+		}
+	}
+	return matches
+}
+
+// completeUse completes `phppark use <php-version> [site]`
+func completeUse(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completePHPVersions(toComplete), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		return completeSiteNames(toComplete), cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/database"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// daemonUnitName is the systemd unit running the API daemon
+const daemonUnitName = "phppark-daemon"
+
+func daemonCmd() *cobra.Command {
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Serve a local REST API for editors and GUIs",
+		Long: `Daemon serves an HTTP API on a unix socket (default ~/.phppark/daemon.sock) or a
+localhost address, so tools can drive PHPark without parsing CLI output.
+Requests must send "Authorization: Bearer <token>" with the token from
+~/.phppark/daemon.token.
+
+  GET    /sites                  List sites
+  GET    /sites/{name}           Show a site
+  POST   /sites                  Link a site: {"name": "...", "path": "..."}
+  DELETE /sites/{name}           Unlink a site
+  POST   /sites/{name}/secure    Enable HTTPS
+  DELETE /sites/{name}/secure    Disable HTTPS
+  POST   /rebuild                Rebuild all site configs
+  GET    /sites/{name}/logs      Tail nginx logs (?type=error|access&lines=100)`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(listen)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "", "Listen on a localhost address (e.g., 127.0.0.1:7070) instead of the unix socket")
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Run the API in the background with systemd",
+		Long: `Install writes and enables a systemd unit for the API, restarted if it fails
+and logging to the journal (journalctl -u phppark-daemon). Without sudo it's a
+user unit running as you; with sudo, a system unit running as root, using
+root's ~/.phppark (and token) like sudo phppark does. The health watcher has
+its own: phppark watch install.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemonInstall(listen)
+		},
+	}
+	installCmd.Flags().StringVar(&listen, "listen", "", "Listen on a localhost address (e.g., 127.0.0.1:7070) instead of the unix socket")
+
+	cmd.AddCommand(installCmd)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the background API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := services.RemoveUnit(daemonUnitName); err != nil {
+				return err
+			}
+			fmt.Println("✅ API daemon removed")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func runDaemonInstall(listen string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	// Generate the token now, so it can be handed to tools right away
+	if _, err := daemonToken(paths); err != nil {
+		return err
+	}
+
+	args := []string{exe, "daemon"}
+	if listen != "" {
+		args = append(args, "--listen", listen)
+	} else {
+		listen = daemonSocket(paths)
+	}
+
+	unit := &services.Unit{
+		Name:        daemonUnitName,
+		Description: "PHPark API daemon",
+		ExecStart:   args,
+	}
+	// Under sudo the daemon deploys configs like sudo phppark would, and
+	// reads the token from the home it was just written to
+	if !services.UserScope() {
+		unit.User = "root"
+	}
+	if err := services.InstallUnit(unit); err != nil {
+		return err
+	}
+
+	scope := "system"
+	if services.UserScope() {
+		scope = "user"
+	}
+	fmt.Printf("✅ API daemon running on %s (%s unit %s)\n", listen, scope, daemonUnitName)
+	fmt.Printf("   Token: %s\n", filepath.Join(paths.Home, "daemon.token"))
+	return nil
+}
+
+func runDaemon(listen string) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	token, err := daemonToken(paths)
+	if err != nil {
+		return err
+	}
+
+	var listener net.Listener
+	if listen == "" {
+		socket := daemonSocket(paths)
+		os.Remove(socket) // Left behind by a previous run
+
+		listener, err = net.Listen("unix", socket)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", socket, err)
+		}
+		// Only the owner may connect
+		if err := os.Chmod(socket, 0600); err != nil {
+			return fmt.Errorf("failed to secure %s: %w", socket, err)
+		}
+		listen = socket
+	} else {
+		host, _, err := net.SplitHostPort(listen)
+		if err != nil {
+			return fmt.Errorf("invalid listen address: %w", err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("refusing to listen on %s: use a loopback address", listen)
+		}
+
+		listener, err = net.Listen("tcp", listen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", listen, err)
+		}
+	}
+
+	fmt.Printf("🛰️  PHPark API listening on %s\n", listen)
+	fmt.Printf("   Token: %s\n", filepath.Join(paths.Home, "daemon.token"))
+
+	return http.Serve(listener, requireToken(token, newAPI(paths.Oplog)))
+}
+
+// daemonSocket is the default unix socket for the API
+func daemonSocket(paths *config.Paths) string {
+	return filepath.Join(paths.Home, "daemon.sock")
+}
+
+// daemonToken reads the API token, generating it on first use
+func daemonToken(paths *config.Paths) (string, error) {
+	path := filepath.Join(paths.Home, "daemon.token")
+
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+
+	token, err := database.GeneratePassword()
+	if err != nil {
+		return "", err
+	}
+
+	if err := oplog.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return token, nil
+}
+
+// requireToken rejects requests without the bearer token
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, apiResponse{Error: "invalid or missing token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiResponse is the body of every API response. Output carries what the
+// equivalent CLI command printed.
+type apiResponse struct {
+	OK     bool   `json:"ok"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Data   any    `json:"data,omitempty"`
+}
+
+// api serializes commands: they share stdout capture and the registry
+type api struct {
+	mu    sync.Mutex
+	oplog string
+}
+
+func newAPI(oplogPath string) http.Handler {
+	a := &api{oplog: oplogPath}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /sites", a.listSites)
+	mux.HandleFunc("GET /sites/{name}", a.getSite)
+	mux.HandleFunc("POST /sites", a.linkSite)
+	mux.HandleFunc("DELETE /sites/{name}", a.command(func(r *http.Request) error {
+		return runUnlink(r.PathValue("name"))
+	}))
+	mux.HandleFunc("POST /sites/{name}/secure", a.command(func(r *http.Request) error {
+		return runSecure(r.PathValue("name"), false)
+	}))
+	mux.HandleFunc("DELETE /sites/{name}/secure", a.command(func(r *http.Request) error {
+		return runUnsecure(r.PathValue("name"))
+	}))
+	mux.HandleFunc("POST /rebuild", a.command(func(r *http.Request) error {
+		return runRebuild(nil, rebuildOptions{})
+	}))
+	mux.HandleFunc("GET /sites/{name}/logs", a.siteLogs)
+
+	return mux
+}
+
+// command wraps a CLI command as a handler
+func (a *api) command(fn func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.mu.Lock()
+		oplog.Open(a.oplog, "phppark daemon: "+r.Method+" "+r.URL.Path)
+		output, err := captureOutput(func() error { return fn(r) })
+		a.mu.Unlock()
+
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, apiResponse{Output: output, Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, apiResponse{OK: true, Output: output})
+	}
+}
+
+func (a *api) listSites(w http.ResponseWriter, r *http.Request) {
+	sites, err := config.LoadSites()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{OK: true, Data: sites.ListSites()})
+}
+
+func (a *api) getSite(w http.ResponseWriter, r *http.Request) {
+	site, err := config.GetSite(r.PathValue("name"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiResponse{Error: err.Error()})
+		return
+	}
+	if site == nil {
+		writeJSON(w, http.StatusNotFound, apiResponse{Error: "site not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{OK: true, Data: site})
+}
+
+func (a *api) linkSite(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiResponse{Error: "invalid JSON body"})
+		return
+	}
+	if !filepath.IsAbs(req.Path) {
+		writeJSON(w, http.StatusBadRequest, apiResponse{Error: "path must be absolute"})
+		return
+	}
+
+	a.command(func(r *http.Request) error {
+		return runLink(req.Name, linkOptions{path: req.Path})
+	})(w, r)
+}
+
+func (a *api) siteLogs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiResponse{Error: err.Error()})
+		return
+	}
+	if sites.FindSite(name) == nil {
+		writeJSON(w, http.StatusNotFound, apiResponse{Error: "site not found"})
+		return
+	}
+
+	logType := r.URL.Query().Get("type")
+	if logType == "" {
+		logType = "error"
+	}
+	if logType != "error" && logType != "access" {
+		writeJSON(w, http.StatusBadRequest, apiResponse{Error: "type must be error or access"})
+		return
+	}
+
+	lines := 100
+	if n, err := strconv.Atoi(r.URL.Query().Get("lines")); err == nil && n > 0 {
+		lines = n
+	}
+
+	path := siteLogPath(name, logType+".log")
+	tail, err := tailFile(path, lines)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{OK: true, Data: tail})
+}
+
+// tailFile returns the last n lines of a file
+func tailFile(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// captureOutput runs fn with stdout redirected, returning what it printed
+func captureOutput(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fnErr := fn()
+
+	os.Stdout = stdout
+	w.Close()
+	output := <-done
+	r.Close()
+
+	return output, fnErr
+}
+
+func writeJSON(w http.ResponseWriter, status int, body apiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/database"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func dbCreateCmd() *cobra.Command {
+	var withUser bool
+	var charset, collation string
+
+	cmd := &cobra.Command{
+		Use:   "db:create <site>",
+		Short: "Create a database for a site",
+		Long:  `Db:create creates a MySQL/MariaDB database named after a site and prints .env credentials.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDBCreate(args[0], withUser, charset, collation)
+		},
+	}
+
+	cmd.Flags().BoolVar(&withUser, "user", false, "Create a dedicated database user")
+	cmd.Flags().StringVar(&charset, "charset", "", "Character set (default from config)")
+	cmd.Flags().StringVar(&collation, "collation", "", "Collation (default: the charset's usual one)")
+
+	return cmd
+}
+
+func runDBCreate(siteName string, withUser bool, charset, collation string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if charset != "" {
+		cfg.Database.Charset = charset
+		cfg.Database.Collation = database.DefaultCollation(charset)
+	}
+	if collation != "" {
+		cfg.Database.Collation = collation
+	}
+	if err := database.CheckCollation(cfg.Database.Charset, cfg.Database.Collation); err != nil {
+		return err
+	}
+
+	if err := createSiteDatabase(site, cfg, withUser); err != nil {
+		return err
+	}
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	return nil
+}
+
+// createSiteDatabase creates the site's database (and optionally a user),
+// records it on the site, and prints ready-to-paste .env credentials.
+// The caller is responsible for saving the registry.
+func createSiteDatabase(site *config.Site, cfg *config.Config, withUser bool) error {
+	if !database.IsInstalled() {
+		return i18n.Errorf(i18n.MySQLNotFound)
+	}
+
+	server := databaseServer(cfg)
+	dbName := database.DatabaseName(site.Name)
+
+	fmt.Printf("🗄️  Creating database: %s\n", dbName)
+	if err := server.CreateDatabase(dbName, cfg.Database.Charset, cfg.Database.Collation); err != nil {
+		return err
+	}
+	site.Database = dbName
+
+	// Sites without a dedicated user share the admin account, whose
+	// password is never printed
+	username := cfg.Database.AdminUser
+	password := ""
+
+	if withUser {
+		generated, err := database.GeneratePassword()
+		if err != nil {
+			return err
+		}
+		username = database.UserName(dbName)
+		password = generated
+
+		fmt.Printf("👤 Creating user: %s\n", username)
+		if err := server.CreateUser(username, password, dbName); err != nil {
+			return err
+		}
+		site.DatabaseUser = username
+	}
+
+	printDatabaseEnv(cfg, dbName, username, password)
+	return nil
+}
+
+// printDatabaseEnv prints .env lines for a site's database. An empty
+// password means the admin account, whose password is left for the user to
+// fill in.
+func printDatabaseEnv(cfg *config.Config, dbName, username, password string) {
+	fmt.Println("\n✅ Database ready! Add to your .env:")
+	fmt.Println()
+	fmt.Println("DB_CONNECTION=mysql")
+	fmt.Printf("DB_HOST=%s\n", cfg.Database.Host)
+	fmt.Printf("DB_PORT=%d\n", cfg.Database.Port)
+	fmt.Printf("DB_DATABASE=%s\n", dbName)
+	fmt.Printf("DB_USERNAME=%s\n", username)
+	fmt.Printf("DB_PASSWORD=%s\n", password)
+	if password == "" && cfg.Database.AdminPassword != "" {
+		fmt.Println("\n   DB_PASSWORD is the admin password (database.admin_password in config.yaml)")
+		fmt.Println("   For a password of the site's own: phppark db:create <site> --user")
+	}
+}
+
+// databaseServer builds the admin connection from config. Without an admin
+// password PHPark relies on unix socket authentication.
+func databaseServer(cfg *config.Config) *database.Server {
+	server := &database.Server{
+		Host:          "localhost",
+		Port:          cfg.Database.Port,
+		AdminUser:     cfg.Database.AdminUser,
+		AdminPassword: cfg.Database.AdminPassword,
+	}
+	if cfg.Database.AdminPassword != "" {
+		server.Host = cfg.Database.Host
+	}
+	return server
+}
+
+func dbDropCmd() *cobra.Command {
+	var force bool
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "db:drop <site>",
+		Short: "Drop a site's database",
+		Long: `Db:drop drops the database (and dedicated user) created for a site. A site
+with no database on record needs the one to drop named with --database.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDBDrop(args[0], name, force)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+	cmd.Flags().StringVar(&name, "database", "", "Database to drop when the site has none on record")
+
+	return cmd
+}
+
+func runDBDrop(siteName, name string, force bool) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	// Never guess: a database named like the site may belong to something else
+	dbName := site.Database
+	switch {
+	case dbName == "" && name == "":
+		return fmt.Errorf("site '%s' has no database on record (name the one to drop with: phppark db:drop %s --database <name>)", siteName, siteName)
+	case dbName != "" && name != "" && name != dbName:
+		return fmt.Errorf("site '%s' uses database '%s', not '%s'", siteName, dbName, name)
+	case dbName == "":
+		dbName = name
+	}
+
+	if !force {
+		fmt.Printf("⚠️  This will permanently delete database '%s'\n", dbName)
+		i18n.Printf(i18n.Continue)
+
+		var response string
+		fmt.Scanln(&response)
+
+		if response != "y" && response != "Y" && response != "yes" {
+			fmt.Println(i18n.T(i18n.Cancelled))
+			return nil
+		}
+	}
+
+	server := databaseServer(cfg)
+
+	if err := server.DropDatabase(dbName); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Dropped database: %s\n", dbName)
+
+	if site.DatabaseUser != "" {
+		if err := server.DropUser(site.DatabaseUser); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		} else {
+			fmt.Printf("🗑️  Dropped user: %s\n", site.DatabaseUser)
+		}
+	}
+
+	site.Database = ""
+	site.DatabaseUser = ""
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	return nil
+}
+
+func dbListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "db:list",
+		Short: "List databases",
+		Long:  `Db:list shows all databases on the server and which site each belongs to.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDBList()
+		},
+	}
+}
+
+func runDBList() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	if !database.IsInstalled() {
+		return i18n.Errorf(i18n.MySQLNotFound)
+	}
+
+	databases, err := databaseServer(cfg).ListDatabases()
+	if err != nil {
+		return err
+	}
+
+	if len(databases) == 0 {
+		fmt.Println("📋 No databases found")
+		fmt.Println("\nTo create one: phppark db:create <site>")
+		return nil
+	}
+
+	// Map databases back to their sites
+	owners := make(map[string]string)
+	for _, site := range sites.ListSites() {
+		if site.Database != "" {
+			owners[site.Database] = site.Name
+		}
+	}
+
+	fmt.Printf("📋 Databases (%d total)\n\n", len(databases))
+	for _, name := range databases {
+		if owner, ok := owners[name]; ok {
+			fmt.Printf("🗄️  %s (%s.%s)\n", name, owner, cfg.SiteDomain())
+		} else {
+			fmt.Printf("🗄️  %s\n", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// stagedSite is a site whose config is installed but not yet tested
+type stagedSite struct {
+	site       *config.Site
+	configPath string
+	content    []byte // Rollback may remove the generated config itself
+	rollback   services.Rollback
+}
+
+// deploySites writes and deploys the configs of many sites with a single
+// config test and reload, instead of one per site. If the combined config
+// fails the test, sites are re-applied one at a time so only the ones that
+// break it are rolled back. It returns the error for each site that failed.
+func deploySites(sites []*config.Site, cfg *config.Config) (map[string]error, error) {
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	installNginxGlobal(server, cfg, sites...)
+
+	failed := make(map[string]error)
+	var staged []stagedSite
+
+	for _, site := range sites {
+		// Builtin sites are served by `php -S`, not the web server, and
+		// disabled sites keep their installed config until they're enabled
+		if site.Builtin || site.Disabled {
+			continue
+		}
+
+		configPath, err := writeSiteConfig(site, cfg, server)
+		if err != nil {
+			failed[site.Name] = err
+			continue
+		}
+
+		content, err := os.ReadFile(configPath)
+		if err != nil {
+			failed[site.Name] = err
+			continue
+		}
+
+		rollback, err := server.Stage(sharedName(site.Name), configPath)
+		if err != nil {
+			failed[site.Name] = err
+			continue
+		}
+		staged = append(staged, stagedSite{site: site, configPath: configPath, content: content, rollback: rollback})
+	}
+
+	if len(staged) == 0 {
+		return failed, nil
+	}
+
+	if err := server.Test(); err != nil {
+		staged = isolateFailures(server, staged, failed)
+	}
+
+	var reloadErr error
+	if len(staged) > 0 {
+		reloadErr = reloadOrStart(server)
+	}
+
+	deployed := make([]config.Site, len(staged))
+	for i, s := range staged {
+		deployed[i] = *s.site
+	}
+	startSiteFPM(server, deployed, cfg)
+
+	return failed, reloadErr
+}
+
+// isolateFailures finds the sites that break the config test: everything is
+// rolled back, then re-applied one site at a time, keeping each only if the
+// config still passes. It returns the sites left deployed.
+func isolateFailures(server webserver.Server, staged []stagedSite, failed map[string]error) []stagedSite {
+	for i := len(staged) - 1; i >= 0; i-- {
+		if err := staged[i].rollback(); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not roll back %s: %v\n", staged[i].site.Name, err)
+		}
+	}
+
+	var kept []stagedSite
+	for _, s := range staged {
+		if err := oplog.WriteFile(s.configPath, s.content, 0644); err != nil {
+			failed[s.site.Name] = err
+			continue
+		}
+
+		rollback, err := server.Stage(sharedName(s.site.Name), s.configPath)
+		if err != nil {
+			failed[s.site.Name] = err
+			continue
+		}
+
+		if err := server.Test(); err != nil {
+			failed[s.site.Name] = fmt.Errorf("%s config test failed: %w", server.Name(), err)
+			if err := rollback(); err != nil {
+				fmt.Printf("   ⚠️  Warning: Could not roll back %s: %v\n", s.site.Name, err)
+			}
+			continue
+		}
+
+		s.rollback = rollback
+		kept = append(kept, s)
+	}
+
+	return kept
+}
+
+// printConfigTestDetail shows the file and line a failed config test
+// points at, so the user can see what to fix
+func printConfigTestDetail(err error, indent string) {
+	var testErr *services.ConfigTestError
+	if !errors.As(err, &testErr) || testErr.File == "" {
+		return
+	}
+
+	fmt.Printf("%s→ %s:%d\n", indent, testErr.File, testErr.Line)
+	if testErr.Source != "" {
+		fmt.Printf("%s  %d | %s\n", indent, testErr.Line, testErr.Source)
+	}
+}
+
+// reloadOrStart applies the new configuration, starting the server if it
+// isn't running yet
+func reloadOrStart(server webserver.Server) error {
+	if !server.Running() {
+		return server.Start()
+	}
+	if err := server.Reload(); err != nil {
+		return fmt.Errorf("failed to reload %s: %w", server.Name(), err)
+	}
+	return nil
+}
+
+// installNginxGlobal writes nginx's shared http-level include with an
+// upstream for every PHP version a site could use: the installed ones, the
+// default, and those of the registered sites and the ones being deployed.
+// In multi-user mode the upstreams already there are kept too. A changed
+// include is tested before any site is deployed against it, and put back
+// if nginx rejects it; sites then pass PHP to their sockets directly (see
+// sharedUpstreams). Other web servers have no global include.
+func installNginxGlobal(server webserver.Server, cfg *config.Config, deploying ...*config.Site) {
+	if server.Name() != "nginx" {
+		return
+	}
+
+	versions := []string{cfg.DefaultPHP}
+	if installed, err := php.DetectPHPVersions(); err == nil {
+		for _, v := range installed {
+			versions = append(versions, v.Version)
+		}
+	}
+	if sites, err := config.LoadSites(); err == nil {
+		versions = append(versions, sitePHPVersions(sites.ListSites(), cfg)...)
+	}
+	for _, site := range deploying {
+		if site.PHPVersion != "" {
+			versions = append(versions, site.PHPVersion)
+		}
+		for _, mount := range site.Mounts {
+			if mount.PHPVersion != "" {
+				versions = append(versions, mount.PHPVersion)
+			}
+		}
+	}
+
+	listen := make(map[string]string)
+	for _, version := range versions {
+		if version != "" {
+			listen[version] = phpFPMListen(cfg, version)
+		}
+	}
+
+	upstreams := nginx.PHPUpstreams(userNamespace, listen)
+	// Other users' sites rely on the upstreams they added
+	if cfg.MultiUser {
+		if current, err := os.ReadFile(services.NginxGlobalConfigPath()); err == nil {
+			upstreams = nginx.MergeUpstreams(upstreams, nginx.ParseUpstreams(string(current)))
+		}
+	}
+
+	content, err := nginx.GenerateGlobalConfig(&nginx.GlobalConfig{
+		CacheDir:   services.NginxCacheDir,
+		Upstreams:  upstreams,
+		StatusAddr: cfg.RuntimeStatus,
+	})
+	var rollback services.Rollback
+	if err == nil {
+		rollback, err = services.StageNginxGlobalConfig(content)
+	}
+	if err == nil && rollback != nil {
+		if err = server.Test(); err != nil {
+			err = fmt.Errorf("%s config test failed: %w", server.Name(), err)
+			if rollbackErr := rollback(); rollbackErr != nil {
+				err = fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not update %s: %v\n", services.NginxGlobalConfigPath(), err)
+		fmt.Println("   Sites pass PHP requests to PHP-FPM directly until it's fixed")
+	}
+
+	// The sites' JSON access logs (see phppark traffic) grow without it
+	if err := services.InstallNginxLogrotate(); err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not rotate the JSON access logs: %v\n", err)
+	}
+}
+
+// sharedUpstreams returns the upstreams the installed global include
+// defines. Sites only use an upstream (and the include's log formats) when
+// it's there, so a missing or rolled-back include never breaks them.
+func sharedUpstreams() map[string]bool {
+	content, err := os.ReadFile(services.NginxGlobalConfigPath())
+	if err != nil {
+		return nil
+	}
+	upstreams := make(map[string]bool)
+	for _, upstream := range nginx.ParseUpstreams(string(content)) {
+		upstreams[upstream.Name] = true
+	}
+	return upstreams
+}
+
+// phpFPMListen returns where a PHP version's PHP-FPM listens: fpm_listen's
+// socket or host:port, or the distro's socket
+func phpFPMListen(cfg *config.Config, phpVersion string) string {
+	if listen := cfg.FPMListenFor(phpVersion); listen != "" {
+		return listen
+	}
+	return nginx.GetPHPSocket(phpVersion)
+}
+
+// parseFPMAddress normalizes a --fpm address (tcp://host:port, host:port,
+// unix:///path or /path) to the host:port or socket path nginx is given
+func parseFPMAddress(address string) (string, error) {
+	invalid := fmt.Errorf("invalid --fpm '%s' (expected tcp://host:port or unix:///path/to.sock)", address)
+
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		address = strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+
+	if strings.HasPrefix(address, "/") {
+		return filepath.Clean(address), nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" {
+		return "", invalid
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", invalid
+	}
+	return address, nil
+}
+
+// remoteRoot maps a site's document root to where its remote PHP-FPM sees
+// it, with the site directory mounted at FPMRoot
+func remoteRoot(site *config.Site, root string) string {
+	rel, err := filepath.Rel(site.Path, root)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return site.FPMRoot
+	}
+	return filepath.Join(site.FPMRoot, rel)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+func disableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable <site>",
+		Short: "Take a site offline without unlinking it",
+		Long: `Disable stops the web server serving a site but keeps everything else: the
+site stays registered with its settings, and its certificate and installed
+config stay in place. With nginx only the sites-enabled link is removed; where
+the server reads configs from a directory of its own (an include dir, conf.d,
+FrankenPHP, the docker backend) the config is renamed to <name>.disabled.
+
+Rebuild, sync and the watcher leave disabled sites alone. Bring a site back
+with 'phppark enable'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDisable(args[0])
+		},
+	}
+}
+
+func enableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "enable <site>",
+		Short: "Serve a disabled site again",
+		Long: `Enable puts back what 'phppark disable' took away and redeploys the site's
+config, so settings changed while it was disabled take effect.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEnable(args[0])
+		},
+	}
+}
+
+func runDisable(siteName string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which has no web server config (stop it with: phppark stop)", siteName)
+	}
+	if site.Disabled {
+		fmt.Printf("⏸️  %s is already disabled\n", siteName)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := server.Disable(sharedName(site.Name)); err != nil {
+		return fmt.Errorf("failed to disable %s: %w", siteName, err)
+	}
+	if err := server.Test(); err != nil {
+		if err := server.Enable(sharedName(site.Name)); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+		return fmt.Errorf("%s config test failed: %w", server.Name(), err)
+	}
+	if err := server.Reload(); err != nil {
+		fmt.Printf("⚠️  Warning: Could not reload %s: %v\n", server.Name(), err)
+	}
+
+	site.Disabled = true
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Printf("⏸️  Disabled %s (settings, config and certificate kept)\n", siteURL(site, cfg))
+	fmt.Printf("   Bring it back with: phppark enable %s\n", siteName)
+	return nil
+}
+
+func runEnable(siteName string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if !site.Disabled {
+		fmt.Printf("✅ %s is already enabled\n", siteName)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("▶️  Enabling %s\n", siteName)
+
+	// The deploy below installs the config if it's gone missing since
+	if err := server.Enable(sharedName(site.Name)); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+
+	site.Disabled = false
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to deploy %s: %w", siteName, err)
+	}
+
+	fmt.Printf("\n✅ %s is back\n", siteURL(site, cfg))
+	return nil
+}
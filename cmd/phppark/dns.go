@@ -0,0 +1,402 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/dns"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// dnsUnitName is the systemd unit running the resolved driver's responder
+const dnsUnitName = "phppark-dns"
+
+// lanDNSUnitName is the systemd unit answering the LAN domain on the LAN
+// address
+const lanDNSUnitName = "phppark-lan-dns"
+
+func dnsServeCmd() *cobra.Command {
+	var domain, listen, answer string
+	var port int
+
+	cmd := &cobra.Command{
+		Use:   "dns:serve",
+		Short: "Run PHPark's DNS responder (used by the resolved DNS driver)",
+		Long: `DNS:serve answers queries for every name under the site domain with
+127.0.0.1, on 127.0.0.1 only. 'phppark trust' runs it under systemd when
+dns.driver is "resolved"; systemd-resolved forwards just that domain to it.
+With lan enabled it also runs one on the LAN address for the LAN domain.
+Names outside the domain are refused, never forwarded.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return i18n.Errorf(i18n.LoadConfigFailed, err)
+			}
+			if domain == "" {
+				domain = cfg.Domain
+			}
+			if port == 0 {
+				port = cfg.DNS.Port
+			}
+
+			answerIP := net.ParseIP(answer)
+			if answerIP == nil || answerIP.To4() == nil {
+				return fmt.Errorf("--answer must be an IPv4 address, got %q", answer)
+			}
+
+			responder := &dns.Responder{Domain: domain, Addr: net.JoinHostPort(listen, strconv.Itoa(port)), Answer: answerIP}
+			fmt.Printf("🌐 Answering *.%s on %s\n", domain, responder.Addr)
+			return responder.ListenAndServe()
+		},
+	}
+
+	cmd.Flags().StringVar(&domain, "domain", "", "Domain to answer for (default: the configured domain)")
+	cmd.Flags().IntVar(&port, "port", 0, "Port to listen on (default: dns.port from config.yaml)")
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1", "Address to listen on")
+	cmd.Flags().StringVar(&answer, "answer", "127.0.0.1", "Address names resolve to")
+
+	return cmd
+}
+
+// dnsmasqResolvedDriver runs dnsmasq on dns.port, with systemd-resolved
+// routing the site domain to it, so port 53 stays with whatever has it
+const dnsmasqResolvedDriver = "dnsmasq-resolved"
+
+// dnsConfigured reports whether site hostnames are set up to resolve with
+// the configured DNS driver
+func dnsConfigured(cfg *config.Config) (bool, error) {
+	switch cfg.DNS.Driver {
+	case "resolved":
+		return dns.CheckResolvedDNS(cfg.Domain), nil
+	case dnsmasqResolvedDriver:
+		configured, err := dns.CheckDNS(cfg.Domain)
+		return configured && dns.CheckResolvedDNS(cfg.Domain), err
+	}
+	return dns.CheckDNS(cfg.Domain)
+}
+
+// trustResolved sets up the resolved driver: the responder under systemd,
+// and a systemd-resolved drop-in routing the site domain to it
+func trustResolved(cfg *config.Config) error {
+	// Undo the dnsmasq driver if it was set up before, including handing
+	// port 53 back to the stub listener
+	if configured, _ := dns.CheckDNS(cfg.Domain); configured || dns.IsSystemdResolvedStubDisabled() {
+		fmt.Println("Switching from dnsmasq...")
+		if err := dns.RemoveDNS(cfg.Domain); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	unit := &services.Unit{
+		Name:        dnsUnitName,
+		Description: "PHPark DNS responder",
+		ExecStart:   []string{exe, "dns:serve", "--domain", cfg.Domain, "--port", strconv.Itoa(cfg.DNS.Port)},
+	}
+	if err := services.InstallUnit(unit); err != nil {
+		return fmt.Errorf("failed to start DNS responder: %w", err)
+	}
+	fmt.Printf("✅ DNS responder running on 127.0.0.1:%d\n", cfg.DNS.Port)
+
+	fmt.Println("⚠️  Configuring systemd-resolved requires sudo access")
+	if err := dns.SetupResolvedDNS(cfg.Domain, cfg.DNS.Port); err != nil {
+		return fmt.Errorf("failed to setup DNS: %w", err)
+	}
+	fmt.Printf("✅ systemd-resolved routes .%s to PHPark (stub listener and /etc/resolv.conf untouched)\n", cfg.Domain)
+
+	return nil
+}
+
+// trustDnsmasqResolved sets up the dnsmasq-resolved driver: dnsmasq on
+// 127.0.0.1:<dns.port>, and a systemd-resolved drop-in routing the site
+// domain to it. Port 53 and /etc/resolv.conf are left alone, so it gets
+// along with other local resolvers (e.g., Docker's).
+func trustDnsmasqResolved(cfg *config.Config) error {
+	// Undo the other drivers if they were set up before
+	if dns.IsSystemdResolvedStubDisabled() {
+		fmt.Println("Handing port 53 back to systemd-resolved...")
+		if err := dns.RevertSystemdResolvedStub(); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+	if services.IsUnitActive(dnsUnitName) {
+		fmt.Println("Switching from PHPark's DNS responder...")
+		if err := services.RemoveUnit(dnsUnitName); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+
+	fmt.Println(i18n.T(i18n.NeedsSudo))
+
+	// The port has to be in place before dnsmasq restarts, or it tries 53
+	configureDnsmasqListen(cfg)
+	if err := dns.SetupDNS(cfg.Domain); err != nil {
+		return fmt.Errorf("failed to setup DNS: %w", err)
+	}
+	fmt.Printf("✅ dnsmasq answers .%s on 127.0.0.1:%d\n", cfg.Domain, cfg.DNS.Port)
+
+	if err := dns.SetupResolvedDNS(cfg.Domain, cfg.DNS.Port); err != nil {
+		return fmt.Errorf("failed to setup DNS: %w", err)
+	}
+	fmt.Printf("✅ systemd-resolved routes .%s to dnsmasq (port 53 and /etc/resolv.conf untouched)\n", cfg.Domain)
+
+	return nil
+}
+
+// untrustResolved removes the drop-in and stops the responder
+func untrustResolved() error {
+	if err := dns.RemoveResolvedDNS(); err != nil {
+		return fmt.Errorf("failed to remove DNS: %w", err)
+	}
+	if err := services.RemoveUnit(dnsUnitName); err != nil {
+		return fmt.Errorf("failed to remove DNS responder: %w", err)
+	}
+	return nil
+}
+
+func dnsDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dns:doctor",
+		Short: "Trace how site hostnames resolve and find where it breaks",
+		Long: `DNS:doctor follows a site hostname through every hop of this machine's
+resolution chain: the NSS hosts: line, /etc/resolv.conf, what's listening on
+port 53, the systemd-resolved stub, and dnsmasq or PHPark's responder. Each hop
+is queried directly, and the first one that fails is reported with the
+command that fixes it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDNSDoctor()
+		},
+	}
+}
+
+// dnsDoctor prints hop results and remembers the first broken one
+type dnsDoctor struct {
+	broken string
+	fix    string
+}
+
+func (d *dnsDoctor) pass(hop, detail string) {
+	fmt.Printf("✅ %-14s %s\n", hop, detail)
+}
+
+func (d *dnsDoctor) warn(hop, detail string) {
+	fmt.Printf("⚠️  %-14s %s\n", hop, detail)
+}
+
+func (d *dnsDoctor) fail(hop, detail, fix string) {
+	fmt.Printf("❌ %-14s %s\n", hop, detail)
+	if fix != "" {
+		fmt.Printf("   %-14s Fix: %s\n", "", fix)
+	}
+	if d.broken == "" {
+		d.broken, d.fix = hop, fix
+	}
+}
+
+// query asks one server for hostname, expecting 127.0.0.1
+func (d *dnsDoctor) query(hop, addr, hostname, fix string) {
+	addrs, err := dns.QueryServer(addr, hostname)
+
+	// The lookup error repeats the name and server; keep just the reason
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		err = errors.New(dnsErr.Err)
+	}
+
+	switch {
+	case err != nil:
+		d.fail(hop, fmt.Sprintf("%s: no answer (%v)", addr, err), fix)
+	case !containsString(addrs, "127.0.0.1"):
+		d.fail(hop, fmt.Sprintf("%s answered %s, not 127.0.0.1", addr, strings.Join(addrs, ", ")), fix)
+	default:
+		d.pass(hop, fmt.Sprintf("%s → 127.0.0.1", addr))
+	}
+}
+
+func runDNSDoctor() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	hostname := "example." + cfg.Domain
+	if sites, err := config.LoadSites(); err == nil && len(sites.ListSites()) > 0 {
+		hostname = sites.ListSites()[0].Name + "." + cfg.SiteDomain()
+	}
+
+	fmt.Printf("🩺 Tracing %s (driver: %s)\n\n", hostname, cfg.DNS.Driver)
+
+	d := &dnsDoctor{}
+	// Both drivers behind systemd-resolved keep its stub as the nameserver
+	resolved := cfg.DNS.Driver == "resolved" || cfg.DNS.Driver == dnsmasqResolvedDriver
+
+	// 1. NSS decides whether DNS is consulted at all
+	hosts, err := dns.NSSHosts()
+	switch {
+	case err != nil:
+		d.warn("NSS", fmt.Sprintf("can't read /etc/nsswitch.conf: %v", err))
+	case !strings.Contains(hosts, "dns") && !strings.Contains(hosts, "resolve"):
+		d.fail("NSS", "hosts: "+hosts+" (no dns or resolve source)", "add \"dns\" to the hosts: line in /etc/nsswitch.conf")
+	default:
+		d.pass("NSS", "hosts: "+hosts)
+		if cfg.Domain == "local" && strings.Contains(hosts, "mdns") {
+			d.warn("NSS", ".local is answered by mDNS before DNS; pick another domain")
+		}
+	}
+
+	// 2. resolv.conf picks the nameserver
+	target, nameservers, err := dns.ResolvConf()
+	servers := strings.Join(nameservers, ", ")
+	link := "plain file"
+	if target != "" {
+		link = "→ " + target
+	}
+	switch {
+	case err != nil:
+		d.fail("resolv.conf", fmt.Sprintf("can't read /etc/resolv.conf: %v", err), "sudo phppark trust")
+	case len(nameservers) == 0:
+		d.fail("resolv.conf", link+", no nameservers", "sudo phppark trust")
+	case resolved && !containsString(nameservers, "127.0.0.53"):
+		d.fail("resolv.conf", fmt.Sprintf("%s, nameserver %s (not the systemd-resolved stub)", link, servers),
+			"sudo ln -sf /run/systemd/resolve/stub-resolv.conf /etc/resolv.conf")
+	case !resolved && !containsString(nameservers, "127.0.0.1"):
+		d.fail("resolv.conf", fmt.Sprintf("%s, nameserver %s (queries never reach dnsmasq)", link, servers), "sudo phppark trust")
+	default:
+		d.pass("resolv.conf", fmt.Sprintf("%s, nameserver %s", link, servers))
+	}
+
+	// 3. Who holds port 53
+	if listeners, err := dns.PortListeners("53"); err != nil {
+		d.warn("Port 53", "can't list sockets (ss not available)")
+	} else if len(listeners) == 0 {
+		d.warn("Port 53", "nothing listening")
+	} else {
+		d.pass("Port 53", strings.Join(listeners, "; "))
+	}
+
+	// 4. The hops behind the nameserver
+	if resolved {
+		if !dns.IsSystemdResolvedActive() {
+			d.fail("resolved", "systemd-resolved is not running", "sudo systemctl start systemd-resolved")
+		} else if !dns.CheckResolvedDNS(cfg.Domain) {
+			d.fail("resolved", fmt.Sprintf("no drop-in routing ~%s to PHPark", cfg.Domain), "sudo phppark trust")
+		} else {
+			d.pass("resolved", fmt.Sprintf("routes ~%s to 127.0.0.1:%d", cfg.Domain, cfg.DNS.Port))
+		}
+
+		localAddr := "127.0.0.1:" + strconv.Itoa(cfg.DNS.Port)
+		if cfg.DNS.Driver == dnsmasqResolvedDriver {
+			if configured, _ := dns.CheckDNS(cfg.Domain); !configured {
+				d.fail("dnsmasq", fmt.Sprintf("no /etc/dnsmasq.d/%s", cfg.Domain), "sudo phppark trust")
+			} else if !dns.IsDnsmasqRunning() {
+				d.fail("dnsmasq", "dnsmasq is not running", "sudo systemctl restart dnsmasq")
+			} else {
+				d.query("dnsmasq", localAddr, hostname, "sudo phppark trust")
+			}
+		} else if !services.IsUnitActive(dnsUnitName) {
+			d.fail("Responder", dnsUnitName+" is not running", "sudo phppark trust")
+		} else {
+			d.query("Responder", localAddr, hostname, "sudo systemctl restart "+dnsUnitName)
+		}
+
+		d.query("Stub", dns.ResolvedStubAddr, hostname, "sudo systemctl restart systemd-resolved")
+	} else {
+		if configured, _ := dns.CheckDNS(cfg.Domain); !configured {
+			d.fail("dnsmasq", fmt.Sprintf("no /etc/dnsmasq.d/%s", cfg.Domain), "sudo phppark trust")
+		} else if !dns.IsDnsmasqRunning() {
+			d.fail("dnsmasq", "dnsmasq is not running", "sudo systemctl restart dnsmasq")
+		} else {
+			d.query("dnsmasq", dns.DnsmasqAddr, hostname, "sudo systemctl restart dnsmasq")
+		}
+	}
+
+	// 5. The nameservers resolv.conf actually lists
+	for _, server := range nameservers {
+		d.query("Nameserver", net.JoinHostPort(server, "53"), hostname, "sudo phppark trust")
+	}
+
+	// 6. End to end, the way applications resolve
+	addrs, err := dns.SystemResolve(hostname)
+	switch {
+	case err != nil:
+		d.fail("System", hostname+" does not resolve", "sudo phppark trust")
+	case !containsString(addrs, "127.0.0.1"):
+		d.fail("System", fmt.Sprintf("%s resolves to %s", hostname, strings.Join(addrs, ", ")), "check /etc/hosts for a stale entry")
+	default:
+		d.pass("System", hostname+" → 127.0.0.1")
+	}
+
+	fmt.Println()
+	if d.broken == "" {
+		fmt.Printf("✅ .%s resolves correctly end to end\n", cfg.Domain)
+		return nil
+	}
+
+	fmt.Printf("❌ The chain breaks at: %s\n", d.broken)
+	if d.fix != "" {
+		fmt.Printf("   Run: %s\n", d.fix)
+	}
+	return nil
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// configureDnsmasqListen applies the dns listen settings from config.yaml
+// to dnsmasq
+func configureDnsmasqListen(cfg *config.Config) {
+	listen := dns.DnsmasqListen{
+		Addresses:      cfg.DNS.ListenAddress,
+		Interfaces:     cfg.DNS.Interface,
+		BindInterfaces: cfg.DNS.BindInterfaces,
+	}
+	if cfg.DNS.Driver == dnsmasqResolvedDriver {
+		listen.Port = cfg.DNS.Port
+		// Only systemd-resolved asks it, so keep it on loopback unless told
+		// otherwise
+		if len(listen.Addresses) == 0 && len(listen.Interfaces) == 0 {
+			listen.Addresses = []string{"127.0.0.1"}
+			listen.BindInterfaces = true
+		}
+	} else if cfg.LANDomain() != "" && len(listen.Addresses) == 0 && len(listen.Interfaces) == 0 {
+		// dnsmasq forwards what it doesn't know, so it stays off the LAN:
+		// colleagues ask the LAN responder, which needs port 53 there free
+		listen.Addresses = []string{"127.0.0.1"}
+		listen.BindInterfaces = true
+	}
+
+	changed, err := dns.ConfigureDnsmasqListen(listen)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: could not apply dnsmasq listen settings: %v\n", err)
+		return
+	}
+	if changed {
+		fmt.Println("✅ Updated dnsmasq listen settings")
+	}
+
+	// This machine resolves through 127.0.0.1, so dnsmasq must still answer there
+	restricted := len(listen.Addresses) > 0 || len(listen.Interfaces) > 0
+	if restricted && !containsString(listen.Addresses, "127.0.0.1") && !containsString(listen.Interfaces, "lo") {
+		fmt.Println("⚠️  dnsmasq won't listen on loopback: add 127.0.0.1 to dns.listen_address or lo to dns.interface")
+	}
+}
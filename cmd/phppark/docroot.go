@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func docrootCmd() *cobra.Command {
+	var reset bool
+
+	cmd := &cobra.Command{
+		Use:   "docroot <site> [dir]",
+		Short: "Set the directory a site is served from",
+		Long: `Docroot pins a site's document root to a directory inside it (e.g., web/ or
+app/webroot), so it's no longer guessed from docroot_candidates. Without a
+directory, the current document root is shown; --reset goes back to guessing.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && !reset {
+				return runDocrootShow(args[0])
+			}
+			if len(args) == 2 && reset {
+				return fmt.Errorf("--reset doesn't take a directory")
+			}
+			dir := ""
+			if len(args) == 2 {
+				dir = args[1]
+			}
+			return runDocroot(args[0], dir)
+		},
+	}
+
+	cmd.Flags().BoolVar(&reset, "reset", false, "Go back to finding the document root from docroot_candidates")
+
+	return cmd
+}
+
+func runDocroot(siteName, dir string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Proxy != "" {
+		return fmt.Errorf("site '%s' is proxied, so it has no document root", siteName)
+	}
+
+	if dir != "" {
+		if dir, err = siteDocRoot(site.Path, dir); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	site.DocRoot = dir
+	// The built-in server gets the document root on its command line
+	process := site.FindProcess(builtinProcessName)
+	if site.Builtin && process != nil {
+		process.Command = builtinCommand(site, cfg)
+	}
+
+	fmt.Printf("📂 Serving %s.%s from %s\n", siteName, cfg.SiteDomain(), siteDocumentRoot(site, cfg))
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if site.Builtin {
+		if process == nil {
+			return nil
+		}
+		if err := installProcessUnit(site, process, cfg); err != nil {
+			return fmt.Errorf("failed to restart the built-in server: %w", err)
+		}
+		fmt.Println("   ✅ Built-in server restarted")
+		return nil
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update nginx config: %w", err)
+	}
+	return nil
+}
+
+func runDocrootShow(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	root := siteDocumentRoot(site, cfg)
+	if site.DocRoot == "" {
+		root += " (detected)"
+	}
+	fmt.Printf("   Docroot: %s\n", root)
+	return nil
+}
+
+// siteDocRoot checks a --docroot: a directory inside the site, given
+// relative to it. It returns it cleaned up.
+func siteDocRoot(sitePath, dir string) (string, error) {
+	clean, err := cleanSiteSubdir(dir)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(sitePath, clean)); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", filepath.Join(sitePath, clean))
+	}
+	return clean, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// dumpServerUnitName is the systemd unit running var-dump-server
+const dumpServerUnitName = "phppark-dump-server"
+
+func dumpServerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump-server",
+		Short: "Collect dump() output from every site in one place",
+		Long: `Dump-server runs Symfony's var-dump-server as a service under the default PHP
+version and points every site at it, so dump() calls land in its log instead
+of the page (or a JSON response). Sites get VAR_DUMPER_FORMAT=server and
+VAR_DUMPER_SERVER as fastcgi params; a site's own env (phppark env set) wins.
+Sites on a remote PHP-FPM (link --fpm) are left alone.`,
+	}
+
+	var port int
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start var-dump-server and send every site's dumps to it",
+		Long: `Start installs symfony/var-dumper into ~/.phppark/tools if needed (with
+composer), runs var-dump-server as a systemd service that restarts if it
+crashes, and rebuilds the site configs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpServerStart(port)
+		},
+	}
+	startCmd.Flags().IntVar(&port, "port", 9912, "Port var-dump-server listens on (127.0.0.1)")
+	cmd.AddCommand(startCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stop",
+		Short: "Stop var-dump-server and send dumps back to the page",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpServerStop()
+		},
+	})
+
+	var lines int
+	tailCmd := &cobra.Command{
+		Use:         "tail",
+		Short:       "Follow the dumps as they arrive",
+		Annotations: map[string]string{passthroughAnnotation: ""},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDumpServerTail(lines)
+		},
+	}
+	tailCmd.Flags().IntVarP(&lines, "lines", "n", 50, "Lines of earlier dumps to show first")
+	cmd.AddCommand(tailCmd)
+
+	return cmd
+}
+
+func runDumpServerStart(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid --port %d", port)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+	if cfg.Backend == "docker" {
+		return fmt.Errorf("dump-server needs the native backend: containerized PHP-FPM can't reach 127.0.0.1")
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	binary := php.BinaryPath(cfg.DefaultPHP)
+	if binary == "" {
+		return missingDependency(i18n.PHPNotInstalled, cfg.DefaultPHP)
+	}
+
+	fmt.Println("🐛 Starting var-dump-server...")
+
+	script, err := ensureVarDumper(binary)
+	if err != nil {
+		return err
+	}
+
+	address := "127.0.0.1:" + strconv.Itoa(port)
+	unit := &services.Unit{
+		Name:        dumpServerUnitName,
+		Description: "PHPark var-dump-server",
+		ExecStart:   []string{binary, script, "--host=" + address},
+		LogFile:     dumpServerLogFile(paths),
+	}
+	if err := services.InstallUnit(unit); err != nil {
+		return err
+	}
+
+	cfg.DumpServer = address
+	if err := config.SaveConfig(cfg); err != nil {
+		return i18n.Errorf(i18n.SaveConfigFailed, err)
+	}
+
+	if err := redeployAllSites(cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ var-dump-server listening on %s (PHP %s)\n", address, cfg.DefaultPHP)
+	fmt.Println("   Follow dumps: phppark dump-server tail")
+	return nil
+}
+
+func runDumpServerStop() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	fmt.Println("🐛 Stopping var-dump-server...")
+
+	if err := services.RemoveUnit(dumpServerUnitName); err != nil {
+		return err
+	}
+
+	if cfg.DumpServer != "" {
+		cfg.DumpServer = ""
+		if err := config.SaveConfig(cfg); err != nil {
+			return i18n.Errorf(i18n.SaveConfigFailed, err)
+		}
+		if err := redeployAllSites(cfg); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\n✅ var-dump-server stopped, dumps go back to the page")
+	return nil
+}
+
+func runDumpServerTail(lines int) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	logFile := dumpServerLogFile(paths)
+	if _, err := os.Stat(logFile); err != nil {
+		return fmt.Errorf("no dumps yet (%s); start the server with: phppark dump-server start", logFile)
+	}
+
+	cmd := exec.Command("tail", "-n", strconv.Itoa(lines), "-f", logFile)
+	attachTerminal(cmd)
+	return cmd.Run()
+}
+
+// ensureVarDumper installs symfony/var-dumper if it isn't there yet, and
+// returns the path of its var-dump-server. It goes in the system dir: the
+// unit runs as the user behind sudo, who can't read root's ~/.phppark.
+func ensureVarDumper(phpBinary string) (string, error) {
+	dir := filepath.Join(config.SystemDir, "var-dumper")
+	script := filepath.Join(dir, "vendor", "bin", "var-dump-server")
+	if _, err := os.Stat(script); err == nil {
+		return script, nil
+	}
+
+	composer, err := exec.LookPath("composer")
+	if err != nil {
+		return "", missingDependency(i18n.ComposerNotFound)
+	}
+
+	fmt.Println("   Installing symfony/var-dumper...")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	cmd := exec.Command(phpBinary, composer, "require", "--no-interaction", "--working-dir="+dir, "symfony/var-dumper")
+	showOutput(cmd)
+	if err := oplog.Run(cmd); err != nil {
+		return "", fmt.Errorf("failed to install symfony/var-dumper: %w", err)
+	}
+
+	return script, nil
+}
+
+// dumpServerLogFile is where var-dump-server's output (the dumps) goes
+func dumpServerLogFile(paths *config.Paths) string {
+	return filepath.Join(paths.Logs, "dump-server.log")
+}
+
+// siteEnv is the environment a site's PHP gets: its own, plus the
+// var-dump-server's address while it runs
+func siteEnv(site *config.Site, cfg *config.Config) map[string]string {
+	if cfg.DumpServer == "" || site.FPM != "" {
+		return site.Env
+	}
+
+	env := map[string]string{
+		"VAR_DUMPER_FORMAT": "server",
+		"VAR_DUMPER_SERVER": cfg.DumpServer,
+	}
+	for name, value := range site.Env {
+		env[name] = value
+	}
+	return env
+}
+
+// redeployAllSites rewrites every site's config after a change to the
+// config they all share
+func redeployAllSites(cfg *config.Config) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	allSites := sites.ListSites()
+	var selected []*config.Site
+	for i := range allSites {
+		if !allSites[i].Builtin {
+			selected = append(selected, &allSites[i])
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	failures, err := deploySites(selected, cfg)
+	if err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+
+	var failed []string
+	for _, site := range selected {
+		if err, ok := failures[site.Name]; ok {
+			fmt.Printf("   ❌ %s: %v\n", site.Name, err)
+			failed = append(failed, site.Name)
+		}
+	}
+	fmt.Printf("   ✅ Updated %d site config(s)\n", len(selected)-len(failed))
+
+	if len(failed) > 0 {
+		return partialFailure(fmt.Errorf("failed to update %d site(s)", len(failed)))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+func envCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Manage per-site environment variables",
+		Long:  `Env manages environment variables passed to PHP for a site via fastcgi_param.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set <site> KEY=VALUE...",
+		Short: "Set environment variables for a site",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEnvSet(args[0], args[1:])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset <site> KEY...",
+		Short: "Remove environment variables from a site",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEnvUnset(args[0], args[1:])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list <site>",
+		Short: "List environment variables for a site",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEnvList(args[0])
+		},
+	})
+
+	return cmd
+}
+
+func runEnvSet(siteName string, pairs []string) error {
+	// Parse and validate everything before touching the registry
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid assignment '%s' (expected KEY=VALUE)", pair)
+		}
+		if err := nginx.ValidateEnv(name, value); err != nil {
+			return err
+		}
+		vars[name] = value
+	}
+
+	return updateSiteEnv(siteName, func(site *config.Site) {
+		if site.Env == nil {
+			site.Env = make(map[string]string)
+		}
+		for name, value := range vars {
+			site.Env[name] = value
+			fmt.Printf("   ✅ %s set\n", name)
+		}
+	})
+}
+
+func runEnvUnset(siteName string, names []string) error {
+	return updateSiteEnv(siteName, func(site *config.Site) {
+		for _, name := range names {
+			if _, ok := site.Env[name]; !ok {
+				fmt.Printf("   ⏭️  %s not set\n", name)
+				continue
+			}
+			delete(site.Env, name)
+			fmt.Printf("   🗑️  %s removed\n", name)
+		}
+		if len(site.Env) == 0 {
+			site.Env = nil
+		}
+	})
+}
+
+// updateSiteEnv applies a change to a site's environment, saves the
+// registry, and regenerates the site's nginx config
+func updateSiteEnv(siteName string, apply func(site *config.Site)) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	fmt.Printf("🔧 Updating environment for %s.%s...\n", siteName, cfg.SiteDomain())
+
+	apply(site)
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update nginx config: %w", err)
+	}
+
+	fmt.Println("\n✅ Environment updated")
+	return nil
+}
+
+func runEnvList(siteName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if len(site.Env) == 0 {
+		fmt.Printf("📋 No environment variables set for %s\n", siteName)
+		fmt.Printf("\nTo add one: phppark env set %s APP_ENV=local\n", siteName)
+		return nil
+	}
+
+	names := make([]string, 0, len(site.Env))
+	for name := range site.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("📋 Environment for %s (%d variable(s))\n\n", siteName, len(names))
+	for _, name := range names {
+		fmt.Printf("   %s=%s\n", name, site.Env[name])
+	}
+
+	return nil
+}
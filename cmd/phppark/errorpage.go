@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/docker"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// errorPageLogLines is how much of PHP-FPM's log a diagnostic page shows
+const errorPageLogLines = 20
+
+// wantsErrorPage reports whether nginx should serve a site the diagnostic
+// page when PHP-FPM doesn't answer: only sites that run PHP through it
+func wantsErrorPage(site *config.Site, cfg *config.Config, template string) bool {
+	if !cfg.ErrorPages || site.Builtin || site.Proxy != "" || site.Octane {
+		return false
+	}
+	return template != nginx.TemplateStatic && template != nginx.TemplateSPA
+}
+
+// writeErrorPage writes a site's diagnostic pages and returns their paths:
+// one for any client, and one with the current end of its PHP-FPM's log
+// for clients on this machine (the log can give away more than a LAN
+// visitor should see). They go in a world-readable directory, so nginx's
+// workers can read them.
+func writeErrorPage(site *config.Site, cfg *config.Config, paths *config.Paths, phpVersion, listen string) (string, string, error) {
+	diagnosis := fpmDiagnosis(site, cfg, paths, phpVersion, listen)
+	local, err := nginx.GenerateErrorPage(diagnosis)
+	if err != nil {
+		return "", "", err
+	}
+	diagnosis.LogHidden = true
+	public, err := nginx.GenerateErrorPage(diagnosis)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(paths.ErrorPages, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", paths.ErrorPages, err)
+	}
+
+	page, localPage := errorPagePaths(site, paths)
+	for path, content := range map[string]string{page: public, localPage: local} {
+		if err := oplog.WriteFile(path, []byte(content), 0644); err != nil {
+			return "", "", fmt.Errorf("failed to write error page: %w", err)
+		}
+	}
+	return page, localPage, nil
+}
+
+// errorPagePaths returns where a site's diagnostic pages go: the one for
+// any client, and the one for clients on this machine
+func errorPagePaths(site *config.Site, paths *config.Paths) (string, string) {
+	return filepath.Join(paths.ErrorPages, sharedName(site.Name)+".html"),
+		filepath.Join(paths.ErrorPages, sharedName(site.Name)+".local.html")
+}
+
+// fpmDiagnosis gathers what a site's diagnostic page says about its PHP-FPM
+func fpmDiagnosis(site *config.Site, cfg *config.Config, paths *config.Paths, phpVersion, listen string) *nginx.ErrorDiagnosis {
+	d := &nginx.ErrorDiagnosis{
+		ServerName: site.Name + "." + cfg.SiteDomain(),
+		PHPVersion: phpVersion,
+		Listen:     listen,
+		Written:    time.Now(),
+	}
+
+	switch {
+	case site.FPM != "":
+		// A remote PHP-FPM isn't PHPark's to restart or read
+	case cfg.Backend == "docker":
+		container := docker.FPMContainer(phpVersion)
+		d.Service = container
+		d.Fixes = []string{
+			"phppark start",
+			"docker logs --tail 50 " + container,
+		}
+	default:
+		d.Service = fmt.Sprintf("php%s-fpm", phpVersion)
+		d.LogPath = fmt.Sprintf("/var/log/php%s-fpm.log", phpVersion)
+		if hasSiteFPM(site, cfg) {
+			d.Service = services.SiteFPMUnitName(siteFPMName(site.Name))
+			d.LogPath = services.SiteFPMLogFile(paths.Logs, siteFPMName(site.Name))
+		}
+		d.Fixes = []string{
+			"sudo systemctl restart " + d.Service,
+			"sudo journalctl -u " + d.Service + " -n 50",
+		}
+
+		tail, err := tailFile(d.LogPath, errorPageLogLines)
+		if err != nil {
+			d.LogError = err.Error()
+		}
+		d.LogTail = tail
+	}
+
+	return d
+}
+
+// refreshErrorPages rewrites the diagnostic pages of the sites on a PHP
+// version, so they show the log from when its PHP-FPM went down rather
+// than from their last deploy
+func refreshErrorPages(sites []config.Site, cfg *config.Config, phpVersion string) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return
+	}
+
+	for i := range sites {
+		site := &sites[i]
+		version := site.PHPVersion
+		if version == "" {
+			version = cfg.DefaultPHP
+		}
+		if version != phpVersion || !wantsErrorPage(site, cfg, siteTemplate(site)) {
+			continue
+		}
+
+		listen := phpFPMListen(cfg, version)
+		switch {
+		case site.FPM != "":
+			listen = site.FPM
+		case hasSiteFPM(site, cfg):
+			listen = services.SiteFPMSocket(siteFPMName(site.Name))
+		}
+		if _, _, err := writeErrorPage(site, cfg, paths, version, listen); err != nil {
+			fmt.Printf("⚠️  Failed to refresh error page for %s: %v\n", site.Name, err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/stevepop/phppark/internal/hooks"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// Exit codes, so scripts can tell failures apart. Anything not covered
+// exits with exitFailure.
+const (
+	exitOK                = 0
+	exitFailure           = 1 // The command failed
+	exitPartial           = 2 // Some of the sites or steps failed, the rest succeeded
+	exitMissingDependency = 3 // A program PHPark needs isn't installed (composer, wp, PHP X.Y, ...)
+	exitNotInstalled      = 4 // PHPark itself isn't installed (run: phppark install)
+)
+
+// exitError is an error that exits with a specific code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// partialFailure marks an error as some, but not all, of the work failing
+func partialFailure(err error) error {
+	return &exitError{code: exitPartial, err: err}
+}
+
+// missingDependency marks an error as a required program being absent
+func missingDependency(m *i18n.Message, args ...any) error {
+	return &exitError{code: exitMissingDependency, err: i18n.Errorf(m, args...)}
+}
+
+// exitCode returns the code a command's error exits with
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
+
+// silenceOutput sends the narration on stdout, and what helper programs
+// and hooks print, to /dev/null for --quiet. Errors still go to stderr, and
+// passthrough output (wp, exports, --json) to stdout.
+func silenceOutput() {
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+		helperStdout = devNull
+		hooks.Stdout = devNull
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/compose"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a site's runtime for other environments",
+	}
+
+	var output string
+	dockerCmd := &cobra.Command{
+		Use:   "docker [site]",
+		Short: "Export a site as a docker-compose.yml",
+		Long: `Export docker writes a docker-compose.yml reproducing the site's runtime: its
+nginx config, PHP version, environment variables and any database or Redis
+service it uses. Place the file in the site directory and run docker compose up.`,
+		Annotations: map[string]string{passthroughAnnotation: ""},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExportDocker(args[0], output)
+		},
+	}
+	dockerCmd.Flags().StringVarP(&output, "output", "o", "", "Write to a file instead of stdout")
+
+	cmd.AddCommand(dockerCmd)
+	return cmd
+}
+
+func runExportDocker(siteName, output string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if site.Proxy != "" {
+		return fmt.Errorf("site '%s' is proxied to %s and has no PHP runtime to export", siteName, site.Proxy)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	// Same nginx config as locally, pointed at the php service
+	docRoot, err := filepath.Rel(site.Path, siteDocumentRoot(site, cfg))
+	if err != nil {
+		return fmt.Errorf("failed to resolve document root: %w", err)
+	}
+
+	nginxCfg := nginx.CreateSiteConfig(site.Name, compose.AppDir, cfg.SiteDomain(), phpVersion, false)
+	nginxCfg.ServerName = fmt.Sprintf("%s.%s localhost", site.Name, cfg.SiteDomain())
+	nginxCfg.Root = filepath.Join(compose.AppDir, docRoot)
+	nginxCfg.FastCGIPass = "php:9000"
+
+	nginxConfig, err := nginx.GenerateConfig(nginxCfg)
+	if err != nil {
+		return fmt.Errorf("failed to generate nginx config: %w", err)
+	}
+
+	// Redis is detected from the project's .env and the site's variables
+	env := readDotEnv(filepath.Join(site.Path, ".env"))
+	for name, value := range site.Env {
+		env[name] = value
+	}
+
+	data, err := compose.Generate(&compose.Site{
+		Name:        site.Name,
+		PHPVersion:  phpVersion,
+		Env:         site.Env,
+		NginxConfig: nginxConfig,
+		Database:    site.Database,
+		DBUser:      site.DatabaseUser,
+		Redis:       compose.UsesRedis(env),
+	})
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := terminalStdout.Write(data)
+		return err
+	}
+
+	if err := oplog.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Printf("✅ Exported %s.%s to %s\n", site.Name, cfg.SiteDomain(), output)
+	if site.Database != "" {
+		fmt.Println("   💡 Database credentials in the export are placeholders (secret)")
+	}
+	return nil
+}
+
+// readDotEnv reads KEY=VALUE lines from a .env file, ignoring comments.
+// A missing file yields an empty map.
+func readDotEnv(path string) map[string]string {
+	env := make(map[string]string)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return env
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	return env
+}
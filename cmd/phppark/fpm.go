@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+func fpmStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "fpm:status <site>",
+		Short: "Show PHP-FPM worker status for a site",
+		Long: `Fpm:status fetches the PHP-FPM status page for the pool serving a site and shows worker usage.
+
+The page needs fpm_status: true in config.yaml. Rebuilding turns it on in the
+pools of the PHP versions sites use, and off again once fpm_status is unset.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFPMStatus(args[0])
+		},
+	}
+}
+
+func runFPMStatus(siteName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if !cfg.FPMStatus {
+		fmt.Println("⚠️  PHP-FPM status pages are disabled")
+		fmt.Println(i18n.T(i18n.EnableInConfig))
+		fmt.Println("  fpm_status: true")
+		fmt.Println(i18n.T(i18n.ThenRebuild))
+		return nil
+	}
+
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which has no PHP-FPM pool", siteName)
+	}
+
+	hostname := fmt.Sprintf("%s.%s", site.Name, cfg.SiteDomain())
+	status, err := services.FetchFPMStatus(siteURL(site, cfg))
+	if err != nil {
+		return fmt.Errorf("failed to fetch FPM status: %w\n   Try: sudo phppark rebuild", err)
+	}
+
+	uptime := time.Duration(status.StartSince) * time.Second
+
+	fmt.Printf("📊 PHP-FPM Status for %s (PHP %s)\n\n", hostname, phpVersion)
+	fmt.Printf("Pool:          %s (%s)\n", status.Pool, status.ProcessManager)
+	fmt.Printf("Uptime:        %s\n", uptime)
+	fmt.Printf("Accepted:      %d connection(s)\n", status.AcceptedConn)
+
+	fmt.Println("\n=== Workers ===")
+	fmt.Printf("Active:        %d\n", status.ActiveProcesses)
+	fmt.Printf("Idle:          %d\n", status.IdleProcesses)
+	fmt.Printf("Total:         %d\n", status.TotalProcesses)
+	fmt.Printf("Max active:    %d\n", status.MaxActiveProcesses)
+
+	fmt.Println("\n=== Pressure ===")
+	fmt.Printf("Listen queue:  %d (max %d)\n", status.ListenQueue, status.MaxListenQueue)
+	fmt.Printf("Slow requests: %d\n", status.SlowRequests)
+
+	if status.MaxChildrenReached > 0 {
+		fmt.Printf("Max children:  ⚠️  reached %d time(s) — consider raising pm.max_children\n", status.MaxChildrenReached)
+	} else {
+		fmt.Println("Max children:  ✅ never reached")
+	}
+
+	return nil
+}
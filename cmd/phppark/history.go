@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+func historyCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show what PHPark changed on the system",
+		Long: `History lists the files PHPark wrote or removed, the commands it ran and the
+services it restarted, grouped by the phppark command that did it. The full
+record is kept as JSON lines in ~/.phppark/phppark.log.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistory(limit)
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 50, "Number of recent operations to show (0 for all)")
+
+	return cmd
+}
+
+// openOplog starts recording operations for this invocation. Values of
+// KEY=VALUE arguments (e.g., env set) are left out of the log.
+func openOplog(cmd *cobra.Command, args []string) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return
+	}
+
+	words := []string{cmd.CommandPath()}
+	for _, arg := range args {
+		if key, _, ok := strings.Cut(arg, "="); ok {
+			arg = key + "=…"
+		}
+		words = append(words, arg)
+	}
+
+	oplog.Open(paths.Oplog, strings.Join(words, " "))
+}
+
+func runHistory(limit int) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	entries, err := oplog.Read(paths.Oplog, limit)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", paths.Oplog, err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("📜 No operations recorded yet")
+		return nil
+	}
+
+	fmt.Printf("📜 History (last %d operation(s))\n", len(entries))
+
+	invocation := ""
+	for i, entry := range entries {
+		// One heading per command run
+		if i == 0 || entry.Invocation != invocation || entry.Time.Sub(entries[i-1].Time) > time.Minute {
+			invocation = entry.Invocation
+			fmt.Printf("\n%s  %s\n", entry.Time.Format("2006-01-02 15:04:05"), invocation)
+		}
+
+		icon := "✅"
+		if entry.Error != "" {
+			icon = "❌"
+		}
+
+		line := fmt.Sprintf("   %s %-7s %s", icon, entry.Op, entry.Target)
+		if entry.Op == "symlink" && len(entry.Args) == 1 {
+			line += " → " + entry.Args[0]
+		} else if len(entry.Args) > 0 {
+			line += " " + strings.Join(entry.Args, " ")
+		}
+		if entry.ExitCode != nil && *entry.ExitCode != 0 {
+			line += fmt.Sprintf(" (exit %d)", *entry.ExitCode)
+		} else if entry.Error != "" {
+			line += " (" + entry.Error + ")"
+		}
+		fmt.Println(line)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/hooks"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// runHook runs the global and project hooks for a site event. An
+// untrusted project's hooks are skipped with a notice rather than failing
+// the operation.
+func runHook(event hooks.Event, site *config.Site, cfg *config.Config) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	err = hooks.Run(paths.Hooks, paths.TrustedHooks, event, site, cfg)
+	var untrusted *hooks.UntrustedError
+	if errors.As(err, &untrusted) {
+		fmt.Printf("   ⚠️  Skipped %s hooks: %s isn't trusted (review it, then run: phppark hooks trust %s)\n", event, hooks.ProjectFile, site.Path)
+		return nil
+	}
+	return err
+}
+
+// runPostHook runs a post-event hook, where a failure can only be reported
+func runPostHook(event hooks.Event, site *config.Site, cfg *config.Config) {
+	if err := runHook(event, site, cfg); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+}
+
+func hooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Trust or distrust a project's .phppark.yml hooks",
+		Long: `A project's .phppark.yml hooks are commands from whoever wrote its
+directory, so PHPark only runs them once you've trusted the file. Trust is
+recorded as a hash of .phppark.yml: when the file changes, its hooks stop
+running until it's trusted again.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "trust [path]",
+		Short: "Show a project's hooks and let them run",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksTrust(args, true)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "untrust [path]",
+		Short: "Stop a project's hooks from running",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooksTrust(args, false)
+		},
+	})
+
+	return cmd
+}
+
+func runHooksTrust(args []string, trust bool) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	dir, err := resolveSiteDir(path)
+	if err != nil {
+		return err
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	if !trust {
+		if err := hooks.Untrust(paths.TrustedHooks, dir); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s's hooks won't run\n", dir)
+		return nil
+	}
+
+	project, err := hooks.LoadProject(dir)
+	if err != nil {
+		return err
+	}
+
+	events := make([]string, 0, len(project.Hooks))
+	for event := range project.Hooks {
+		events = append(events, string(event))
+	}
+	sort.Strings(events)
+
+	fmt.Printf("🪝 Hooks in %s:\n", dir)
+	for _, event := range events {
+		for _, command := range project.Hooks[hooks.Event(event)] {
+			fmt.Printf("   %-14s %s\n", event, command)
+		}
+	}
+
+	if err := hooks.Trust(paths.TrustedHooks, dir); err != nil {
+		return err
+	}
+	fmt.Printf("\n✅ Trusted until %s changes\n", hooks.ProjectFile)
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func infoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <site>",
+		Short: "Show everything PHPark knows about a site",
+		Long:  `Info shows a site's settings, supervised processes and when it was registered, changed and last seen responding.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInfo(args[0])
+		},
+	}
+}
+
+func runInfo(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	fmt.Printf("🔗 %s\n", site.Name)
+	if site.Description != "" {
+		fmt.Printf("   %s\n", site.Description)
+	}
+	fmt.Println()
+	fmt.Printf("   URL:       %s\n", siteURL(site, cfg))
+	if url := lanURL(site, cfg); url != "" {
+		fmt.Printf("   LAN URL:   %s\n", url)
+	}
+	fmt.Printf("   Path:      %s\n", site.Path)
+	if site.Proxy == "" {
+		fmt.Printf("   Docroot:   %s\n", siteDocumentRoot(site, cfg))
+	}
+	fmt.Printf("   Type:      %s\n", site.Type)
+	if site.Disabled {
+		fmt.Printf("   Status:    ⏸️  disabled (phppark enable %s)\n", site.Name)
+	}
+	if site.ParkedIn != "" {
+		fmt.Printf("   Parked in: %s\n", site.ParkedIn)
+	}
+
+	switch {
+	case site.Proxy != "":
+		fmt.Printf("   Proxy:     %s\n", site.Proxy)
+	case site.PHPVersion != "":
+		fmt.Printf("   PHP:       %s\n", site.PHPVersion)
+	default:
+		fmt.Printf("   PHP:       %s (default)\n", cfg.DefaultPHP)
+	}
+
+	if site.FPM != "" {
+		fmt.Printf("   PHP-FPM:   %s\n", site.FPM)
+		if site.FPMRoot != "" {
+			fmt.Printf("   FPM root:  %s\n", site.FPMRoot)
+		}
+	}
+
+	if template := siteTemplate(site); template != "" && site.Proxy == "" {
+		if site.Template == "" {
+			template += " (detected)"
+		}
+		fmt.Printf("   Template:  %s\n", template)
+	}
+
+	ssl := "no"
+	if site.Secured {
+		ssl = "yes"
+	}
+	fmt.Printf("   HTTPS:     %s\n", ssl)
+
+	if site.Octane {
+		fmt.Printf("   Octane:    port %d\n", site.Port)
+	}
+	if site.Builtin {
+		fmt.Printf("   Built-in:  port %d\n", site.Port)
+	}
+	if site.MaxBody != "" {
+		fmt.Printf("   Max body:  %s\n", site.MaxBody)
+	}
+	if site.Timeout > 0 {
+		fmt.Printf("   Timeout:   %ds\n", site.Timeout)
+	}
+	if site.ThrottleRate != "" || site.ThrottleDelay > 0 {
+		rate := "full speed"
+		if site.ThrottleRate != "" {
+			rate = site.ThrottleRate + "/s"
+		}
+		fmt.Printf("   Throttle:  %s, %dms delay\n", rate, site.ThrottleDelay)
+	}
+	if site.Preload != "" {
+		fmt.Printf("   Preload:   %s\n", site.Preload)
+	}
+	if site.Profiler != "" {
+		fmt.Printf("   Profiler:  %s\n", site.Profiler)
+	}
+	if site.Database != "" {
+		fmt.Printf("   Database:  %s\n", site.Database)
+	}
+	if len(site.Env) > 0 {
+		fmt.Printf("   Env:       %d variable(s)\n", len(site.Env))
+	}
+	if len(site.Workers) > 0 {
+		var names []string
+		for _, worker := range site.Workers {
+			names = append(names, worker.Name)
+		}
+		fmt.Printf("   Workers:   %s\n", strings.Join(names, ", "))
+	}
+	if len(site.Processes) > 0 {
+		var names []string
+		for _, process := range site.Processes {
+			names = append(names, process.Name)
+		}
+		fmt.Printf("   Processes: %s\n", strings.Join(names, ", "))
+	}
+	if site.Scheduler {
+		fmt.Println("   Scheduler: on")
+	}
+	if len(site.Mounts) > 0 {
+		var mounts []string
+		for _, mount := range site.Mounts {
+			mounts = append(mounts, mount.Path+" ("+mount.Dir+")")
+		}
+		fmt.Printf("   Mounts:    %s\n", strings.Join(mounts, ", "))
+	}
+
+	fmt.Println()
+	printSiteTimes(site, "   ")
+	if site.ExpiresAt != nil {
+		fmt.Printf("   Expires:   %s (temporary, see: phppark serve)\n", site.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	return nil
+}
+
+// printSiteTimes prints when a site was registered, changed and last seen
+func printSiteTimes(site *config.Site, indent string) {
+	fmt.Printf("%sCreated:   %s\n", indent, formatSiteTime(site.CreatedAt, "unknown"))
+	fmt.Printf("%sUpdated:   %s\n", indent, formatSiteTime(site.UpdatedAt, "unknown"))
+	fmt.Printf("%sLast seen: %s\n", indent, formatSiteTime(site.LastSeen, "never"))
+}
+
+// formatSiteTime shows a timestamp with how long ago it was, or unset
+// when it was never recorded
+func formatSiteTime(t *time.Time, unset string) string {
+	if t == nil {
+		return unset
+	}
+
+	ago := time.Since(*t)
+	var rel string
+	switch {
+	case ago < time.Minute:
+		rel = "just now"
+	case ago < time.Hour:
+		rel = fmt.Sprintf("%d min ago", int(ago.Minutes()))
+	case ago < 48*time.Hour:
+		rel = fmt.Sprintf("%d hours ago", int(ago.Hours()))
+	default:
+		rel = fmt.Sprintf("%d days ago", int(ago.Hours()/24))
+	}
+
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), rel)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/dns"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// siteAliases returns the extra hostnames a site is served under: its name
+// under the LAN domain when lan is enabled
+func siteAliases(site *config.Site, cfg *config.Config) []string {
+	lanDomain := cfg.LANDomain()
+	if lanDomain == "" || site.Builtin {
+		return nil
+	}
+	return []string{site.Name + "." + lanDomain}
+}
+
+// lanURL returns the address colleagues open a site at, or "" when lan is
+// off
+func lanURL(site *config.Site, cfg *config.Config) string {
+	aliases := siteAliases(site, cfg)
+	if len(aliases) == 0 {
+		return ""
+	}
+	// Same scheme and port as the local URL, with the LAN hostname
+	local := siteURL(site, cfg)
+	return strings.Replace(local, site.Name+"."+cfg.SiteDomain(), aliases[0], 1)
+}
+
+// lanAddress returns the address LAN names resolve to: lan.address from
+// config.yaml, or the address of the interface with the default route
+func lanAddress(cfg *config.Config) (string, error) {
+	if cfg.LAN.Address != "" {
+		return cfg.LAN.Address, nil
+	}
+	return dns.LANAddress()
+}
+
+// trustLAN has dnsmasq answer the LAN domain for this machine, runs the
+// LAN responder that answers it for colleagues, and tells the user how
+// they reach it. dnsmasq itself stays on loopback: it forwards every other
+// name upstream, which on the LAN would make it an open resolver. Only the
+// dnsmasq driver answers on the network; the others get hosts-file
+// instructions.
+func trustLAN(cfg *config.Config) {
+	lanDomain := cfg.LANDomain()
+	if lanDomain == "" {
+		return
+	}
+
+	addr, err := lanAddress(cfg)
+	if err != nil {
+		fmt.Printf("⚠️  Could not find this machine's LAN address: %v\n", err)
+		fmt.Println("   Set lan.address in ~/.phppark/config.yaml")
+		return
+	}
+
+	fmt.Printf("\n🌐 Sites are also served as <site>.%s on %s\n", lanDomain, addr)
+
+	if cfg.DNS.Driver != "" && cfg.DNS.Driver != "dnsmasq" {
+		fmt.Printf("   The %s driver doesn't answer on the network, so colleagues add hosts entries:\n", cfg.DNS.Driver)
+		fmt.Printf("   %s <site>.%s\n", addr, lanDomain)
+		return
+	}
+
+	if err := dns.SetupLANDNS(lanDomain, addr); err != nil {
+		fmt.Printf("⚠️  Warning: could not configure .%s: %v\n", lanDomain, err)
+		return
+	}
+
+	// Port 53 on the LAN address needs root
+	if services.UserScope() {
+		fmt.Printf("⚠️  Run 'sudo phppark trust' to answer .%s on %s\n", lanDomain, addr)
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: could not find phppark binary: %v\n", err)
+		return
+	}
+	unit := &services.Unit{
+		Name:        lanDNSUnitName,
+		Description: "PHPark LAN DNS responder",
+		ExecStart:   []string{exe, "dns:serve", "--domain", lanDomain, "--listen", addr, "--port", "53", "--answer", addr},
+		User:        "root",
+	}
+	if err := services.InstallUnit(unit); err != nil {
+		fmt.Printf("⚠️  Warning: could not start the LAN DNS responder: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ %s answers .%s with %s (and refuses other names)\n", lanDNSUnitName, lanDomain, addr)
+	fmt.Printf("   Colleagues point .%s at %s as their DNS server, e.g. in dnsmasq:\n", lanDomain, addr)
+	fmt.Printf("   server=/%s/%s\n", lanDomain, addr)
+	fmt.Println("   Run 'phppark rebuild' so existing sites and certificates pick up the names")
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+func startCmd() *cobra.Command {
+	var tag string
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the web server, PHP-FPM, and all supervised processes",
+		Long: `Start brings up the web server, the PHP-FPM versions your sites use, and every queue worker and supervised process.
+With --tag only the PHP-FPM versions and processes of sites with that tag are started.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStart(tag)
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "Only start what sites with this tag use")
+
+	return cmd
+}
+
+func stopCmd() *cobra.Command {
+	var tag string
+
+	cmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop supervised processes, PHP-FPM, and the web server",
+		Long: `Stop shuts down every queue worker and supervised process, then the PHP-FPM versions your sites use and the web server.
+With --tag only the workers and processes of sites with that tag are stopped, since the
+web server and PHP-FPM are shared with other sites.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStop(tag)
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "Only stop the workers and processes of sites with this tag")
+
+	return cmd
+}
+
+func runStart(tag string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	fpm, err := webserver.NewFPM(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🚀 Starting PHPark...")
+
+	if err := server.Start(); err != nil {
+		fmt.Printf("   ❌ %s: %v\n", server.Name(), err)
+	} else {
+		fmt.Printf("   ✅ %s\n", server.Name())
+	}
+
+	for _, version := range fpmVersions(server, sites.Tagged(tag), cfg) {
+		if err := fpm.Start(version); err != nil {
+			fmt.Printf("   ❌ PHP %s-FPM: %v\n", version, err)
+		} else {
+			fmt.Printf("   ✅ PHP %s-FPM\n", version)
+		}
+	}
+
+	forEachSiteUnit(sites.Tagged(tag), func(label, unit string) {
+		if err := services.StartUnit(unit); err != nil {
+			fmt.Printf("   ❌ %s: %v\n", label, err)
+		} else {
+			fmt.Printf("   ✅ %s\n", label)
+		}
+	})
+
+	fmt.Println("\n✅ PHPark started")
+	return nil
+}
+
+func runStop(tag string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	fpm, err := webserver.NewFPM(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🛑 Stopping PHPark...")
+
+	// Stop processes first so they don't error against a stopped stack
+	forEachSiteUnit(sites.Tagged(tag), func(label, unit string) {
+		if err := services.StopUnit(unit); err != nil {
+			fmt.Printf("   ⚠️  %s: %v\n", label, err)
+		} else {
+			fmt.Printf("   ⏹️  %s\n", label)
+		}
+	})
+
+	// The web server and PHP-FPM also serve untagged sites
+	if tag != "" {
+		fmt.Printf("\n✅ Stopped processes of sites tagged '%s'\n", tag)
+		return nil
+	}
+
+	for _, version := range fpmVersions(server, sites.ListSites(), cfg) {
+		if err := fpm.Stop(version); err != nil {
+			fmt.Printf("   ⚠️  PHP %s-FPM: %v\n", version, err)
+		} else {
+			fmt.Printf("   ⏹️  PHP %s-FPM\n", version)
+		}
+	}
+
+	if err := server.Stop(); err != nil {
+		fmt.Printf("   ⚠️  %s: %v\n", server.Name(), err)
+	} else {
+		fmt.Printf("   ⏹️  %s\n", server.Name())
+	}
+
+	fmt.Println("\n✅ PHPark stopped")
+	return nil
+}
+
+// fpmVersions returns the PHP-FPM versions PHPark manages: none when the
+// web server runs PHP itself
+func fpmVersions(server webserver.Server, sites []config.Site, cfg *config.Config) []string {
+	if server.EmbedsPHP() {
+		return nil
+	}
+	return sitePHPVersions(sites, cfg)
+}
+
+// sitePHPVersions returns the distinct PHP-FPM versions used by PHP sites
+// and the apps mounted in sites
+func sitePHPVersions(sites []config.Site, cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var versions []string
+	add := func(version string) {
+		if version == "" {
+			version = cfg.DefaultPHP
+		}
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+
+	for _, site := range sites {
+		// A remote PHP-FPM isn't ours to start
+		if site.Proxy == "" && !site.Builtin && site.FPM == "" {
+			add(site.PHPVersion)
+		}
+
+		// Mounts run on the local PHP-FPM, whatever the site uses
+		for _, mount := range site.Mounts {
+			version := mount.PHPVersion
+			if version == "" {
+				version = site.PHPVersion
+			}
+			add(version)
+		}
+	}
+
+	return versions
+}
+
+// forEachSiteUnit calls fn for every worker and process unit of the sites
+func forEachSiteUnit(sites []config.Site, fn func(label, unit string)) {
+	for _, site := range sites {
+		for _, worker := range site.Workers {
+			for n := 1; n <= worker.Count; n++ {
+				label := fmt.Sprintf("%s/%s #%d", site.Name, worker.Name, n)
+				fn(label, workerUnitName(site.Name, worker.Name, n))
+			}
+		}
+		for _, process := range site.Processes {
+			label := fmt.Sprintf("%s/%s", site.Name, process.Name)
+			fn(label, processUnitName(site.Name, process.Name))
+		}
+		if len(siteFPMSettings(&site)) > 0 && site.FPM == "" && site.Proxy == "" {
+			fn(site.Name+" PHP-FPM", services.SiteFPMUnitName(siteFPMName(site.Name)))
+		}
+	}
+}
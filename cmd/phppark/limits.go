@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+// limitsOptions holds flags for the limits command
+type limitsOptions struct {
+	maxBody string // Largest request body (e.g., 512M)
+	timeout int    // Request timeout in seconds
+	reset   bool   // Go back to the web server's defaults
+}
+
+func limitsCmd() *cobra.Command {
+	var opts limitsOptions
+
+	cmd := &cobra.Command{
+		Use:   "limits <site>",
+		Short: "Set a site's upload size and request timeout",
+		Long: `Limits raises (or shows) how large a request body a site accepts and how long
+a request may run. Both go into the site's web server config (nginx or
+Apache), and for PHP sites also into php.ini (upload_max_filesize,
+post_max_size, max_execution_time) so PHP doesn't reject what the web server
+lets through. The php.ini settings need the site's own PHP-FPM, which PHPark
+starts, so they don't reach other sites sharing its PHP version's pool.
+Without flags, the current limits are shown.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			if !flags.Changed("max-body") && !flags.Changed("timeout") && !opts.reset {
+				return runLimitsShow(args[0])
+			}
+			return runLimits(args[0], opts, flags.Changed("max-body"), flags.Changed("timeout"))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.maxBody, "max-body", "", "Largest request body, e.g., 512M (\"\" for the default)")
+	cmd.Flags().IntVar(&opts.timeout, "timeout", 0, "Seconds a request may run (0 for the default)")
+	cmd.Flags().BoolVar(&opts.reset, "reset", false, "Go back to the default limits")
+
+	return cmd
+}
+
+func runLimits(siteName string, opts limitsOptions, setBody, setTimeout bool) error {
+	if opts.maxBody != "" {
+		if err := nginx.ValidateSize(opts.maxBody); err != nil {
+			return err
+		}
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("--timeout can't be negative")
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which has no web server config", siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if opts.reset {
+		site.MaxBody = ""
+		site.Timeout = 0
+	}
+	if setBody {
+		site.MaxBody = opts.maxBody
+	}
+	if setTimeout {
+		site.Timeout = opts.timeout
+	}
+
+	fmt.Printf("🔧 Updating limits for %s.%s...\n", siteName, cfg.SiteDomain())
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	// Proxied sites have no PHP to raise them for
+	if site.Proxy == "" {
+		if err := checkSiteFPM(site, cfg); err != nil {
+			fmt.Printf("   ⚠️  PHP keeps its own php.ini limits: %v\n", err)
+		} else if err := applySiteFPM(site, cfg); err != nil {
+			return err
+		} else if hasSiteFPM(site, cfg) {
+			fmt.Printf("   ✅ The site's own PHP-FPM (%s) applies them to PHP\n", siteFPMName(site.Name))
+		}
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update nginx config: %w", err)
+	}
+
+	fmt.Println()
+	printLimits(site)
+	return nil
+}
+
+func runLimitsShow(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	printLimits(site)
+	return nil
+}
+
+// printLimits shows a site's limits, or the defaults it falls back to
+func printLimits(site *config.Site) {
+	maxBody := "default (nginx: 1M)"
+	if site.Proxy != "" && !site.Octane {
+		maxBody = "unlimited (proxied)"
+	} else if siteTemplate(site) == nginx.TemplateWordPress {
+		maxBody = "default (WordPress: " + nginx.WordPressMaxBody + ")"
+	}
+	if site.MaxBody != "" {
+		maxBody = site.MaxBody
+	}
+
+	timeout := "default (60s)"
+	if siteTemplate(site) == nginx.TemplateMagento {
+		timeout = fmt.Sprintf("default (Magento: %ds)", nginx.MagentoTimeout)
+	}
+	if site.Timeout > 0 {
+		timeout = fmt.Sprintf("%ds", site.Timeout)
+	}
+
+	fmt.Printf("   Max body: %s\n", maxBody)
+	fmt.Printf("   Timeout:  %s\n", timeout)
+}
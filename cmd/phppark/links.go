@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/health"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// siteCheckTimeout bounds each site's reachability check, so one hung site
+// doesn't stall a listing
+const siteCheckTimeout = 3 * time.Second
+
+// linksOptions holds flags for the links command
+type linksOptions struct {
+	long    bool   // Add tag, origin, timestamp and description columns
+	wide    bool   // Show full paths
+	tag     string // Only sites with this tag
+	sort    string // Sort column
+	kind    string // Only "park" or "link" sites
+	php     string // Only sites on this PHP version
+	secured bool   // Only secured sites
+	check   bool   // Request each site and show how it answered
+}
+
+func linksCmd() *cobra.Command {
+	var opts linksOptions
+
+	cmd := &cobra.Command{
+		Use:   "links",
+		Short: "List all linked sites",
+		Long:  `List displays all parked and linked sites managed by PHPark.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLinks(opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.long, "long", "l", false, "Also show tags, when sites were created, changed and last seen, where parked sites came from, and their descriptions")
+	cmd.Flags().BoolVarP(&opts.wide, "wide", "w", false, "Show full paths instead of shortening them")
+	cmd.Flags().StringVar(&opts.tag, "tag", "", "Only list sites with this tag")
+	cmd.Flags().StringVar(&opts.sort, "sort", "name", "Sort by name, php, type, path, created, updated or seen")
+	cmd.Flags().StringVar(&opts.kind, "type", "", "Only list \"park\" or \"link\" sites")
+	cmd.Flags().StringVar(&opts.php, "php", "", "Only list sites using this PHP version")
+	cmd.Flags().BoolVar(&opts.secured, "secured", false, "Only list sites served over HTTPS")
+	cmd.Flags().BoolVar(&opts.check, "check", false, "Send each site a HEAD request and show its status (200, 502, unreachable)")
+
+	return cmd
+}
+
+func runLinks(opts linksOptions) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Check if empty
+	if len(sites.ListSites()) == 0 {
+		fmt.Println("📋 No sites registered yet.")
+		fmt.Println("\nTo add sites:")
+		fmt.Println("  phppark park ~/sites    # Park a directory")
+		fmt.Println("  phppark link myapp      # Link current directory")
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	var matched []config.Site
+	for _, site := range sites.Tagged(opts.tag) {
+		if opts.kind != "" && site.Type != opts.kind {
+			continue
+		}
+		if opts.php != "" && sitePHP(&site, cfg) != opts.php {
+			continue
+		}
+		if opts.secured && !site.Secured {
+			continue
+		}
+		matched = append(matched, site)
+	}
+
+	if len(matched) == 0 {
+		fmt.Println("📋 No sites match")
+		return nil
+	}
+
+	if err := sortSites(matched, opts.sort, cfg); err != nil {
+		return err
+	}
+
+	var checks map[string]*health.Result
+	if opts.check {
+		checks = checkSites(matched, cfg)
+	}
+
+	printSiteTable(matched, cfg, checks, opts.long, opts.wide)
+	return nil
+}
+
+// sitePHP returns the PHP version a site runs, or "-" for sites that
+// don't run PHP through PHPark
+func sitePHP(site *config.Site, cfg *config.Config) string {
+	if site.Proxy != "" {
+		return "-"
+	}
+	if site.PHPVersion != "" {
+		return site.PHPVersion
+	}
+	return cfg.DefaultPHP
+}
+
+// sortSites orders sites by a table column
+func sortSites(sites []config.Site, by string, cfg *config.Config) error {
+	var less func(a, b *config.Site) bool
+
+	switch by {
+	case "", "name":
+		less = func(a, b *config.Site) bool { return a.Name < b.Name }
+	case "php":
+		less = func(a, b *config.Site) bool { return sitePHP(a, cfg) < sitePHP(b, cfg) }
+	case "type":
+		less = func(a, b *config.Site) bool { return a.Type < b.Type }
+	case "path":
+		less = func(a, b *config.Site) bool { return a.Path < b.Path }
+	case "created":
+		less = func(a, b *config.Site) bool { return timeBefore(a.CreatedAt, b.CreatedAt) }
+	case "updated":
+		less = func(a, b *config.Site) bool { return timeBefore(a.UpdatedAt, b.UpdatedAt) }
+	case "seen":
+		less = func(a, b *config.Site) bool { return timeBefore(a.LastSeen, b.LastSeen) }
+	default:
+		return fmt.Errorf("unknown sort '%s' (use name, php, type, path, created, updated or seen)", by)
+	}
+
+	sort.SliceStable(sites, func(i, j int) bool { return less(&sites[i], &sites[j]) })
+	return nil
+}
+
+// timeBefore orders unrecorded times first
+func timeBefore(a, b *time.Time) bool {
+	switch {
+	case b == nil:
+		return false
+	case a == nil:
+		return true
+	default:
+		return a.Before(*b)
+	}
+}
+
+// checkSites sends each site a HEAD request, all at once, and returns how
+// they answered by site name
+func checkSites(sites []config.Site, cfg *config.Config) map[string]*health.Result {
+	results := make(map[string]*health.Result, len(sites))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := range sites {
+		if sites[i].Disabled {
+			continue
+		}
+
+		wg.Add(1)
+		go func(site *config.Site) {
+			defer wg.Done()
+			result := health.Probe(health.Target{URL: siteURL(site, cfg), Local: true, Head: true}, siteCheckTimeout)
+			mu.Lock()
+			results[site.Name] = result
+			mu.Unlock()
+		}(&sites[i])
+	}
+
+	wg.Wait()
+	return results
+}
+
+// siteBadge is a site's check result for a STATUS column, or that it's
+// disabled (and so wasn't checked)
+func siteBadge(site *config.Site, checks map[string]*health.Result) string {
+	if site.Disabled {
+		return "⏸️  disabled"
+	}
+	return checks[site.Name].Badge()
+}
+
+// siteLabel is siteBadge in ASCII, so the columns after STATUS line up
+func siteLabel(site *config.Site, checks map[string]*health.Result) string {
+	if site.Disabled {
+		return "disabled"
+	}
+	return checks[site.Name].Label()
+}
+
+// printSiteTable prints sites as an aligned table, with a STATUS column
+// when they've been checked
+func printSiteTable(sites []config.Site, cfg *config.Config, checks map[string]*health.Result, long, wide bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	header := "NAME\tURL\tPHP\tSSL\tTYPE\tPATH"
+	if checks != nil {
+		header = "NAME\tURL\tSTATUS\tPHP\tSSL\tTYPE\tPATH"
+	}
+	if long {
+		header += "\tTAGS\tCREATED\tUPDATED\tLAST SEEN\tPARKED IN\tNOTE"
+	}
+	fmt.Fprintln(w, header)
+
+	for i := range sites {
+		site := &sites[i]
+
+		ssl := "-"
+		if site.Secured {
+			ssl = "yes"
+		}
+
+		path := site.Path
+		if !wide {
+			path = shortenPath(path, 40)
+		}
+
+		columns := []string{site.Name, siteURL(site, cfg)}
+		if checks != nil {
+			columns = append(columns, siteLabel(site, checks))
+		}
+		// Proxied sites show their upstream where others show PHP
+		phpColumn := sitePHP(site, cfg)
+		if site.Proxy != "" {
+			phpColumn = "→ " + site.Proxy
+		}
+		columns = append(columns, phpColumn, ssl, site.Type, path)
+		row := strings.Join(columns, "\t")
+		if long {
+			tags := strings.Join(site.Tags, ",")
+			if tags == "" {
+				tags = "-"
+			}
+			parkedIn := "-"
+			if site.ParkedIn != "" {
+				parkedIn = site.ParkedIn
+				if !wide {
+					parkedIn = shortenPath(parkedIn, 30)
+				}
+			}
+			note := site.Description
+			if !wide {
+				note = shortenNote(note, 40)
+			}
+			row += "\t" + strings.Join([]string{tags, shortAgo(site.CreatedAt), shortAgo(site.UpdatedAt), shortAgo(site.LastSeen), parkedIn, note}, "\t")
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	w.Flush()
+	fmt.Printf("\n%d site(s)\n", len(sites))
+}
+
+// shortenPath abbreviates the home directory as ~ and trims long paths
+// from the left so the project directory stays visible
+func shortenPath(path string, max int) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = filepath.Join("~", rel)
+		}
+	}
+
+	runes := []rune(path)
+	if len(runes) <= max {
+		return path
+	}
+	return "…" + string(runes[len(runes)-max+1:])
+}
+
+// shortenNote cuts a description to max characters for a table column
+func shortenNote(note string, max int) string {
+	runes := []rune(note)
+	if len(runes) <= max {
+		return note
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// shortAgo is a compact "how long ago" for table columns
+func shortAgo(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	ago := time.Since(*t)
+	switch {
+	case ago < time.Minute:
+		return "now"
+	case ago < time.Hour:
+		return fmt.Sprintf("%dm ago", int(ago.Minutes()))
+	case ago < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(ago.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(ago.Hours()/24))
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// lintProblem is something wrong with a deployed config that nginx -t
+// doesn't catch
+type lintProblem struct {
+	site    string
+	problem string
+	fix     string
+}
+
+func lintCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lint [site...]",
+		Short: "Check deployed nginx configs for problems nginx -t doesn't catch",
+		Long: `Lint reads the nginx configs PHPark deployed and checks what they refer to:
+the PHP-FPM sockets (or upstreams and TCP addresses) they pass to exist and
+answer, certificate and key files exist and belong together, the document
+root exists and holds a front controller (index.php or index.html), and no
+other enabled config claims the same server_name (nginx only warns and
+ignores the later one). Exits non-zero if anything was found.`,
+		// Problems are a result, not a usage mistake
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLint(args)
+		},
+	}
+}
+
+func runLint(names []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+	if server.Name() != "nginx" || cfg.Backend == "docker" {
+		return fmt.Errorf("lint only handles the native nginx web server")
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	var selected []config.Site
+	for _, name := range names {
+		site := sites.FindSite(name)
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, name)
+		}
+		selected = append(selected, *site)
+	}
+	if len(names) == 0 {
+		selected = sites.ListSites()
+	}
+
+	upstreams := make(map[string]string)
+	if content, err := os.ReadFile(services.NginxGlobalConfigPath()); err == nil {
+		for _, upstream := range nginx.ParseUpstreams(string(content)) {
+			upstreams[upstream.Name] = upstream.Server
+		}
+	}
+
+	fmt.Printf("🔍 Linting nginx configs for %d site(s)...\n\n", len(selected))
+
+	var problems []lintProblem
+	checked := 0
+	for i := range selected {
+		site := &selected[i]
+		// Builtin sites have no web server config
+		if site.Builtin {
+			continue
+		}
+		checked++
+
+		path := server.DeployedPath(paths, sharedName(site.Name))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, lintProblem{
+				site:    site.Name,
+				problem: "config isn't deployed (" + path + ")",
+				fix:     "phppark rebuild " + site.Name,
+			})
+			continue
+		}
+
+		problems = append(problems, lintFastCGI(site, content, upstreams)...)
+		problems = append(problems, lintCertificates(site, content)...)
+		problems = append(problems, lintDocRoot(site, content)...)
+	}
+	problems = append(problems, lintServerNames(selected, cfg, server, paths)...)
+
+	if len(problems) == 0 {
+		fmt.Printf("✅ %d config(s) look right\n", checked)
+		return nil
+	}
+
+	for _, p := range problems {
+		fmt.Printf("❌ %s.%s: %s\n", p.site, cfg.SiteDomain(), p.problem)
+		if p.fix != "" {
+			fmt.Printf("   Fix: %s\n", p.fix)
+		}
+	}
+
+	fmt.Printf("\n📋 %d problem(s) in %d config(s)\n", len(problems), checked)
+	return fmt.Errorf("%d problem(s)", len(problems))
+}
+
+// lintFastCGI checks that what a config passes PHP to is there: a socket
+// file, an upstream defined in the global include, or a TCP address that
+// accepts connections
+func lintFastCGI(site *config.Site, content []byte, upstreams map[string]string) []lintProblem {
+	var problems []lintProblem
+	seen := make(map[string]bool)
+	for _, args := range nginx.Directives(content, "fastcgi_pass") {
+		if len(args) == 0 || seen[args[0]] {
+			continue
+		}
+		target := args[0]
+		seen[target] = true
+
+		if !strings.HasPrefix(target, "unix:") && !strings.Contains(target, ":") {
+			server, ok := upstreams[target]
+			if !ok {
+				problems = append(problems, lintProblem{
+					site:    site.Name,
+					problem: fmt.Sprintf("passes PHP to upstream %s, which %s doesn't define", target, services.NginxGlobalConfigPath()),
+					fix:     "phppark rebuild " + site.Name,
+				})
+				continue
+			}
+			target = server
+		}
+
+		if problem := fastCGIProblem(target); problem != "" {
+			problems = append(problems, lintProblem{
+				site:    site.Name,
+				problem: problem,
+				fix:     "phppark start (or check fpm_listen in config.yaml)",
+			})
+		}
+	}
+	return problems
+}
+
+// fastCGIProblem says why nothing answers at a FastCGI address, or ""
+func fastCGIProblem(target string) string {
+	if socket, ok := strings.CutPrefix(target, "unix:"); ok {
+		info, err := os.Stat(socket)
+		switch {
+		case err != nil:
+			return "PHP-FPM socket " + socket + " doesn't exist"
+		case info.Mode()&os.ModeSocket == 0:
+			return socket + " isn't a socket"
+		}
+		return ""
+	}
+
+	conn, err := net.DialTimeout("tcp", target, 2*time.Second)
+	if err != nil {
+		return "nothing accepts connections at " + target
+	}
+	conn.Close()
+	return ""
+}
+
+// lintCertificates checks that a config's certificate and key exist and
+// are a pair. nginx only finds out when it next reloads, and then stops.
+func lintCertificates(site *config.Site, content []byte) []lintProblem {
+	certs := nginx.Directives(content, "ssl_certificate")
+	keys := nginx.Directives(content, "ssl_certificate_key")
+	if len(certs) == 0 && len(keys) == 0 {
+		return nil
+	}
+
+	fix := "phppark secure " + site.Name
+	if len(certs) == 0 || len(keys) == 0 || len(certs[0]) == 0 || len(keys[0]) == 0 {
+		return []lintProblem{{site: site.Name, problem: "has a certificate without a key, or a key without a certificate", fix: fix}}
+	}
+
+	cert, key := certs[0][0], keys[0][0]
+	for _, file := range []string{cert, key} {
+		if _, err := os.Stat(file); err != nil {
+			return []lintProblem{{site: site.Name, problem: file + " doesn't exist", fix: fix}}
+		}
+	}
+	if _, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		return []lintProblem{{site: site.Name, problem: fmt.Sprintf("certificate and key don't match (%v)", err), fix: fix}}
+	}
+	return nil
+}
+
+// lintDocRoot checks that a config's document root exists and holds one of
+// its index files. Proxied sites may have no root at all.
+func lintDocRoot(site *config.Site, content []byte) []lintProblem {
+	roots := nginx.Directives(content, "root")
+	if len(roots) == 0 || len(roots[0]) == 0 {
+		return nil
+	}
+	root := roots[0][0]
+
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return []lintProblem{{
+			site:    site.Name,
+			problem: "document root " + root + " doesn't exist",
+			fix:     fmt.Sprintf("phppark docroot %s <dir>, or restore the directory", site.Name),
+		}}
+	}
+
+	// The upstream handles everything but static files
+	if site.Proxy != "" || site.Octane {
+		return nil
+	}
+
+	index := []string{"index.php", "index.html", "index.htm"}
+	if found := nginx.Directives(content, "index"); len(found) > 0 {
+		index = found[0]
+	}
+	for _, name := range index {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			return nil
+		}
+	}
+	return []lintProblem{{
+		site:    site.Name,
+		problem: fmt.Sprintf("document root %s has no front controller (%s)", root, strings.Join(index, ", ")),
+		fix:     fmt.Sprintf("phppark docroot %s <dir>, if the site is served from another directory", site.Name),
+	}}
+}
+
+// lintServerNames finds the hostnames of the checked sites that another
+// enabled config also claims. nginx serves the first it loads and only
+// warns about the rest, so one of the sites silently isn't reachable.
+func lintServerNames(sites []config.Site, cfg *config.Config, server webserver.Server, paths *config.Paths) []lintProblem {
+	claims := make(map[string][]string)
+	for _, file := range services.NginxEnabledConfigs() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, args := range nginx.Directives(content, "server_name") {
+			for _, name := range args {
+				if !containsString(claims[name], file) {
+					claims[name] = append(claims[name], file)
+				}
+			}
+		}
+	}
+
+	var problems []lintProblem
+	for _, site := range sites {
+		if site.Builtin {
+			continue
+		}
+		hostname := site.Name + "." + cfg.SiteDomain()
+		own, err := filepath.EvalSymlinks(server.DeployedPath(paths, sharedName(site.Name)))
+		if err != nil {
+			continue
+		}
+
+		var others []string
+		for _, file := range claims[hostname] {
+			if resolved, err := filepath.EvalSymlinks(file); err != nil || resolved != own {
+				others = append(others, file)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		sort.Strings(others)
+		problems = append(problems, lintProblem{
+			site:    site.Name,
+			problem: fmt.Sprintf("server_name %s is also claimed by %s", hostname, strings.Join(others, ", ")),
+			fix:     "remove the other config (see: phppark clean) or rename one of the sites",
+		})
+	}
+	return problems
+}
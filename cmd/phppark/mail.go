@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+func mailCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mail",
+		Short: "Capture outgoing email locally with Mailpit",
+		Long:  `Mail manages a local Mailpit instance that catches all mail sent by your sites.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "install",
+		Short: "Install and start Mailpit",
+		Long:  `Install downloads Mailpit, runs it as a service, and serves its inbox at mail.test.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, _ := services.GetService("mailpit")
+			return installService(svc, true)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove Mailpit",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, _ := services.GetService("mailpit")
+			return uninstallService(svc)
+		},
+	})
+
+	return cmd
+}
@@ -2,39 +2,102 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/stevepop/phppark/internal/config"
 	"github.com/stevepop/phppark/internal/dns"
+	"github.com/stevepop/phppark/internal/hooks"
+	"github.com/stevepop/phppark/internal/i18n"
 	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
 	"github.com/stevepop/phppark/internal/php"
 	"github.com/stevepop/phppark/internal/services"
 	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
 )
 
 var version = "0.1.0-dev"
 
 func main() {
+	var verbose, quiet, plain bool
+	var home string
+
+	// Until config.yaml is read, for errors about flags and arguments
+	i18n.SetLocale(i18n.Detect(""))
+
 	rootCmd := &cobra.Command{
 		Use:     "phppark",
 		Short:   "PHPark - Development environment manager for Linux",
 		Long:    `A modern development environment manager for Linux inspired by Laravel Valet.`,
 		Version: version,
+		// main prints the error, once
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// Set in the environment so hooks and the phppark runs they
+			// make use it too
+			if home != "" {
+				if abs, err := filepath.Abs(home); err == nil {
+					os.Setenv(config.HomeEnv, abs)
+				}
+			}
+			if quiet {
+				cmd.SilenceUsage = true
+				silenceOutput()
+			} else if plain || (wantsPlainOutput() && !isPassthrough(cmd)) {
+				startPlainOutput()
+			}
+			oplog.SetVerbose(verbose)
+			openOplog(cmd, args)
+			applyNginxLayout()
+			applyMultiUser()
+			applyLocale()
+		},
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Print each command PHPark runs and its output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing but errors (on stderr), for scripts")
+	rootCmd.PersistentFlags().StringVar(&home, "home", "", "Keep PHPark's config and state in this directory (default: $PHPPARK_HOME, or ~/.phppark)")
+	rootCmd.PersistentFlags().BoolVar(&plain, "plain", false, "Print plain text, without emoji or escape codes (default when NO_COLOR is set or output isn't a terminal)")
+
 	// Add commands
 	rootCmd.AddCommand(installCmd())
 	rootCmd.AddCommand(setupCmd())
 	rootCmd.AddCommand(parkCmd())
 	rootCmd.AddCommand(linkCmd())
+	rootCmd.AddCommand(newCmd())
+	rootCmd.AddCommand(wpCmd())
 	rootCmd.AddCommand(unlinkCmd())
+	rootCmd.AddCommand(disableCmd())
+	rootCmd.AddCommand(enableCmd())
+	rootCmd.AddCommand(serveCmd())
 	rootCmd.AddCommand(linksCmd())
+	rootCmd.AddCommand(infoCmd())
+	rootCmd.AddCommand(tagCmd())
+	rootCmd.AddCommand(noteCmd())
+	rootCmd.AddCommand(hooksCmd())
+	rootCmd.AddCommand(searchCmd())
 	rootCmd.AddCommand(rebuildCmd())
+	rootCmd.AddCommand(cleanCmd())
+	rootCmd.AddCommand(syncCmd())
+	rootCmd.AddCommand(applyCmd())
+	rootCmd.AddCommand(profileCmd())
+	rootCmd.AddCommand(renewCmd())
+	rootCmd.AddCommand(throttleCmd())
+	rootCmd.AddCommand(trafficCmd())
+	rootCmd.AddCommand(dumpServerCmd())
+	rootCmd.AddCommand(preloadCmd())
+	rootCmd.AddCommand(templatesCmd())
 	rootCmd.AddCommand(secureCmd())
 	rootCmd.AddCommand(unsecureCmd())
 	rootCmd.AddCommand(phpListCmd())
@@ -42,11 +105,138 @@ func main() {
 	rootCmd.AddCommand(statusCmd())
 	rootCmd.AddCommand(trustCmd())
 	rootCmd.AddCommand(untrustCmd())
+	rootCmd.AddCommand(dnsServeCmd())
+	rootCmd.AddCommand(dnsDoctorCmd())
+	rootCmd.AddCommand(fpmStatusCmd())
+	rootCmd.AddCommand(phpWhichCmd())
+	rootCmd.AddCommand(envCmd())
+	rootCmd.AddCommand(limitsCmd())
+	rootCmd.AddCommand(docrootCmd())
+	rootCmd.AddCommand(mountCmd())
+	rootCmd.AddCommand(unmountCmd())
+	rootCmd.AddCommand(dbCreateCmd())
+	rootCmd.AddCommand(dbDropCmd())
+	rootCmd.AddCommand(dbListCmd())
+	rootCmd.AddCommand(mailCmd())
+	rootCmd.AddCommand(serviceCmd())
+	rootCmd.AddCommand(adminerCmd())
+	rootCmd.AddCommand(pmaCmd())
+	rootCmd.AddCommand(workerCmd())
+	rootCmd.AddCommand(scheduleCmd())
+	rootCmd.AddCommand(runCmd())
+	rootCmd.AddCommand(startCmd())
+	rootCmd.AddCommand(stopCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(testCmd())
+	rootCmd.AddCommand(benchCmd())
+	rootCmd.AddCommand(auditCmd())
+	rootCmd.AddCommand(lintCmd())
+	rootCmd.AddCommand(watchCmd())
+	rootCmd.AddCommand(watchConfigCmd())
+	rootCmd.AddCommand(daemonCmd())
+	rootCmd.AddCommand(historyCmd())
+
+	err := rootCmd.Execute()
+	if err != nil {
+		fmt.Fprint(os.Stderr, i18n.Sprintf(i18n.ErrorPrefix, err))
+		printConfigTestDetail(err, "   ")
+	}
+	flushOutput()
+	os.Exit(exitCode(err))
+}
+
+// applyNginxLayout points the nginx helpers at the install config.yaml
+// describes, if it's not the Debian default
+func applyNginxLayout() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return
+	}
+
+	services.SetNginxLayout(services.NginxLayout{
+		Binary:     cfg.Nginx.Binary,
+		Service:    cfg.Nginx.Service,
+		ConfDir:    cfg.Nginx.ConfDir,
+		IncludeDir: cfg.Nginx.IncludeDir,
+	})
+}
+
+// applyLocale picks the language messages are printed in, now config.yaml
+// can be read
+func applyLocale() {
+	locale := ""
+	if cfg, err := config.LoadConfig(); err == nil {
+		locale = cfg.Locale
+	}
+	i18n.SetLocale(i18n.Detect(locale))
+}
+
+// userNamespace is the name of the user PHPark runs for in multi-user
+// mode, set by applyMultiUser
+var userNamespace string
+
+// siteNamespaces caches siteNamespace by site name
+var siteNamespaces = make(map[string]string)
+
+// applyMultiUser turns on multi-user naming if config.yaml asks for it
+func applyMultiUser() {
+	if cfg, err := config.LoadConfig(); err == nil && cfg.MultiUser {
+		userNamespace = config.Namespace()
+	}
+}
+
+// sharedName is what a site is called in places other users' sites live
+// too (/etc/nginx, /var/log/nginx, systemd units): its name, with its
+// owner's appended in multi-user mode
+func sharedName(siteName string) string {
+	if userNamespace == "" {
+		return siteName
+	}
+	return siteName + "." + siteNamespace(siteName)
+}
+
+// siteNamespace returns the user a site belongs to in multi-user mode: the
+// owner of its directory, so the name doesn't change with who runs phppark
+// (or whether through sudo). Sites that aren't registered yet, and those in
+// directories owned by root, belong to the user PHPark runs for.
+func siteNamespace(siteName string) string {
+	if namespace, ok := siteNamespaces[siteName]; ok {
+		return namespace
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return userNamespace
+	}
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return userNamespace
+	}
+
+	namespace := userNamespace
+	if owner := pathOwner(site.Path); owner != nil {
+		namespace = config.UserNamespace(owner.Username)
+	}
+	siteNamespaces[siteName] = namespace
+	return namespace
+}
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+// pathOwner returns the user that owns a path, or nil if it's root's or
+// can't be looked up
+func pathOwner(path string) *user.User {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Uid == 0 {
+		return nil
+	}
+	owner, err := user.LookupId(strconv.FormatUint(uint64(stat.Uid), 10))
+	if err != nil {
+		return nil
 	}
+	return owner
 }
 
 func installCmd() *cobra.Command {
@@ -73,7 +263,7 @@ func runInstall() error {
 		return nil
 	}
 
-	fmt.Println("🚀 Installing PHPark...\n")
+	fmt.Print("🚀 Installing PHPark...\n\n")
 
 	// Create directory structure
 	if err := paths.EnsureDirectories(); err != nil {
@@ -83,26 +273,34 @@ func runInstall() error {
 	// Create default config
 	defaultConfig := config.DefaultConfig()
 	if err := config.SaveConfig(defaultConfig); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+		return i18n.Errorf(i18n.SaveConfigFailed, err)
 	}
 
 	// Create empty sites registry
 	emptySites := &config.SiteRegistry{Sites: []config.Site{}}
 	if err := config.SaveSites(emptySites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
-	fmt.Println("✅ PHPark installed successfully!")
+	fmt.Println(i18n.T(i18n.InstallSucceeded))
 	fmt.Printf("\nConfiguration directory: %s\n", paths.Home)
 	fmt.Printf("Config file: %s\n", paths.Config)
 	fmt.Printf("Sites file: %s\n", paths.Sites)
 
-	fmt.Println("\n🔧 Checking system requirements...")
+	// Install CLI shims so `phppark use` can switch PHP without sudo
+	if err := installShims(paths); err != nil {
+		fmt.Printf("⚠️  Warning: Could not install PHP shims: %v\n", err)
+	} else {
+		offerShimPath(paths)
+	}
+	offerRenewTimer()
+
+	fmt.Println(i18n.T(i18n.CheckingSystem))
 
 	missingDeps := []string{}
 
 	// Check for nginx
-	if _, err := exec.LookPath("nginx"); err != nil {
+	if _, err := exec.LookPath(services.NginxBinary()); err != nil {
 		missingDeps = append(missingDeps, "nginx")
 	}
 
@@ -118,12 +316,12 @@ func runInstall() error {
 	}
 
 	if len(missingDeps) > 0 {
-		fmt.Println("\n⚠️  Missing dependencies detected:")
+		fmt.Println(i18n.T(i18n.MissingDeps))
 		for _, dep := range missingDeps {
 			fmt.Printf("   - %s\n", dep)
 		}
-		fmt.Println("\n💡 Quick install: Run 'sudo phppark setup' to install everything")
-		fmt.Println("   Or install manually: sudo apt install nginx dnsmasq php8.2-fpm")
+		fmt.Println(i18n.T(i18n.QuickInstall))
+		fmt.Println(i18n.T(i18n.InstallManually))
 		return nil
 	}
 
@@ -144,28 +342,123 @@ func runInstall() error {
 		}
 	}
 
-	fmt.Println("\n📚 Next steps:")
-	fmt.Println("  1. Review/edit config: cat ~/.phppark/config.yaml")
-	fmt.Println("  2. Park a directory: phppark park ~/sites")
-	fmt.Println("  3. Link a site: phppark link myapp")
+	fmt.Println(i18n.T(i18n.NextSteps))
+	i18n.Printf(i18n.StepReviewConfig, paths.Config)
+	fmt.Println(i18n.T(i18n.StepPark))
+	fmt.Println(i18n.T(i18n.StepLink))
 
 	return nil
 }
 
+// setupPHPNone is the setup --php value that installs no PHP
+const setupPHPNone = "none"
+
+// setupOfferedPHP are the PHP versions setup offers to pick from when asked
+// interactively; --php takes any other too
+var setupOfferedPHP = []string{"8.1", "8.2", "8.3", "8.4"}
+
+// phpVersionPattern matches a PHP version as PHPark takes it, e.g., 8.3
+var phpVersionPattern = regexp.MustCompile(`^\d+\.\d+$`)
+
 func setupCmd() *cobra.Command {
-	return &cobra.Command{
+	var phpVersions []string
+
+	cmd := &cobra.Command{
 		Use:   "setup",
 		Short: "Complete PHPark setup (install all dependencies)",
-		Long:  `Setup installs PHPark and all required dependencies (nginx, dnsmasq, PHP).`,
+		Long: `Setup installs PHPark and all required dependencies (nginx, dnsmasq, PHP).
+--php picks the PHP versions installed (e.g., --php 8.2,8.3); each one's FPM
+is started and the newest becomes the default. With --php none no PHP is
+installed and the default is the newest one already there. Without --php,
+setup asks which versions to install when run in a terminal.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetup()
+			if !cmd.Flags().Changed("php") && stdinIsTerminal() {
+				phpVersions = promptPHPVersions(phpVersions)
+			}
+			return runSetup(phpVersions)
 		},
 	}
+
+	cmd.Flags().StringSliceVar(&phpVersions, "php", []string{"8.3"}, "PHP versions to install, the newest becoming the default (e.g., 8.2,8.3), or \"none\"")
+
+	return cmd
+}
+
+// stdinIsTerminal reports whether someone is there to answer questions
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptPHPVersions asks which PHP versions setup should install, by number
+// or version, returning defaults when the answer is empty
+func promptPHPVersions(defaults []string) []string {
+	fmt.Println("🐘 Which PHP versions should be installed?")
+	for i, version := range setupOfferedPHP {
+		mark := ""
+		if containsString(defaults, version) {
+			mark = " (default)"
+		}
+		fmt.Printf("  %d) PHP %s%s\n", i+1, version, mark)
+	}
+	fmt.Printf("Pick one or more (e.g., 2,3 or 8.2,8.3), or none [%s]: ", strings.Join(defaults, ","))
+
+	var response string
+	fmt.Scanln(&response)
+	if response == "" {
+		fmt.Println()
+		return defaults
+	}
+
+	var picked []string
+	for _, answer := range strings.Split(response, ",") {
+		answer = strings.TrimSpace(answer)
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(setupOfferedPHP) {
+			answer = setupOfferedPHP[n-1]
+		}
+		picked = append(picked, answer)
+	}
+	fmt.Println()
+	return picked
 }
 
-func runSetup() error {
+// setupPHPList validates setup's --php versions, returning them without
+// duplicates, newest first. "none" (alone) returns no versions.
+func setupPHPList(values []string) ([]string, error) {
+	var versions []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == setupPHPNone {
+			if len(values) > 1 {
+				return nil, fmt.Errorf("--php none can't be combined with versions")
+			}
+			return nil, nil
+		}
+		if !phpVersionPattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid --php '%s' (expected a version such as 8.3, or none)", value)
+		}
+		if !containsString(versions, value) {
+			versions = append(versions, value)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("--php needs a version such as 8.3, or none")
+	}
+
+	// Newest first, as DetectPHPVersions sorts them
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+	return versions, nil
+}
+
+func runSetup(phpValues []string) error {
 	if os.Getuid() != 0 {
-		return fmt.Errorf("setup must be run as root: use 'sudo phppark setup'")
+		return i18n.Errorf(i18n.SetupNeedsRoot)
+	}
+	versions, err := setupPHPList(phpValues)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("🚀 PHPark Complete Setup")
@@ -173,7 +466,9 @@ func runSetup() error {
 	fmt.Println("\nThis will install:")
 	fmt.Println("  • nginx (web server)")
 	fmt.Println("  • dnsmasq (DNS resolver)")
-	fmt.Println("  • PHP 8.3-FPM (with common extensions)")
+	for _, version := range versions {
+		fmt.Printf("  • PHP %s-FPM (with common extensions)\n", version)
+	}
 	fmt.Println("  • PHPark configuration")
 	fmt.Printf("\nContinue? (Y/n): ")
 
@@ -188,54 +483,71 @@ func runSetup() error {
 	// Update package list first
 	fmt.Println("\n📦 Updating package list...")
 	cmd := exec.Command("apt-get", "update")
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Stream(cmd); err != nil {
 		fmt.Printf("⚠️  Warning: apt-get update failed: %v\n", err)
 	}
 
 	// Install nginx
 	fmt.Println("\n📦 Installing nginx...")
 	cmd = exec.Command("apt-get", "install", "-y", "nginx")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install nginx: %w", err)
+	if err := oplog.Stream(cmd); err != nil {
+		return i18n.Errorf(i18n.InstallFailed, "nginx", err)
 	}
 	fmt.Println("✅ Nginx installed")
 
 	// Install dnsmasq
 	fmt.Println("\n📦 Installing dnsmasq...")
 	cmd = exec.Command("apt-get", "install", "-y", "dnsmasq")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install dnsmasq: %w", err)
+	if err := oplog.Stream(cmd); err != nil {
+		return i18n.Errorf(i18n.InstallFailed, "dnsmasq", err)
 	}
 	fmt.Println("✅ dnsmasq installed")
 
 	// Install software-properties-common (for add-apt-repository)
 	fmt.Println("\n📦 Installing prerequisites...")
 	cmd = exec.Command("apt-get", "install", "-y", "software-properties-common")
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Stream(cmd); err != nil {
 		fmt.Printf("⚠️  Warning: Could not install software-properties-common: %v\n", err)
 	}
 
-	// Install PHP 8.3
+	// Install PHP
 	// NOTE: this must happen BEFORE we disable the systemd-resolved stub listener.
 	// Disabling the stub replaces /etc/resolv.conf with 127.0.0.1 (dnsmasq), but
 	// dnsmasq isn't running yet at this point — so any network operations (apt,
 	// add-apt-repository) would fail with DNS resolution errors.
-	fmt.Println("\n📦 Installing PHP 8.3-FPM...")
-	if err := php.InstallPHP("8.3"); err != nil {
-		return fmt.Errorf("failed to install PHP: %w", err)
+	var installed []string
+	for _, version := range versions {
+		fmt.Printf("\n📦 Installing PHP %s-FPM...\n", version)
+		if err := php.InstallPHP(version); err != nil {
+			// One version failing (e.g., not packaged for this release)
+			// shouldn't cost the others
+			if len(versions) == 1 {
+				return i18n.Errorf(i18n.InstallFailed, "PHP "+version, err)
+			}
+			fmt.Printf("⚠️  Warning: PHP %s not installed: %v\n", version, err)
+			continue
+		}
+		installed = append(installed, version)
+	}
+	if len(versions) > 0 && len(installed) == 0 {
+		return i18n.Errorf(i18n.NoPHPInstalled, strings.Join(versions, ", "))
 	}
 
 	// Now that all packages are installed (no more network ops needed), disable
 	// the systemd-resolved stub listener so dnsmasq can bind port 53.
 	// We only disable the stub — systemd-resolved keeps running so that VPN,
-	// DHCP, and NetworkManager DNS routing continue to work normally.
-	if dns.CheckSystemdResolvedConflict() {
-		fmt.Println("\n⚠️  systemd-resolved stub listener is occupying port 53")
+	// DHCP, and NetworkManager DNS routing continue to work normally. The
+	// drivers that route through systemd-resolved leave the stub alone.
+	routedDNS := false
+	if cfg, err := config.LoadConfig(); err == nil {
+		routedDNS = cfg.DNS.Driver == "resolved" || cfg.DNS.Driver == dnsmasqResolvedDriver
+	}
+	if !routedDNS && dns.CheckSystemdResolvedConflict() {
+		fmt.Println(i18n.T(i18n.StubListenerBusy))
 		fmt.Println("   Disabling stub listener (systemd-resolved will keep running)...")
 		if err := dns.DisableSystemdResolvedStub(); err != nil {
 			fmt.Printf("   ⚠️  Warning: could not fix automatically: %v\n", err)
-			fmt.Println("   To fix manually, add DNSStubListener=no to /etc/systemd/resolved.conf")
-			fmt.Println("   then run: sudo systemctl restart systemd-resolved")
+			fmt.Println(i18n.T(i18n.StubListenerManual))
 		} else {
 			fmt.Println("   ✅ Stub listener disabled — systemd-resolved still running for VPN/DHCP DNS")
 		}
@@ -254,17 +566,24 @@ func runSetup() error {
 		return fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	// Create default config
+	// Create default config, defaulting to the newest PHP installed
 	defaultConfig := config.DefaultConfig()
-	defaultConfig.DefaultPHP = "8.3"
+	phpVersion := defaultConfig.DefaultPHP
+	if len(installed) > 0 {
+		phpVersion = installed[0]
+	} else if detected, err := php.DetectPHPVersions(); err == nil && len(detected) > 0 {
+		// --php none: the newest PHP already there, if any
+		phpVersion = detected[0].Version
+	}
+	defaultConfig.DefaultPHP = phpVersion
 	if err := config.SaveConfig(defaultConfig); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+		return i18n.Errorf(i18n.SaveConfigFailed, err)
 	}
 
 	// Create empty sites registry
 	emptySites := &config.SiteRegistry{Sites: []config.Site{}}
 	if err := config.SaveSites(emptySites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
 	// Start services
@@ -276,21 +595,32 @@ func runSetup() error {
 		fmt.Println("✅ Nginx started")
 	}
 
-	if err := services.StartPHPFPM("8.3"); err != nil {
-		fmt.Printf("⚠️  Warning: Could not start PHP-FPM: %v\n", err)
-	} else {
-		fmt.Println("✅ PHP 8.3-FPM started")
+	started := installed
+	if len(versions) == 0 {
+		if php.BinaryPath(phpVersion) != "" {
+			started = []string{phpVersion}
+		}
+	}
+	if len(started) == 0 {
+		fmt.Printf("💡 No PHP installed (add one with: sudo phppark use %s)\n", phpVersion)
+	}
+	for _, version := range started {
+		if err := services.StartPHPFPM(version); err != nil {
+			fmt.Printf("⚠️  Warning: Could not start PHP %s-FPM: %v\n", version, err)
+		} else {
+			fmt.Printf("✅ PHP %s-FPM started\n", version)
+		}
 	}
 
 	// Success message
 	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("✅ Setup complete!")
+	fmt.Println(i18n.T(i18n.SetupComplete))
 	fmt.Println(strings.Repeat("=", 50))
 
 	fmt.Printf("\nConfiguration directory: %s\n", paths.Home)
-	fmt.Printf("Default PHP version: 8.3\n")
+	fmt.Printf("Default PHP version: %s\n", phpVersion)
 
-	fmt.Println("\n📚 Try it out:")
+	fmt.Println(i18n.T(i18n.TryItOut))
 	fmt.Println("  mkdir -p ~/sites/myapp/public")
 	fmt.Println("  echo '<?php phpinfo(); ?>' > ~/sites/myapp/public/index.php")
 	fmt.Println("  cd ~/sites")
@@ -298,13 +628,24 @@ func runSetup() error {
 	fmt.Println("  sudo phppark trust")
 	fmt.Println("  curl http://myapp.test")
 
-	fmt.Println("\n💡 Tip: Run 'phppark status' to see your configuration")
+	fmt.Println(i18n.T(i18n.StatusTip))
 
 	return nil
 }
 
+// parkOptions holds flags for the park command
+type parkOptions struct {
+	withDB   bool     // Create a database for each new site
+	secure   bool     // Serve new sites over HTTPS
+	php      string   // PHP version for new sites (default: the global default)
+	exclude  []string // Glob patterns of subdirectories that aren't sites
+	template string   // Web server template for new sites (default: detected)
+}
+
 func parkCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts parkOptions
+
+	cmd := &cobra.Command{
 		Use:   "park [path]",
 		Short: "Park a directory - serve all subdirectories as sites",
 		Long:  `Park registers a directory so all subdirectories are served as <dirname>.test`,
@@ -314,18 +655,26 @@ func parkCmd() *cobra.Command {
 			if len(args) > 0 {
 				path = args[0]
 			}
-			return runPark(path)
+			return runPark(path, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.withDB, "with-db", false, "Create a database for each new site")
+	cmd.Flags().BoolVar(&opts.secure, "secure", false, "Generate certificates and serve new sites over HTTPS")
+	cmd.Flags().StringVar(&opts.php, "php", "", "PHP version for new sites (default: the global default)")
+	cmd.Flags().StringSliceVar(&opts.exclude, "exclude", nil, "Skip subdirectories matching these globs (e.g., \"archive-*,tmp\")")
+	cmd.Flags().StringVar(&opts.template, "template", "", "Web server template for new sites (default: detected per site, see: phppark templates)")
+
+	return cmd
 }
 
-func runPark(path string) error {
+func runPark(path string, opts parkOptions) error {
 	// If no path provided, use current directory
 	if path == "" {
 		var err error
 		path, err = os.Getwd()
 		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
+			return i18n.Errorf(i18n.CurrentDirFailed, err)
 		}
 		fmt.Printf("💡 No path provided, using current directory\n")
 	}
@@ -339,10 +688,10 @@ func runPark(path string) error {
 	// Check if directory exists
 	info, err := os.Stat(absPath)
 	if err != nil {
-		return fmt.Errorf("path does not exist: %w", err)
+		return i18n.Errorf(i18n.PathNotExist, err)
 	}
 	if !info.IsDir() {
-		return fmt.Errorf("path is not a directory: %s", absPath)
+		return i18n.Errorf(i18n.PathNotDir, absPath)
 	}
 
 	// Read all subdirectories
@@ -354,19 +703,41 @@ func runPark(path string) error {
 	// Load existing sites
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
 	// Load config for defaults
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	for _, pattern := range opts.exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --exclude pattern '%s': %w", pattern, err)
+		}
+	}
+
+	if opts.template != "" {
+		if opts.template == nginx.TemplateProxy {
+			return fmt.Errorf("--template proxy needs a URL per site (use: phppark link --proxy)")
+		}
+		if err := validateTemplate(opts.template); err != nil {
+			return err
+		}
+	}
+
+	// Check the PHP version once for every new site
+	if opts.php != "" {
+		if opts.php, err = ensurePHPVersion(opts.php, cfg, true); err != nil {
+			return err
+		}
 	}
 
 	// Track what we're adding
 	added := 0
 	skipped := 0
-	var addedSites []string
+	var parked, addedSites []string
 
 	fmt.Printf("📦 Parking directory: %s\n\n", absPath)
 
@@ -383,6 +754,11 @@ func runPark(path string) error {
 			continue
 		}
 
+		if pattern := matchExclude(name, opts.exclude); pattern != "" {
+			fmt.Printf("⏭️  Skipping '%s' (excluded by %s)\n", name, pattern)
+			continue
+		}
+
 		// Check if site already exists
 		if existing := sites.FindSite(name); existing != nil {
 			fmt.Printf("⏭️  Skipping '%s' (already exists as %s)\n", name, existing.Type)
@@ -396,29 +772,76 @@ func runPark(path string) error {
 			Name:       name,
 			Path:       sitePath,
 			Type:       "park",
-			PHPVersion: "", // Use default
-			Secured:    cfg.UseHTTPS,
+			PHPVersion: opts.php, // Empty uses the default
+			Secured:    cfg.UseHTTPS || opts.secure,
+			ParkedIn:   absPath,
+			Template:   opts.template,
+		}
+
+		if err := runHook(hooks.PreLink, &site, cfg); err != nil {
+			fmt.Printf("⏭️  Skipping '%s' (%v)\n", name, err)
+			skipped++
+			continue
+		}
+
+		// Create database
+		if opts.withDB {
+			if err := createSiteDatabase(&site, cfg, false); err != nil {
+				fmt.Printf("⚠️  %s: failed to create database (%v)\n", name, err)
+			}
 		}
 
 		// Add to registry
 		sites.AddSite(site)
+		parked = append(parked, name)
+	}
+
+	// Write every config, then test and reload once
+	var deploy []*config.Site
+	for _, name := range parked {
+		deploy = append(deploy, sites.FindSite(name))
+	}
+	failures, err := deploySites(deploy, cfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
 
-		// Generate nginx config
-		if err := generateNginxConfig(&site, cfg); err != nil {
+	for _, name := range parked {
+		if err, ok := failures[name]; ok {
 			fmt.Printf("⚠️  %s: failed to generate config (%v)\n", name, err)
-		} else {
-			addedSites = append(addedSites, name)
-			added++
+			printConfigTestDetail(err, "   ")
+			continue
 		}
+		addedSites = append(addedSites, name)
+		added++
 	}
 
 	// Save if we added anything
 	if added > 0 {
 		if err := config.SaveSites(sites); err != nil {
-			return fmt.Errorf("failed to save sites: %w", err)
+			return i18n.Errorf(i18n.SaveSitesFailed, err)
 		}
 	}
 
+	// Folders created here later get the same options (see watch-config)
+	if cfg.Parked == nil {
+		cfg.Parked = make(map[string]config.ParkedDir)
+	}
+	cfg.Parked[absPath] = config.ParkedDir{
+		PHPVersion: opts.php,
+		Secure:     opts.secure,
+		WithDB:     opts.withDB,
+		Exclude:    opts.exclude,
+		Template:   opts.template,
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		i18n.Printf(i18n.Warning, i18n.Errorf(i18n.SaveConfigFailed, err))
+	}
+
+	for _, name := range addedSites {
+		runPostHook(hooks.PostLink, sites.FindSite(name), cfg)
+	}
+
 	// Summary
 	fmt.Println()
 	if added == 0 {
@@ -431,7 +854,7 @@ func runPark(path string) error {
 	} else {
 		fmt.Printf("✅ Parked %d site(s):\n", added)
 		for _, name := range addedSites {
-			fmt.Printf("   • %s.%s\n", name, cfg.Domain)
+			fmt.Printf("   • %s.%s\n", name, cfg.SiteDomain())
 		}
 
 		if skipped > 0 {
@@ -442,27 +865,82 @@ func runPark(path string) error {
 	return nil
 }
 
+// matchExclude returns the first pattern matching a directory name, if any
+func matchExclude(name string, patterns []string) string {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.TrimSpace(pattern), name); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// linkOptions holds flags for the link command
+type linkOptions struct {
+	withDB   bool     // Create a database for the site
+	octane   bool     // Serve through a supervised Laravel Octane server
+	builtin  bool     // Serve with PHP's built-in server instead of a web server
+	port     int      // Port for the Octane or built-in server
+	path     string   // Site directory (default: current directory)
+	secure   bool     // Serve over HTTPS from the start
+	php      string   // PHP version (default: the global default)
+	proxy    string   // Upstream URL to proxy to instead of serving PHP
+	template string   // Framework template (default: detected)
+	fpm      string   // Remote or containerized PHP-FPM (tcp://host:port or a socket)
+	fpmRoot  string   // Where the site is mounted for that PHP-FPM
+	docroots []string // Directories to look for the document root in
+	docroot  string   // Document root, relative to the site (default: detected)
+}
+
 func linkCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts linkOptions
+
+	cmd := &cobra.Command{
 		Use:   "link [name]",
 		Short: "Link current directory as a site",
-		Long:  `Link creates a site that serves the current directory as <name>.test`,
+		Long:  `Link creates a site that serves the current directory (or --path) as <name>.test`,
 		Args:  cobra.MaximumNArgs(1), // 0 or 1 argument
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := ""
 			if len(args) > 0 {
 				name = args[0]
 			}
-			return runLink(name)
+			return runLink(name, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.withDB, "with-db", false, "Create a database for the site")
+	cmd.Flags().BoolVar(&opts.octane, "octane", false, "Serve with Laravel Octane instead of PHP-FPM")
+	cmd.Flags().BoolVar(&opts.builtin, "builtin", false, "Serve with PHP's built-in server (no nginx or root needed)")
+	cmd.Flags().IntVar(&opts.port, "port", 0, "Port for the Octane (default 8000) or built-in server (default: first free from 8100)")
+	cmd.Flags().BoolVar(&opts.secure, "secure", false, "Generate a certificate and serve the site over HTTPS")
+	cmd.Flags().StringVar(&opts.path, "path", "", "Directory to link (default: current directory)")
+	cmd.Flags().StringVar(&opts.php, "php", "", "PHP version for the site (default: the global default)")
+	cmd.Flags().StringVar(&opts.proxy, "proxy", "", "Proxy to an upstream URL (e.g., a Vite or Node server) instead of serving PHP")
+	cmd.Flags().StringVar(&opts.template, "template", "", "Web server template, built-in or your own (default: detected, see: phppark templates)")
+	cmd.Flags().StringVar(&opts.fpm, "fpm", "", "Use a PHP-FPM elsewhere, e.g., in Docker (tcp://127.0.0.1:9083 or unix:///path/to.sock)")
+	cmd.Flags().StringVar(&opts.docroot, "docroot", "", "Directory to serve, relative to the site (e.g., web/; default: detected)")
+	cmd.Flags().StringSliceVar(&opts.docroots, "docroot-candidates", nil, "Directories to look for the document root in, in order (default: docroot_candidates from config.yaml)")
+	cmd.Flags().StringVar(&opts.fpmRoot, "fpm-root", "", "Where the site directory is mounted for --fpm (e.g., /var/www/html)")
+
+	return cmd
 }
 
-func runLink(name string) error {
-	// Get current directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+func runLink(name string, opts linkOptions) error {
+	// Get site directory (current directory unless given)
+	currentDir := opts.path
+	if currentDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return i18n.Errorf(i18n.CurrentDirFailed, err)
+		}
+		currentDir = dir
+	} else {
+		dir, err := resolveSiteDir(currentDir)
+		if err != nil {
+			return err
+		}
+		currentDir = dir
 	}
 
 	// If no name provided, use directory name
@@ -474,7 +952,7 @@ func runLink(name string) error {
 	// Load existing sites
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
 	// Check if site already exists
@@ -489,7 +967,74 @@ func runLink(name string) error {
 	// Load config to get default PHP
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if opts.octane && opts.builtin {
+		return fmt.Errorf("--octane and --builtin can't be combined")
+	}
+	if opts.secure && opts.builtin {
+		return fmt.Errorf("--secure and --builtin can't be combined (the built-in server doesn't support HTTPS)")
+	}
+	if opts.port < 0 || opts.port > 65535 {
+		return fmt.Errorf("--port must be between 1 and 65535, got %d", opts.port)
+	}
+	if opts.proxy != "" {
+		if opts.octane || opts.builtin {
+			return fmt.Errorf("--proxy can't be combined with --octane or --builtin")
+		}
+		if u, err := url.Parse(opts.proxy); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid --proxy '%s' (expected a URL like http://127.0.0.1:5173)", opts.proxy)
+		}
+	}
+
+	if opts.template != "" {
+		if err := validateTemplate(opts.template); err != nil {
+			return err
+		}
+		if opts.template == nginx.TemplateProxy && opts.proxy == "" {
+			return fmt.Errorf("--template proxy needs the upstream URL (use: --proxy http://127.0.0.1:5173)")
+		}
+	}
+
+	if opts.fpm != "" {
+		if opts.proxy != "" || opts.octane || opts.builtin {
+			return fmt.Errorf("--fpm can't be combined with --proxy, --octane or --builtin")
+		}
+		if opts.fpm, err = parseFPMAddress(opts.fpm); err != nil {
+			return err
+		}
+	}
+	if opts.fpmRoot != "" {
+		if opts.fpm == "" {
+			return fmt.Errorf("--fpm-root needs --fpm")
+		}
+		if !filepath.IsAbs(opts.fpmRoot) {
+			return fmt.Errorf("--fpm-root must be an absolute path, got '%s'", opts.fpmRoot)
+		}
+		opts.fpmRoot = filepath.Clean(opts.fpmRoot)
+	}
+
+	if opts.docroot != "" {
+		if opts.proxy != "" {
+			return fmt.Errorf("--docroot can't be combined with --proxy")
+		}
+		if opts.docroot, err = siteDocRoot(currentDir, opts.docroot); err != nil {
+			return err
+		}
+	}
+	for i, dir := range opts.docroots {
+		if opts.docroots[i], err = cleanSiteSubdir(dir); err != nil {
+			return err
+		}
+	}
+
+	// Check the PHP version up front, offering to install it (a remote
+	// PHP-FPM brings its own)
+	if opts.php != "" && opts.fpm == "" {
+		if opts.php, err = ensurePHPVersion(opts.php, cfg, true); err != nil {
+			return err
+		}
 	}
 
 	// Create new site
@@ -497,8 +1042,58 @@ func runLink(name string) error {
 		Name:       name,
 		Path:       currentDir,
 		Type:       "link",
-		PHPVersion: "", // Use default from config
-		Secured:    cfg.UseHTTPS,
+		PHPVersion: opts.php, // Empty uses the default from config
+		Secured:    cfg.UseHTTPS || opts.secure,
+		Proxy:      strings.TrimSuffix(opts.proxy, "/"),
+		Template:   opts.template,
+		FPM:        opts.fpm,
+		FPMRoot:    opts.fpmRoot,
+
+		DocRoot:           opts.docroot,
+		DocRootCandidates: opts.docroots,
+	}
+
+	// Octane sites proxy to a supervised application server
+	if opts.octane {
+		if opts.port == 0 {
+			opts.port = 8000
+		}
+		site.Octane = true
+		site.Port = opts.port
+		site.Proxy = fmt.Sprintf("http://127.0.0.1:%d", opts.port)
+		site.Processes = append(site.Processes, config.Process{
+			Name:    octaneProcessName,
+			Command: fmt.Sprintf("php artisan octane:start --host=127.0.0.1 --port=%d", opts.port),
+		})
+	}
+
+	// Builtin sites are served by a supervised `php -S` on their own port
+	if opts.builtin {
+		site.Builtin = true
+		site.Secured = false
+		site.Port = opts.port
+		if site.Port == 0 {
+			if site.Port, err = assignPort(sites); err != nil {
+				return err
+			}
+		}
+		site.Processes = append(site.Processes, config.Process{
+			Name:    builtinProcessName,
+			Command: builtinCommand(&site, cfg),
+		})
+	}
+
+	if err := runHook(hooks.PreLink, &site, cfg); err != nil {
+		return err
+	}
+
+	// Create database
+	if opts.withDB {
+		if err := createSiteDatabase(&site, cfg, true); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+			fmt.Printf("   Retry with: phppark db:create %s\n", name)
+		}
+		fmt.Println()
 	}
 
 	// Add site to registry
@@ -506,315 +1101,782 @@ func runLink(name string) error {
 
 	// Save registry
 	if err := config.SaveSites(sites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
 	// Generate nginx config
-	fmt.Printf("✅ Linked site: %s.%s\n", name, cfg.Domain)
+	fmt.Printf("✅ Linked site: %s.%s\n", name, cfg.SiteDomain())
 	fmt.Printf("   Path: %s\n", currentDir)
 
-	if err := generateNginxConfig(&site, cfg); err != nil {
-		fmt.Printf("   ⚠️  Warning: %v\n", err)
+	if site.Builtin {
+		if err := installProcessUnit(&site, site.FindProcess(builtinProcessName), cfg); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not start the built-in server: %v\n", err)
+		} else {
+			fmt.Printf("   ✅ Built-in server running: %s\n", builtinURL(&site, cfg.SiteDomain()))
+		}
+		mapBuiltinHost(&site, cfg)
+	} else if err := generateNginxConfig(&site, cfg); err != nil {
+		i18n.Printf(i18n.Warning, err)
+		printConfigTestDetail(err, "      ")
 		fmt.Println("   Site registered but nginx config not created")
 	} else {
 		fmt.Println("   ✅ Nginx config generated")
 	}
+	// Frameworks that keep their code next to the web root expose it when
+	// served from the project directory
+	if template := siteTemplate(&site); site.Proxy == "" && site.DocRoot == "" && siteDocumentRoot(&site, cfg) == site.Path &&
+		(template == "" || template == nginx.TemplateLaravel || template == nginx.TemplateSymfony) {
+		fmt.Println("   ⚠️  No document root directory found, so the whole project directory is served")
+		fmt.Printf("      Pick one with: phppark docroot %s <dir>\n", name)
+	}
+
+	// Start the Octane server
+	if site.Octane {
+		if err := installProcessUnit(&site, site.FindProcess(octaneProcessName), cfg); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not start Octane: %v\n", err)
+		} else {
+			fmt.Printf("   ✅ Octane running on port %d\n", site.Port)
+		}
+	}
 
 	// Rest of success message
-	phpVersion := cfg.DefaultPHP
-	if site.PHPVersion != "" {
-		phpVersion = site.PHPVersion
+	if site.Proxy != "" && !site.Octane {
+		fmt.Printf("   Proxy: %s\n", site.Proxy)
+	} else {
+		phpVersion := cfg.DefaultPHP
+		if site.PHPVersion != "" {
+			phpVersion = site.PHPVersion
+		}
+		fmt.Printf("   PHP:  %s\n", phpVersion)
+	}
+	if site.Secured {
+		fmt.Printf("   URL:  %s\n", siteURL(&site, cfg))
 	}
-	fmt.Printf("   PHP:  %s\n", phpVersion)
+
+	runPostHook(hooks.PostLink, &site, cfg)
 
 	return nil
 }
 
+// resolveSiteDir turns a --path argument (possibly relative or starting
+// with ~) into an absolute path to an existing directory
+func resolveSiteDir(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", i18n.Errorf(i18n.PathNotExist, err)
+	}
+	if !info.IsDir() {
+		return "", i18n.Errorf(i18n.PathNotDir, absPath)
+	}
+
+	return absPath, nil
+}
+
+// unlinkOptions holds flags for the unlink command
+type unlinkOptions struct {
+	all   bool   // Unlink every site
+	kind  string // With all, only "park" or "link" sites
+	force bool   // Skip the confirmation prompt
+}
+
 func unlinkCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "unlink [name]",
+	var opts unlinkOptions
+
+	cmd := &cobra.Command{
+		Use:   "unlink [name...]",
 		Short: "Remove a linked site",
-		Long:  `Unlink removes a site from PHPark management.`,
-		Args:  cobra.ExactArgs(1), // Exactly 1 argument required
+		Long: `Unlink removes sites from PHPark management. Several sites (or --all) are
+removed with a single web server test and reload.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUnlink(args[0])
+			return runUnlinkMany(args, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Unlink every site")
+	cmd.Flags().StringVar(&opts.kind, "type", "", "With --all, only unlink \"park\" or \"link\" sites")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Skip confirmation prompt")
+
+	return cmd
 }
 
+func runUnlinkMany(names []string, opts unlinkOptions) error {
+	switch {
+	case opts.all && len(names) > 0:
+		return fmt.Errorf("pass site names or --all, not both")
+	case !opts.all && len(names) == 0:
+		return fmt.Errorf("specify the sites to unlink, or --all")
+	case opts.kind != "" && !opts.all:
+		return fmt.Errorf("--type only applies with --all")
+	case opts.kind != "" && opts.kind != "park" && opts.kind != "link":
+		return fmt.Errorf("invalid --type '%s' (expected park or link)", opts.kind)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	for _, name := range names {
+		if sites.FindSite(name) == nil {
+			return i18n.Errorf(i18n.SiteNotFound, name)
+		}
+	}
+
+	if opts.all {
+		for _, site := range sites.ListSites() {
+			if opts.kind == "" || site.Type == opts.kind {
+				names = append(names, site.Name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("📋 No sites to unlink")
+			return nil
+		}
+	}
+
+	// Removing more than one site, or whatever --all matched, needs a yes
+	if (opts.all || len(names) > 1) && !opts.force {
+		fmt.Printf("⚠️  This will unlink %d sites: %s\n", len(names), strings.Join(names, ", "))
+		i18n.Printf(i18n.Continue)
+
+		var response string
+		fmt.Scanln(&response)
+
+		if response != "y" && response != "Y" && response != "yes" {
+			fmt.Println(i18n.T(i18n.Cancelled))
+			return nil
+		}
+		fmt.Println()
+	}
+
+	return unlinkSites(names)
+}
+
+// runUnlink removes one site
 func runUnlink(siteName string) error {
+	return unlinkSites([]string{siteName})
+}
+
+// unlinkSites removes sites, then tests and reloads the web server once
+func unlinkSites(names []string) error {
 	// Load sites
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
-	// Find site
-	site := sites.FindSite(siteName)
-	if site == nil {
-		return fmt.Errorf("site '%s' not found", siteName)
+	// Find every site before removing any
+	for _, name := range names {
+		if sites.FindSite(name) == nil {
+			return i18n.Errorf(i18n.SiteNotFound, name)
+		}
 	}
 
 	// Get config
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
 	}
 
-	// Display info
-	fmt.Printf("🗑️  Removing site: %s.%s\n", siteName, cfg.Domain)
-	fmt.Printf("   Path: %s\n", site.Path)
-	fmt.Printf("   Type: %s\n", site.Type)
-
 	// Get paths
 	paths, err := config.GetPaths()
 	if err != nil {
 		return err
 	}
 
-	// Remove nginx config file
-	configPath := filepath.Join(paths.Nginx, siteName+".conf")
-	if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove config: %w", err)
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
 	}
-	fmt.Println("   🗑️  Removed nginx config")
 
-	if err := services.RemoveNginxConfig(siteName); err != nil {
-		fmt.Printf("   ⚠️  Warning: Could not remove from nginx: %v\n", err)
-	} else {
-		fmt.Println("   ✅ Removed from nginx")
+	var removed []config.Site
+	var failed []string
+	unstaged := false
+
+	for _, name := range names {
+		site := *sites.FindSite(name)
+
+		if err := runHook(hooks.PreUnlink, &site, cfg); err != nil {
+			if len(names) == 1 {
+				return err
+			}
+			fmt.Printf("⏭️  Skipping '%s' (%v)\n\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+
+		if err := teardownSite(&site, server, cfg, paths); err != nil {
+			if len(names) == 1 {
+				return err
+			}
+			fmt.Printf("   ❌ %v\n\n", err)
+			failed = append(failed, name)
+			continue
+		}
+		if !site.Builtin {
+			unstaged = true
+		}
+
+		sites.RemoveSite(name)
+		removed = append(removed, site)
+		fmt.Println()
 	}
 
 	// Remove from registry
-	sites.RemoveSite(siteName)
-	if err := config.SaveSites(sites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+	if len(removed) > 0 {
+		if err := config.SaveSites(sites); err != nil {
+			return i18n.Errorf(i18n.SaveSitesFailed, err)
+		}
 	}
 
-	fmt.Println("\n✅ Site unlinked successfully")
+	// Apply every removal with one test and reload
+	if unstaged {
+		if err := server.Test(); err != nil {
+			fmt.Printf("⚠️  Warning: %v\n", err)
+			printConfigTestDetail(err, "   ")
+		} else if err := server.Reload(); err != nil {
+			fmt.Printf("⚠️  Warning: Could not reload %s: %v\n", server.Name(), err)
+		} else {
+			fmt.Printf("✅ Reloaded %s\n", server.Name())
+		}
+	}
 
+	if len(removed) == 1 && len(failed) == 0 {
+		fmt.Println("\n✅ Site unlinked successfully")
+	} else if len(removed) > 0 {
+		fmt.Printf("\n✅ Unlinked %d site(s)\n", len(removed))
+	}
+
+	for i := range removed {
+		runPostHook(hooks.PostUnlink, &removed[i], cfg)
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("failed to unlink %s", strings.Join(failed, ", "))
+		if len(removed) > 0 {
+			return partialFailure(err)
+		}
+		return err
+	}
 	return nil
 }
 
-func linksCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "links",
-		Short: "List all linked sites",
-		Long:  `List displays all parked and linked sites managed by PHPark.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLinks()
-		},
+// teardownSite removes a site's config, certificate, supervised processes
+// and scheduler.
+// The web server is left to be tested and reloaded by the caller.
+func teardownSite(site *config.Site, server webserver.Server, cfg *config.Config, paths *config.Paths) error {
+	siteName := site.Name
+
+	// Display info
+	fmt.Printf("🗑️  Removing site: %s.%s\n", siteName, cfg.SiteDomain())
+	fmt.Printf("   Path: %s\n", site.Path)
+	fmt.Printf("   Type: %s\n", site.Type)
+
+	if site.Builtin {
+		// Builtin sites have no web server config, only a hosts entry
+		if os.Geteuid() == 0 {
+			if err := dns.RemoveHostsEntry(siteName + "." + cfg.SiteDomain()); err != nil {
+				i18n.Printf(i18n.Warning, err)
+			}
+		}
+	} else {
+		// Put a disabled site's config back where Unstage looks for it
+		if site.Disabled {
+			if err := server.Enable(sharedName(siteName)); err != nil {
+				i18n.Printf(i18n.Warning, err)
+			}
+		}
+
+		// Remove generated config file
+		configPath := server.ConfigPath(paths, siteName)
+		if err := oplog.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove config: %w", err)
+		}
+		fmt.Printf("   🗑️  Removed %s config\n", server.Name())
+
+		if err := server.Unstage(sharedName(siteName)); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not remove from %s: %v\n", server.Name(), err)
+		} else {
+			fmt.Printf("   ✅ Removed from %s\n", server.Name())
+		}
+	}
+
+	// Remove the site's certificate, which nothing else uses
+	if ssl.CertificateExists(siteName, paths.Certificates) || site.Secured {
+		if err := ssl.RemoveCertificate(siteName, paths.Certificates); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		} else {
+			fmt.Println("   🗑️  Removed SSL certificate")
+		}
+	}
+
+	// Stop queue workers
+	for i := range site.Workers {
+		removeWorkerUnits(site.Name, &site.Workers[i])
+	}
+	if len(site.Workers) > 0 {
+		fmt.Println("   🗑️  Removed queue workers")
+	}
+
+	// Stop the site's own PHP-FPM
+	if hasSiteFPM(site, cfg) {
+		if err := services.RemoveSiteFPM(siteFPMName(site.Name), paths.SiteFPM); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		} else {
+			fmt.Println("   🗑️  Removed the site's PHP-FPM")
+		}
+	}
+
+	// Stop supervised processes
+	for _, process := range site.Processes {
+		if err := services.RemoveUnit(processUnitName(site.Name, process.Name)); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+	if len(site.Processes) > 0 {
+		fmt.Println("   🗑️  Removed supervised processes")
+	}
+
+	// Cancel a temporary site's expiry timer
+	if site.ExpiresAt != nil {
+		services.RemoveTimer(serveUnitName(site.Name)) // Non-fatal, the timer may have fired
+	}
+
+	// Stop the scheduler
+	if site.Scheduler {
+		if err := services.RemoveTimer(scheduleUnitName(site.Name)); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		} else {
+			fmt.Println("   🗑️  Removed scheduler")
+		}
 	}
+
+	return nil
 }
 
-func runLinks() error {
-	// Load sites
-	sites, err := config.LoadSites()
-	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+func generateNginxConfig(site *config.Site, cfg *config.Config) error {
+	// Builtin sites are served by `php -S`, not the web server
+	if site.Builtin {
+		return nil
 	}
 
-	// Check if empty
-	allSites := sites.ListSites()
-	if len(allSites) == 0 {
-		fmt.Println("📋 No sites registered yet.")
-		fmt.Println("\nTo add sites:")
-		fmt.Println("  phppark park ~/sites    # Park a directory")
-		fmt.Println("  phppark link myapp      # Link current directory")
+	// Settings changed while a site is disabled are deployed when it's
+	// enabled again
+	if site.Disabled {
+		fmt.Printf("   ⏸️  %s is disabled; changes apply on 'phppark enable %s'\n", site.Name, site.Name)
 		return nil
 	}
 
-	// Display sites
-	fmt.Printf("📋 Registered Sites (%d total)\n\n", len(allSites))
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
 
-	for _, site := range allSites {
-		// Site name and URL
-		fmt.Printf("🔗 %s.test\n", site.Name)
+	installNginxGlobal(server, cfg, site)
 
-		// Path
-		fmt.Printf("   Path: %s\n", site.Path)
+	configPath, err := writeSiteConfig(site, cfg, server)
+	if err != nil {
+		return err
+	}
 
-		// Type
-		typeIcon := "📌"
-		if site.Type == "park" {
-			typeIcon = "📦"
-		}
-		fmt.Printf("   Type: %s %s\n", typeIcon, site.Type)
+	fmt.Printf("   📄 Config: %s\n", configPath)
 
-		// PHP version
-		phpVersion := site.PHPVersion
-		if phpVersion == "" {
-			phpVersion = "(default)"
+	// Deploy to the web server
+	if err := server.Deploy(sharedName(site.Name), configPath); err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not deploy to %s: %v\n", server.Name(), err)
+		if server.Name() == "nginx" {
+			fmt.Printf("   Run manually: sudo cp ~/.phppark/nginx/*.conf %s/\n", filepath.Dir(services.NginxConfigPath(sharedName(site.Name))))
 		}
-		fmt.Printf("   PHP:  %s\n", phpVersion)
+	} else {
+		fmt.Printf("   ✅ Deployed to %s\n", server.Name())
+	}
 
-		// HTTPS status
-		httpsStatus := "❌ HTTP"
-		if site.Secured {
-			httpsStatus = "✅ HTTPS"
-		}
-		fmt.Printf("   SSL:  %s\n", httpsStatus)
+	startSiteFPM(server, []config.Site{*site}, cfg)
 
-		fmt.Println() // Empty line between sites
+	// Ensure the web server is running
+	if err := server.Start(); err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not start %s: %v\n", server.Name(), err)
 	}
 
 	return nil
 }
 
-func generateNginxConfig(site *config.Site, cfg *config.Config) error {
+// writeSiteConfig renders a site's web server config and writes it to
+// PHPark's config directory, returning its path
+func writeSiteConfig(site *config.Site, cfg *config.Config, server webserver.Server) (string, error) {
+	configPath, configContent, err := renderSiteConfig(site, cfg, server)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to file
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := oplog.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+
+	// Fix permissions (proxied sites serve no files, Octane sites still
+	// serve static assets)
+	if site.Proxy == "" || site.Octane {
+		if err := services.FixSitePermissions(site.Path, cfg.Permissions.Mode, cfg.Permissions.Skip); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not fix permissions: %v\n", err)
+		}
+	}
+	for _, mount := range site.Mounts {
+		if err := services.FixSitePermissions(mount.Dir, cfg.Permissions.Mode, cfg.Permissions.Skip); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not fix permissions of %s: %v\n", mount.Dir, err)
+		}
+	}
+
+	return configPath, nil
+}
+
+// renderSiteConfig renders a site's web server config without writing it,
+// returning where it belongs and its content
+func renderSiteConfig(site *config.Site, cfg *config.Config, server webserver.Server) (string, string, error) {
+	return renderSite(site, cfg, server, true)
+}
+
+// previewSiteConfig renders a site's config like renderSiteConfig, but
+// without creating the certificate or diagnostic pages it refers to, so
+// comparing it with what's deployed changes nothing
+func previewSiteConfig(site *config.Site, cfg *config.Config, server webserver.Server) (string, string, error) {
+	return renderSite(site, cfg, server, false)
+}
+
+// renderSite renders a site's config, creating the files it refers to
+// when prepare is set
+func renderSite(site *config.Site, cfg *config.Config, server webserver.Server, prepare bool) (string, string, error) {
 	paths, err := config.GetPaths()
 	if err != nil {
-		return err
+		return "", "", err
+	}
+
+	// Determine PHP version
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	// Create site config
+	nginxCfg := nginx.CreateSiteConfig(
+		site.Name,        // siteName
+		site.Path,        // sitePath
+		cfg.SiteDomain(), // domain
+		phpVersion,       // phpVersion
+		site.Secured,     // useSSL
+	)
+
+	nginxCfg.SiteName = sharedName(site.Name) // Names the log files
+	nginxCfg.Root = siteDocumentRoot(site, cfg)
+	nginxCfg.ListenPort, nginxCfg.SSLPort = cfg.SitePorts()
+	nginxCfg.Aliases = siteAliases(site, cfg)
+	nginxCfg.RedirectHTTP = site.Secured && cfg.HTTPSRedirect && !site.NoRedirect
+	nginxCfg.FPMStatus = cfg.FPMStatus
+	nginxCfg.PHPSocket, nginxCfg.FastCGIPass = localFastCGI(cfg, server, phpVersion)
+	// nginx gets PHP-FPM through the upstreams in the global include
+	nginxCfg.Shared = server.Name() == "nginx" && sharedUpstreams() != nil
+	// A remote PHP-FPM is passed to directly, bypassing the local upstreams
+	if site.FPM != "" {
+		nginxCfg.PHPSocket = site.FPM
+		nginxCfg.FastCGIPass = nginx.FastCGITarget(site.FPM)
+	}
+	// So is a site's own PHP-FPM (see applySiteFPM)
+	if hasSiteFPM(site, cfg) {
+		nginxCfg.PHPSocket = services.SiteFPMSocket(siteFPMName(site.Name))
+		nginxCfg.FastCGIPass = nginx.FastCGITarget(nginxCfg.PHPSocket)
+	}
+	nginxCfg.Env = nginx.EnvParams(siteEnv(site, cfg))
+	nginxCfg.ProxyPass = site.Proxy
+	nginxCfg.Octane = site.Octane
+	nginxCfg.MaxBodySize = site.MaxBody
+	nginxCfg.Timeout = site.Timeout
+	nginxCfg.LimitRate = site.ThrottleRate
+	// The site's own PHP-FPM prepends the script that waits this long
+	if hasSiteFPM(site, cfg) {
+		nginxCfg.Delay = site.ThrottleDelay
+	}
+	nginxCfg.Template = siteTemplate(site)
+	if nginxCfg.Template != "" && !nginx.IsTemplate(nginxCfg.Template) {
+		source, err := loadUserTemplate(paths, nginxCfg.Template)
+		if err != nil {
+			return "", "", err
+		}
+		nginxCfg.TemplateSource = source
+	}
+	if root := nginx.TemplateDocumentRoot(nginxCfg.Template, site.Path); root != "" && site.DocRoot == "" {
+		nginxCfg.Root = root
+	}
+	if nginxCfg.Template == nginx.TemplateWordPress {
+		nginxCfg.Multisite = nginx.IsWordPressMultisite(site.Path)
+	}
+	if site.FPMRoot != "" {
+		nginxCfg.RemoteRoot = remoteRoot(site, nginxCfg.Root)
+	}
+	for _, mount := range site.Mounts {
+		version := mount.PHPVersion
+		if version == "" {
+			version = phpVersion
+		}
+		listen, pass := localFastCGI(cfg, server, version)
+		nginxCfg.Mounts = append(nginxCfg.Mounts, nginx.Mount{
+			Path:        mount.Path,
+			Root:        mountDocumentRoot(&mount, cfg),
+			PHPSocket:   listen,
+			FastCGIPass: pass,
+		})
+	}
+	if server.Name() == "nginx" && wantsErrorPage(site, cfg, nginxCfg.Template) {
+		nginxCfg.ErrorPage, nginxCfg.LocalErrorPage = errorPagePaths(site, paths)
+		if prepare {
+			if _, _, err := writeErrorPage(site, cfg, paths, phpVersion, nginxCfg.PHPSocket); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	// If secured, add certificate paths
+	if site.Secured && prepare {
+		// Sites secured by default (use_https) have no certificate yet
+		if err := ensureCertificate(site, cfg, paths); err != nil {
+			return "", "", err
+		}
+	}
+	if site.Secured {
+
+		nginxCfg.CertPath = ssl.CertPath(site.Name, paths.Certificates)
+		nginxCfg.KeyPath = ssl.KeyPath(site.Name, paths.Certificates)
+	}
+
+	// Generate config content
+	configContent, err := server.Generate(nginxCfg)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate config: %w", err)
+	}
+
+	return server.ConfigPath(paths, site.Name), configContent, nil
+}
+
+// localFastCGI returns where a PHP version's local PHP-FPM listens and the
+// fastcgi_pass target for it: the upstream in nginx's global include, or
+// the socket or address itself for other web servers and when the include
+// doesn't define one
+func localFastCGI(cfg *config.Config, server webserver.Server, phpVersion string) (string, string) {
+	listen := phpFPMListen(cfg, phpVersion)
+	if upstream := nginx.UpstreamName(userNamespace, phpVersion); server.Name() == "nginx" && sharedUpstreams()[upstream] {
+		return listen, upstream
 	}
+	return listen, nginx.FastCGITarget(listen)
+}
 
-	// Determine PHP version
-	phpVersion := site.PHPVersion
-	if phpVersion == "" {
-		phpVersion = cfg.DefaultPHP
+// siteDocumentRoot returns the directory a site is served from: the one it
+// was given, or the first of its docroot candidates (the site's own,
+// config.yaml's or the built-in list) that exists, or the project root
+func siteDocumentRoot(site *config.Site, cfg *config.Config) string {
+	if site.DocRoot != "" {
+		return filepath.Join(site.Path, site.DocRoot)
 	}
 
-	// Create site config
-	nginxCfg := nginx.CreateSiteConfig(
-		site.Name,    // siteName
-		site.Path,    // sitePath
-		cfg.Domain,   // domain
-		phpVersion,   // phpVersion
-		site.Secured, // useSSL
-	)
+	candidates := site.DocRootCandidates
+	if len(candidates) == 0 {
+		candidates = cfg.DocRootCandidates
+	}
+	if len(candidates) == 0 {
+		candidates = nginx.DocRootCandidates
+	}
+	return nginx.FindDocumentRoot(site.Path, candidates)
+}
 
-	// If secured, add certificate paths
-	if site.Secured {
-		nginxCfg.CertPath = filepath.Join(paths.Certificates, site.Name+".crt")
-		nginxCfg.KeyPath = filepath.Join(paths.Certificates, site.Name+".key")
+// cleanSiteSubdir checks that dir is a directory inside a site, given
+// relative to it, and cleans it up
+func cleanSiteSubdir(dir string) (string, error) {
+	clean := filepath.Clean(dir)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("'%s' must be a directory inside the site, relative to it (e.g., public)", dir)
 	}
+	return clean, nil
+}
 
-	// Generate config content
-	configContent, err := nginx.GenerateConfig(nginxCfg)
-	if err != nil {
-		return fmt.Errorf("failed to generate config: %w", err)
+// siteTemplate returns the template a site is served with: the one it was
+// linked with, or whatever its files look like
+func siteTemplate(site *config.Site) string {
+	if site.Template != "" {
+		return site.Template
 	}
+	return nginx.DetectTemplate(site.Path)
+}
 
-	// Write to file
-	configPath := filepath.Join(paths.Nginx, site.Name+".conf")
-	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+// startSiteFPM starts PHP-FPM for the versions the sites use, and the
+// sites' own PHP-FPMs that aren't running yet (unless the server runs PHP
+// itself)
+func startSiteFPM(server webserver.Server, sites []config.Site, cfg *config.Config) {
+	if server.EmbedsPHP() {
+		return
 	}
 
-	fmt.Printf("   📄 Config: %s\n", configPath)
+	fpm, err := webserver.NewFPM(cfg)
+	if err != nil {
+		i18n.Printf(i18n.Warning, err)
+		return
+	}
+
+	for _, phpVersion := range sitePHPVersions(sites, cfg) {
+		if err := fpm.Start(phpVersion); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not start PHP-FPM: %v\n", err)
+		}
 
-	// Fix permissions first
-	if err := services.FixSitePermissions(site.Path); err != nil {
-		fmt.Printf("   ⚠️  Warning: Could not fix permissions: %v\n", err)
+		if cfg.FPMStatus {
+			if err := fpm.EnableStatus(phpVersion); err != nil {
+				fmt.Printf("   ⚠️  Warning: Could not enable FPM status page: %v\n", err)
+			}
+		} else if err := fpm.DisableStatus(phpVersion); err != nil {
+			// Left from when fpm_status was on
+			fmt.Printf("   ⚠️  Warning: Could not disable FPM status page: %v\n", err)
+		}
 	}
 
-	// Deploy to nginx
-	if err := services.DeployNginxConfig(site.Name, configPath); err != nil {
-		fmt.Printf("   ⚠️  Warning: Could not deploy to nginx: %v\n", err)
-		fmt.Println("   Run manually: sudo cp ~/.phppark/nginx/*.conf /etc/nginx/sites-available/")
-	} else {
-		fmt.Printf("   ✅ Deployed to nginx\n")
+	for i := range sites {
+		site := &sites[i]
+		if !hasSiteFPM(site, cfg) {
+			continue
+		}
+		if _, err := os.Stat(services.SiteFPMSocket(siteFPMName(site.Name))); err == nil {
+			continue
+		}
+		if err := applySiteFPM(site, cfg); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
 	}
+}
 
-	// Start PHP-FPM
-	if phpVersion != "" {
-		if err := services.StartPHPFPM(phpVersion); err != nil {
-			fmt.Printf("   ⚠️  Warning: Could not start PHP-FPM: %v\n", err)
+// ensureCertificate generates a certificate for a secured site if none exists,
+// so an SSL config never references missing files. A self-signed one that
+// doesn't cover the site's LAN name yet is replaced.
+func ensureCertificate(site *config.Site, cfg *config.Config, paths *config.Paths) error {
+	aliases := siteAliases(site, cfg)
+	if ssl.CertificateExists(site.Name, paths.Certificates) {
+		// A key from before keys got their own directory moves there
+		if err := ssl.MigrateKey(site.Name, paths.Certificates); err != nil {
+			return err
+		}
+		if ssl.CoversNames(site.Name, paths.Certificates, aliases) {
+			return nil
+		}
+		// One issued elsewhere is left alone
+		if selfSigned, err := ssl.SelfSigned(site.Name, paths.Certificates); err != nil || !selfSigned {
+			return nil
 		}
 	}
 
-	// Ensure nginx is running
-	if err := services.StartNginx(); err != nil {
-		fmt.Printf("   ⚠️  Warning: Could not start nginx: %v\n", err)
+	certPaths, err := ssl.GenerateSelfSignedCert(site.Name, cfg.SiteDomain(), paths.Certificates, aliases...)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate: %w", err)
 	}
 
+	fmt.Printf("   📜 Certificate: %s\n", certPaths.CertFile)
 	return nil
 }
 
-func rebuildCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "rebuild",
-		Short: "Rebuild all nginx configurations",
-		Long:  `Rebuild regenerates nginx configuration files for all registered sites.`,
+func secureCmd() *cobra.Command {
+	var all bool
+	var tag string
+	var noRedirect bool
+
+	cmd := &cobra.Command{
+		Use:   "secure [site]",
+		Short: "Enable HTTPS for a site",
+		Long:  `Secure generates SSL certificates and enables HTTPS for a site, or with --all for every site (optionally only those with --tag).`,
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRebuild()
+			if all || tag != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("pass a site or --all, not both")
+				}
+				return runSecureAll(tag, noRedirect)
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("specify a site to secure, or --all")
+			}
+			return runSecure(args[0], noRedirect)
 		},
 	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Secure every site that isn't already secured")
+	cmd.Flags().StringVar(&tag, "tag", "", "With --all, only secure sites with this tag")
+	cmd.Flags().BoolVar(&noRedirect, "no-redirect", false, "Keep answering plain HTTP instead of redirecting it to HTTPS")
+
+	return cmd
 }
 
-func runRebuild() error {
-	// Load sites
+// runSecureAll secures every unsecured site (with the tag, if given)
+func runSecureAll(tag string, noRedirect bool) error {
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
-	// Load config
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	var names []string
+	for _, site := range sites.Tagged(tag) {
+		if !site.Secured && !site.Builtin {
+			names = append(names, site.Name)
+		}
 	}
 
-	allSites := sites.ListSites()
-	if len(allSites) == 0 {
-		fmt.Println("📋 No sites to rebuild")
+	if len(names) == 0 {
+		fmt.Println("📋 No sites to secure")
 		return nil
 	}
 
-	fmt.Printf("🔨 Rebuilding nginx configs for %d site(s)...\n\n", len(allSites))
-
-	success := 0
 	failed := 0
-
-	for _, site := range allSites {
-		fmt.Printf("   %s.%s ... ", site.Name, cfg.Domain)
-
-		if err := generateNginxConfig(&site, cfg); err != nil {
-			fmt.Printf("❌ failed (%v)\n", err)
+	for _, name := range names {
+		if err := runSecure(name, noRedirect); err != nil {
+			fmt.Printf("   ❌ %s: %v\n", name, err)
 			failed++
-		} else {
-			fmt.Printf("✅\n")
-			success++
 		}
+		fmt.Println()
 	}
 
-	fmt.Printf("\n✅ Rebuilt %d config(s)", success)
-	if failed > 0 {
-		fmt.Printf(", %d failed", failed)
+	if failed == len(names) {
+		return i18n.Errorf(i18n.SitesFailed, failed, len(names))
+	} else if failed > 0 {
+		return partialFailure(i18n.Errorf(i18n.SitesFailed, failed, len(names)))
 	}
-	fmt.Println()
-
+	fmt.Printf("✅ Secured %d site(s)\n", len(names))
 	return nil
 }
 
-func secureCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "secure [site]",
-		Short: "Enable HTTPS for a site",
-		Long:  `Secure generates SSL certificates and enables HTTPS for a site.`,
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSecure(args[0])
-		},
-	}
-}
-
-func runSecure(siteName string) error {
+func runSecure(siteName string, noRedirect bool) error {
 	// Load sites
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
 	// Find site
 	site := sites.FindSite(siteName)
 	if site == nil {
-		return fmt.Errorf("site '%s' not found", siteName)
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
 	}
 
 	// Load config
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
 	}
 
 	// Get paths
@@ -823,10 +1885,23 @@ func runSecure(siteName string) error {
 		return err
 	}
 
-	fmt.Printf("🔒 Securing %s.%s...\n", siteName, cfg.Domain)
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which doesn't support HTTPS", siteName)
+	}
+
+	if err := runHook(hooks.PreSecure, site, cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("🔒 Securing %s.%s...\n", siteName, cfg.SiteDomain())
 
 	// Check if already secured
 	if site.Secured {
+		// Only the redirect setting is changing
+		if site.NoRedirect != noRedirect && ssl.CertificateExists(siteName, paths.Certificates) {
+			return updateRedirect(sites, site, cfg, noRedirect)
+		}
+
 		fmt.Println("   ⚠️  Site is already secured")
 
 		// Check if certs exist
@@ -839,7 +1914,7 @@ func runSecure(siteName string) error {
 	}
 
 	// Generate certificates
-	certPaths, err := ssl.GenerateSelfSignedCert(siteName, cfg.Domain, paths.Certificates)
+	certPaths, err := ssl.GenerateSelfSignedCert(siteName, cfg.SiteDomain(), paths.Certificates, siteAliases(site, cfg)...)
 	if err != nil {
 		return fmt.Errorf("failed to generate certificate: %w", err)
 	}
@@ -849,11 +1924,12 @@ func runSecure(siteName string) error {
 
 	// Update site to be secured
 	site.Secured = true
+	site.NoRedirect = noRedirect
 	sites.AddSite(*site) // Updates existing
 
 	// Save sites
 	if err := config.SaveSites(sites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
 	// Regenerate nginx config with SSL
@@ -862,9 +1938,31 @@ func runSecure(siteName string) error {
 	}
 
 	fmt.Println("\n✅ Site secured successfully!")
-	fmt.Printf("   Access via: https://%s.%s\n", siteName, cfg.Domain)
+	fmt.Printf("   Access via: %s\n", siteURL(site, cfg))
 	fmt.Println("\n⚠️  Note: You may need to accept the self-signed certificate in your browser")
 
+	runPostHook(hooks.PostSecure, site, cfg)
+
+	return nil
+}
+
+// updateRedirect turns the HTTP to HTTPS redirect of a secured site on or off
+func updateRedirect(sites *config.SiteRegistry, site *config.Site, cfg *config.Config, noRedirect bool) error {
+	site.NoRedirect = noRedirect
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update nginx config: %w", err)
+	}
+
+	if noRedirect {
+		fmt.Println("\n✅ Plain HTTP is served as well as HTTPS")
+	} else {
+		fmt.Println("\n✅ Plain HTTP now redirects to HTTPS")
+	}
 	return nil
 }
 
@@ -884,19 +1982,19 @@ func runUnsecure(siteName string) error {
 	// Load sites
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
 	// Find site
 	site := sites.FindSite(siteName)
 	if site == nil {
-		return fmt.Errorf("site '%s' not found", siteName)
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
 	}
 
 	// Load config
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
 	}
 
 	// Get paths
@@ -905,7 +2003,7 @@ func runUnsecure(siteName string) error {
 		return err
 	}
 
-	fmt.Printf("🔓 Unsecuring %s.%s...\n", siteName, cfg.Domain)
+	fmt.Printf("🔓 Unsecuring %s.%s...\n", siteName, cfg.SiteDomain())
 
 	// Check if not secured
 	if !site.Secured {
@@ -913,6 +2011,10 @@ func runUnsecure(siteName string) error {
 		return nil
 	}
 
+	if err := runHook(hooks.PreUnsecure, site, cfg); err != nil {
+		return err
+	}
+
 	// Remove certificates
 	if err := ssl.RemoveCertificate(siteName, paths.Certificates); err != nil {
 		fmt.Printf("   ⚠️  Warning: failed to remove certificates: %v\n", err)
@@ -922,11 +2024,12 @@ func runUnsecure(siteName string) error {
 
 	// Update site to be unsecured
 	site.Secured = false
+	site.NoRedirect = false
 	sites.AddSite(*site) // Updates existing
 
 	// Save sites
 	if err := config.SaveSites(sites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
 	// Regenerate nginx config without SSL
@@ -935,7 +2038,9 @@ func runUnsecure(siteName string) error {
 	}
 
 	fmt.Println("\n✅ Site unsecured successfully!")
-	fmt.Printf("   Access via: http://%s.%s\n", siteName, cfg.Domain)
+	fmt.Printf("   Access via: %s\n", siteURL(site, cfg))
+
+	runPostHook(hooks.PostUnsecure, site, cfg)
 
 	return nil
 }
@@ -952,7 +2057,7 @@ func phpListCmd() *cobra.Command {
 }
 
 func runPHPList() error {
-	fmt.Println("🔍 Detecting PHP versions...\n")
+	fmt.Print("🔍 Detecting PHP versions...\n\n")
 
 	versions, err := php.DetectPHPVersions()
 	if err != nil {
@@ -989,10 +2094,11 @@ func runPHPList() error {
 
 func useCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "use <php-version> [site]",
-		Short: "Set PHP version for a site (or globally)",
-		Long:  `Use sets the PHP version for a specific site, or updates the default if no site specified.`,
-		Args:  cobra.RangeArgs(1, 2), // 1 or 2 arguments
+		Use:               "use <php-version> [site]",
+		Short:             "Set PHP version for a site (or globally)",
+		Long:              `Use sets the PHP version for a specific site, or updates the default if no site specified.`,
+		Args:              cobra.RangeArgs(1, 2), // 1 or 2 arguments
+		ValidArgsFunction: completeUse,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			phpVersion := args[0]
 			siteName := ""
@@ -1005,81 +2111,50 @@ func useCmd() *cobra.Command {
 }
 
 func runUse(phpVersion, siteName string) error {
-	// Detect available PHP versions
-	versions, err := php.DetectPHPVersions()
+	// Load config
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to detect PHP versions: %w", err)
-	}
-
-	// Format version (allow "8.2" or just "8.2")
-	phpVersion = php.FormatVersion(phpVersion)
-
-	// Check if version exists
-	versionExists := php.ValidatePHPVersion(phpVersion, versions)
-
-	if !versionExists {
-		fmt.Printf("❌ PHP %s is not installed\n\n", phpVersion)
-
-		// Show available versions
-		if len(versions) > 0 {
-			fmt.Println("Available versions:")
-			for _, v := range versions {
-				fmt.Printf("  - %s\n", v.Version)
-			}
-			fmt.Println()
-		}
-
-		shouldInstall, err := php.PromptInstallPHP(phpVersion)
-		if err != nil {
-			return err
-		}
-
-		if shouldInstall {
-			if err := php.InstallPHP(phpVersion); err != nil {
-				return fmt.Errorf("installation failed: %w", err)
-			}
-
-			// Re-detect versions
-			versions, err = php.DetectPHPVersions()
-			if err != nil {
-				return fmt.Errorf("failed to detect PHP versions: %w", err)
-			}
-
-			// Verify installation
-			if !php.ValidatePHPVersion(phpVersion, versions) {
-				return fmt.Errorf("installation completed but PHP %s not detected", phpVersion)
-			}
-
-			fmt.Printf("\n✅ PHP %s is now available!\n\n", phpVersion)
-		} else {
-			return fmt.Errorf("PHP %s is required but not installed", phpVersion)
-		}
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
 	}
 
-	// Load config
-	cfg, err := config.LoadConfig()
+	phpVersion, err = ensurePHPVersion(phpVersion, cfg, siteName != "")
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return err
 	}
 
 	// If no site specified, update global default
 	if siteName == "" {
 		cfg.DefaultPHP = phpVersion
 		if err := config.SaveConfig(cfg); err != nil {
-			return fmt.Errorf("failed to save config: %w", err)
+			return i18n.Errorf(i18n.SaveConfigFailed, err)
 		}
 
 		fmt.Printf("✅ Set default PHP version to %s\n", phpVersion)
 
-		// Switch CLI PHP version
-		phpPath := fmt.Sprintf("/usr/bin/php%s", phpVersion)
-		cmd := exec.Command("update-alternatives", "--set", "php", phpPath)
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("\n⚠️  Warning: Could not update CLI PHP: %v\n", err)
-			fmt.Printf("   Sites will use PHP %s via PHP-FPM\n", phpVersion)
-			fmt.Printf("   To manually switch CLI: sudo update-alternatives --set php %s\n", phpPath)
+		// Switch CLI PHP version. The shim reads the new default on every
+		// call, so when it is on PATH there is nothing else to do.
+		paths, err := config.GetPaths()
+		if err != nil {
+			return err
+		}
+		if err := installShims(paths); err != nil {
+			fmt.Printf("\n⚠️  Warning: Could not install PHP shims: %v\n", err)
+		}
+
+		if php.ShimsOnPath(paths.Bin) {
+			fmt.Printf("   ✅ CLI PHP switched to %s (via %s)\n", phpVersion, paths.Bin)
 		} else {
-			fmt.Printf("   ✅ CLI PHP switched to %s\n", phpVersion)
+			// Fall back to update-alternatives (Debian/Ubuntu, requires root)
+			phpPath := fmt.Sprintf("/usr/bin/php%s", phpVersion)
+			cmd := exec.Command("update-alternatives", "--set", "php", phpPath)
+			if err := oplog.Run(cmd); err != nil {
+				fmt.Printf("\n⚠️  Warning: Could not update CLI PHP: %v\n", err)
+				fmt.Printf("   Sites will use PHP %s via PHP-FPM\n", phpVersion)
+				fmt.Printf("   To switch CLI without sudo, add to your shell profile:\n")
+				fmt.Printf("   export PATH=\"%s:$PATH\"\n", paths.Bin)
+			} else {
+				fmt.Printf("   ✅ CLI PHP switched to %s\n", phpVersion)
+			}
 		}
 
 		fmt.Println("\nNew sites will use PHP", phpVersion)
@@ -1092,12 +2167,12 @@ func runUse(phpVersion, siteName string) error {
 	// Update specific site
 	sites, err := config.LoadSites()
 	if err != nil {
-		return fmt.Errorf("failed to load sites: %w", err)
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
 	}
 
 	site := sites.FindSite(siteName)
 	if site == nil {
-		return fmt.Errorf("site '%s' not found", siteName)
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
 	}
 
 	// Update site's PHP version
@@ -1105,28 +2180,123 @@ func runUse(phpVersion, siteName string) error {
 	sites.AddSite(*site)
 
 	if err := config.SaveSites(sites); err != nil {
-		return fmt.Errorf("failed to save sites: %w", err)
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
 	}
 
-	fmt.Printf("✅ Set PHP %s for %s.%s\n", phpVersion, siteName, cfg.Domain)
+	fmt.Printf("✅ Set PHP %s for %s.%s\n", phpVersion, siteName, cfg.SiteDomain())
+	if hasSiteFPM(site, cfg) {
+		if err := applySiteFPM(site, cfg); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		} else {
+			fmt.Printf("   The site's own PHP-FPM now runs PHP %s\n", phpVersion)
+		}
+	}
+	if paths, err := config.GetPaths(); err == nil && php.ShimsOnPath(paths.Bin) {
+		fmt.Printf("   CLI php inside %s now uses PHP %s\n", site.Path, phpVersion)
+	}
 	fmt.Println("\n⚠️  Note: Run 'sudo phppark rebuild' to apply changes")
 
 	return nil
 }
 
+// ensurePHPVersion normalizes a PHP version and checks it's installed,
+// offering to install it when it isn't. forSite is set for per-site
+// versions, which the docker backend runs from images.
+func ensurePHPVersion(phpVersion string, cfg *config.Config, forSite bool) (string, error) {
+	// Detect available PHP versions
+	versions, err := php.DetectPHPVersions()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect PHP versions: %w", err)
+	}
+
+	// Format version (allow "8.2" or just "8.2")
+	phpVersion = php.FormatVersion(phpVersion)
+
+	// Check if version exists. With the docker backend sites run PHP from
+	// images, so only the global CLI version needs a host install.
+	versionExists := php.ValidatePHPVersion(phpVersion, versions) ||
+		(cfg.Backend == "docker" && forSite)
+
+	if !versionExists {
+		fmt.Printf("❌ PHP %s is not installed\n\n", phpVersion)
+
+		// Show available versions
+		if len(versions) > 0 {
+			fmt.Println("Available versions:")
+			for _, v := range versions {
+				fmt.Printf("  - %s\n", v.Version)
+			}
+			fmt.Println()
+		}
+
+		shouldInstall, err := php.PromptInstallPHP(phpVersion)
+		if err != nil {
+			return "", err
+		}
+
+		if shouldInstall {
+			if err := php.InstallPHP(phpVersion); err != nil {
+				return "", fmt.Errorf("installation failed: %w", err)
+			}
+
+			// Re-detect versions
+			versions, err = php.DetectPHPVersions()
+			if err != nil {
+				return "", fmt.Errorf("failed to detect PHP versions: %w", err)
+			}
+
+			// Verify installation
+			if !php.ValidatePHPVersion(phpVersion, versions) {
+				return "", fmt.Errorf("installation completed but PHP %s not detected", phpVersion)
+			}
+
+			fmt.Printf("\n✅ PHP %s is now available!\n\n", phpVersion)
+		} else {
+			return "", missingDependency(i18n.PHPNotInstalled, phpVersion)
+		}
+	}
+
+	return phpVersion, nil
+}
+
 func statusCmd() *cobra.Command {
-	return &cobra.Command{
+	var incidents bool
+	var limit int
+	var showRuntime bool
+
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show PHPark installation status",
-		Long:  `Status displays the current PHPark configuration and system status.`,
+		Long: `Status displays the current PHPark configuration and system status,
+including the systemd state of nginx, dnsmasq and each PHP-FPM version in use.
+A service that isn't running is shown with how it last exited and the
+commands to restart it and read its logs.
+
+With --runtime it shows what nginx and PHP-FPM are doing instead: active
+connections, requests per second, and how busy each PHP version's workers are,
+read from status pages nginx serves to localhost only. They're off until
+config.yaml names an address for them (runtime_status: 127.0.0.1:8089) and
+the sites are rebuilt.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if incidents {
+				return runIncidents(limit)
+			}
+			if showRuntime {
+				return runRuntime()
+			}
 			return runStatus()
 		},
 	}
+
+	cmd.Flags().BoolVar(&incidents, "incidents", false, "Show incidents recorded by the health watcher")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of incidents to show (with --incidents)")
+	cmd.Flags().BoolVar(&showRuntime, "runtime", false, "Show nginx connections, request rates and PHP-FPM worker usage")
+
+	return cmd
 }
 
 func runStatus() error {
-	fmt.Println("📊 PHPark Status\n")
+	fmt.Print("📊 PHPark Status\n\n")
 
 	// Check if PHPark is installed
 	paths, err := config.GetPaths()
@@ -1139,20 +2309,23 @@ func runStatus() error {
 	if paths.Exists() {
 		fmt.Printf("✅ PHPark is installed at %s\n", paths.Home)
 	} else {
-		fmt.Printf("❌ PHPark is not installed\n")
-		fmt.Println("   Run: phppark install")
-		return nil
+		return &exitError{code: exitNotInstalled, err: i18n.Errorf(i18n.NotInstalled)}
 	}
 
 	// Configuration
 	fmt.Println("\n=== Configuration ===")
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Printf("⚠️  Failed to load config: %v\n", err)
+		i18n.Printf(i18n.WarnLoadConfig, err)
 	} else {
 		fmt.Printf("Domain:      .%s\n", cfg.Domain)
 		fmt.Printf("Default PHP: %s\n", cfg.DefaultPHP)
 		fmt.Printf("HTTPS:       %v\n", cfg.UseHTTPS)
+		fmt.Printf("Web server:  %s\n", cfg.WebServer)
+		if httpPort, httpsPort := cfg.SitePorts(); httpPort != 80 || httpsPort != 443 {
+			fmt.Printf("Ports:       %d (http), %d (https)\n", httpPort, httpsPort)
+		}
+		fmt.Printf("Backend:     %s\n", cfg.Backend)
 		fmt.Printf("Config:      %s\n", paths.Config)
 	}
 
@@ -1160,7 +2333,7 @@ func runStatus() error {
 	fmt.Println("\n=== Sites ===")
 	sites, err := config.LoadSites()
 	if err != nil {
-		fmt.Printf("⚠️  Failed to load sites: %v\n", err)
+		i18n.Printf(i18n.WarnLoadSites, err)
 	} else {
 		allSites := sites.ListSites()
 		fmt.Printf("Total sites: %d\n", len(allSites))
@@ -1184,6 +2357,35 @@ func runStatus() error {
 		fmt.Printf("  Parked:    %d\n", parked)
 		fmt.Printf("  Secured:   %d (HTTPS)\n", secured)
 		fmt.Printf("Registry:    %s\n", paths.Sites)
+
+		// Queue workers
+		workerLines := []string{}
+		for _, site := range allSites {
+			for _, worker := range site.Workers {
+				active := countActiveWorkers(site.Name, &worker)
+				status := "✅"
+				if active < worker.Count {
+					status = "❌"
+				}
+				workerLines = append(workerLines, fmt.Sprintf("%s %s/%s: %d/%d running", status, site.Name, worker.Name, active, worker.Count))
+			}
+		}
+		if len(workerLines) > 0 {
+			fmt.Println("\n=== Workers ===")
+			for _, line := range workerLines {
+				fmt.Println(line)
+			}
+		}
+
+		// A HEAD request per site catches broken FPM sockets and missing
+		// docroots before a browser does
+		if len(allSites) > 0 && cfg != nil {
+			fmt.Println("\n=== Site Health ===")
+			checks := checkSites(allSites, cfg)
+			for i := range allSites {
+				fmt.Printf("%-16s %s\n", siteBadge(&allSites[i], checks), siteURL(&allSites[i], cfg))
+			}
+		}
 	}
 
 	// Nginx Configs
@@ -1244,14 +2446,24 @@ func runStatus() error {
 	fmt.Printf("Arch:        %s\n", runtime.GOARCH)
 
 	// Check for nginx
-	if _, err := exec.LookPath("nginx"); err == nil {
-		cmd := exec.Command("nginx", "-v")
+	if _, err := exec.LookPath(services.NginxBinary()); err == nil {
+		cmd := exec.Command(services.NginxBinary(), "-v")
 		output, _ := cmd.CombinedOutput()
 		fmt.Printf("Nginx:       ✅ %s\n", strings.TrimSpace(string(output)))
 	} else {
 		fmt.Println("Nginx:       ❌ Not found")
 	}
 
+	// Check for Apache (only relevant to the apache backend)
+	if cfg != nil && cfg.WebServer == "apache" {
+		if _, err := exec.LookPath("apachectl"); err == nil {
+			output, _ := exec.Command("apachectl", "-v").CombinedOutput()
+			fmt.Printf("Apache:      ✅ %s\n", strings.SplitN(strings.TrimSpace(string(output)), "\n", 2)[0])
+		} else {
+			fmt.Println("Apache:      ❌ Not found")
+		}
+	}
+
 	// Check for dnsmasq
 	if _, err := exec.LookPath("dnsmasq"); err == nil {
 		fmt.Println("dnsmasq:     ✅ Installed")
@@ -1259,15 +2471,21 @@ func runStatus() error {
 		fmt.Println("dnsmasq:     ❌ Not found")
 	}
 
+	// Installed isn't running: show what systemd says about each service
+	if cfg != nil && sites != nil {
+		printServiceStates(cfg, sites.ListSites())
+	}
+
 	fmt.Println("\n" + strings.Repeat("─", 50))
 	fmt.Println("Run 'phppark links' to see all registered sites")
 
 	// DNS Configuration
 	fmt.Println("\n=== DNS ===")
-	isConfigured, err := dns.CheckDNS(cfg.Domain)
+	isConfigured, err := dnsConfigured(cfg)
 	if err != nil {
 		fmt.Printf("⚠️  Failed to check DNS: %v\n", err)
 	} else {
+		fmt.Printf("Driver:      %s\n", cfg.DNS.Driver)
 		if isConfigured {
 			fmt.Printf("Status:      ✅ Configured for .%s\n", cfg.Domain)
 		} else {
@@ -1294,11 +2512,38 @@ func runTrust() error {
 	// Load config to get domain
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
 	}
 
 	fmt.Printf("🔧 Configuring DNS for .%s domains...\n\n", cfg.Domain)
 
+	switch cfg.DNS.Driver {
+	case "resolved":
+		if err := trustResolved(cfg); err != nil {
+			return err
+		}
+		trustLAN(cfg)
+		reportResolution(cfg)
+		offerRenewTimer()
+		return nil
+	case dnsmasqResolvedDriver:
+		if err := trustDnsmasqResolved(cfg); err != nil {
+			return err
+		}
+		trustLAN(cfg)
+		reportResolution(cfg)
+		offerRenewTimer()
+		return nil
+	}
+
+	// Stop routing through systemd-resolved if a driver did before
+	if dns.CheckResolvedDNS(cfg.Domain) {
+		fmt.Println("Switching from systemd-resolved routing...")
+		if err := dns.RemoveResolvedDNS(); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+
 	// Check if already configured
 	isConfigured, err := dns.CheckDNS(cfg.Domain)
 	if err != nil {
@@ -1309,7 +2554,7 @@ func runTrust() error {
 	// the dnsmasq config file already exists. A previous failed run may have
 	// written the config without ever freeing port 53.
 	if dns.CheckSystemdResolvedConflict() {
-		fmt.Println("\n⚠️  systemd-resolved stub listener is occupying port 53")
+		fmt.Println(i18n.T(i18n.StubListenerBusy))
 		fmt.Println("   This is common on Ubuntu/Debian systems (including EC2 instances).")
 		fmt.Println("   PHPark can disable the stub listener only — systemd-resolved will keep")
 		fmt.Println("   running, so VPN routing, DHCP DNS, and NetworkManager continue to work.")
@@ -1318,11 +2563,11 @@ func runTrust() error {
 		fmt.Scanln(&ans)
 		if ans == "" || ans == "y" || ans == "Y" || ans == "yes" {
 			if err := dns.DisableSystemdResolvedStub(); err != nil {
-				fmt.Printf("   ⚠️  Warning: %v\n", err)
+				i18n.Printf(i18n.Warning, err)
 				fmt.Println("   To fix manually, add DNSStubListener=no to /etc/systemd/resolved.conf")
 				fmt.Println("   then run: sudo systemctl restart systemd-resolved")
 			} else {
-				fmt.Println("   ✅ Stub listener disabled — systemd-resolved still running for VPN/DHCP DNS\n")
+				fmt.Print("   ✅ Stub listener disabled — systemd-resolved still running for VPN/DHCP DNS\n\n")
 			}
 		}
 	}
@@ -1331,7 +2576,7 @@ func runTrust() error {
 		fmt.Printf("✅ DNS resolver is configured for .%s\n", cfg.Domain)
 	} else {
 		fmt.Println("Setting up dnsmasq...")
-		fmt.Println("⚠️  This requires sudo access")
+		fmt.Println(i18n.T(i18n.NeedsSudo))
 
 		if err := dns.SetupDNS(cfg.Domain); err != nil {
 			return fmt.Errorf("failed to setup DNS: %w", err)
@@ -1340,14 +2585,24 @@ func runTrust() error {
 		fmt.Printf("\n✅ DNS configured for .%s domains\n", cfg.Domain)
 	}
 
+	configureDnsmasqListen(cfg)
+	trustLAN(cfg)
+
 	// Always ensure dnsmasq is running — the config file may exist from a
 	// previous partial run where the service never successfully started.
-	if err := exec.Command("sudo", "systemctl", "restart", "dnsmasq").Run(); err != nil {
+	if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "dnsmasq")); err != nil {
 		fmt.Printf("⚠️  Warning: could not restart dnsmasq: %v\n", err)
 	} else {
 		fmt.Println("✅ dnsmasq running")
 	}
 
+	reportResolution(cfg)
+	offerRenewTimer()
+	return nil
+}
+
+// reportResolution checks that a few site hostnames resolve after trust
+func reportResolution(cfg *config.Config) {
 	fmt.Println("\nTesting resolution...")
 
 	// Test resolution
@@ -1364,7 +2619,7 @@ func runTrust() error {
 
 		for i := 0; i < testCount; i++ {
 			site := sites.ListSites()[i]
-			hostname := fmt.Sprintf("%s.%s", site.Name, cfg.Domain)
+			hostname := fmt.Sprintf("%s.%s", site.Name, cfg.SiteDomain())
 
 			fmt.Printf("Testing %s ... ", hostname)
 
@@ -1395,8 +2650,6 @@ func runTrust() error {
 	fmt.Println("\n" + strings.Repeat("─", 50))
 	fmt.Println("✅ DNS setup complete!")
 	fmt.Printf("All .%s domains now resolve to localhost\n", cfg.Domain)
-
-	return nil
 }
 
 func untrustCmd() *cobra.Command {
@@ -1413,14 +2666,42 @@ func untrustCmd() *cobra.Command {
 func runUntrust() error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	// Every user's sites resolve through the same .test setup
+	if cfg.MultiUser {
+		fmt.Printf("💡 Multi-user mode: DNS for .%s is shared with other users, so it's left in place\n", cfg.Domain)
+		return nil
 	}
 
 	fmt.Printf("🔧 Removing DNS configuration for .%s domains...\n", cfg.Domain)
-	fmt.Println("⚠️  This requires sudo access")
+	fmt.Println(i18n.T(i18n.NeedsSudo))
 
-	if err := dns.RemoveDNS(cfg.Domain); err != nil {
-		return fmt.Errorf("failed to remove DNS: %w", err)
+	switch cfg.DNS.Driver {
+	case "resolved":
+		if err := untrustResolved(); err != nil {
+			return err
+		}
+	case dnsmasqResolvedDriver:
+		if err := dns.RemoveResolvedDNS(); err != nil {
+			return fmt.Errorf("failed to remove DNS: %w", err)
+		}
+		if err := dns.RemoveDNS(cfg.Domain); err != nil {
+			return fmt.Errorf("failed to remove DNS: %w", err)
+		}
+	default:
+		if lanDomain := cfg.LANDomain(); lanDomain != "" {
+			if err := dns.RemoveLANDNS(lanDomain); err != nil {
+				i18n.Printf(i18n.Warning, err)
+			}
+			if err := services.RemoveUnit(lanDNSUnitName); err != nil {
+				i18n.Printf(i18n.Warning, err)
+			}
+		}
+		if err := dns.RemoveDNS(cfg.Domain); err != nil {
+			return fmt.Errorf("failed to remove DNS: %w", err)
+		}
 	}
 
 	fmt.Printf("\n✅ DNS configuration removed for .%s\n", cfg.Domain)
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// mountPathPattern matches the URL paths apps can be mounted at: segments
+// of letters, digits, "-", "_" and "."
+var mountPathPattern = regexp.MustCompile(`^(/[A-Za-z0-9_-][A-Za-z0-9._-]*)+$`)
+
+func mountCmd() *cobra.Command {
+	var phpVersion string
+
+	cmd := &cobra.Command{
+		Use:   "mount <site> [path dir]",
+		Short: "Serve another app under a path of a site",
+		Long: `Mount serves an app from its own directory under a path of an existing site,
+e.g., a WordPress blog at /blog of a Laravel shop:
+
+  phppark mount shop /blog ~/code/blog-wp
+
+Requests under the path are served from the app's document root (found in its
+directory the way a site's is, e.g., public/) and its PHP runs on the local
+PHP-FPM for the site's PHP version, or --php's. Without a path and directory,
+the site's mounts are listed. Mounts work with nginx and Apache; a user
+template places them with {{template "mounts" .}}.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 && len(args) != 3 {
+				return fmt.Errorf("expected a site, or a site, a path and a directory")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return runMountList(args[0])
+			}
+			return runMount(args[0], args[1], args[2], phpVersion)
+		},
+	}
+
+	cmd.Flags().StringVar(&phpVersion, "php", "", "PHP version the app runs on (default: the site's)")
+
+	return cmd
+}
+
+func unmountCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unmount <site> <path>",
+		Short: "Stop serving an app mounted under a path of a site",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUnmount(args[0], args[1])
+		},
+	}
+}
+
+func runMount(siteName, path, dir, phpVersion string) error {
+	path, err := cleanMountPath(path)
+	if err != nil {
+		return err
+	}
+	if dir, err = resolveSiteDir(dir); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+	if server.EmbedsPHP() {
+		return fmt.Errorf("mounts need nginx or Apache (web_server: %s)", server.Name())
+	}
+
+	if phpVersion != "" {
+		if phpVersion, err = ensurePHPVersion(phpVersion, cfg, true); err != nil {
+			return err
+		}
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Builtin {
+		return fmt.Errorf("site '%s' runs on PHP's built-in server, which can't serve mounts", siteName)
+	}
+	if existing := site.FindMount(path); existing != nil {
+		return fmt.Errorf("%s of '%s' already serves %s (phppark unmount %s %s first)", path, siteName, existing.Dir, siteName, path)
+	}
+
+	mount := config.Mount{Path: path, Dir: dir, PHPVersion: phpVersion}
+	site.Mounts = append(site.Mounts, mount)
+
+	if err := deployMounts(sites, site, cfg, server); err != nil {
+		return err
+	}
+	fmt.Printf("📂 Serving %s%s from %s\n", siteURL(site, cfg), path, mountDocumentRoot(&mount, cfg))
+	return nil
+}
+
+// deployMounts deploys a site whose mounts changed, then records the change.
+// A config that doesn't render or that the web server rejects is rolled
+// back and leaves the registry as it was.
+func deployMounts(sites *config.SiteRegistry, site *config.Site, cfg *config.Config, server webserver.Server) error {
+	if site.Disabled {
+		fmt.Printf("   ⏸️  %s is disabled; changes apply on 'phppark enable %s'\n", site.Name, site.Name)
+	} else {
+		failures, err := deploySites([]*config.Site{site}, cfg)
+		if err, ok := failures[site.Name]; ok {
+			printConfigTestDetail(err, "   ")
+			return fmt.Errorf("failed to update %s config: %w", server.Name(), err)
+		}
+		if err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+	return nil
+}
+
+func runUnmount(siteName, path string) error {
+	path = strings.TrimRight(path, "/")
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if !site.RemoveMount(path) {
+		return fmt.Errorf("nothing is mounted at %s of '%s'", path, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := deployMounts(sites, site, cfg, server); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Unmounted %s%s\n", siteURL(site, cfg), path)
+	return nil
+}
+
+func runMountList(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if len(site.Mounts) == 0 {
+		fmt.Printf("📂 %s has no mounts\n", siteName)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	for _, mount := range site.Mounts {
+		version := mount.PHPVersion
+		if version == "" {
+			version = site.PHPVersion
+		}
+		if version == "" {
+			version = cfg.DefaultPHP
+		}
+		fmt.Printf("📂 %s%s\n", siteURL(site, cfg), mount.Path)
+		fmt.Printf("   Docroot: %s\n", mountDocumentRoot(&mount, cfg))
+		fmt.Printf("   PHP:     %s\n", version)
+	}
+	return nil
+}
+
+// cleanMountPath checks a URL path to mount an app at, returning it
+// without a trailing slash
+func cleanMountPath(path string) (string, error) {
+	clean := strings.TrimRight(path, "/")
+	if !mountPathPattern.MatchString(clean) || strings.Contains(clean, "/..") {
+		return "", fmt.Errorf("invalid path '%s' (expected a URL path such as /blog)", path)
+	}
+	// PHPark's own pages live there
+	if clean == "/__phppark" || strings.HasPrefix(clean, "/__phppark/") {
+		return "", fmt.Errorf("%s is reserved for PHPark", clean)
+	}
+	return clean, nil
+}
+
+// mountDocumentRoot returns the directory a mounted app is served from:
+// where its template says, or the first docroot candidate it has, or its
+// directory itself
+func mountDocumentRoot(mount *config.Mount, cfg *config.Config) string {
+	if root := nginx.TemplateDocumentRoot(nginx.DetectTemplate(mount.Dir), mount.Dir); root != "" {
+		return root
+	}
+
+	candidates := cfg.DocRootCandidates
+	if len(candidates) == 0 {
+		candidates = nginx.DocRootCandidates
+	}
+	return nginx.FindDocumentRoot(mount.Dir, candidates)
+}
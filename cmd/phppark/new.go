@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+const wordpressZipURL = "https://wordpress.org/latest.zip"
+
+// newOptions holds flags for the new command
+type newOptions struct {
+	template string // Project template: laravel, symfony or wordpress
+	withDB   bool   // Create a database for the site
+	secure   bool   // Serve over HTTPS from the start
+	php      string // PHP version (default: the global default)
+}
+
+// projectCreators maps each template to the composer package it's created
+// from. WordPress isn't a composer project and is handled separately.
+var projectCreators = map[string]string{
+	"laravel": "laravel/laravel",
+	"symfony": "symfony/skeleton",
+}
+
+func newCmd() *cobra.Command {
+	var opts newOptions
+
+	cmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Create a new project and link it as a site",
+		Long: `New creates a project in ./<name> from a template (composer create-project for
+Laravel and Symfony, wp core download for WordPress), then links it as
+<name>.test, optionally with a database and HTTPS, and prints its URL.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNew(args[0], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.template, "template", "laravel", "Project template: laravel, symfony or wordpress")
+	cmd.Flags().BoolVar(&opts.withDB, "with-db", false, "Create a database for the site")
+	cmd.Flags().BoolVar(&opts.secure, "secure", false, "Generate a certificate and serve the site over HTTPS")
+	cmd.Flags().StringVar(&opts.php, "php", "", "PHP version for the site (default: the global default)")
+
+	return cmd
+}
+
+func runNew(name string, opts newOptions) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid project name '%s'", name)
+	}
+
+	template := strings.ToLower(opts.template)
+	if _, ok := projectCreators[template]; !ok && template != nginx.TemplateWordPress {
+		return fmt.Errorf("unknown template '%s' (available: laravel, symfony, wordpress)", opts.template)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf(i18n.CurrentDirFailed, err)
+	}
+	dir := filepath.Join(cwd, name)
+
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if sites.FindSite(name) != nil {
+		return i18n.Errorf(i18n.SiteExists, name)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	// Settle the PHP version first, so the project is created with it
+	if opts.php != "" {
+		if opts.php, err = ensurePHPVersion(opts.php, cfg, true); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("🏗️  Creating %s project in %s...\n\n", template, dir)
+
+	if template == nginx.TemplateWordPress {
+		err = createWordPress(dir, opts.php)
+	} else {
+		err = createComposerProject(projectCreators[template], dir, opts.php)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+
+	linkOpts := linkOptions{path: dir, withDB: opts.withDB, secure: opts.secure, php: opts.php}
+	if template != nginx.TemplateLaravel {
+		linkOpts.template = template
+	}
+	if err := runLink(name, linkOpts); err != nil {
+		return err
+	}
+
+	site, err := config.GetSite(name)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotLinked, name)
+	}
+
+	fmt.Printf("\n🎉 %s is ready: %s\n", name, siteURL(site, cfg))
+	return nil
+}
+
+// createComposerProject runs `composer create-project` for a package
+func createComposerProject(pkg, dir, phpVersion string) error {
+	composer, err := exec.LookPath("composer")
+	if err != nil {
+		return missingDependency(i18n.ComposerNotFound)
+	}
+
+	args := []string{composer, "create-project", pkg, dir}
+	// Run composer under the site's PHP rather than whatever its shebang finds
+	if binary := php.BinaryPath(phpVersion); phpVersion != "" && binary != "" {
+		args = append([]string{binary}, args...)
+	}
+
+	if err := runCreator(args); err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+	return nil
+}
+
+// createWordPress downloads WordPress core with WP-CLI, or straight from
+// wordpress.org, checked against its published SHA-1, when WP-CLI isn't
+// installed
+func createWordPress(dir, phpVersion string) error {
+	if wp, err := exec.LookPath("wp"); err == nil {
+		args := []string{wp, "core", "download", "--path=" + dir}
+		if binary := php.BinaryPath(phpVersion); phpVersion != "" && binary != "" {
+			args = append([]string{binary}, args...)
+		}
+		if err := runCreator(args); err != nil {
+			return fmt.Errorf("failed to download WordPress: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Println("   WP-CLI not found, downloading from wordpress.org...")
+
+	archive, err := os.CreateTemp("", "phppark-wordpress-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	archive.Close()
+	defer os.Remove(archive.Name())
+
+	if err := services.DownloadPublishedSHA1(wordpressZipURL, wordpressZipURL+".sha1", archive.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to download WordPress: %w (install WP-CLI to download it with: wp core download)", err)
+	}
+	// The archive has everything under wordpress/
+	if err := services.ExtractZip(archive.Name(), dir, 1); err != nil {
+		oplog.RemoveAll(dir)
+		return fmt.Errorf("failed to extract WordPress: %w", err)
+	}
+
+	// Hand the files to the user who ran sudo
+	if user := sudoUser(); user != "" {
+		if err := oplog.Run(exec.Command("chown", "-R", user+":", dir)); err != nil {
+			fmt.Printf("   ⚠️  Warning: could not hand %s to %s: %v\n", dir, user, err)
+		}
+	}
+	return nil
+}
+
+// runCreator runs a project creator with its output shown, as the user who
+// ran sudo so the project doesn't end up owned by root
+func runCreator(args []string) error {
+	if user := sudoUser(); user != "" {
+		args = append([]string{"sudo", "-u", user, "-H"}, args...)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	showOutput(cmd)
+	return oplog.Run(cmd)
+}
+
+// sudoUser returns the user behind sudo when running as root through it
+func sudoUser() string {
+	if os.Geteuid() != 0 {
+		return ""
+	}
+	if user := services.InvokingUser(); user != "root" {
+		return user
+	}
+	return ""
+}
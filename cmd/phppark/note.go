@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func noteCmd() *cobra.Command {
+	var clear bool
+
+	cmd := &cobra.Command{
+		Use:   "note <site> [text]",
+		Short: "Describe a site, or show its description",
+		Long: `Note sets a free-text description for a site (e.g., "client X staging clone"),
+shown by info, links --long and the daemon's site API, and matched by search.
+With no text it shows the site's description.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNote(args[0], strings.Join(args[1:], " "), clear)
+		},
+	}
+
+	cmd.Flags().BoolVar(&clear, "clear", false, "Remove the site's description")
+
+	return cmd
+}
+
+func runNote(siteName, text string, clear bool) error {
+	text = strings.TrimSpace(text)
+	if text != "" && clear {
+		return fmt.Errorf("--clear takes no text")
+	}
+
+	if text == "" && !clear {
+		site, err := config.GetSite(siteName)
+		if err != nil {
+			return i18n.Errorf(i18n.LoadSitesFailed, err)
+		}
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, siteName)
+		}
+		if site.Description == "" {
+			fmt.Printf("📝 %s has no description\n", siteName)
+			return nil
+		}
+		fmt.Printf("📝 %s: %s\n", siteName, site.Description)
+		return nil
+	}
+
+	found := false
+	err := config.UpdateSites(func(sites *config.SiteRegistry) error {
+		if site := sites.FindSite(siteName); site != nil {
+			site.Description = text
+			found = true
+		}
+		return nil
+	})
+	if err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+	if !found {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if clear {
+		fmt.Printf("✅ Removed %s's description\n", siteName)
+	} else {
+		fmt.Printf("✅ %s: %s\n", siteName, text)
+	}
+	return nil
+}
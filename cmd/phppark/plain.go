@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// terminalStdout and terminalStderr are the process's own stdout and
+// stderr, kept before plain mode or --quiet replace os.Stdout and os.Stderr.
+// Only PHPark's messages are filtered: programs it runs write here directly,
+// keeping their TTY, colours and exact bytes.
+var terminalStdout, terminalStderr = os.Stdout, os.Stderr
+
+// helperStdout is where programs PHPark runs as a step of a command
+// (project creators, composer installs) print: the terminal, or nowhere
+// with --quiet
+var helperStdout io.Writer = os.Stdout
+
+// passthroughAnnotation marks a command whose output is another program's
+// or data for a pipe. Plain mode is only turned on for it with --plain.
+const passthroughAnnotation = "passthrough"
+
+// isPassthrough reports whether a command's output is passed through
+// as-is: it's marked with passthroughAnnotation, or asked for --json
+func isPassthrough(cmd *cobra.Command) bool {
+	if _, ok := cmd.Annotations[passthroughAnnotation]; ok {
+		return true
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	return err == nil && asJSON
+}
+
+// attachTerminal hands the terminal to a program whose output is what the
+// command is for (wp, tail -f)
+func attachTerminal(cmd *exec.Cmd) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = terminalStdout
+	cmd.Stderr = terminalStderr
+}
+
+// showOutput shows a helper program's output as it prints it
+func showOutput(cmd *exec.Cmd) {
+	cmd.Stdout = helperStdout
+	cmd.Stderr = terminalStderr
+}
+
+// flushOutput waits for plain output (if on) to reach the terminal or file;
+// main calls it before exiting
+var flushOutput = func() {}
+
+// wantsPlainOutput reports whether output should be plain without --plain:
+// NO_COLOR is set, TERM is dumb, or stdout isn't a terminal (logs, CI, pipes)
+func wantsPlainOutput() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// startPlainOutput passes PHPark's own stdout and stderr through a filter
+// that drops emoji and ANSI escape codes and turns box-drawing into ASCII.
+// It writes as output arrives, so prompts without a newline still show.
+func startPlainOutput() {
+	var done []chan struct{}
+	var pipes []*os.File
+
+	for _, target := range []**os.File{&os.Stdout, &os.Stderr} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return
+		}
+
+		out := *target
+		*target = w
+		pipes = append(pipes, w)
+
+		finished := make(chan struct{})
+		done = append(done, finished)
+		go func() {
+			copyPlain(out, r)
+			r.Close()
+			close(finished)
+		}()
+	}
+
+	flushOutput = func() {
+		for _, w := range pipes {
+			w.Close()
+		}
+		for _, finished := range done {
+			<-finished
+		}
+	}
+}
+
+// copyPlain copies r to w as plain text. A rune or escape sequence split
+// between reads is held back until the rest arrives.
+func copyPlain(w io.Writer, r io.Reader) {
+	buf := make([]byte, 32*1024)
+	var pending []byte
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			text, rest := plainText(pending, err != nil)
+			io.WriteString(w, text)
+			pending = append([]byte(nil), rest...)
+		}
+		if err != nil {
+			if len(pending) > 0 {
+				text, _ := plainText(pending, true)
+				io.WriteString(w, text)
+			}
+			return
+		}
+	}
+}
+
+// plainText converts as much of b as is complete, returning the rest. With
+// final, everything is converted.
+func plainText(b []byte, final bool) (string, []byte) {
+	var out bytes.Buffer
+	// Emoji are followed by padding to line up text; dropping one drops
+	// the spaces after it too when it starts a line or word
+	skipSpaces := 0
+
+	for i := 0; i < len(b); {
+		if b[i] == 0x1b {
+			end := escapeEnd(b[i:])
+			if end < 0 {
+				if final {
+					return out.String(), nil
+				}
+				return out.String(), b[i:]
+			}
+			i += end
+			continue
+		}
+
+		if !final && !utf8.FullRune(b[i:]) {
+			return out.String(), b[i:]
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		i += size
+
+		if r == ' ' && skipSpaces > 0 {
+			skipSpaces--
+			continue
+		}
+		skipSpaces = 0
+
+		if isEmoji(r) {
+			written := out.Bytes()
+			if len(written) == 0 || written[len(written)-1] == ' ' || written[len(written)-1] == '\n' {
+				skipSpaces = 2
+			}
+			continue
+		}
+
+		if ascii, ok := boxDrawing(r); ok {
+			out.WriteString(ascii)
+			continue
+		}
+		if r == utf8.RuneError && size == 1 {
+			out.WriteByte(b[i-1])
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String(), nil
+}
+
+// escapeEnd returns the length of the ANSI escape sequence at the start of
+// b, or -1 if it isn't complete yet
+func escapeEnd(b []byte) int {
+	if len(b) < 2 {
+		return -1
+	}
+
+	switch b[1] {
+	case '[':
+		// CSI: parameters, then a final byte in 0x40-0x7e
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return -1
+	case ']':
+		// OSC (e.g., terminal titles and links): ends with BEL or ESC \
+		for i := 2; i < len(b); i++ {
+			if b[i] == 0x07 {
+				return i + 1
+			}
+			if b[i] == 0x1b && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return -1
+	}
+	return 2
+}
+
+// isEmoji reports whether a rune is an emoji, a pictographic symbol or one
+// of the joiners and selectors that combine them
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff: // Emoji, pictographs, flags
+		return true
+	case r >= 0x2600 && r <= 0x27bf: // Symbols and dingbats (✅, ❌, ⚡)
+		return true
+	case r >= 0x2300 && r <= 0x23ff: // Technical symbols (⏭, ⏰, ⌛)
+		return true
+	case r >= 0x2b00 && r <= 0x2bff: // Arrows and stars (⭐, ⬆)
+		return true
+	case r == 0xfe0f || r == 0xfe0e || r == 0x200d || r == 0x20e3:
+		return true
+	case r == 0x2139 || r == 0x203c || r == 0x2049:
+		return true
+	}
+	return false
+}
+
+// boxDrawing returns the ASCII for a box-drawing character or bullet
+func boxDrawing(r rune) (string, bool) {
+	switch {
+	case r == '•':
+		return "-", true
+	case r < 0x2500 || r > 0x257f:
+		return "", false
+	case strings.ContainsRune("─━┄┅┈┉╌╍═", r):
+		return "-", true
+	case strings.ContainsRune("│┃┆┇┊┋╎╏║", r):
+		return "|", true
+	}
+	return "+", true
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+const pmaSiteName = "pma"
+
+// pmaConfigTemplate is written to config.inc.php. The blowfish secret must
+// be exactly 32 bytes, so it is stored hex-encoded and decoded at runtime.
+const pmaConfigTemplate = `<?php
+// Managed by PHPark - regenerated by 'phppark pma on'
+$cfg['blowfish_secret'] = sodium_hex2bin('%s');
+
+$i = 1;
+$cfg['Servers'][$i]['auth_type'] = 'cookie';
+$cfg['Servers'][$i]['host'] = '%s';
+$cfg['Servers'][$i]['port'] = '%d';
+$cfg['Servers'][$i]['compress'] = false;
+$cfg['Servers'][$i]['AllowNoPassword'] = true;
+
+$cfg['TempDir'] = sys_get_temp_dir();
+`
+
+func pmaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pma",
+		Short: "Host phpMyAdmin at pma.test",
+		Long:  `Pma serves phpMyAdmin as pma.test, configured against the database server in config.yaml.`,
+	}
+
+	var version string
+	on := &cobra.Command{
+		Use:   "on",
+		Short: "Download phpMyAdmin and serve it at pma.test",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPMAOn(version)
+		},
+	}
+	on.Flags().StringVar(&version, "version", "", "phpMyAdmin version (default: latest)")
+	cmd.AddCommand(on)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "off",
+		Short: "Stop serving phpMyAdmin and remove it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPMAOff()
+		},
+	})
+
+	return cmd
+}
+
+// pmaDownloadURL returns the all-languages archive for a version
+func pmaDownloadURL(version string) string {
+	if version == "" {
+		return "https://www.phpmyadmin.net/downloads/phpMyAdmin-latest-all-languages.zip"
+	}
+	return fmt.Sprintf("https://files.phpmyadmin.net/phpMyAdmin/%s/phpMyAdmin-%s-all-languages.zip", version, version)
+}
+
+func runPMAOn(version string) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	toolDir := filepath.Join(paths.Tools, "phpmyadmin")
+	archive := filepath.Join(paths.Tools, "phpmyadmin.zip")
+
+	label := version
+	if label == "" {
+		label = "latest"
+	}
+	fmt.Printf("📥 Downloading phpMyAdmin (%s)...\n", label)
+
+	url := pmaDownloadURL(version)
+	download, err := services.PublishedSHA256(url, url+".sha256")
+	if err == nil {
+		err = services.DownloadVerified(download, archive, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download phpMyAdmin: %w", err)
+	}
+	defer os.Remove(archive)
+
+	// Replace any previous install so upgrades don't leave stale files
+	if err := oplog.RemoveAll(toolDir); err != nil {
+		return fmt.Errorf("failed to remove old install: %w", err)
+	}
+
+	fmt.Println("   Extracting...")
+	if err := services.ExtractZip(archive, toolDir, 1); err != nil {
+		return fmt.Errorf("failed to extract phpMyAdmin: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate blowfish secret: %w", err)
+	}
+
+	content := fmt.Sprintf(pmaConfigTemplate, hex.EncodeToString(secret), cfg.Database.Host, cfg.Database.Port)
+	if err := oplog.WriteFile(filepath.Join(toolDir, "config.inc.php"), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write config.inc.php: %w", err)
+	}
+	fmt.Println("   ✅ Generated config.inc.php")
+
+	if err := services.ChownToInvokingUser(toolDir); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+
+	site := config.Site{
+		Name: pmaSiteName,
+		Path: toolDir,
+		Type: "link",
+	}
+	if err := registerSite(site, cfg); err != nil {
+		return err
+	}
+
+	fmt.Println("\n✅ phpMyAdmin is ready!")
+	fmt.Printf("   URL:    %s\n", siteURL(&site, cfg))
+	fmt.Printf("   Server: %s:%d\n", cfg.Database.Host, cfg.Database.Port)
+
+	return nil
+}
+
+func runPMAOff() error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	toolDir := filepath.Join(paths.Tools, "phpmyadmin")
+
+	if err := unregisterSite(pmaSiteName, toolDir); err != nil {
+		return err
+	}
+
+	if err := oplog.RemoveAll(toolDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", toolDir, err)
+	}
+
+	fmt.Println("\n✅ phpMyAdmin removed")
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+func preloadCmd() *cobra.Command {
+	var off bool
+
+	cmd := &cobra.Command{
+		Use:   "preload <site> [preload.php]",
+		Short: "Run a site with opcache preloading",
+		Long: `Preload sets opcache.preload to a script inside the site (e.g., Symfony's
+config/preload.php), so preloading can be tried locally before production.
+PHP-FPM only preloads when its master starts, for all of its pools, so the
+site gets its own PHP-FPM (a systemd unit running the site's PHP version,
+with opcache.preload_user set to www-data) and is restarted on every change.
+A preload script that fails stops PHP-FPM from starting; PHPark then goes back
+to the previous setting. Without a script, the current one is shown; --off
+turns preloading off.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && !off {
+				return runPreloadShow(args[0])
+			}
+			if len(args) == 2 && off {
+				return fmt.Errorf("--off doesn't take a script")
+			}
+			script := ""
+			if len(args) == 2 {
+				script = args[1]
+			}
+			return runPreload(args[0], script)
+		},
+	}
+
+	cmd.Flags().BoolVar(&off, "off", false, "Turn preloading off")
+
+	return cmd
+}
+
+func runPreload(siteName, script string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+	if err := checkSiteFPM(site, cfg); err != nil {
+		return err
+	}
+
+	if script != "" {
+		if script, err = sitePreloadScript(site.Path, script); err != nil {
+			return err
+		}
+	}
+
+	previous := site.Preload
+	site.Preload = script
+	if script == "" {
+		fmt.Printf("⚡ Turning preloading off for %s.%s...\n", siteName, cfg.SiteDomain())
+	} else {
+		fmt.Printf("⚡ Preloading %s for %s.%s...\n", script, siteName, cfg.SiteDomain())
+	}
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if script == "" {
+		// Off the site's own PHP-FPM (unless it still profiles) before it goes away
+		if err := generateNginxConfig(site, cfg); err != nil {
+			return fmt.Errorf("failed to update site config: %w", err)
+		}
+		if err := applySiteFPM(site, cfg); err != nil {
+			return err
+		}
+		fmt.Printf("\n✅ Preloading off for %s.%s\n", siteName, cfg.SiteDomain())
+		return nil
+	}
+
+	if err := applySiteFPM(site, cfg); err != nil {
+		return err
+	}
+
+	// PHP-FPM exits right away when the preload script fails
+	unit := services.SiteFPMUnitName(siteFPMName(site.Name))
+	time.Sleep(time.Second)
+	if !services.IsUnitActive(unit) {
+		site.Preload = previous
+		if err := config.SaveSites(sites); err != nil {
+			i18n.Printf(i18n.WarnSaveSites, err)
+		}
+		if err := applySiteFPM(site, cfg); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+		return fmt.Errorf("PHP-FPM didn't start with %s, so it was put back (see: sudo journalctl -u %s -n 50)", script, unit)
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update site config: %w", err)
+	}
+
+	fmt.Printf("\n✅ %s.%s preloads %s\n", siteName, cfg.SiteDomain(), script)
+	fmt.Printf("   Check it with opcache_get_status()['preload_statistics'] in the site\n")
+	return nil
+}
+
+func runPreloadShow(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if site.Preload != "" {
+		fmt.Printf("   Preload: %s\n", site.Preload)
+		return nil
+	}
+
+	fmt.Println("   Preload: off")
+	if _, err := os.Stat(filepath.Join(site.Path, "config", "preload.php")); err == nil {
+		fmt.Printf("   Turn it on with: phppark preload %s config/preload.php\n", siteName)
+	}
+	return nil
+}
+
+// sitePreloadScript checks that a preload script is a file inside the
+// site, given relative to it (or absolute), and returns it relative to it
+func sitePreloadScript(sitePath, script string) (string, error) {
+	full := script
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(sitePath, script)
+	}
+
+	rel, err := filepath.Rel(sitePath, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("'%s' must be a script inside the site (e.g., config/preload.php)", script)
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return "", fmt.Errorf("preload script not found: %s", full)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("'%s' is a directory, not a preload script", script)
+	}
+	return rel, nil
+}
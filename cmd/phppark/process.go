@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// octaneProcessName is the supervised process for Octane sites
+const octaneProcessName = "octane"
+
+func runCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Supervise long-running processes for a site",
+		Long:  `Run supervises long-running commands (Horizon, websocket servers, npm run dev) for a site, restarting them on failure.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add <site> <name> -- <command>",
+		Short: "Add a supervised process to a site",
+		Example: `  phppark run add myapp horizon -- php artisan horizon
+  phppark run add myapp vite -- npm run dev`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 2 || len(args) < 3 {
+				return fmt.Errorf("usage: phppark run add <site> <name> -- <command>")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProcessAdd(args[0], args[1], shellJoin(args[2:]))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list [site]",
+		Short: "List supervised processes",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			siteName := ""
+			if len(args) > 0 {
+				siteName = args[0]
+			}
+			return runProcessList(siteName)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <site> <name>",
+		Short: "Stop and remove a supervised process",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProcessRemove(args[0], args[1])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:         "logs <site> <name>",
+		Short:       "Follow a process's output",
+		Annotations: map[string]string{passthroughAnnotation: ""},
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProcessLogs(args[0], args[1])
+		},
+	})
+
+	return cmd
+}
+
+func runProcessAdd(siteName, name, command string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if site.FindProcess(name) != nil {
+		return fmt.Errorf("process '%s' already exists for %s (remove it first)", name, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	process := config.Process{Name: name, Command: command}
+
+	fmt.Printf("⚙️  Adding process '%s' to %s.%s...\n", name, siteName, cfg.SiteDomain())
+
+	if err := installProcessUnit(site, &process, cfg); err != nil {
+		return err
+	}
+
+	site.Processes = append(site.Processes, process)
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	logFile, _ := processLogFile(site.Name, name)
+	fmt.Printf("\n✅ Process '%s' running\n", name)
+	fmt.Printf("   Command: %s\n", command)
+	fmt.Printf("   Logs:    %s\n", logFile)
+
+	return nil
+}
+
+func runProcessList(siteName string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	found := 0
+	for _, site := range sites.ListSites() {
+		if siteName != "" && site.Name != siteName {
+			continue
+		}
+		for _, process := range site.Processes {
+			if found == 0 {
+				fmt.Println("📋 Supervised Processes")
+				fmt.Println()
+			}
+			found++
+
+			status := "✅ running"
+			if !services.IsUnitActive(processUnitName(site.Name, process.Name)) {
+				status = "❌ stopped"
+			}
+
+			fmt.Printf("⚙️  %s/%s (%s)\n", site.Name, process.Name, status)
+			fmt.Printf("   Command: %s\n", process.Command)
+			fmt.Println()
+		}
+	}
+
+	if found == 0 {
+		fmt.Println("📋 No supervised processes")
+		fmt.Println("\nTo add one:")
+		fmt.Println("  phppark run add myapp horizon -- php artisan horizon")
+	}
+
+	return nil
+}
+
+func runProcessRemove(siteName, name string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if site.FindProcess(name) == nil {
+		return fmt.Errorf("process '%s' not found for %s", name, siteName)
+	}
+
+	fmt.Printf("🗑️  Removing process %s/%s...\n", siteName, name)
+
+	if err := services.RemoveUnit(processUnitName(site.Name, name)); err != nil {
+		return err
+	}
+
+	site.RemoveProcess(name)
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Println("\n✅ Process removed")
+	return nil
+}
+
+func runProcessLogs(siteName, name string) error {
+	logFile, err := processLogFile(siteName, name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(logFile); err != nil {
+		return fmt.Errorf("no logs yet for %s/%s (%s)", siteName, name, logFile)
+	}
+
+	cmd := exec.Command("tail", "-n", "50", "-f", logFile)
+	attachTerminal(cmd)
+	return cmd.Run()
+}
+
+// installProcessUnit writes and starts the systemd unit for a process
+func installProcessUnit(site *config.Site, process *config.Process, cfg *config.Config) error {
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	logFile, err := processLogFile(site.Name, process.Name)
+	if err != nil {
+		return err
+	}
+
+	unit := &services.Unit{
+		Name:        processUnitName(site.Name, process.Name),
+		Description: fmt.Sprintf("PHPark process %s/%s", site.Name, process.Name),
+		ExecStart:   []string{"/bin/sh", "-c", "exec " + sitePHPCommand(process.Command, phpVersion)},
+		WorkingDir:  site.Path,
+		LogFile:     logFile,
+	}
+
+	return services.InstallUnit(unit)
+}
+
+// processLogFile returns where a process's output is captured
+func processLogFile(siteName, name string) (string, error) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("%s-%s.log", services.UnitSafe(siteName), services.UnitSafe(name))
+	return filepath.Join(paths.Logs, fileName), nil
+}
+
+func processUnitName(siteName, name string) string {
+	return fmt.Sprintf("phppark-run-%s-%s", services.UnitSafe(sharedName(siteName)), services.UnitSafe(name))
+}
+
+// shellJoin rebuilds a command line from arguments, single-quoting any that
+// need it so /bin/sh sees the same words
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+func profileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Switch between separate sets of sites",
+		Long: `Profiles keep separate sets of sites (e.g., one per client), each with its
+own registry, TLD, default PHP version, parked directories and certificates.
+Everything else in config.yaml (web server, DNS, ports, ...) is machine-wide
+and stays in ~/.phppark/config.yaml whichever profile is active. Only the active profile's sites are served: switching with
+'phppark profile use' takes down the current profile's sites and workers and
+deploys the other profile's. The "default" profile is the one in ~/.phppark.
+
+'phppark profile enable <site>' is unrelated: it sets up a code profiler for
+a site.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileList()
+		},
+	}
+
+	var opts profileCreateOptions
+	create := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a profile, starting from the active profile's TLD and PHP version",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileCreate(args[0], opts)
+		},
+	}
+	create.Flags().StringVar(&opts.tld, "tld", "", "TLD for the profile's sites (default: the active profile's)")
+	create.Flags().StringVar(&opts.php, "php", "", "Default PHP version for the profile (default: the active profile's)")
+
+	use := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Switch to a profile, redeploying its sites",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileUse(args[0])
+		},
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileList()
+		},
+	}
+
+	var force bool
+	remove := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete an inactive profile with its sites and certificates",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileDelete(args[0], force)
+		},
+	}
+	remove.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+
+	var driver string
+	enable := &cobra.Command{
+		Use:   "enable <site>",
+		Short: "Set up a profiler (SPX, XHProf or Blackfire) for a site",
+		Long: `Enable installs a profiling extension for the site's PHP version if it's
+missing and loads it for that site only: the site gets its own PHP-FPM
+(a systemd unit running the same PHP version) with the extension and its
+settings, so other sites aren't slowed down or exposed. Blackfire's probe is
+the exception, as its package loads it for every site. SPX is built from
+source, the others come from apt.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileEnable(args[0], driver)
+		},
+	}
+	enable.Flags().StringVar(&driver, "driver", "spx", "Profiler: "+profilerNames())
+
+	disable := &cobra.Command{
+		Use:   "disable <site>",
+		Short: "Stop profiling a site",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileDisable(args[0])
+		},
+	}
+
+	cmd.AddCommand(create, use, list, remove, enable, disable)
+	return cmd
+}
+
+// profileCreateOptions holds flags for profile create
+type profileCreateOptions struct {
+	tld string // TLD for the profile's sites
+	php string // Default PHP version
+}
+
+func runProfileList() error {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return err
+	}
+
+	active, err := config.ActiveProfile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("📋 Profiles:")
+	for _, name := range profiles {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Printf(" %s %s\n", marker, name)
+	}
+	return nil
+}
+
+func runProfileCreate(name string, opts profileCreateOptions) error {
+	if err := config.ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if opts.tld != "" {
+		cfg.Domain = opts.tld
+	}
+	if opts.php != "" {
+		cfg.DefaultPHP = php.FormatVersion(opts.php)
+	}
+
+	if err := config.CreateProfile(name, cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Created profile %s (.%s, PHP %s)\n", name, cfg.Domain, cfg.DefaultPHP)
+	fmt.Printf("   Switch to it with: phppark profile use %s\n", name)
+	return nil
+}
+
+func runProfileUse(name string) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+	if !config.ProfileExists(paths, name) {
+		return fmt.Errorf("profile '%s' not found (create it with: phppark profile create %s)", name, name)
+	}
+
+	current, err := config.ActiveProfile()
+	if err != nil {
+		return err
+	}
+	if current == name {
+		fmt.Printf("✅ Already using profile %s\n", name)
+		return nil
+	}
+
+	fmt.Printf("🔀 Switching from profile %s to %s...\n\n", current, name)
+
+	if err := deactivateProfile(paths); err != nil {
+		return err
+	}
+
+	if err := config.SetActiveProfile(name); err != nil {
+		return err
+	}
+
+	return activateProfile(name)
+}
+
+// deactivateProfile takes the active profile's sites off the web server and
+// stops their workers and processes. The registry is left as it is.
+func deactivateProfile(paths *config.Paths) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	allSites := sites.ListSites()
+	if len(allSites) == 0 {
+		return nil
+	}
+
+	fmt.Printf("⏸️  Taking down %d site(s)...\n", len(allSites))
+
+	forEachSiteUnit(allSites, func(label, unit string) {
+		if err := services.StopUnit(unit); err != nil {
+			fmt.Printf("   ⚠️  %s: %v\n", label, err)
+		}
+	})
+
+	unstaged := false
+	for _, site := range allSites {
+		if site.Builtin {
+			continue
+		}
+		if err := oplog.Remove(server.ConfigPath(paths, site.Name)); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("   ⚠️  Warning: %s: %v\n", site.Name, err)
+		}
+		if err := server.Unstage(sharedName(site.Name)); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not remove %s from %s: %v\n", site.Name, server.Name(), err)
+			continue
+		}
+		unstaged = true
+	}
+
+	if unstaged {
+		if err := server.Test(); err != nil {
+			i18n.Printf(i18n.Warning, err)
+			printConfigTestDetail(err, "   ")
+		} else if err := server.Reload(); err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not reload %s: %v\n", server.Name(), err)
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// activateProfile deploys the now active profile's sites and starts their
+// workers and processes
+func activateProfile(name string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	allSites := sites.ListSites()
+	var failed []string
+	if len(allSites) > 0 {
+		fmt.Printf("🔨 Deploying %d site(s)...\n", len(allSites))
+
+		selected := make([]*config.Site, len(allSites))
+		for i := range allSites {
+			selected[i] = &allSites[i]
+		}
+		failures, err := deploySites(selected, cfg)
+		if err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+		for _, site := range allSites {
+			if err, ok := failures[site.Name]; ok {
+				fmt.Printf("   ❌ %s: %v\n", site.Name, err)
+				failed = append(failed, site.Name)
+			}
+		}
+
+		forEachSiteUnit(allSites, func(label, unit string) {
+			if err := services.StartUnit(unit); err != nil {
+				fmt.Printf("   ⚠️  %s: %v\n", label, err)
+			}
+		})
+		fmt.Println()
+	}
+
+	fmt.Printf("✅ Using profile %s: %d site(s) on .%s, PHP %s by default\n", name, len(allSites), cfg.Domain, cfg.DefaultPHP)
+
+	if resolves, _ := dnsConfigured(cfg); !resolves {
+		fmt.Printf("💡 .%s isn't set up to resolve yet. Run: phppark trust\n", cfg.Domain)
+	}
+
+	if len(failed) > 0 {
+		return partialFailure(fmt.Errorf("failed to deploy %d of %d site(s)", len(failed), len(allSites)))
+	}
+	return nil
+}
+
+func runProfileDelete(name string, force bool) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+	if !config.ProfileExists(paths, name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+
+	if !force {
+		fmt.Printf("⚠️  This will delete profile %s with its site registry and certificates\n", name)
+		i18n.Printf(i18n.Continue)
+
+		var response string
+		fmt.Scanln(&response)
+
+		if response != "y" && response != "Y" && response != "yes" {
+			fmt.Println(i18n.T(i18n.Cancelled))
+			return nil
+		}
+	}
+
+	if err := config.DeleteProfile(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Deleted profile %s\n", name)
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/php"
+)
+
+// profiler is a profiling extension `phppark profile enable` sets up
+type profiler struct {
+	extension string                     // Shared object name, e.g., "spx" for spx.so
+	install   func(version string) error // Installs the extension for a PHP version
+	settings  map[string]string          // php.ini settings for the site's own PHP-FPM (none when the extension loads everywhere)
+	usage     func(url string) []string  // How to get to the profiles
+}
+
+// spxKey is the key SPX's web UI asks for; access is limited to localhost
+const spxKey = "phppark"
+
+var profilers = map[string]profiler{
+	"spx": {
+		extension: "spx",
+		install:   php.BuildSPX,
+		settings: map[string]string{
+			"extension":             "spx.so",
+			"spx.http_enabled":      "1",
+			"spx.http_key":          spxKey,
+			"spx.http_ip_whitelist": "127.0.0.1",
+		},
+		usage: func(url string) []string {
+			return []string{
+				fmt.Sprintf("Open the control panel: %s/?SPX_KEY=%s&SPX_UI_URI=/", url, spxKey),
+				"Tick \"Enabled\", load the pages to profile, then open their reports in the panel",
+			}
+		},
+	},
+	"xhprof": {
+		extension: "xhprof",
+		install:   func(version string) error { return php.InstallExtensionPackage(version, "xhprof") },
+		settings: map[string]string{
+			"extension":         "xhprof.so",
+			"xhprof.output_dir": "/tmp/xhprof",
+		},
+		usage: func(url string) []string {
+			return []string{
+				"Wrap the code to profile in xhprof_enable() and xhprof_disable()",
+				"Save the runs in /tmp/xhprof and view them with a UI such as XHGui",
+			}
+		},
+	},
+	"blackfire": {
+		extension: "blackfire",
+		install:   func(version string) error { return php.InstallBlackfire() },
+		usage: func(url string) []string {
+			return []string{
+				"Set your credentials once: sudo blackfire agent:config && blackfire client:config",
+				fmt.Sprintf("Profile a page: blackfire curl %s (or use the browser extension)", url),
+				"The Blackfire probe loads for every site on this PHP version",
+			}
+		},
+	},
+}
+
+// profilerNames lists the profiler drivers, for help and errors
+func profilerNames() string {
+	names := make([]string, 0, len(profilers))
+	for name := range profilers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func runProfileEnable(siteName, driverName string) error {
+	driver, ok := profilers[driverName]
+	if !ok {
+		return fmt.Errorf("unknown profiler '%s' (available: %s)", driverName, profilerNames())
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+	if err := checkSiteFPM(site, cfg); err != nil {
+		return err
+	}
+
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	fmt.Printf("🔬 Enabling %s for %s.%s (PHP %s)...\n", driverName, siteName, cfg.SiteDomain(), phpVersion)
+
+	if !php.ExtensionInstalled(phpVersion, driver.extension) {
+		if err := driver.install(phpVersion); err != nil {
+			return err
+		}
+	}
+
+	site.Profiler = driverName
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if err := applySiteFPM(site, cfg); err != nil {
+		return err
+	}
+	if hasSiteFPM(site, cfg) {
+		fmt.Printf("   ✅ Started the site's own PHP-FPM (%s)\n", siteFPMName(site.Name))
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update site config: %w", err)
+	}
+
+	fmt.Printf("\n✅ %s enabled for %s.%s\n", driverName, siteName, cfg.SiteDomain())
+	for _, line := range driver.usage(siteURL(site, cfg)) {
+		fmt.Printf("   %s\n", line)
+	}
+	fmt.Printf("   Turn it off with: phppark profile disable %s\n", siteName)
+	return nil
+}
+
+func runProfileDisable(siteName string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Profiler == "" {
+		fmt.Printf("No profiler is enabled for %s\n", siteName)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	driverName := site.Profiler
+	fmt.Printf("🔬 Disabling %s for %s.%s...\n", driverName, siteName, cfg.SiteDomain())
+
+	site.Profiler = ""
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	// Back to the shared pool first, so the site's PHP-FPM isn't removed
+	// while nginx still passes to it
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update site config: %w", err)
+	}
+	if err := applySiteFPM(site, cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ %s disabled for %s.%s\n", driverName, siteName, cfg.SiteDomain())
+	if driverName == "blackfire" {
+		fmt.Println("   The Blackfire probe stays installed for every site (remove it with: sudo apt-get remove blackfire-php)")
+	}
+	return nil
+}
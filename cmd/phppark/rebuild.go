@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/hooks"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// rebuildOptions holds flags for the rebuild command
+type rebuildOptions struct {
+	tag     string // Only sites with this tag
+	changed bool   // Skip sites whose generated config wouldn't change
+	diff    bool   // Only show how deployed configs would change
+}
+
+func rebuildCmd() *cobra.Command {
+	var opts rebuildOptions
+
+	cmd := &cobra.Command{
+		Use:   "rebuild [site...]",
+		Short: "Rebuild all nginx configurations",
+		Long: `Rebuild regenerates nginx configuration files for all registered sites, or
+only the named ones. With --changed, sites whose config would come out
+identical to the deployed one are reported as up to date and left alone.
+With --diff nothing is written, not even a missing certificate: the new
+configs are compared with the deployed ones instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRebuild(args, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.tag, "tag", "", "Only rebuild sites with this tag")
+	cmd.Flags().BoolVar(&opts.changed, "changed", false, "Only rebuild sites whose config would change")
+	cmd.Flags().BoolVar(&opts.diff, "diff", false, "Show a diff of what would change without writing anything")
+
+	return cmd
+}
+
+func runRebuild(names []string, opts rebuildOptions) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		if sites.FindSite(name) == nil {
+			return i18n.Errorf(i18n.SiteNotFound, name)
+		}
+		wanted[name] = true
+	}
+
+	allSites := sites.ListSites()
+	var selected []*config.Site
+	for i := range allSites {
+		site := &allSites[i]
+		if len(wanted) > 0 && !wanted[site.Name] {
+			continue
+		}
+		if opts.tag != "" && !site.HasTag(opts.tag) {
+			continue
+		}
+		selected = append(selected, site)
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("📋 No sites to rebuild")
+		return nil
+	}
+
+	if opts.diff {
+		return showRebuildDiff(selected, cfg, server)
+	}
+
+	fmt.Printf("🔨 Rebuilding nginx configs for %d site(s)...\n\n", len(selected))
+
+	// Keys from before they got their own directory move there, so the
+	// rebuilt configs point at it
+	if paths, err := config.GetPaths(); err == nil {
+		moved, err := ssl.MigrateKeys(paths.Certificates)
+		if err != nil {
+			fmt.Printf("   ⚠️  Warning: failed to move private keys: %v\n", err)
+		}
+		if len(moved) > 0 {
+			fmt.Printf("   🔑 Moved %d private key(s) to %s\n\n", len(moved), filepath.Join(paths.Certificates, ssl.KeyDirName))
+		}
+	}
+
+	// Fill in metadata for sites registered before it was recorded
+	backfilled := false
+	for i := range allSites {
+		if allSites[i].Type == "park" && allSites[i].ParkedIn == "" {
+			allSites[i].ParkedIn = filepath.Dir(allSites[i].Path)
+			backfilled = true
+		}
+	}
+	if backfilled {
+		if err := config.SaveSites(sites); err != nil {
+			i18n.Printf(i18n.WarnSaveSites, err)
+		}
+	}
+
+	success := 0
+	failed := 0
+	current := 0
+	disabled := 0
+
+	var rebuild []*config.Site
+	for _, site := range selected {
+		if site.Disabled {
+			fmt.Printf("   %s.%s ... ⏸️  disabled\n", site.Name, cfg.SiteDomain())
+			disabled++
+			continue
+		}
+		if opts.changed && upToDate(site, cfg, server) {
+			fmt.Printf("   %s.%s ... up to date\n", site.Name, cfg.SiteDomain())
+			current++
+			continue
+		}
+		if err := runHook(hooks.PreRebuild, site, cfg); err != nil {
+			fmt.Printf("   %s.%s ... ❌ skipped (%v)\n", site.Name, cfg.SiteDomain(), err)
+			failed++
+			continue
+		}
+		rebuild = append(rebuild, site)
+	}
+
+	// Write every config, then test and reload once
+	if len(rebuild) > 0 {
+		failures, err := deploySites(rebuild, cfg)
+		if err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+
+		for _, site := range rebuild {
+			if err, ok := failures[site.Name]; ok {
+				fmt.Printf("   %s.%s ... ❌ failed (%v)\n", site.Name, cfg.SiteDomain(), err)
+				printConfigTestDetail(err, "      ")
+				failed++
+				continue
+			}
+			fmt.Printf("   %s.%s ... ✅\n", site.Name, cfg.SiteDomain())
+			success++
+			runPostHook(hooks.PostRebuild, site, cfg)
+		}
+	}
+
+	fmt.Printf("\n✅ Rebuilt %d config(s)", success)
+	if current > 0 {
+		fmt.Printf(", %d up to date", current)
+	}
+	if disabled > 0 {
+		fmt.Printf(", %d disabled", disabled)
+	}
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d site(s) failed to rebuild", failed, len(selected))
+		if success+current > 0 {
+			return partialFailure(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// showRebuildDiff renders sites' configs and prints a unified diff against
+// the files the web server currently reads
+func showRebuildDiff(sites []*config.Site, cfg *config.Config, server webserver.Server) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, site := range sites {
+		// Builtin sites have no web server config
+		if site.Builtin {
+			continue
+		}
+
+		_, content, err := previewSiteConfig(site, cfg, server)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", site.Name, err)
+			continue
+		}
+		if site.Secured && !ssl.CertificateExists(site.Name, paths.Certificates) {
+			fmt.Printf("📜 %s: a certificate would be created\n", site.Name)
+		}
+
+		diff, err := diffConfig(server.DeployedPath(paths, sharedName(site.Name)), content)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			continue
+		}
+
+		fmt.Print(diff)
+		changed++
+	}
+
+	if changed == 0 {
+		fmt.Println("✅ Deployed configs are up to date")
+	} else {
+		fmt.Printf("\n📋 %d of %d config(s) would change. Run without --diff to apply.\n", changed, len(sites))
+	}
+	return nil
+}
+
+// diffConfig returns a unified diff from a deployed file (or nothing, if it
+// doesn't exist yet) to new content, or "" when they're the same
+func diffConfig(deployed, content string) (string, error) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		return "", missingDependency(i18n.DiffNotFound)
+	}
+
+	tmp, err := os.CreateTemp("", "phppark-rebuild-*.conf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+
+	old := deployed
+	if _, err := os.Stat(deployed); os.IsNotExist(err) {
+		old = os.DevNull
+	}
+
+	output, err := exec.Command("diff", "-u", "--label", deployed, "--label", deployed+" (rebuilt)", old, tmp.Name()).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// Exit status 1 means the files differ
+		return string(output), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("diff failed: %w", err)
+	}
+	return "", nil
+}
+
+// upToDate reports whether rendering a site's config again would produce
+// exactly what the web server reads now. The rendered config covers
+// everything that feeds it: the site record, the template and the global
+// config. A config edited or lost on the server counts as changed, as does
+// a secured site missing its certificate.
+func upToDate(site *config.Site, cfg *config.Config, server webserver.Server) bool {
+	// Builtin sites have no web server config to compare
+	if site.Builtin {
+		return false
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return false
+	}
+	if site.Secured && !ssl.CertificateExists(site.Name, paths.Certificates) {
+		return false
+	}
+
+	_, content, err := previewSiteConfig(site, cfg, server)
+	if err != nil {
+		return false
+	}
+
+	existing, err := os.ReadFile(server.DeployedPath(paths, sharedName(site.Name)))
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(existing, []byte(content))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// registerSite registers (or updates) a site PHPark manages on the user's
+// behalf, such as a service proxy or hosted tool, and deploys its nginx
+// config. It refuses to replace a user site of the same name.
+func registerSite(site config.Site, cfg *config.Config) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	if existing := sites.FindSite(site.Name); existing != nil && existing.Path != site.Path {
+		return fmt.Errorf("site '%s' already exists at %s", site.Name, existing.Path)
+	}
+
+	sites.AddSite(site)
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	return generateNginxConfig(&site, cfg)
+}
+
+// unregisterSite unlinks a site registered with registerSite, leaving any
+// user site of the same name (at a different path) untouched
+func unregisterSite(name, path string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(name)
+	if site == nil || site.Path != path {
+		return nil
+	}
+
+	return runUnlink(name)
+}
+
+// siteURL returns the URL a site is served at, with the port when the web
+// server isn't on the standard ones
+func siteURL(site *config.Site, cfg *config.Config) string {
+	if site.Builtin {
+		return builtinURL(site, cfg.SiteDomain())
+	}
+
+	httpPort, httpsPort := cfg.SitePorts()
+	scheme, port := "http", httpPort
+	if site.Secured {
+		scheme, port = "https", httpsPort
+	}
+
+	url := fmt.Sprintf("%s://%s.%s", scheme, site.Name, cfg.SiteDomain())
+	if (scheme == "http" && port != 80) || (scheme == "https" && port != 443) {
+		url += fmt.Sprintf(":%d", port)
+	}
+	return url
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// renewUnitName is the systemd timer (and service) renewing certificates
+const renewUnitName = "phppark-renew"
+
+// renewOptions holds flags for the renew command
+type renewOptions struct {
+	all    bool          // Every secured site
+	within time.Duration // Only certificates expiring sooner than this
+	force  bool          // Renew even if not nearing expiry
+}
+
+func renewCmd() *cobra.Command {
+	var opts renewOptions
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "renew [site]",
+		Short: "Renew certificates nearing expiry",
+		Long: `Renew replaces the certificates of secured sites that expire within --days
+with new ones for the same names (SANs), with a fresh key of the same type and
+size, then reloads the web server once. Unlike rebuild it touches nothing
+else, so it's safe to run from cron or a timer:
+
+  0 3 * * * phppark renew --all --quiet
+
+or let 'phppark renew install' set up a weekly systemd timer for it. Only
+PHPark's self-signed certificates can be renewed; one issued elsewhere is
+reported and left alone.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.all == (len(args) == 1) {
+				return fmt.Errorf("specify a site to renew, or --all")
+			}
+			opts.within = time.Duration(days) * 24 * time.Hour
+			siteName := ""
+			if len(args) == 1 {
+				siteName = args[0]
+			}
+			return runRenew(siteName, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Renew every secured site's certificate that's nearing expiry")
+	cmd.Flags().IntVar(&days, "days", 30, "Renew certificates expiring within this many days")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Renew even if the certificate isn't nearing expiry")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "install",
+		Short: "Renew certificates every week with a systemd timer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRenewInstall()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the certificate renewal timer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := services.RemoveTimer(renewUnitName); err != nil {
+				return err
+			}
+			fmt.Println("✅ Certificate renewal timer removed")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func runRenew(siteName string, opts renewOptions) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	var candidates []config.Site
+	if siteName != "" {
+		site := sites.FindSite(siteName)
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, siteName)
+		}
+		if !site.Secured {
+			return fmt.Errorf("site '%s' isn't secured (run: phppark secure %s)", siteName, siteName)
+		}
+		candidates = []config.Site{*site}
+	} else {
+		for _, site := range sites.ListSites() {
+			if site.Secured && !site.Builtin {
+				candidates = append(candidates, site)
+			}
+		}
+	}
+
+	var renewed, failed []string
+	for _, site := range candidates {
+		expiry, err := ssl.CertificateExpiry(site.Name, paths.Certificates)
+		if err != nil {
+			fmt.Printf("   ⚠️  %s: no readable certificate (run: phppark sync --apply): %v\n", site.Name, err)
+			failed = append(failed, site.Name)
+			continue
+		}
+
+		remaining := time.Until(expiry)
+		if remaining > opts.within && !opts.force {
+			if siteName != "" {
+				fmt.Printf("✅ %s.%s is valid until %s, nothing to renew (--force to renew anyway)\n", site.Name, cfg.SiteDomain(), expiry.Format("2006-01-02"))
+			}
+			continue
+		}
+
+		certPaths, err := ssl.RenewCertificate(site.Name, paths.Certificates)
+		if err != nil {
+			fmt.Printf("   ❌ %s: %v\n", site.Name, err)
+			failed = append(failed, site.Name)
+			continue
+		}
+
+		newExpiry, _ := ssl.CertificateExpiry(site.Name, paths.Certificates)
+		fmt.Printf("   🔄 %s.%s: renewed until %s (%s)\n", site.Name, cfg.SiteDomain(), newExpiry.Format("2006-01-02"), certPaths.CertFile)
+		renewed = append(renewed, site.Name)
+	}
+
+	if len(renewed) > 0 {
+		if err := reloadForCertificates(cfg); err != nil {
+			return err
+		}
+		fmt.Printf("\n✅ Renewed %d certificate(s)\n", len(renewed))
+	} else if len(failed) == 0 && siteName == "" {
+		fmt.Println("✅ No certificates need renewing")
+	}
+
+	if len(failed) > 0 {
+		err := fmt.Errorf("failed to renew %s", strings.Join(failed, ", "))
+		if len(renewed) > 0 {
+			return partialFailure(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// reloadForCertificates makes the web server pick up replaced certificates
+func reloadForCertificates(cfg *config.Config) error {
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := server.Test(); err != nil {
+		printConfigTestDetail(err, "   ")
+		return fmt.Errorf("%s config test failed after renewing: %w", server.Name(), err)
+	}
+	if err := server.Reload(); err != nil {
+		return fmt.Errorf("failed to reload %s: %w", server.Name(), err)
+	}
+	fmt.Printf("   ✅ Reloaded %s\n", server.Name())
+	return nil
+}
+
+func runRenewInstall() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	unit := &services.Unit{
+		Name:        renewUnitName,
+		Description: "PHPark certificate renewal",
+		ExecStart:   []string{exe, "renew", "--all", "--quiet"},
+		// Reloading the web server needs root
+		User: "root",
+		// Laptops are often off at the weekly run
+		Persistent: true,
+	}
+	if err := services.InstallTimer(unit, "weekly"); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Certificates expiring within 30 days are renewed weekly (%s.timer)\n", renewUnitName)
+	return nil
+}
+
+// offerRenewTimer asks to renew certificates automatically, unless the
+// timer is already installed
+func offerRenewTimer() {
+	if services.IsUnitActive(renewUnitName + ".timer") {
+		return
+	}
+
+	fmt.Println("\n💡 PHPark's certificates last a year; a weekly timer can renew them before they expire")
+
+	// Nobody to ask: a scripted run doesn't get a root timer it didn't ask for
+	if !stdinIsTerminal() {
+		fmt.Println("   To install it: phppark renew install")
+		return
+	}
+
+	fmt.Printf("   Install it? (Y/n): ")
+
+	var response string
+	fmt.Scanln(&response)
+
+	if response != "" && response != "y" && response != "Y" && response != "yes" {
+		fmt.Println("   To install it later: phppark renew install")
+		return
+	}
+
+	if err := runRenewInstall(); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// runtimeSampleInterval is how far apart `status --runtime` reads the
+// counters it turns into rates
+const runtimeSampleInterval = time.Second
+
+// runtimeSample is one reading of nginx's and each upstream's counters
+type runtimeSample struct {
+	nginx    *services.NginxStatus
+	nginxErr error
+	fpm      map[string]*services.FPMStatus // By upstream name
+	fpmErr   map[string]error
+}
+
+// sampleRuntime reads the status pages the global include serves
+func sampleRuntime(addr string, upstreams []nginx.Upstream) *runtimeSample {
+	sample := &runtimeSample{
+		fpm:    make(map[string]*services.FPMStatus),
+		fpmErr: make(map[string]error),
+	}
+	sample.nginx, sample.nginxErr = services.FetchNginxStatus(addr)
+	for _, upstream := range upstreams {
+		status, err := services.FetchUpstreamFPMStatus(addr, upstream.Name)
+		if err != nil {
+			sample.fpmErr[upstream.Name] = err
+			continue
+		}
+		sample.fpm[upstream.Name] = status
+	}
+	return sample
+}
+
+// runRuntime shows nginx connections and request rate, and how busy each
+// PHP version's workers are, so a site starving the rest stands out
+func runRuntime() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+	if server.Name() != "nginx" || cfg.Backend == "docker" {
+		return fmt.Errorf("--runtime only handles the native nginx web server")
+	}
+
+	if cfg.RuntimeStatus == "" {
+		return fmt.Errorf("the status server is off (set runtime_status: 127.0.0.1:8089 in config.yaml, then run: sudo phppark rebuild)")
+	}
+	content, err := os.ReadFile(services.NginxGlobalConfigPath())
+	if err != nil || !strings.Contains(string(content), "listen "+cfg.RuntimeStatus+";") {
+		return fmt.Errorf("the status server isn't deployed yet (run: sudo phppark rebuild)")
+	}
+	upstreams := nginx.ParseUpstreams(string(content))
+
+	// Upstreams are named after versions; map them back for display
+	labels := make(map[string]string)
+	if sites, err := config.LoadSites(); err == nil {
+		for _, version := range append(sitePHPVersions(sites.ListSites(), cfg), cfg.DefaultPHP) {
+			labels[nginx.UpstreamName(userNamespace, version)] = "PHP " + version
+		}
+	}
+
+	first := sampleRuntime(cfg.RuntimeStatus, upstreams)
+	time.Sleep(runtimeSampleInterval)
+	second := sampleRuntime(cfg.RuntimeStatus, upstreams)
+	seconds := runtimeSampleInterval.Seconds()
+
+	fmt.Printf("📊 PHPark Runtime (sampled over %s)\n\n", runtimeSampleInterval)
+
+	fmt.Println("=== nginx ===")
+	if second.nginxErr != nil || first.nginxErr != nil {
+		err := second.nginxErr
+		if err == nil {
+			err = first.nginxErr
+		}
+		fmt.Printf("⚠️  Could not read stub_status: %v\n", err)
+	} else {
+		status := second.nginx
+		// Leave out the status requests made between the two readings
+		requests := max(status.Requests-first.nginx.Requests-int64(len(upstreams))-1, 0)
+		fmt.Printf("Connections: %d active (%d reading, %d writing, %d waiting)\n",
+			status.Active, status.Reading, status.Writing, status.Waiting)
+		fmt.Printf("Requests:    %.1f/s\n", float64(requests)/seconds)
+	}
+
+	fmt.Println("\n=== PHP-FPM ===")
+	if len(upstreams) == 0 {
+		fmt.Println("No PHP-FPM upstreams deployed")
+		return nil
+	}
+	if !cfg.FPMStatus {
+		fmt.Println("⚠️  PHP-FPM status pages are disabled")
+		fmt.Println(i18n.T(i18n.EnableInConfig))
+		fmt.Println("  fpm_status: true")
+		fmt.Println(i18n.T(i18n.ThenRebuild))
+		return nil
+	}
+
+	for _, upstream := range upstreams {
+		label := labels[upstream.Name]
+		if label == "" {
+			label = upstream.Name
+		}
+
+		before, after := first.fpm[upstream.Name], second.fpm[upstream.Name]
+		if before == nil || after == nil {
+			err := second.fpmErr[upstream.Name]
+			if err == nil {
+				err = first.fpmErr[upstream.Name]
+			}
+			fmt.Printf("%-9s ⚠️  %v\n", label+":", err)
+			continue
+		}
+
+		// The worker answering the status request counts as active, and
+		// the second reading counts itself as accepted
+		active := max(after.ActiveProcesses-1, 0)
+		busy := 0.0
+		if after.TotalProcesses > 0 {
+			busy = 100 * float64(active) / float64(after.TotalProcesses)
+		}
+		requests := max(after.AcceptedConn-before.AcceptedConn-1, 0)
+		fmt.Printf("%-9s %d/%d workers busy (%.0f%%), %.1f req/s, queue %d\n",
+			label+":", active, after.TotalProcesses, busy,
+			float64(requests)/seconds, after.ListenQueue)
+
+		if after.MaxChildrenReached > 0 {
+			fmt.Printf("          ⚠️  max children reached %d time(s) — consider raising pm.max_children\n", after.MaxChildrenReached)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// scheduleCommand is run every minute for sites with the scheduler enabled
+const scheduleCommand = "php artisan schedule:run"
+
+func scheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run a site's task scheduler every minute",
+		Long:  `Schedule runs "php artisan schedule:run" every minute for a site using a systemd timer.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "enable <site>",
+		Short: "Enable the scheduler for a site",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleEnable(args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable <site>",
+		Short: "Disable the scheduler for a site",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleDisable(args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List sites with the scheduler enabled",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleList()
+		},
+	})
+
+	return cmd
+}
+
+func runScheduleEnable(siteName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	fmt.Printf("⏰ Enabling scheduler for %s.%s...\n", siteName, cfg.SiteDomain())
+
+	// Re-installing is safe and picks up PHP version changes
+	unit := &services.Unit{
+		Name:        scheduleUnitName(site.Name),
+		Description: fmt.Sprintf("PHPark scheduler for %s", site.Name),
+		ExecStart:   []string{"/bin/sh", "-c", "exec " + sitePHPCommand(scheduleCommand, phpVersion)},
+		WorkingDir:  site.Path,
+	}
+	if err := services.InstallTimer(unit, "*-*-* *:*:00"); err != nil {
+		return err
+	}
+
+	site.Scheduler = true
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Println("\n✅ Scheduler enabled (runs every minute)")
+	fmt.Printf("   PHP:  %s\n", phpVersion)
+	fmt.Printf("   Logs: journalctl -u %s\n", scheduleUnitName(site.Name))
+
+	return nil
+}
+
+func runScheduleDisable(siteName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	fmt.Printf("⏰ Disabling scheduler for %s...\n", siteName)
+
+	if err := services.RemoveTimer(scheduleUnitName(site.Name)); err != nil {
+		return err
+	}
+
+	site.Scheduler = false
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Println("\n✅ Scheduler disabled")
+	return nil
+}
+
+func runScheduleList() error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	found := 0
+	for _, site := range sites.ListSites() {
+		if !site.Scheduler {
+			continue
+		}
+		if found == 0 {
+			fmt.Println("📋 Scheduled Sites")
+			fmt.Println()
+		}
+		found++
+
+		status := "✅ active"
+		if !services.IsUnitActive(scheduleUnitName(site.Name) + ".timer") {
+			status = "❌ inactive"
+		}
+		fmt.Printf("⏰ %s (%s)\n", site.Name, status)
+	}
+
+	if found == 0 {
+		fmt.Println("📋 No sites have the scheduler enabled")
+		fmt.Println("\nTo enable: phppark schedule enable <site>")
+	}
+
+	return nil
+}
+
+func scheduleUnitName(siteName string) string {
+	return fmt.Sprintf("phppark-schedule-%s", services.UnitSafe(sharedName(siteName)))
+}
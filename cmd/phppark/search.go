@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func searchCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "search <pattern>",
+		Short: "Find sites by name, path, tag or note",
+		Long: `Search lists sites whose name, path, tags or description (see: phppark note)
+match a pattern. Patterns with *, ? or [ are globs matched against the whole
+name, tag, path, directory name or description; anything else matches as a
+case-insensitive substring.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSearch(args[0], asJSON)
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print matching sites as JSON")
+
+	return cmd
+}
+
+func runSearch(pattern string, asJSON bool) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	matched := []config.Site{}
+	for _, site := range sites.ListSites() {
+		if siteMatches(&site, pattern) {
+			matched = append(matched, site)
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(terminalStdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matched)
+	}
+
+	if len(matched) == 0 {
+		fmt.Printf("🔍 No sites match '%s'\n", pattern)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sortSites(matched, "name", cfg)
+	printSiteTable(matched, cfg, nil, false, false)
+	return nil
+}
+
+// siteMatches reports whether a site's name, path, tags or description
+// match pattern
+func siteMatches(site *config.Site, pattern string) bool {
+	fields := append([]string{site.Name, site.Path, filepath.Base(site.Path), site.Description}, site.Tags...)
+
+	if strings.ContainsAny(pattern, "*?[") {
+		for _, field := range fields {
+			if ok, _ := filepath.Match(pattern, field); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	pattern = strings.ToLower(pattern)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// serveOptions holds flags for the serve command
+type serveOptions struct {
+	name string        // Site name (default: the first free tmpN)
+	ttl  time.Duration // How long the site lives
+	stop bool          // Remove the temporary site now
+}
+
+func serveCmd() *cobra.Command {
+	var opts serveOptions
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the current directory as a temporary site",
+		Long: `Serve links the current directory as a throwaway site and prints its URL.
+When --ttl runs out, a systemd timer unlinks it again (config, certificate and
+registry entry); 'phppark serve --stop' does the same straight away. Expired
+sites the timer missed are cleaned up the next time serve runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.stop {
+				return runServeStop(opts.name)
+			}
+			return runServe(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "Site name (default: tmp1, tmp2, ...)")
+	cmd.Flags().DurationVar(&opts.ttl, "ttl", 2*time.Hour, "How long to keep the site")
+	cmd.Flags().BoolVar(&opts.stop, "stop", false, "Remove the temporary site for this directory (or --name) now")
+
+	return cmd
+}
+
+func runServe(opts serveOptions) error {
+	if opts.ttl <= 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+
+	removeExpiredSites()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf(i18n.CurrentDirFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	name := opts.name
+	if name == "" {
+		name = freeTempName(sites)
+	} else if sites.FindSite(name) != nil {
+		return i18n.Errorf(i18n.SiteExists, name)
+	}
+
+	if err := runLink(name, linkOptions{path: dir}); err != nil {
+		return err
+	}
+
+	// Mark it temporary
+	sites, err = config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	site := sites.FindSite(name)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotLinked, name)
+	}
+
+	expires := time.Now().Add(opts.ttl).Truncate(time.Second)
+	site.ExpiresAt = &expires
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if err := installExpiryTimer(name, expires); err != nil {
+		fmt.Printf("   ⚠️  Warning: could not schedule removal: %v\n", err)
+		fmt.Println("   It will be removed the next time 'phppark serve' runs after it expires")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	fmt.Printf("\n🌐 Serving %s\n", siteURL(site, cfg))
+	fmt.Printf("   ⏳ Temporary: removed at %s (in %s)\n", expires.Format("15:04"), opts.ttl)
+	fmt.Println("   Stop early with: phppark serve --stop")
+	return nil
+}
+
+// runServeStop removes a temporary site: the named one, or the ones serving
+// the current directory
+func runServeStop(name string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	var names []string
+	if name != "" {
+		site := sites.FindSite(name)
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, name)
+		}
+		if site.ExpiresAt == nil {
+			return fmt.Errorf("site '%s' isn't temporary (use: phppark unlink %s)", name, name)
+		}
+		names = append(names, name)
+	} else {
+		dir, err := os.Getwd()
+		if err != nil {
+			return i18n.Errorf(i18n.CurrentDirFailed, err)
+		}
+		for _, site := range sites.ListSites() {
+			if site.ExpiresAt != nil && site.Path == dir {
+				names = append(names, site.Name)
+			}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no temporary site serves this directory (use --name)")
+		}
+	}
+
+	return unlinkSites(names)
+}
+
+// removeExpiredSites unlinks temporary sites whose time is up
+func removeExpiredSites() {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return
+	}
+
+	var expired []string
+	for _, site := range sites.ListSites() {
+		if site.ExpiresAt != nil && time.Now().After(*site.ExpiresAt) {
+			expired = append(expired, site.Name)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	fmt.Printf("🧹 Removing %d expired temporary site(s)...\n", len(expired))
+	if err := unlinkSites(expired); err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+	fmt.Println()
+}
+
+// installExpiryTimer schedules `phppark serve --stop` for when a temporary
+// site expires
+func installExpiryTimer(name string, expires time.Time) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	unit := &services.Unit{
+		Name:        serveUnitName(name),
+		Description: fmt.Sprintf("PHPark temporary site %s expiry", name),
+		ExecStart:   []string{exe, "serve", "--stop", "--name", name},
+		// Removing web server configs needs root
+		User: "root",
+	}
+	return services.InstallTimer(unit, expires.Format("2006-01-02 15:04:05"))
+}
+
+// serveUnitName returns the timer unit that removes a temporary site
+func serveUnitName(siteName string) string {
+	return fmt.Sprintf("phppark-serve-%s", services.UnitSafe(sharedName(siteName)))
+}
+
+// freeTempName returns the first tmpN name not in use
+func freeTempName(sites *config.SiteRegistry) string {
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("tmp%d", n)
+		if sites.FindSite(name) == nil {
+			return name
+		}
+	}
+}
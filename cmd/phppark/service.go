@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+func serviceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Install and manage supporting services",
+		Long:  `Service installs optional supporting services (search, mail, storage) and runs them under systemd.`,
+	}
+
+	var proxy bool
+	install := &cobra.Command{
+		Use:   "install <service>",
+		Short: "Install and start a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceInstall(args[0], proxy)
+		},
+	}
+	install.Flags().BoolVar(&proxy, "proxy", false, "Expose the service as a .test site")
+	cmd.AddCommand(install)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall <service>",
+		Short: "Stop and remove a service (including its data)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceUninstall(args[0])
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List available services",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServiceList()
+		},
+	})
+
+	return cmd
+}
+
+func runServiceInstall(name string, proxy bool) error {
+	svc, ok := services.GetService(name)
+	if !ok {
+		return i18n.Errorf(i18n.UnknownService, name)
+	}
+
+	return installService(svc, proxy)
+}
+
+// installService installs a managed service, optionally exposes its HTTP
+// ports as proxied sites, and prints the .env settings to use it
+func installService(svc *services.ManagedService, proxy bool) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	serviceDir := filepath.Join(paths.Services, svc.Name)
+
+	fmt.Printf("📦 Installing %s...\n", svc.Name)
+	fmt.Println(i18n.T(i18n.NeedsSudo))
+
+	secrets, err := svc.Install(serviceDir)
+	if err != nil {
+		return fmt.Errorf("failed to install %s: %w", svc.Name, err)
+	}
+	fmt.Printf("   ✅ %s running\n", svc.Name)
+
+	if proxy {
+		for _, p := range svc.Proxies {
+			upstream := fmt.Sprintf("http://127.0.0.1:%d", p.Port)
+			site := config.Site{Name: p.Site, Path: serviceDir, Type: "link", Proxy: upstream}
+			if err := registerSite(site, cfg); err != nil {
+				i18n.Printf(i18n.Warning, err)
+				fmt.Printf("   Still available at %s\n", upstream)
+			}
+		}
+	}
+
+	fmt.Printf("\n✅ %s installed!\n", svc.Name)
+	for _, p := range svc.Proxies {
+		if proxy {
+			fmt.Printf("   URL: %s\n", siteURL(&config.Site{Name: p.Site}, cfg))
+		} else {
+			fmt.Printf("   URL: http://127.0.0.1:%d\n", p.Port)
+		}
+	}
+
+	if hints := svc.EnvHints(secrets); len(hints) > 0 {
+		fmt.Println("\nAdd to your .env:")
+		fmt.Println()
+		for _, line := range hints {
+			fmt.Println(line)
+		}
+	}
+
+	return nil
+}
+
+func runServiceUninstall(name string) error {
+	svc, ok := services.GetService(name)
+	if !ok {
+		return i18n.Errorf(i18n.UnknownService, name)
+	}
+
+	return uninstallService(svc)
+}
+
+// uninstallService removes a managed service and any sites proxying to it
+func uninstallService(svc *services.ManagedService) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	serviceDir := filepath.Join(paths.Services, svc.Name)
+
+	fmt.Printf("🗑️  Removing %s...\n", svc.Name)
+
+	for _, p := range svc.Proxies {
+		if err := unregisterSite(p.Site, serviceDir); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+
+	if err := svc.Uninstall(serviceDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", svc.Name, err)
+	}
+
+	fmt.Printf("\n✅ %s removed\n", svc.Name)
+	return nil
+}
+
+func runServiceList() error {
+	fmt.Println("📋 Available Services")
+	fmt.Println()
+
+	for _, svc := range services.ListServices() {
+		status := "⚪ not running"
+		if svc.IsRunning() {
+			status = "✅ running"
+		}
+		fmt.Printf("%-12s %s\n", svc.Name, status)
+		fmt.Printf("             %s\n", svc.Description)
+	}
+
+	fmt.Println("\nInstall with: sudo phppark service install <service> [--proxy]")
+	return nil
+}
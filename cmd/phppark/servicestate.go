@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// systemService is a service status reports the systemd state of
+type systemService struct {
+	unit    string
+	restart string // Command that brings it back
+	own     bool   // Installed by PHPark, possibly as a user unit
+}
+
+// systemServices returns the services sites depend on: the web server, DNS
+// and each PHP-FPM version in use
+func systemServices(cfg *config.Config, sites []config.Site) []systemService {
+	var list []systemService
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return nil
+	}
+
+	switch cfg.WebServer {
+	case "apache":
+		list = append(list, systemService{unit: services.DetectApacheLayout().Service, restart: "sudo phppark start"})
+	case "frankenphp":
+		list = append(list, systemService{unit: services.FrankenPHPUnit, restart: "sudo phppark start", own: true})
+	default:
+		list = append(list, systemService{unit: services.NginxService(), restart: "sudo phppark start"})
+	}
+
+	switch cfg.DNS.Driver {
+	case "resolved":
+		list = append(list, systemService{unit: dnsUnitName, restart: "sudo systemctl restart " + dnsUnitName, own: true})
+	default:
+		list = append(list, systemService{unit: "dnsmasq", restart: "sudo systemctl restart dnsmasq"})
+	}
+
+	for _, version := range fpmVersions(server, sites, cfg) {
+		list = append(list, systemService{unit: services.PHPFPMService(version), restart: "sudo phppark start"})
+	}
+
+	return list
+}
+
+// printServiceStates prints each service's systemd state, and for ones
+// that aren't running, how it last exited and the commands to look into it
+func printServiceStates(cfg *config.Config, sites []config.Site) {
+	// The docker backend's nginx and PHP-FPM are containers, not units
+	if cfg.Backend == "docker" {
+		fmt.Println("Services:    run in Docker containers (see: docker ps)")
+		return
+	}
+
+	fmt.Println("Services:")
+	for _, service := range systemServices(cfg, sites) {
+		status := services.ServiceStatus
+		if service.own {
+			status = services.UnitStatus
+		}
+		state, err := status(service.unit)
+		if err != nil {
+			fmt.Printf("  ⚠️  %-16s %v\n", service.unit, err)
+			continue
+		}
+		if !state.Loaded {
+			fmt.Printf("  ❌ %-16s not installed\n", service.unit)
+			continue
+		}
+
+		switch state.Active {
+		case "active":
+			fmt.Printf("  ✅ %-16s active (%s) since %s\n", service.unit, state.Sub, state.Since)
+			continue
+		case "failed":
+			fmt.Printf("  ❌ %-16s failed (%s, exit status %s) since %s\n", service.unit, state.Result, state.ExitStatus, state.Since)
+		default:
+			fmt.Printf("  ⚠️  %-16s %s (%s)", service.unit, state.Active, state.Sub)
+			if state.Since != "" {
+				fmt.Printf(" since %s", state.Since)
+			}
+			fmt.Println()
+		}
+		// A user unit is managed through the user's own systemd instance
+		restart, journalctl := service.restart, "journalctl"
+		if state.UserScope {
+			restart = "systemctl --user restart " + service.unit
+			journalctl = "journalctl --user"
+		}
+		fmt.Printf("     Restart: %s\n", restart)
+		fmt.Printf("     Logs:    %s -u %s -n 50 --no-pager\n", journalctl, service.unit)
+	}
+}
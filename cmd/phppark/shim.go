@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/php"
+)
+
+func phpWhichCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "php:which [dir]",
+		Short:  "Print the PHP binary PHPark uses for a directory",
+		Long:   `Php:which resolves the PHP binary for a directory (default: current) from its site's PHP version or the global default. Used by the php shim.`,
+		Args:   cobra.MaximumNArgs(1),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := ""
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runPHPWhich(dir)
+		},
+	}
+}
+
+func runPHPWhich(dir string) error {
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return i18n.Errorf(i18n.CurrentDirFailed, err)
+		}
+	}
+
+	version, err := resolvePHPVersionForDir(dir)
+	if err != nil {
+		return err
+	}
+
+	binary := php.BinaryPath(version)
+	if binary == "" {
+		return missingDependency(i18n.PHPNotInstalled, version)
+	}
+
+	fmt.Println(binary)
+	return nil
+}
+
+// resolvePHPVersionForDir returns the PHP version of the site containing dir
+// (deepest match wins), or the configured default
+func resolvePHPVersionForDir(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return "", i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	version := cfg.DefaultPHP
+	longest := 0
+	for _, site := range sites.ListSites() {
+		if site.PHPVersion == "" {
+			continue
+		}
+		if absDir == site.Path || strings.HasPrefix(absDir, site.Path+string(filepath.Separator)) {
+			if len(site.Path) > longest {
+				longest = len(site.Path)
+				version = site.PHPVersion
+			}
+		}
+	}
+
+	return version, nil
+}
+
+// installShims writes the php shim pointing back at this executable
+func installShims(paths *config.Paths) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate phppark binary: %w", err)
+	}
+
+	return php.WriteShims(paths.Bin, executable)
+}
+
+// offerShimPath asks the user whether to add the shim directory to PATH
+func offerShimPath(paths *config.Paths) {
+	if php.ShimsOnPath(paths.Bin) {
+		return
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	profile := php.ShellProfile(homeDir)
+
+	fmt.Printf("\n💡 PHPark can switch CLI PHP without sudo using shims in %s\n", paths.Bin)
+	fmt.Printf("   Add it to your PATH in %s? (Y/n): ", profile)
+
+	var response string
+	fmt.Scanln(&response)
+
+	if response != "" && response != "y" && response != "Y" && response != "yes" {
+		fmt.Printf("   To enable later, add to your shell profile:\n")
+		fmt.Printf("   %s\n", php.PathExport(paths.Bin))
+		return
+	}
+
+	if err := php.AddShimsToProfile(profile, paths.Bin); err != nil {
+		i18n.Printf(i18n.Warning, err)
+		return
+	}
+
+	fmt.Printf("   ✅ Updated %s — open a new shell to pick it up\n", profile)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// siteFPMSettings returns the php.ini settings a site needs its own PHP-FPM
+// for. Sites with none share their PHP version's pool.
+func siteFPMSettings(site *config.Site) map[string]string {
+	settings := make(map[string]string)
+	if driver, ok := profilers[site.Profiler]; ok {
+		for name, value := range driver.settings {
+			settings[name] = value
+		}
+	}
+	if site.Preload != "" {
+		settings["opcache.preload"] = filepath.Join(site.Path, site.Preload)
+		// Required when the master runs as root
+		settings["opcache.preload_user"] = "www-data"
+	}
+	if site.ThrottleDelay > 0 {
+		settings["auto_prepend_file"] = delayScriptPath
+	}
+	for name, value := range nginx.PHPLimits(site.MaxBody, site.Timeout) {
+		settings[name] = value
+	}
+	return settings
+}
+
+// hasSiteFPM reports whether a site is served by its own PHP-FPM
+func hasSiteFPM(site *config.Site, cfg *config.Config) bool {
+	if site.Builtin || site.Proxy != "" || site.FPM != "" || cfg.Backend == "docker" || cfg.WebServer == "frankenphp" {
+		return false
+	}
+	return len(siteFPMSettings(site)) > 0
+}
+
+// siteFPMName is the unit-safe name of a site's own PHP-FPM
+func siteFPMName(siteName string) string {
+	return services.UnitSafe(sharedName(siteName))
+}
+
+// applySiteFPM starts, restarts or removes a site's own PHP-FPM to match
+// its settings
+func applySiteFPM(site *config.Site, cfg *config.Config) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	if !hasSiteFPM(site, cfg) {
+		return services.RemoveSiteFPM(siteFPMName(site.Name), paths.SiteFPM)
+	}
+
+	version := site.PHPVersion
+	if version == "" {
+		version = cfg.DefaultPHP
+	}
+
+	if site.ThrottleDelay > 0 {
+		if err := ensureDelayScript(); err != nil {
+			return err
+		}
+	}
+
+	fpm := &services.SiteFPM{
+		Name:    siteFPMName(site.Name),
+		Version: version,
+		INI:     siteFPMSettings(site),
+	}
+	if err := services.InstallSiteFPM(fpm, paths.SiteFPM, paths.Logs); err != nil {
+		return fmt.Errorf("failed to start PHP-FPM for %s: %w", site.Name, err)
+	}
+	return nil
+}
+
+// checkSiteFPM returns why a site can't get its own PHP-FPM, if it can't
+func checkSiteFPM(site *config.Site, cfg *config.Config) error {
+	switch {
+	case site.Builtin:
+		return fmt.Errorf("site '%s' uses the built-in PHP server, not PHP-FPM", site.Name)
+	case site.Proxy != "":
+		return fmt.Errorf("site '%s' is proxied, so it doesn't run PHP-FPM", site.Name)
+	case site.FPM != "":
+		return fmt.Errorf("site '%s' uses a remote PHP-FPM (%s), which PHPark doesn't manage", site.Name, site.FPM)
+	case cfg.Backend == "docker":
+		return fmt.Errorf("a site's own PHP-FPM needs the native backend")
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+	if server.EmbedsPHP() {
+		return fmt.Errorf("%s runs PHP itself, without PHP-FPM", server.Name())
+	}
+	return nil
+}
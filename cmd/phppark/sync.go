@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+func syncCmd() *cobra.Command {
+	var apply bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Check the registry, deployed configs and certificates agree",
+		Long: `Sync compares sites.json with the web server configs that are deployed and
+the certificates in ~/.phppark/certificates, and reports where they've
+drifted apart:
+
+  - a site with no deployed config
+  - a deployed config that differs from what 'phppark rebuild' would produce
+  - a secured site with no certificate
+  - a certificate for a site that isn't secured, or isn't registered
+  - a generated nginx config for a site that isn't registered
+
+With --apply, configs are redeployed (creating missing certificates), unused
+certificates are removed, and stale configs are deleted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(apply)
+		},
+	}
+
+	cmd.Flags().BoolVar(&apply, "apply", false, "Fix what's found")
+
+	return cmd
+}
+
+// syncReport is the drift sync found
+type syncReport struct {
+	redeploy    []*config.Site   // Missing or outdated configs, or no certificate
+	unusedCerts []string         // Certificates no secured site uses
+	orphans     []orphanedConfig // Generated configs of unregistered sites
+}
+
+func (r *syncReport) empty() bool {
+	return len(r.redeploy) == 0 && len(r.unusedCerts) == 0 && len(r.orphans) == 0
+}
+
+func runSync(apply bool) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🔄 Comparing sites.json, deployed configs and certificates...")
+	fmt.Println()
+
+	allSites := sites.ListSites()
+	report := checkSync(allSites, cfg, server, paths)
+	if report.empty() {
+		fmt.Println("✅ Everything is in sync")
+		return nil
+	}
+
+	problems := len(report.redeploy) + len(report.unusedCerts) + len(report.orphans)
+	if !apply {
+		fmt.Printf("\n📋 %d problem(s). Run with --apply to fix them.\n", problems)
+		return nil
+	}
+
+	fmt.Println("\n🔧 Fixing...")
+	var failed []string
+
+	if len(report.redeploy) > 0 {
+		failures, err := deploySites(report.redeploy, cfg)
+		if err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+		for _, site := range report.redeploy {
+			if err, ok := failures[site.Name]; ok {
+				fmt.Printf("   ❌ %s: %v\n", site.Name, err)
+				failed = append(failed, site.Name)
+				continue
+			}
+			fmt.Printf("   ✅ Redeployed %s\n", site.Name)
+		}
+	}
+
+	for _, name := range report.unusedCerts {
+		if err := ssl.RemoveCertificate(name, paths.Certificates); err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			failed = append(failed, name+" certificate")
+			continue
+		}
+		fmt.Printf("   🗑️  Removed certificate %s.crt\n", name)
+	}
+
+	if len(report.orphans) > 0 {
+		if err := removeOrphanedConfigs(report.orphans, paths); err != nil {
+			failed = append(failed, "stale configs")
+		}
+	}
+
+	if len(failed) > 0 {
+		return partialFailure(fmt.Errorf("failed to fix %s", strings.Join(failed, ", ")))
+	}
+	fmt.Println("\n✅ In sync")
+	return nil
+}
+
+// checkSync prints and returns the drift between sites, their deployed
+// configs and the certificates directory
+func checkSync(allSites []config.Site, cfg *config.Config, server webserver.Server, paths *config.Paths) *syncReport {
+	report := &syncReport{}
+	secured := make(map[string]bool)
+	registered := make(map[string]bool)
+
+	for i := range allSites {
+		site := &allSites[i]
+		registered[site.Name] = true
+		if site.Secured {
+			secured[site.Name] = true
+		}
+
+		// Builtin sites are served by `php -S`, with no config or certificate
+		if site.Builtin {
+			continue
+		}
+
+		// A secured site's config can't be right without its certificate
+		if site.Secured && !ssl.CertificateExists(site.Name, paths.Certificates) {
+			fmt.Printf("   ❌ %s: secured but has no certificate\n", site.Name)
+			report.redeploy = append(report.redeploy, site)
+			continue
+		}
+
+		// Disabled sites aren't deployed on purpose
+		if site.Disabled {
+			continue
+		}
+
+		deployed, err := os.ReadFile(server.DeployedPath(paths, sharedName(site.Name)))
+		if err != nil {
+			fmt.Printf("   ❌ %s: no deployed %s config\n", site.Name, server.Name())
+			report.redeploy = append(report.redeploy, site)
+			continue
+		}
+
+		_, content, err := previewSiteConfig(site, cfg, server)
+		if err != nil {
+			fmt.Printf("   ⚠️  %s: could not render its config: %v\n", site.Name, err)
+			continue
+		}
+		if !bytes.Equal(deployed, []byte(content)) {
+			fmt.Printf("   ❌ %s: deployed config differs from what rebuild would produce (see: phppark rebuild --diff %s)\n", site.Name, site.Name)
+			report.redeploy = append(report.redeploy, site)
+		}
+	}
+
+	for _, name := range certificateNames(paths) {
+		switch {
+		case !registered[name]:
+			fmt.Printf("   ⚠️  %s: certificate for a site that isn't registered\n", name)
+		case !secured[name]:
+			fmt.Printf("   ⚠️  %s: certificate but the site isn't secured\n", name)
+		default:
+			continue
+		}
+		report.unusedCerts = append(report.unusedCerts, name)
+	}
+
+	if server.Name() == "nginx" && cfg.Backend != "docker" {
+		report.orphans = orphanedConfigs(allSites, paths)
+		for _, orphan := range report.orphans {
+			fmt.Printf("   ⚠️  %s: config for %s, which isn't registered\n", orphan.path, orphan.site)
+		}
+	}
+
+	return report
+}
+
+// certificateNames lists the sites with a certificate in the certificates
+// directory
+func certificateNames(paths *config.Paths) []string {
+	entries, err := os.ReadDir(paths.Certificates)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".crt" {
+			names = append(names, strings.TrimSuffix(entry.Name(), ".crt"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// validTag matches tag names: lowercase letters, digits, "-", "_" and "."
+var validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+func tagCmd() *cobra.Command {
+	var remove bool
+
+	cmd := &cobra.Command{
+		Use:   "tag <site> [tag...]",
+		Short: "Tag a site, or list its tags",
+		Long: `Tag adds tags to a site so groups of sites can be handled together with --tag
+(links, rebuild, secure --all, start and stop). With no tags it lists the site's tags.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTag(args[0], args[1:], remove)
+		},
+	}
+
+	cmd.Flags().BoolVar(&remove, "remove", false, "Remove the tags instead of adding them")
+
+	return cmd
+}
+
+func runTag(siteName string, tags []string, remove bool) error {
+	for _, tag := range tags {
+		if !validTag.MatchString(tag) {
+			return fmt.Errorf("invalid tag '%s' (use lowercase letters, digits, '-', '_' and '.')", tag)
+		}
+	}
+
+	if len(tags) == 0 {
+		site, err := config.GetSite(siteName)
+		if err != nil {
+			return i18n.Errorf(i18n.LoadSitesFailed, err)
+		}
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, siteName)
+		}
+		if len(site.Tags) == 0 {
+			fmt.Printf("🏷️  %s has no tags\n", siteName)
+			return nil
+		}
+		fmt.Printf("🏷️  %s: %s\n", siteName, strings.Join(site.Tags, ", "))
+		return nil
+	}
+
+	var site *config.Site
+	err := config.UpdateSites(func(sites *config.SiteRegistry) error {
+		if site = sites.FindSite(siteName); site == nil {
+			return nil
+		}
+		for _, tag := range tags {
+			switch {
+			case remove:
+				site.Tags = removeTag(site.Tags, tag)
+			case !site.HasTag(tag):
+				site.Tags = append(site.Tags, tag)
+			}
+		}
+		sort.Strings(site.Tags)
+		return nil
+	})
+	if err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	if remove {
+		fmt.Printf("✅ Removed %s from %s\n", strings.Join(tags, ", "), siteName)
+	} else {
+		fmt.Printf("✅ Tagged %s: %s\n", siteName, strings.Join(site.Tags, ", "))
+	}
+	return nil
+}
+
+func removeTag(tags []string, tag string) []string {
+	var kept []string
+	for _, t := range tags {
+		if t != tag {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+// userTemplateExt is the extension of user templates in ~/.phppark/templates
+const userTemplateExt = ".conf"
+
+func templatesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "templates",
+		Short: "List the web server templates sites can use",
+		Long: `Templates lists the built-in nginx templates and your own from
+~/.phppark/templates. A user template is <name>.conf holding a Go text/template
+of a full server block; it sees the same fields as the built-in ones (e.g.,
+{{.ServerNames}}, {{.Root}}, {{.FastCGIPass}}, {{.UseSSL}}) and can include the
+site's limits and environment with {{template "fastcgi" .}} (in the PHP
+location), its throttle with {{template "throttle" .}} and the apps mounted in
+it with {{template "mounts" .}} (both in the server block). Pick one with 'phppark link --template <name>' (also on park).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplates()
+		},
+	}
+}
+
+func runTemplates() error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("📄 Built-in templates:")
+	for _, info := range nginx.BuiltinTemplates {
+		line := fmt.Sprintf("   %-10s %s", info.Name, info.Description)
+		if info.Detected != "" {
+			line += fmt.Sprintf(" (detected: %s)", info.Detected)
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\n📄 User templates (%s):\n", paths.Templates)
+	names := userTemplates(paths)
+	if len(names) == 0 {
+		fmt.Printf("   None yet - add <name>%s there\n", userTemplateExt)
+		return nil
+	}
+	for _, name := range names {
+		if nginx.IsTemplate(name) {
+			fmt.Printf("   %-10s (ignored: a built-in template has this name)\n", name)
+			continue
+		}
+		fmt.Printf("   %s\n", name)
+	}
+	return nil
+}
+
+// userTemplates lists the names of the templates in ~/.phppark/templates
+func userTemplates(paths *config.Paths) []string {
+	entries, err := os.ReadDir(paths.Templates)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), userTemplateExt) {
+			names = append(names, strings.TrimSuffix(entry.Name(), userTemplateExt))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTemplate checks that a --template names a built-in or user template
+func validateTemplate(name string) error {
+	if nginx.IsTemplate(name) {
+		return nil
+	}
+
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+	if _, err := loadUserTemplate(paths, name); err != nil {
+		return fmt.Errorf("unknown template '%s' (see: phppark templates)", name)
+	}
+	return nil
+}
+
+// loadUserTemplate reads a user template from ~/.phppark/templates
+func loadUserTemplate(paths *config.Paths, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name '%s'", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(paths.Templates, name+userTemplateExt))
+	if err != nil {
+		return "", fmt.Errorf("failed to read template '%s': %w", name, err)
+	}
+	return string(data), nil
+}
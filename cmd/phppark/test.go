@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/health"
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+func testCmd() *cobra.Command {
+	var siteName string
+	var useDNS bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Smoke-test every site over HTTP(S)",
+		Long: `Test requests each registered site and reports its status code, TLS validity
+and response time. Requests go to 127.0.0.1 with the site's Host header unless
+--dns is given. Exits non-zero if any site fails.`,
+		Args: cobra.NoArgs,
+		// A failing site is a result, not a usage mistake
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTest(siteName, useDNS, timeout)
+		},
+	}
+
+	cmd.Flags().StringVar(&siteName, "site", "", "Only test this site")
+	cmd.Flags().BoolVar(&useDNS, "dns", false, "Resolve site hostnames through DNS instead of 127.0.0.1")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "Timeout per request")
+
+	return cmd
+}
+
+func runTest(siteName string, useDNS bool, timeout time.Duration) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	toTest := sites.ListSites()
+	if siteName != "" {
+		site := sites.FindSite(siteName)
+		if site == nil {
+			return i18n.Errorf(i18n.SiteNotFound, siteName)
+		}
+		toTest = []config.Site{*site}
+	}
+
+	if len(toTest) == 0 {
+		fmt.Println("📋 No sites to test")
+		return nil
+	}
+
+	fmt.Printf("🧪 Testing %d site(s)...\n\n", len(toTest))
+
+	failed := 0
+	skipped := 0
+	seen := make(map[string]time.Time)
+	for i := range toTest {
+		if toTest[i].Disabled {
+			fmt.Printf("   ⏸️  %-32s disabled\n", siteURL(&toTest[i], cfg))
+			skipped++
+			continue
+		}
+
+		result := health.Probe(health.Target{URL: siteURL(&toTest[i], cfg), Local: !useDNS}, timeout)
+
+		icon := "✅"
+		switch {
+		case !result.OK():
+			icon = "❌"
+			failed++
+		case result.TLS == health.TLSUntrusted:
+			icon = "⚠️ "
+		}
+		if result.OK() {
+			seen[toTest[i].Name] = time.Now().Truncate(time.Second)
+		}
+
+		fmt.Printf("   %s %-32s %s\n", icon, result.URL, result.Summary())
+	}
+
+	if err := config.MarkSeen(seen); err != nil {
+		fmt.Printf("\n⚠️  Warning: failed to record results: %v\n", err)
+	}
+
+	tested := len(toTest) - skipped
+	if failed > 0 && failed == tested {
+		return i18n.Errorf(i18n.SitesFailed, failed, tested)
+	} else if failed > 0 {
+		return partialFailure(i18n.Errorf(i18n.SitesFailed, failed, tested))
+	}
+
+	fmt.Printf("\n✅ All %d site(s) passed\n", tested)
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// throttleOptions holds flags for the throttle command
+type throttleOptions struct {
+	rate  string        // Response speed per second (e.g., 512k)
+	delay time.Duration // Wait before PHP handles each request
+	off   bool          // Back to full speed
+}
+
+func throttleCmd() *cobra.Command {
+	var opts throttleOptions
+
+	cmd := &cobra.Command{
+		Use:   "throttle <site>",
+		Short: "Slow a site down to test slow connections",
+		Long: `Throttle makes a site behave like it's on a slow network, so loading states
+and timeouts can be tried on any device without browser devtools. --rate caps
+how fast nginx sends every response (nginx's limit_rate, e.g., 512k per
+second). --delay makes each PHP request wait before it runs, like latency;
+static files and proxied sites only get the rate. The delay runs in the
+site's own PHP-FPM, so other sites sharing its PHP version aren't slowed. Without flags, the current
+throttle is shown; --off goes back to full speed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			if !flags.Changed("rate") && !flags.Changed("delay") && !opts.off {
+				return runThrottleShow(args[0])
+			}
+			return runThrottle(args[0], opts, flags.Changed("rate"), flags.Changed("delay"))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.rate, "rate", "", "Response speed per second, e.g., 512k or 1m (\"\" for full speed)")
+	cmd.Flags().DurationVar(&opts.delay, "delay", 0, "Wait before each PHP request, e.g., 200ms (0 for none)")
+	cmd.Flags().BoolVar(&opts.off, "off", false, "Remove the throttle")
+
+	return cmd
+}
+
+func runThrottle(siteName string, opts throttleOptions, setRate, setDelay bool) error {
+	if opts.rate != "" {
+		if err := nginx.ValidateSize(opts.rate); err != nil {
+			return err
+		}
+	}
+	if opts.delay < 0 {
+		return fmt.Errorf("--delay can't be negative")
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which has no web server config", siteName)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+	if cfg.WebServer != "" && cfg.WebServer != "nginx" {
+		return fmt.Errorf("throttle needs the nginx web server (web_server is %s)", cfg.WebServer)
+	}
+
+	// The delay script runs in the site's own PHP-FPM
+	if setDelay && opts.delay > 0 {
+		if err := checkSiteFPM(site, cfg); err != nil {
+			return fmt.Errorf("--delay needs the site's own PHP-FPM: %w", err)
+		}
+	}
+
+	if opts.off {
+		site.ThrottleRate = ""
+		site.ThrottleDelay = 0
+	}
+	if setRate {
+		site.ThrottleRate = opts.rate
+	}
+	if setDelay {
+		site.ThrottleDelay = int(opts.delay.Milliseconds())
+	}
+
+	fmt.Printf("🐢 Updating throttle for %s.%s...\n", siteName, cfg.SiteDomain())
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	if setDelay || opts.off {
+		if err := applySiteFPM(site, cfg); err != nil {
+			return err
+		}
+		if site.ThrottleDelay > 0 {
+			fmt.Printf("   ✅ Started the site's own PHP-FPM (%s), which runs the delay\n", siteFPMName(site.Name))
+		}
+	}
+
+	if err := generateNginxConfig(site, cfg); err != nil {
+		return fmt.Errorf("failed to update nginx config: %w", err)
+	}
+
+	fmt.Println()
+	printThrottle(site)
+	return nil
+}
+
+func runThrottleShow(siteName string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	printThrottle(site)
+	return nil
+}
+
+// printThrottle shows a site's throttle
+func printThrottle(site *config.Site) {
+	if site.ThrottleRate == "" && site.ThrottleDelay == 0 {
+		fmt.Println("   Throttle: off (full speed)")
+		return
+	}
+
+	rate := "full speed"
+	if site.ThrottleRate != "" {
+		rate = site.ThrottleRate + "/s"
+	}
+	fmt.Printf("   Rate:     %s\n", rate)
+	fmt.Printf("   Delay:    %dms\n", site.ThrottleDelay)
+}
+
+// delayScriptPath is where the PHP file that makes throttled requests wait
+// is installed, readable by PHP-FPM's user
+var delayScriptPath = filepath.Join(config.SystemDir, "throttle-delay.php")
+
+// ensureDelayScript writes the PHP file that makes throttled requests wait,
+// if it isn't there yet
+func ensureDelayScript() error {
+	if current, err := os.ReadFile(delayScriptPath); err == nil && bytes.Equal(current, []byte(nginx.DelayScriptSource)) {
+		return nil
+	}
+
+	if err := os.MkdirAll(config.SystemDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", config.SystemDir, err)
+	}
+	if err := os.WriteFile(delayScriptPath, []byte(nginx.DelayScriptSource), 0644); err != nil {
+		return fmt.Errorf("failed to write delay script: %w", err)
+	}
+	oplog.Record(oplog.Entry{Op: "write", Target: delayScriptPath})
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+// trafficOptions holds flags for the traffic command
+type trafficOptions struct {
+	since time.Duration // Only requests this recent
+	top   int           // Rows per table
+}
+
+func trafficCmd() *cobra.Command {
+	var opts trafficOptions
+
+	cmd := &cobra.Command{
+		Use:   "traffic <site>",
+		Short: "Summarize a site's requests from its access log",
+		Long: `Traffic reads a site's JSON access log (/var/log/nginx/<site>.access.json)
+and summarizes the requests of the last --since: the most requested paths with
+their average time, how the status codes are spread, and the slowest requests.
+It's a quick answer to "why is this page slow" without a profiler. Sites get
+the JSON log with nginx; ones deployed before it need 'phppark rebuild'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTraffic(args[0], opts)
+		},
+	}
+
+	cmd.Flags().DurationVar(&opts.since, "since", time.Hour, "Only requests this recent (e.g., 15m, 24h)")
+	cmd.Flags().IntVar(&opts.top, "top", 10, "Rows to show per table")
+
+	return cmd
+}
+
+// pathStats is the traffic of one path
+type pathStats struct {
+	path     string
+	requests int
+	total    time.Duration
+}
+
+func runTraffic(siteName string, opts trafficOptions) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+	if site.Builtin {
+		return fmt.Errorf("site '%s' uses the built-in PHP server, which keeps no access log", siteName)
+	}
+
+	logPath := siteLogPath(siteName, "access.json")
+	if _, err := os.Stat(logPath); os.IsNotExist(err) {
+		return fmt.Errorf("no JSON access log at %s yet (run: phppark rebuild %s, then load some pages)", logPath, siteName)
+	}
+
+	// The log rotated last is kept uncompressed for a day, so a --since
+	// reaching past the rotation reads it first
+	cutoff := time.Now().Add(-opts.since)
+	var entries []*nginx.AccessEntry
+	for _, path := range []string{logPath + ".1", logPath} {
+		read, err := readAccessEntries(path, cutoff)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, read...)
+	}
+
+	// 1h0m0s reads better as 1h
+	since := opts.since.String()
+	if strings.HasSuffix(since, "m0s") {
+		since = strings.TrimSuffix(since, "0s")
+	}
+	if strings.HasSuffix(since, "h0m") {
+		since = strings.TrimSuffix(since, "0m")
+	}
+	fmt.Printf("📈 Traffic for %s in the last %s\n\n", siteName, since)
+	if len(entries) == 0 {
+		fmt.Println("   No requests")
+		return nil
+	}
+
+	printTopPaths(entries, opts.top)
+	printStatusCodes(entries)
+	printSlowest(entries, opts.top)
+
+	fmt.Printf("\n%d request(s)\n", len(entries))
+	return nil
+}
+
+// readAccessEntries returns the entries of a JSON access log since cutoff.
+// A log that doesn't exist has none.
+func readAccessEntries(path string, cutoff time.Time) ([]*nginx.AccessEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read access log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []*nginx.AccessEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry, err := nginx.ParseAccessEntry(scanner.Bytes())
+		if err != nil || entry.Time.Before(cutoff) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read access log: %w", err)
+	}
+	return entries, nil
+}
+
+// printTopPaths shows the most requested paths
+func printTopPaths(entries []*nginx.AccessEntry, top int) {
+	byPath := make(map[string]*pathStats)
+	for _, entry := range entries {
+		stats := byPath[entry.Path()]
+		if stats == nil {
+			stats = &pathStats{path: entry.Path()}
+			byPath[entry.Path()] = stats
+		}
+		stats.requests++
+		stats.total += entry.Duration()
+	}
+
+	paths := make([]*pathStats, 0, len(byPath))
+	for _, stats := range byPath {
+		paths = append(paths, stats)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].requests != paths[j].requests {
+			return paths[i].requests > paths[j].requests
+		}
+		return paths[i].path < paths[j].path
+	})
+
+	fmt.Println("=== Top paths ===")
+	for _, stats := range paths[:min(top, len(paths))] {
+		avg := stats.total / time.Duration(stats.requests)
+		fmt.Printf("   %6d  %8s avg  %s\n", stats.requests, formatMillis(avg), stats.path)
+	}
+}
+
+// printStatusCodes shows how many requests got each status code
+func printStatusCodes(entries []*nginx.AccessEntry) {
+	counts := make(map[int]int)
+	for _, entry := range entries {
+		counts[entry.Status]++
+	}
+
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	fmt.Println("\n=== Status codes ===")
+	for _, code := range codes {
+		icon := "✅"
+		switch {
+		case code >= 500:
+			icon = "❌"
+		case code >= 400:
+			icon = "⚠️ "
+		case code >= 300:
+			icon = "↪️ "
+		}
+		fmt.Printf("   %s %d  %6d  (%.1f%%)\n", icon, code, counts[code], 100*float64(counts[code])/float64(len(entries)))
+	}
+}
+
+// printSlowest shows the requests that took longest
+func printSlowest(entries []*nginx.AccessEntry, top int) {
+	slowest := append([]*nginx.AccessEntry(nil), entries...)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].RequestTime > slowest[j].RequestTime })
+
+	fmt.Println("\n=== Slowest requests ===")
+	for _, entry := range slowest[:min(top, len(slowest))] {
+		fmt.Printf("   %8s  %d  %s %s  (%s)\n", formatMillis(entry.Duration()), entry.Status, entry.Method, entry.URI, entry.Time.Local().Format("15:04:05"))
+	}
+}
+
+// formatMillis shows a duration in whole milliseconds
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// siteLogPath is where nginx writes one of a site's logs (e.g., "access.log",
+// "error.log", "access.json")
+func siteLogPath(siteName, kind string) string {
+	return fmt.Sprintf("/var/log/nginx/%s.%s", sharedName(siteName), kind)
+}
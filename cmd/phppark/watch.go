@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/dns"
+	"github.com/stevepop/phppark/internal/health"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/notify"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/ssl"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// watchUnitName is the systemd unit running the health watcher
+const watchUnitName = "phppark-watch"
+
+// certWarning is how long before expiry the watcher reports a certificate
+const certWarning = 7 * 24 * time.Hour
+
+func watchCmd() *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch services and sites, restarting anything that crashed",
+		Long: `Watch periodically checks the web server, PHP-FPM, dnsmasq and every site.
+Crashed services are restarted, and problems (including certificates about to
+expire) and recoveries are recorded as incidents (see: phppark status
+--incidents) and sent as desktop notifications (see "notify" in config.yaml).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(interval, once)
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Time between checks")
+	cmd.Flags().BoolVar(&once, "once", false, "Run a single check and exit")
+
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Run the watcher in the background with systemd",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatchInstall(interval)
+		},
+	}
+	installCmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Time between checks")
+
+	cmd.AddCommand(installCmd)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the background watcher",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := services.RemoveUnit(watchUnitName); err != nil {
+				return err
+			}
+			fmt.Println("✅ Health watcher removed")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func runWatch(interval time.Duration, once bool) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{incidents: paths.Incidents, certs: paths.Certificates, down: make(map[string]bool)}
+
+	if !once {
+		fmt.Printf("👀 Watching PHPark every %s (incidents: %s)\n", interval, paths.Incidents)
+	}
+
+	for {
+		w.check()
+		if once {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+func runWatchInstall(interval time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	unit := &services.Unit{
+		Name:        watchUnitName,
+		Description: "PHPark health watcher",
+		ExecStart:   []string{exe, "watch", "--interval", interval.String()},
+		// Restarting nginx, PHP-FPM and dnsmasq needs root
+		User: "root",
+	}
+
+	if err := services.InstallUnit(unit); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Health watcher running (%s, every %s)\n", watchUnitName, interval)
+	fmt.Println("   View incidents: phppark status --incidents")
+	return nil
+}
+
+// watcher tracks health between checks so incidents are recorded when
+// something breaks or recovers, not on every tick
+type watcher struct {
+	incidents string
+	certs     string
+	notify    string
+	down      map[string]bool
+}
+
+// check runs one round of checks. Config and sites are reloaded each time
+// so the watcher follows changes without a restart.
+func (w *watcher) check() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		i18n.Printf(i18n.WarnLoadConfig, err)
+		return
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		i18n.Printf(i18n.WarnLoadSites, err)
+		return
+	}
+
+	w.notify = cfg.Notify
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		w.service("web server", func() bool { return false }, func() error { return err })
+		return
+	}
+	w.service(server.Name(), server.Running, server.Start)
+
+	if fpm, err := webserver.NewFPM(cfg); err == nil {
+		for _, version := range fpmVersions(server, sites.ListSites(), cfg) {
+			// Error pages show the log of the crash, not of the last deploy
+			if cfg.ErrorPages && !fpm.Running(version) {
+				refreshErrorPages(sites.ListSites(), cfg, version)
+			}
+			w.service(fmt.Sprintf("php%s-fpm", version),
+				func() bool { return fpm.Running(version) },
+				func() error { return fpm.Start(version) })
+		}
+	}
+
+	if configured, _ := dnsConfigured(cfg); configured {
+		if cfg.DNS.Driver == "resolved" {
+			w.service(dnsUnitName,
+				func() bool { return services.IsUnitActive(dnsUnitName) },
+				func() error { return services.RestartUnit(dnsUnitName) })
+		} else {
+			w.service("dnsmasq", dns.IsDnsmasqRunning, dns.RestartDnsmasq)
+		}
+	}
+
+	seen := make(map[string]time.Time)
+	for _, site := range sites.ListSites() {
+		if site.Disabled {
+			continue
+		}
+
+		result := health.Probe(health.Target{URL: siteURL(&site, cfg), Local: true}, 10*time.Second)
+		w.site(site.Name+"."+cfg.SiteDomain(), result)
+		if result.OK() {
+			seen[site.Name] = time.Now().Truncate(time.Second)
+		}
+
+		if site.Secured {
+			w.certificate(site.Name, site.Name+"."+cfg.SiteDomain())
+		}
+	}
+
+	if err := config.MarkSeen(seen); err != nil {
+		fmt.Printf("⚠️  Failed to record site checks: %v\n", err)
+	}
+}
+
+// service restarts a service that isn't running, recording the incident
+func (w *watcher) service(name string, running func() bool, restart func() error) {
+	if running() {
+		if w.down[name] {
+			delete(w.down, name)
+			w.record(name, "recovered", "")
+		}
+		return
+	}
+
+	action := "restarted"
+	if err := restart(); err != nil {
+		action = "restart failed: " + err.Error()
+	}
+
+	w.down[name] = true
+	w.record(name, "not running", action)
+}
+
+// site records a site becoming unreachable or recovering
+func (w *watcher) site(hostname string, result *health.Result) {
+	if result.OK() {
+		if w.down[hostname] {
+			delete(w.down, hostname)
+			w.record(hostname, "recovered", "")
+		}
+		return
+	}
+
+	if !w.down[hostname] {
+		w.down[hostname] = true
+		w.record(hostname, "unreachable: "+result.Summary(), "")
+	}
+}
+
+// certificate records a site's certificate nearing expiry, once
+func (w *watcher) certificate(siteName, hostname string) {
+	expiry, err := ssl.CertificateExpiry(siteName, w.certs)
+	if err != nil {
+		return
+	}
+
+	key := hostname + " certificate"
+	remaining := time.Until(expiry)
+	if remaining > certWarning {
+		delete(w.down, key)
+		return
+	}
+
+	if !w.down[key] {
+		w.down[key] = true
+		message := fmt.Sprintf("certificate expires in %d day(s)", int(remaining.Hours()/24))
+		if remaining <= 0 {
+			message = "certificate expired"
+		}
+		w.record(key, message, "renew with: phppark renew "+siteName)
+	}
+}
+
+func (w *watcher) record(component, message, action string) {
+	incident := health.Incident{Time: time.Now(), Component: component, Message: message, Action: action}
+	printIncident(incident)
+
+	if err := health.RecordIncident(w.incidents, incident); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+
+	body := message
+	if action != "" {
+		body += " (" + action + ")"
+	}
+	if err := notify.Send(w.notify, "PHPark: "+component, body); err != nil {
+		fmt.Printf("⚠️  Failed to send notification: %v\n", err)
+	}
+}
+
+// printIncident prints one incident line
+func printIncident(incident health.Incident) {
+	icon := "❌"
+	if incident.Message == "recovered" {
+		icon = "✅"
+	}
+
+	line := fmt.Sprintf("%s %s %s: %s", incident.Time.Format("2006-01-02 15:04:05"), icon, incident.Component, incident.Message)
+	if incident.Action != "" {
+		line += " (" + incident.Action + ")"
+	}
+	fmt.Println(line)
+}
+
+// runIncidents prints the most recent incidents
+func runIncidents(limit int) error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	incidents, err := health.ReadIncidents(paths.Incidents, limit)
+	if err != nil {
+		return err
+	}
+
+	if len(incidents) == 0 {
+		fmt.Println("✅ No incidents recorded")
+		fmt.Println("   Start the watcher with: sudo phppark watch install")
+		return nil
+	}
+
+	fmt.Printf("🚨 Incidents (last %d)\n\n", len(incidents))
+	for _, incident := range incidents {
+		printIncident(incident)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/fswatch"
+	"github.com/stevepop/phppark/internal/hooks"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/services"
+	"github.com/stevepop/phppark/internal/webserver"
+)
+
+// watchConfigUnitName is the systemd unit running the config watcher
+const watchConfigUnitName = "phppark-watch-config"
+
+// watchConfigSettle is how long changes must stop before a rebuild, so a
+// git pull or an editor's save triggers one rebuild rather than several
+const watchConfigSettle = 500 * time.Millisecond
+
+func watchConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch-config",
+		Short: "Rebuild sites when config.yaml, the registry, templates or parked directories change",
+		Long: `Watch-config watches config.yaml, the site registry (sites.json or sites.db)
+and ~/.phppark/templates, and when one changes, rebuilds the sites whose config
+would come out different (like 'phppark rebuild --changed'). Editing the YAML
+by hand or pulling it with git then takes effect without a rebuild. Sites
+removed by hand from the registry keep their config until 'phppark clean'.
+
+It also watches the parked directories (those with parked sites): a new
+folder in one is linked as a site right away (e.g., after 'laravel new blog')
+with the --php, --secure, --template, --with-db and --exclude the directory
+was parked with (its .phppark.yml hooks only run if it's been trusted with
+'phppark hooks trust'), and a parked site whose folder is deleted or moved
+away is unlinked. Each
+parked site's folder is watched too, so its config follows what the project
+turns into (e.g., public/ appearing once the installer finishes).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatchConfig()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "install",
+		Short: "Run the config watcher in the background with systemd",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatchConfigInstall()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop and remove the background config watcher",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := services.RemoveUnit(watchConfigUnitName); err != nil {
+				return err
+			}
+			fmt.Println("✅ Config watcher removed")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func runWatchConfig() error {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(paths.Templates, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", paths.Templates, err)
+	}
+
+	w, err := fswatch.New()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if err := watchConfigDirs(w, paths); err != nil {
+		return err
+	}
+	parked := make(map[string]bool)
+	watchParked(w, parked)
+
+	fmt.Printf("👀 Watching %s, the site registry and %s\n", paths.Config, paths.Templates)
+	for dir := range parked {
+		fmt.Printf("   Parked: %s\n", dir)
+	}
+
+	for {
+		events, err := w.Read(watchConfigSettle)
+		if err != nil {
+			return err
+		}
+
+		parkChanged := syncParked(events, parked)
+
+		// Switching profile moves the config and registry
+		if profileSwitched(events, paths) {
+			if paths, err = config.GetPaths(); err != nil {
+				return err
+			}
+			if err := watchConfigDirs(w, paths); err != nil {
+				return err
+			}
+			fmt.Printf("🔀 Profile switched, watching %s\n", paths.Config)
+		} else if !parkChanged && !configChanged(events, paths) && !siteChanged(events, parked) {
+			continue
+		}
+
+		rebuildStale()
+		watchParked(w, parked)
+	}
+}
+
+// watchConfigDirs watches the directories holding the config, the registry
+// and the templates. Files are replaced by renames, so their directories
+// are watched rather than the files themselves.
+func watchConfigDirs(w *fswatch.Watcher, paths *config.Paths) error {
+	for _, dir := range []string{paths.Home, filepath.Dir(paths.Config), paths.Templates} {
+		if err := w.Add(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileSwitched reports whether a batch of changes includes the active
+// profile
+func profileSwitched(events []fswatch.Event, paths *config.Paths) bool {
+	for _, event := range events {
+		if event.Path == filepath.Join(paths.Home, config.ProfileFileName) {
+			return true
+		}
+	}
+	return false
+}
+
+// configChanged reports whether a batch of changes touches anything a
+// site's config is rendered from
+func configChanged(events []fswatch.Event, paths *config.Paths) bool {
+	for _, event := range events {
+		dir, name := filepath.Split(event.Path)
+		dir = filepath.Clean(dir)
+
+		switch {
+		case event.Path == paths.Config, event.Path == paths.BaseConfig:
+			return true
+		case event.Path == paths.Sites, event.Path == paths.SitesDB,
+			event.Path == paths.SitesDB+"-wal", event.Path == paths.SitesDB+"-journal":
+			// Not sites.json.lock: every load opens it, so it would wake
+			// the watcher after each of its own rebuilds
+			return true
+		case dir == paths.Templates && strings.HasSuffix(name, userTemplateExt):
+			return true
+		}
+	}
+	return false
+}
+
+// watchParked watches the parked directories and the folders of the sites
+// parked in them, adding the directories to parked. A directory stays
+// watched after its last site goes, so folders created there later are
+// still linked.
+func watchParked(w *fswatch.Watcher, parked map[string]bool) {
+	sites, err := config.LoadSites()
+	if err != nil {
+		i18n.Printf(i18n.WarnLoadSites, err)
+		return
+	}
+
+	for _, site := range sites.ListSites() {
+		if site.Type != "park" || site.ParkedIn == "" {
+			continue
+		}
+		for _, dir := range []string{site.ParkedIn, site.Path} {
+			if w.Watching(dir) {
+				continue
+			}
+			if err := w.Add(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Printf("⚠️  %v\n", err)
+			}
+		}
+		parked[site.ParkedIn] = true
+	}
+}
+
+// syncParked links the folders that appeared in parked directories and
+// unlinks the parked sites whose folder went away. It reports whether it
+// changed anything.
+func syncParked(events []fswatch.Event, parked map[string]bool) bool {
+	changed := false
+	for _, event := range events {
+		dir, name := filepath.Split(event.Path)
+		dir = filepath.Clean(dir)
+		if !parked[dir] || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		switch {
+		case event.Op == fswatch.Create && event.Dir:
+			if err := autoParkSite(dir, name); err != nil {
+				fmt.Printf("⚠️  Failed to link %s: %v\n", event.Path, err)
+				continue
+			}
+			changed = true
+		case event.Op == fswatch.Remove:
+			site, err := config.GetSite(name)
+			if err != nil || site == nil || site.Type != "park" || site.Path != event.Path {
+				continue
+			}
+			fmt.Printf("\n%s 📁 %s is gone\n", time.Now().Format("2006-01-02 15:04:05"), event.Path)
+			if err := runUnlink(name); err != nil {
+				fmt.Printf("⚠️  Failed to unlink %s: %v\n", name, err)
+				continue
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// autoParkSite registers a new folder in a parked directory as a site with
+// the options the directory was parked with, as 'phppark park' would have,
+// and deploys it. Only trusted projects' hooks run (see: phppark hooks).
+func autoParkSite(parkedIn, name string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	if existing := sites.FindSite(name); existing != nil {
+		if existing.Path != filepath.Join(parkedIn, name) {
+			fmt.Printf("⏭️  Skipping '%s' (already exists as %s)\n", name, existing.Type)
+		}
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	opts := cfg.Parked[parkedIn]
+	if pattern := matchExclude(name, opts.Exclude); pattern != "" {
+		return nil
+	}
+
+	site := config.Site{
+		Name:       name,
+		Path:       filepath.Join(parkedIn, name),
+		Type:       "park",
+		PHPVersion: opts.PHPVersion,
+		Secured:    cfg.UseHTTPS || opts.Secure,
+		ParkedIn:   parkedIn,
+		Template:   opts.Template,
+	}
+
+	fmt.Printf("\n%s 📁 New folder %s\n", time.Now().Format("2006-01-02 15:04:05"), site.Path)
+	if err := runHook(hooks.PreLink, &site, cfg); err != nil {
+		fmt.Printf("⏭️  Skipping '%s' (%v)\n", name, err)
+		return nil
+	}
+
+	if opts.WithDB {
+		if err := createSiteDatabase(&site, cfg, false); err != nil {
+			fmt.Printf("⚠️  %s: failed to create database (%v)\n", name, err)
+		}
+	}
+
+	sites.AddSite(site)
+	failures, err := deploySites([]*config.Site{sites.FindSite(name)}, cfg)
+	if err != nil {
+		i18n.Printf(i18n.Warning, err)
+	}
+	if err, ok := failures[name]; ok {
+		return fmt.Errorf("failed to generate config: %w", err)
+	}
+
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+	runPostHook(hooks.PostLink, &site, cfg)
+
+	fmt.Printf("✅ Linked %s\n", siteURL(&site, cfg))
+	return nil
+}
+
+// siteChanged reports whether a batch of changes touches the top of a
+// parked site's folder, where template detection looks
+func siteChanged(events []fswatch.Event, parked map[string]bool) bool {
+	for _, event := range events {
+		if parked[filepath.Dir(filepath.Dir(event.Path))] {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildStale rebuilds the sites whose rendered config no longer matches
+// the one written. A config that doesn't load (e.g., half-written YAML) is
+// reported and left for the next change.
+func rebuildStale() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		i18n.Printf(i18n.WarnLoadConfig, err)
+		return
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		i18n.Printf(i18n.WarnLoadSites, err)
+		return
+	}
+
+	server, err := webserver.New(cfg)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return
+	}
+
+	var stale []string
+	for _, site := range sites.ListSites() {
+		if !site.Builtin && !upToDate(&site, cfg, server) {
+			stale = append(stale, site.Name)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s 🔄 Changes detected\n", time.Now().Format("2006-01-02 15:04:05"))
+	if err := runRebuild(stale, rebuildOptions{}); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+}
+
+func runWatchConfigInstall() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find phppark binary: %w", err)
+	}
+
+	unit := &services.Unit{
+		Name:        watchConfigUnitName,
+		Description: "PHPark config watcher",
+		ExecStart:   []string{exe, "watch-config"},
+		// Deploying nginx configs and reloading nginx needs root
+		User: "root",
+	}
+
+	if err := services.InstallUnit(unit); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Config watcher running (%s)\n", watchConfigUnitName)
+	fmt.Printf("   View its rebuilds: sudo journalctl -u %s -f\n", watchConfigUnitName)
+	return nil
+}
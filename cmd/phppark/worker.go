@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/php"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+func workerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Supervise queue workers for a site",
+		Long:  `Worker runs queue workers (e.g., php artisan queue:work) for a site as systemd services.`,
+	}
+
+	var count int
+	var name string
+	add := &cobra.Command{
+		Use:     "add <site> <command>",
+		Short:   "Add a queue worker to a site",
+		Example: `  phppark worker add myapp "php artisan queue:work --tries=3" --count 2`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkerAdd(args[0], args[1], name, count)
+		},
+	}
+	add.Flags().IntVar(&count, "count", 1, "Number of processes to run")
+	add.Flags().StringVar(&name, "name", "", "Worker name (default: next number)")
+	cmd.AddCommand(add)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list [site]",
+		Short: "List queue workers",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			siteName := ""
+			if len(args) > 0 {
+				siteName = args[0]
+			}
+			return runWorkerList(siteName)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restart <site> [worker]",
+		Short: "Restart a site's workers (e.g., after deploying code)",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			workerName := ""
+			if len(args) > 1 {
+				workerName = args[1]
+			}
+			return runWorkerRestart(args[0], workerName)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <site> <worker>",
+		Short: "Stop and remove a queue worker",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWorkerRemove(args[0], args[1])
+		},
+	})
+
+	return cmd
+}
+
+func runWorkerAdd(siteName, command, name string, count int) error {
+	if count < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("worker command cannot be empty")
+	}
+
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	if name == "" {
+		name = nextWorkerName(site)
+	} else if existing := findWorkerUnit(site, name); existing != nil {
+		if existing.Name == name {
+			return fmt.Errorf("worker '%s' already exists for %s", name, siteName)
+		}
+		return fmt.Errorf("worker '%s' would share systemd units with '%s' on %s; pick another name", name, existing.Name, siteName)
+	}
+
+	worker := config.Worker{Name: name, Command: command, Count: count}
+
+	fmt.Printf("⚙️  Adding worker '%s' to %s.%s...\n", name, siteName, cfg.SiteDomain())
+
+	if err := installWorkerUnits(site, &worker, cfg); err != nil {
+		// The worker isn't saved, so nothing else would remove the
+		// processes that did start
+		removeWorkerUnits(site.Name, &worker)
+		return err
+	}
+
+	site.Workers = append(site.Workers, worker)
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Printf("\n✅ Worker '%s' running (%d process(es))\n", name, count)
+	journalctl := "journalctl"
+	if services.UserScope() {
+		journalctl = "journalctl --user"
+	}
+	fmt.Printf("   Logs: %s -u '%s-*' -f\n", journalctl, workerUnitPrefix(site.Name, name))
+
+	return nil
+}
+
+func runWorkerList(siteName string) error {
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	found := 0
+	for _, site := range sites.ListSites() {
+		if siteName != "" && site.Name != siteName {
+			continue
+		}
+		for _, worker := range site.Workers {
+			if found == 0 {
+				fmt.Println("📋 Queue Workers")
+				fmt.Println()
+			}
+			found++
+
+			active := countActiveWorkers(site.Name, &worker)
+			status := "✅"
+			if active < worker.Count {
+				status = "⚠️ "
+			}
+
+			fmt.Printf("⚙️  %s/%s\n", site.Name, worker.Name)
+			fmt.Printf("   Command:   %s\n", worker.Command)
+			fmt.Printf("   Processes: %s %d/%d running\n", status, active, worker.Count)
+			fmt.Println()
+		}
+	}
+
+	if found == 0 {
+		fmt.Println("📋 No queue workers configured")
+		fmt.Println("\nTo add one:")
+		fmt.Println(`  phppark worker add myapp "php artisan queue:work"`)
+	}
+
+	return nil
+}
+
+func runWorkerRestart(siteName, workerName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	// Load config
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	restarted := 0
+	for i := range site.Workers {
+		worker := &site.Workers[i]
+		if workerName != "" && worker.Name != workerName {
+			continue
+		}
+
+		fmt.Printf("🔄 Restarting %s/%s...\n", site.Name, worker.Name)
+
+		// Rewrite the units so PHP version changes are picked up
+		if err := installWorkerUnits(site, worker, cfg); err != nil {
+			return err
+		}
+		for n := 1; n <= worker.Count; n++ {
+			if err := services.RestartUnit(workerUnitName(site.Name, worker.Name, n)); err != nil {
+				return err
+			}
+		}
+		restarted++
+	}
+
+	if restarted == 0 {
+		if workerName != "" {
+			return fmt.Errorf("worker '%s' not found for %s", workerName, siteName)
+		}
+		fmt.Printf("📋 No workers configured for %s\n", siteName)
+		return nil
+	}
+
+	fmt.Printf("\n✅ Restarted %d worker(s)\n", restarted)
+	return nil
+}
+
+func runWorkerRemove(siteName, workerName string) error {
+	// Load sites
+	sites, err := config.LoadSites()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+
+	// Find site
+	site := sites.FindSite(siteName)
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	worker := site.FindWorker(workerName)
+	if worker == nil {
+		return fmt.Errorf("worker '%s' not found for %s", workerName, siteName)
+	}
+
+	fmt.Printf("🗑️  Removing worker %s/%s...\n", siteName, workerName)
+
+	removeWorkerUnits(site.Name, worker)
+
+	site.RemoveWorker(workerName)
+	if err := config.SaveSites(sites); err != nil {
+		return i18n.Errorf(i18n.SaveSitesFailed, err)
+	}
+
+	fmt.Println("\n✅ Worker removed")
+	return nil
+}
+
+// installWorkerUnits writes and starts one systemd unit per worker process
+func installWorkerUnits(site *config.Site, worker *config.Worker, cfg *config.Config) error {
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	command := sitePHPCommand(worker.Command, phpVersion)
+
+	for n := 1; n <= worker.Count; n++ {
+		unit := &services.Unit{
+			Name:        workerUnitName(site.Name, worker.Name, n),
+			Description: fmt.Sprintf("PHPark worker %s/%s #%d", site.Name, worker.Name, n),
+			ExecStart:   []string{"/bin/sh", "-c", "exec " + command},
+			WorkingDir:  site.Path,
+		}
+		if err := services.InstallUnit(unit); err != nil {
+			return fmt.Errorf("failed to start worker process %d: %w", n, err)
+		}
+	}
+
+	return nil
+}
+
+// removeWorkerUnits stops and deletes all units for a worker
+func removeWorkerUnits(siteName string, worker *config.Worker) {
+	for n := 1; n <= worker.Count; n++ {
+		unitName := workerUnitName(siteName, worker.Name, n)
+		if err := services.RemoveUnit(unitName); err != nil {
+			i18n.Printf(i18n.Warning, err)
+		}
+	}
+}
+
+// countActiveWorkers returns how many of a worker's processes are running
+func countActiveWorkers(siteName string, worker *config.Worker) int {
+	active := 0
+	for n := 1; n <= worker.Count; n++ {
+		if services.IsUnitActive(workerUnitName(siteName, worker.Name, n)) {
+			active++
+		}
+	}
+	return active
+}
+
+// sitePHPCommand swaps a leading "php" for the given version's binary so
+// commands run with the site's PHP
+func sitePHPCommand(command, phpVersion string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] != "php" {
+		return command
+	}
+
+	binary := php.BinaryPath(phpVersion)
+	if binary == "" {
+		return command
+	}
+
+	return binary + strings.TrimPrefix(strings.TrimSpace(command), "php")
+}
+
+func nextWorkerName(site *config.Site) string {
+	for i := 1; ; i++ {
+		name := strconv.Itoa(i)
+		if findWorkerUnit(site, name) == nil {
+			return name
+		}
+	}
+}
+
+// findWorkerUnit returns the site's worker whose units a worker with this
+// name would use: UnitSafe folds names like a:b and a_b together
+func findWorkerUnit(site *config.Site, name string) *config.Worker {
+	for i := range site.Workers {
+		if services.UnitSafe(site.Workers[i].Name) == services.UnitSafe(name) {
+			return &site.Workers[i]
+		}
+	}
+	return nil
+}
+
+func workerUnitPrefix(siteName, workerName string) string {
+	return fmt.Sprintf("phppark-worker-%s-%s", services.UnitSafe(sharedName(siteName)), services.UnitSafe(workerName))
+}
+
+func workerUnitName(siteName, workerName string, n int) string {
+	return fmt.Sprintf("%s-%d", workerUnitPrefix(siteName, workerName), n)
+}
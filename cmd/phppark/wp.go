@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/php"
+)
+
+func wpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "wp <site> -- <args>",
+		Short: "Run WP-CLI against a WordPress site",
+		Long: `Wp runs WP-CLI for a site with the site's PHP version and --path already set,
+so it works from any directory.`,
+		Example: `  phppark wp blog -- plugin list
+  phppark wp blog -- search-replace http://blog.test https://blog.test`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 || (cmd.ArgsLenAtDash() != -1 && cmd.ArgsLenAtDash() != 1) {
+				return fmt.Errorf("usage: phppark wp <site> -- <args>")
+			}
+			return nil
+		},
+		// WP-CLI reports its own errors
+		SilenceUsage: true,
+		Annotations:  map[string]string{passthroughAnnotation: ""},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWP(args[0], args[1:])
+		},
+	}
+}
+
+func runWP(siteName string, args []string) error {
+	site, err := config.GetSite(siteName)
+	if err != nil {
+		return i18n.Errorf(i18n.LoadSitesFailed, err)
+	}
+	if site == nil {
+		return i18n.Errorf(i18n.SiteNotFound, siteName)
+	}
+
+	root := nginx.WordPressRoot(site.Path)
+	if root == "" {
+		return fmt.Errorf("site '%s' doesn't look like WordPress (no wp-load.php in %s)", siteName, site.Path)
+	}
+
+	wp, err := exec.LookPath("wp")
+	if err != nil {
+		return missingDependency(i18n.WPCLINotFound)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return i18n.Errorf(i18n.LoadConfigFailed, err)
+	}
+
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	command := append([]string{wp, "--path=" + root}, args...)
+	if binary := php.BinaryPath(phpVersion); binary != "" {
+		command = append([]string{binary}, command...)
+	}
+	if user := sudoUser(); user != "" {
+		command = append([]string{"sudo", "-u", user, "-H"}, command...)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = site.Path
+	attachTerminal(cmd)
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			flushOutput()
+			os.Exit(exitErr.ExitCode())
+		}
+		return fmt.Errorf("failed to run wp: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package apache
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+// vhost is the data passed to the Apache template: the same site settings
+// used for nginx plus the distro's Apache log directory
+type vhost struct {
+	*nginx.SiteConfig
+	LogDir       string
+	MaxBodyBytes int64 // LimitRequestBody takes bytes, not a suffixed size
+}
+
+// PHPHandler returns the mod_proxy_fcgi handler for the site's PHP-FPM,
+// over its socket or TCP
+func (v vhost) PHPHandler() string {
+	return phpHandler(v.PHPSocket)
+}
+
+// phpHandler returns the mod_proxy_fcgi handler for a PHP-FPM listening
+// on a socket path or host:port
+func phpHandler(listen string) string {
+	if strings.HasPrefix(listen, "/") {
+		return "proxy:unix:" + listen + "|fcgi://localhost"
+	}
+	return "proxy:fcgi://" + listen
+}
+
+// GenerateConfig generates an Apache vhost from a SiteConfig.
+// PHP is served through mod_proxy_fcgi against the site's FPM socket.
+func GenerateConfig(cfg *nginx.SiteConfig, logDir string) (string, error) {
+	tmpl, err := template.New("apache").Funcs(template.FuncMap{"phpHandler": phpHandler}).Parse(GetTemplate())
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vhost{SiteConfig: cfg, LogDir: logDir, MaxBodyBytes: nginx.SizeBytes(cfg.MaxBodySize)}); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,132 @@
+package apache
+
+const apacheTemplate = `{{define "aliases"}}
+    {{- if .Aliases}}
+    ServerAlias{{range .Aliases}} {{.}}{{end}}
+    {{- end}}
+{{- end}}{{define "body"}}
+    ServerName {{.ServerName}}
+    {{- template "aliases" .}}
+    {{- if or (not .ProxyPass) .Octane}}
+    DocumentRoot "{{.Root}}"
+    {{- end}}
+
+    # Logging
+    ErrorLog {{.LogDir}}/{{.SiteName}}.error.log
+    CustomLog {{.LogDir}}/{{.SiteName}}.access.log combined
+    {{- if .MaxBodySize}}
+    LimitRequestBody {{.MaxBodyBytes}}
+    {{- end}}
+    {{- if .Timeout}}
+    ProxyTimeout {{.Timeout}}
+    {{- end}}
+    {{- if and .ProxyPass (not .Octane)}}
+    {{- range .Mounts}}
+    ProxyPass {{.Path}}/ !
+    {{- end}}
+    {{- end}}
+    {{- if .Octane}}
+
+    # Serve static files directly, send everything else to Octane
+    <Directory "{{.Root}}">
+        Require all granted
+    </Directory>
+
+    RewriteEngine On
+    RewriteCond %{REQUEST_URI} ^/index\.php [OR]
+    RewriteCond %{DOCUMENT_ROOT}%{REQUEST_URI} !-f
+    {{- range .Mounts}}
+    RewriteCond %{REQUEST_URI} !^{{.Path}}/
+    {{- end}}
+    RewriteRule ^/(.*)$ {{.ProxyPass}}/$1 [P,L]
+    ProxyPassReverse / {{.ProxyPass}}/
+    ProxyPreserveHost On
+    {{- else if .ProxyPass}}
+
+    # Forward everything to the upstream service (websockets included)
+    ProxyPreserveHost On
+    ProxyPass / {{.ProxyPass}}/ upgrade=websocket
+    ProxyPassReverse / {{.ProxyPass}}/
+    {{- else}}
+
+    # Laravel/PHP framework friendly
+    <Directory "{{.Root}}">
+        AllowOverride All
+        Require all granted
+        DirectoryIndex index.php index.html index.htm
+        FallbackResource /index.php
+    </Directory>
+    {{- if .FPMStatus}}
+
+    # PHP-FPM status and ping pages (localhost only)
+    <LocationMatch "^/(fpm-status|fpm-ping)$">
+        Require local
+        SetHandler "{{.PHPHandler}}"
+    </LocationMatch>
+    {{- end}}
+
+    # PHP-FPM configuration
+    <FilesMatch "\.php$">
+        SetHandler "{{.PHPHandler}}"
+    </FilesMatch>
+    {{- range .Env}}
+    SetEnv {{.Name}} {{.Value}}
+    {{- end}}
+
+    # Deny access to hidden files
+    <FilesMatch "^\.">
+        Require all denied
+    </FilesMatch>
+    {{- end}}
+    {{- range .Mounts}}
+
+    # {{.Path}} is another app (see: phppark mount)
+    RedirectMatch 301 ^{{.Path}}$ {{.Path}}/
+    Alias {{.Path}}/ "{{.Root}}/"
+    <Directory "{{.Root}}">
+        AllowOverride All
+        Require all granted
+        DirectoryIndex index.php index.html index.htm
+        FallbackResource {{.Path}}/index.php
+        <FilesMatch "\.php$">
+            SetHandler "{{phpHandler .PHPSocket}}"
+        </FilesMatch>
+    </Directory>
+    {{- end}}
+{{- end}}<VirtualHost *:{{.ListenPort}}>
+    {{- if and .UseSSL .RedirectHTTP}}
+    ServerName {{.ServerName}}
+    {{- if .Aliases}}
+    {{- template "aliases" .}}
+
+    # Redirect to the name the request came in on
+    RewriteEngine On
+    RewriteRule ^/?(.*)$ https://%{SERVER_NAME}{{if ne .SSLPort 443}}:{{.SSLPort}}{{end}}/$1 [R=301,L]
+    {{- else}}
+    Redirect permanent / https://{{.ServerName}}{{if ne .SSLPort 443}}:{{.SSLPort}}{{end}}/
+    {{- end}}
+    {{- else}}
+    {{- template "body" .}}
+    {{- end}}
+</VirtualHost>
+{{- if .UseSSL}}
+
+<VirtualHost *:{{.SSLPort}}>
+    {{- template "body" .}}
+
+    SSLEngine on
+    SSLCertificateFile {{.CertPath}}
+    SSLCertificateKeyFile {{.KeyPath}}
+    {{- if .ProxyPass}}
+
+    # Tell the upstream the request arrived over HTTPS
+    RequestHeader set X-Forwarded-Proto "https"
+    {{- end}}
+</VirtualHost>
+{{- end}}
+`
+
+// GetTemplate returns the Apache vhost template
+func GetTemplate() string {
+	return apacheTemplate
+}
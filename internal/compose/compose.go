@@ -0,0 +1,164 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AppDir is where the site is mounted inside the containers
+const AppDir = "/var/www/html"
+
+// Site describes what a docker-compose export reproduces for a site
+type Site struct {
+	Name        string            // e.g., "myapp"
+	PHPVersion  string            // e.g., "8.3"
+	Env         map[string]string // Site environment variables
+	NginxConfig string            // nginx server block for the nginx container
+	Database    string            // Database name, if the site has one
+	DBUser      string            // Database user (defaults to the database name)
+	Redis       bool              // Attach a Redis service
+}
+
+// File is a docker-compose.yml document
+type File struct {
+	Services map[string]*Service `yaml:"services"`
+	Configs  map[string]Config   `yaml:"configs,omitempty"`
+	Volumes  map[string]Volume   `yaml:"volumes,omitempty"`
+}
+
+// Service is a docker-compose service
+type Service struct {
+	Image       string            `yaml:"image"`
+	WorkingDir  string            `yaml:"working_dir,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Configs     []ConfigRef       `yaml:"configs,omitempty"`
+	DependsOn   []string          `yaml:"depends_on,omitempty"`
+}
+
+// Config is an inline docker-compose config
+type Config struct {
+	Content string `yaml:"content"`
+}
+
+// ConfigRef mounts a config into a service
+type ConfigRef struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// Volume is a named volume (default driver)
+type Volume struct{}
+
+// Generate builds a docker-compose.yml for a site, meant to live in the
+// site's root directory
+func Generate(site *Site) ([]byte, error) {
+	env := make(map[string]string, len(site.Env))
+	for name, value := range site.Env {
+		env[name] = value
+	}
+
+	file := &File{
+		Services: map[string]*Service{
+			"nginx": {
+				Image:     "nginx:stable",
+				Ports:     []string{"80:80"},
+				Volumes:   []string{".:" + AppDir},
+				Configs:   []ConfigRef{{Source: "nginx", Target: "/etc/nginx/conf.d/default.conf"}},
+				DependsOn: []string{"php"},
+			},
+			"php": {
+				Image:       fmt.Sprintf("php:%s-fpm", site.PHPVersion),
+				WorkingDir:  AppDir,
+				Volumes:     []string{".:" + AppDir},
+				Environment: env,
+			},
+		},
+		Configs: map[string]Config{
+			"nginx": {Content: configContent(site.NginxConfig)},
+		},
+	}
+
+	php := file.Services["php"]
+
+	if site.Database != "" {
+		user := site.DBUser
+		if user == "" {
+			user = site.Database
+		}
+
+		file.Services["mysql"] = &Service{
+			Image: "mysql:8.0",
+			Environment: map[string]string{
+				"MYSQL_DATABASE":      site.Database,
+				"MYSQL_USER":          user,
+				"MYSQL_PASSWORD":      "secret",
+				"MYSQL_ROOT_PASSWORD": "secret",
+			},
+			Volumes: []string{"mysql-data:/var/lib/mysql"},
+		}
+		file.Volumes = map[string]Volume{"mysql-data": {}}
+
+		env["DB_CONNECTION"] = "mysql"
+		env["DB_HOST"] = "mysql"
+		env["DB_PORT"] = "3306"
+		env["DB_DATABASE"] = site.Database
+		env["DB_USERNAME"] = user
+		env["DB_PASSWORD"] = "secret"
+		php.DependsOn = append(php.DependsOn, "mysql")
+	}
+
+	if site.Redis {
+		file.Services["redis"] = &Service{Image: "redis:7-alpine"}
+
+		env["REDIS_HOST"] = "redis"
+		env["REDIS_PORT"] = "6379"
+		php.DependsOn = append(php.DependsOn, "redis")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Exported by PHPark for %s\n# Usage: docker compose up -d (from the site directory)\n", site.Name)
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to marshal docker-compose.yml: %w", err)
+	}
+	encoder.Close()
+
+	return buf.Bytes(), nil
+}
+
+// configContent prepares a generated config for inlining: compose
+// interpolates $ (nginx variables) unless doubled, and trailing whitespace
+// or repeated blank lines from templates would force a quoted scalar
+func configContent(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.ReplaceAll(strings.Join(lines, "\n"), "$", "$$")
+}
+
+// UsesRedis reports whether a site's environment points at Redis
+func UsesRedis(env map[string]string) bool {
+	if env["REDIS_HOST"] != "" {
+		return true
+	}
+
+	for _, name := range []string{"CACHE_DRIVER", "CACHE_STORE", "QUEUE_CONNECTION", "SESSION_DRIVER"} {
+		if env[name] == "redis" {
+			return true
+		}
+	}
+	return false
+}
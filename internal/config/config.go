@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,24 +17,49 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	cfg, err := loadConfigFile(paths.BaseConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// The active profile's own settings apply over the machine-wide ones
+	if paths.Profile != "" {
+		profile, err := loadProfileConfig(paths.Config)
+		if err != nil {
+			return nil, err
+		}
+		profile.apply(cfg)
+	}
+
+	if err := cfg.checkFPMListen(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadConfigFile reads a config.yaml, or returns the defaults if there is
+// none
+func loadConfigFile(path string) (*Config, error) {
 	// If config file doesn't exist, return defaults
-	if _, err := os.Stat(paths.Config); os.IsNotExist(err) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return DefaultConfig(), nil
 	}
 
 	// Read the file
-	data, err := os.ReadFile(paths.Config)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Parse YAML
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	// Parse YAML over the defaults so settings missing from older
+	// config files keep sensible values
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 // SaveConfig saves the configuration to config.yaml
@@ -48,70 +74,221 @@ func SaveConfig(cfg *Config) error {
 		return err
 	}
 
+	// A profile's own settings go to its config.yaml, leaving the base
+	// config's as they are
+	base := *cfg
+	if paths.Profile != "" {
+		if err := saveProfileConfig(paths.Config, profileConfigOf(cfg)); err != nil {
+			return err
+		}
+
+		current, err := loadConfigFile(paths.BaseConfig)
+		if err != nil {
+			return err
+		}
+		profileConfigOf(current).apply(&base)
+	}
+
 	// Convert to YAML
-	data, err := yaml.Marshal(cfg)
+	data, err := yaml.Marshal(&base)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	// Write to file (0644 = rw-r--r--)
-	if err := os.WriteFile(paths.Config, data, 0644); err != nil {
+	if err := writeFileAtomic(paths.BaseConfig, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
-// LoadSites loads the site registry from sites.json
-// If the file doesn't exist, returns empty registry
+// LoadSites loads the site registry from the configured store
+// (sites.json by default), with when each site was last seen. A missing
+// registry is an empty one.
 func LoadSites() (*SiteRegistry, error) {
-	paths, err := GetPaths()
+	store, err := OpenSiteStore()
 	if err != nil {
 		return nil, err
 	}
+	defer store.Close()
 
-	// If sites file doesn't exist, return empty registry
-	if _, err := os.Stat(paths.Sites); os.IsNotExist(err) {
-		return NewSiteRegistry(), nil
+	registry, err := store.Load()
+	if err != nil {
+		return nil, err
 	}
+	addLastSeen(registry.Sites)
+	return registry, nil
+}
 
-	// Read the file
-	data, err := os.ReadFile(paths.Sites)
+// SaveSites saves the sites this registry added, changed or removed since
+// it was loaded, stamping CreatedAt and UpdatedAt on them. Sites saved by
+// other processes in the meantime are kept: the store is reloaded under
+// the registry's lock and only these changes are applied to it.
+func SaveSites(registry *SiteRegistry) error {
+	return withSiteStore(func(store SiteStore) error {
+		return saveChanges(store, registry)
+	})
+}
+
+// UpdateSites loads the registry, lets update change it and saves the
+// result, holding the registry's exclusive lock throughout so no other
+// save can land in between. An error from update leaves the registry as
+// it was.
+func UpdateSites(update func(registry *SiteRegistry) error) error {
+	return withSiteStore(func(store SiteStore) error {
+		registry, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if err := update(registry); err != nil {
+			return err
+		}
+		return saveChanges(store, registry)
+	})
+}
+
+// saveChanges applies a registry's changes to what the store holds now and
+// saves the result. Call it with the registry's lock held.
+func saveChanges(store SiteStore, registry *SiteRegistry) error {
+	previous, err := store.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read sites file: %w", err)
+		return err
+	}
+	current, err := store.Load()
+	if err != nil {
+		return err
 	}
 
-	// Parse JSON
-	var registry SiteRegistry
-	if err := json.Unmarshal(data, &registry); err != nil {
-		return nil, fmt.Errorf("failed to parse sites file: %w", err)
+	registry.applyTo(current)
+	stampSites(previous, current, time.Now())
+	if err := store.Save(current); err != nil {
+		return err
 	}
 
-	return &registry, nil
+	// What was just saved is the base for the next save
+	registry.remember()
+	return nil
+}
+
+// remember records the registry's sites as the store's, so later saves
+// only write what changes after this
+func (sr *SiteRegistry) remember() {
+	sr.loaded = make(map[string]string, len(sr.Sites))
+	for _, site := range sr.Sites {
+		sr.loaded[site.Name] = siteJSON(&site)
+	}
+	sr.removed = nil
 }
 
-// SaveSites saves the site registry to sites.json
-func SaveSites(registry *SiteRegistry) error {
+// applyTo copies the sites added or changed in this registry since it was
+// loaded into current, and removes the ones removed with RemoveSite. A
+// registry that wasn't loaded from a store counts every site as added.
+func (sr *SiteRegistry) applyTo(current *SiteRegistry) {
+	for name := range sr.removed {
+		current.RemoveSite(name)
+	}
+	for _, site := range sr.Sites {
+		if loaded, ok := sr.loaded[site.Name]; ok && loaded == siteJSON(&site) {
+			continue
+		}
+		current.AddSite(site)
+	}
+}
+
+// siteJSON is a site as stored, for telling whether it changed
+func siteJSON(site *Site) string {
+	data, err := json.Marshal(site)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// withSiteStore runs fn on the configured store while holding the
+// registry's exclusive lock
+func withSiteStore(fn func(store SiteStore) error) error {
 	paths, err := GetPaths()
 	if err != nil {
 		return err
 	}
 
-	// Ensure directories exist
-	if err := paths.EnsureDirectories(); err != nil {
+	unlock, err := lockFile(paths.Sites, true)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	// Convert to pretty JSON
-	data, err := json.MarshalIndent(registry, "", "  ")
+	store, err := openSiteStore(true)
 	if err != nil {
-		return fmt.Errorf("failed to marshal sites: %w", err)
+		return err
 	}
+	defer store.Close()
 
-	// Write to file (0644 = rw-r--r--)
-	if err := os.WriteFile(paths.Sites, data, 0644); err != nil {
-		return fmt.Errorf("failed to write sites file: %w", err)
+	return fn(store)
+}
+
+// stampSites sets the timestamps of sites added or changed since previous
+func stampSites(previous, registry *SiteRegistry, now time.Time) {
+	now = now.Truncate(time.Second)
+
+	for i := range registry.Sites {
+		site := &registry.Sites[i]
+
+		old := previous.FindSite(site.Name)
+		if old == nil {
+			if site.CreatedAt == nil {
+				site.CreatedAt = &now
+			}
+			site.UpdatedAt = &now
+			continue
+		}
+
+		// A site rebuilt from scratch (e.g., relinked) keeps its history.
+		// Sites saved before timestamps existed keep an unknown CreatedAt.
+		if site.CreatedAt == nil {
+			site.CreatedAt = old.CreatedAt
+		}
+		if !sameSettings(old, site) {
+			site.UpdatedAt = &now
+		} else if site.UpdatedAt == nil {
+			site.UpdatedAt = old.UpdatedAt
+		}
 	}
+}
 
-	return nil
+// sameSettings reports whether two versions of a site differ only in
+// their timestamps
+func sameSettings(a, b *Site) bool {
+	x, y := *a, *b
+	for _, s := range []*Site{&x, &y} {
+		s.CreatedAt, s.UpdatedAt = nil, nil
+	}
+
+	xj, err := json.Marshal(x)
+	if err != nil {
+		return false
+	}
+	yj, err := json.Marshal(y)
+	if err != nil {
+		return false
+	}
+	return string(xj) == string(yj)
+}
+
+// GetSite looks up one site by name, or returns nil if it isn't registered
+func GetSite(name string) (*Site, error) {
+	store, err := OpenSiteStore()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	site, err := store.Find(name)
+	if site == nil || err != nil {
+		return site, err
+	}
+	sites := []Site{*site}
+	addLastSeen(sites)
+	return &sites[0], nil
 }
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// lockFile takes an flock on path+".lock", shared for readers and
+// exclusive for writers, and returns a function that releases it. The lock
+// file is separate from the data file so atomic renames don't swap the
+// inode being locked. Readers open it read-only, so users who can read the
+// data but not write next to it (e.g., a root-owned home read without sudo)
+// can still load it; with no lock file there's no writer to wait for.
+func lockFile(path string, exclusive bool) (func(), error) {
+	var f *os.File
+	var err error
+	if exclusive {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		f, err = os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	} else {
+		f, err = os.OpenFile(path+".lock", os.O_CREATE|os.O_RDONLY, 0644)
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			if f, err = os.Open(path + ".lock"); err != nil {
+				return func() {}, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and
+// renames it over path, so readers see either the old or the new file,
+// never a partial one
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	err := replaceFile(path, data, perm)
+	entry := oplog.Entry{Op: "write", Target: path}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	oplog.Record(entry)
+	return err
+}
+
+// replaceFile is writeFileAtomic without the oplog entry, for state
+// PHPark rewrites too often to be worth recording
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
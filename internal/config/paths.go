@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -14,44 +16,120 @@ const (
 
 	// SitesFileName stores the site registry
 	SitesFileName = "sites.json"
+
+	// ProfileFileName records the active profile
+	ProfileFileName = "profile"
+
+	// HomeEnv is the environment variable that moves ~/.phppark elsewhere
+	// (e.g., for CI jobs, or homes on a network mount)
+	HomeEnv = "PHPPARK_HOME"
 )
 
 // Paths holds all PHPark directory and file paths
 type Paths struct {
-	Home         string // ~/.phppark
-	Config       string // ~/.phppark/config.yaml
-	Sites        string // ~/.phppark/sites.json
-	Nginx        string // ~/.phppark/nginx (generated configs)
-	Certificates string // ~/.phppark/certificates (SSL certs)
+	Home         string // ~/.phppark, or $PHPPARK_HOME
+	Profile      string // The active profile ("" for the default one)
+	Config       string // ~/.phppark/config.yaml (or profiles/<name>/config.yaml, with the profile's own settings)
+	BaseConfig   string // ~/.phppark/config.yaml (machine-wide settings, whichever profile is active)
+	Sites        string // ~/.phppark/sites.json (or profiles/<name>/sites.json)
+	SitesDB      string // ~/.phppark/sites.db (registry: sqlite)
+	Seen         string // ~/.phppark/seen.json (when each site last answered a health check)
+	Nginx        string // ~/.phppark/nginx (generated configs, kept per profile)
+	Apache       string // ~/.phppark/apache (generated vhosts for the apache backend, kept per profile)
+	FrankenPHP   string // ~/.phppark/frankenphp (binary, Caddyfile and site entries)
+	Docker       string // ~/.phppark/docker (FPM sockets and pool configs for the docker backend)
+	Certificates string // ~/.phppark/certificates (SSL certs, kept per profile)
 	Logs         string // ~/.phppark/logs
+	Incidents    string // ~/.phppark/logs/incidents.log (health watcher)
+	Bin          string // ~/.phppark/bin (CLI shims)
+	Services     string // ~/.phppark/services (managed service binaries and data)
+	Tools        string // ~/.phppark/tools (hosted tools like Adminer)
+	Hooks        string // ~/.phppark/hooks (global lifecycle hooks)
+	TrustedHooks string // ~/.phppark/trusted-hooks.json (projects whose .phppark.yml hooks may run)
+	Templates    string // ~/.phppark/templates (user nginx templates)
+	Oplog        string // ~/.phppark/phppark.log (record of changes made to the system)
+	Profiles     string // ~/.phppark/profiles (each profile's config, registry and certificates)
+	PHPCache     string // ~/.phppark/php-versions.json (detected PHP versions, for completion)
+	ErrorPages   string // /usr/local/share/phppark/errors (diagnostic pages nginx serves when PHP-FPM is down)
+	SiteFPM      string // ~/.phppark/fpm (configs of the PHP-FPM instances run for single sites)
 }
 
+// SystemDir holds the files PHPark installs for the web server and PHP-FPM
+// to read. Their users can't reach into ~/.phppark, which is /root's under
+// sudo.
+const SystemDir = "/usr/local/share/phppark"
+
 // GetPaths returns all PHPark paths
 func GetPaths() (*Paths, error) {
-	homeDir, err := os.UserHomeDir()
+	phparkHome, err := homeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	phparkHome := filepath.Join(homeDir, "."+AppName)
+	// A profile has its own settings, registry, certificates and generated
+	// site configs; everything else is shared
+	profile := readActiveProfile(phparkHome)
+	profileHome := phparkHome
+	if profile != "" {
+		profileHome = filepath.Join(phparkHome, "profiles", profile)
+	}
 
 	return &Paths{
 		Home:         phparkHome,
-		Config:       filepath.Join(phparkHome, ConfigFileName),
-		Sites:        filepath.Join(phparkHome, SitesFileName),
-		Nginx:        filepath.Join(phparkHome, "nginx"),
-		Certificates: filepath.Join(phparkHome, "certificates"),
+		Profile:      profile,
+		Config:       filepath.Join(profileHome, ConfigFileName),
+		BaseConfig:   filepath.Join(phparkHome, ConfigFileName),
+		Sites:        filepath.Join(profileHome, SitesFileName),
+		SitesDB:      filepath.Join(profileHome, "sites.db"),
+		Seen:         filepath.Join(profileHome, "seen.json"),
+		Nginx:        filepath.Join(profileHome, "nginx"),
+		Apache:       filepath.Join(profileHome, "apache"),
+		FrankenPHP:   filepath.Join(phparkHome, "frankenphp"),
+		Docker:       filepath.Join(phparkHome, "docker"),
+		Certificates: filepath.Join(profileHome, "certificates"),
 		Logs:         filepath.Join(phparkHome, "logs"),
+		Incidents:    filepath.Join(phparkHome, "logs", "incidents.log"),
+		Bin:          filepath.Join(phparkHome, "bin"),
+		Services:     filepath.Join(phparkHome, "services"),
+		Tools:        filepath.Join(phparkHome, "tools"),
+		Hooks:        filepath.Join(phparkHome, "hooks"),
+		TrustedHooks: filepath.Join(phparkHome, "trusted-hooks.json"),
+		Templates:    filepath.Join(phparkHome, "templates"),
+		Oplog:        filepath.Join(phparkHome, "phppark.log"),
+		Profiles:     filepath.Join(phparkHome, "profiles"),
+		PHPCache:     filepath.Join(phparkHome, "php-versions.json"),
+		ErrorPages:   filepath.Join(SystemDir, "errors"),
+		SiteFPM:      filepath.Join(phparkHome, "fpm"),
 	}, nil
 }
 
+// homeDir returns where PHPark keeps its state: $PHPPARK_HOME when set,
+// otherwise ~/.phppark
+func homeDir() (string, error) {
+	if home := os.Getenv(HomeEnv); home != "" {
+		return filepath.Abs(home)
+	}
+
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userHome, "."+AppName), nil
+}
+
 // EnsureDirectories creates all required directories if they don't exist
 func (p *Paths) EnsureDirectories() error {
 	directories := []string{
 		p.Home,
 		p.Nginx,
+		p.Apache,
 		p.Certificates,
 		p.Logs,
+		p.Bin,
+		p.Services,
+		p.Tools,
+		p.Hooks,
+		p.Templates,
 	}
 
 	for _, dir := range directories {
@@ -71,3 +149,43 @@ func (p *Paths) Exists() bool {
 	}
 	return info.IsDir()
 }
+
+// Namespace returns the user PHPark runs for (the one behind sudo, if any)
+// as a hostname label, used to keep users apart in multi-user mode
+func Namespace() string {
+	name := os.Getenv("SUDO_USER")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	return dnsLabel(name)
+}
+
+// UserNamespace returns a user's name as a namespace, like Namespace
+func UserNamespace(name string) string {
+	return dnsLabel(name)
+}
+
+// Hostname is this machine's short hostname as a DNS label, for the names
+// sites are served under on the LAN
+func Hostname() string {
+	name, _ := os.Hostname()
+	name, _, _ = strings.Cut(name, ".")
+	return dnsLabel(name)
+}
+
+// dnsLabel lowercases a name and replaces what a DNS label can't hold
+func dnsLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, name)
+	return strings.Trim(label, "-")
+}
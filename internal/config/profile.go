@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the name of the profile that lives directly in
+// ~/.phppark
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateProfileName checks a profile name is usable as a directory name
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name '%s' (use lowercase letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// readActiveProfile returns the profile recorded in ~/.phppark/profile, or
+// "" for the default one (or if the recorded profile no longer exists)
+func readActiveProfile(phparkHome string) string {
+	data, err := os.ReadFile(filepath.Join(phparkHome, ProfileFileName))
+	if err != nil {
+		return ""
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" || name == DefaultProfile || ValidateProfileName(name) != nil {
+		return ""
+	}
+	if info, err := os.Stat(filepath.Join(phparkHome, "profiles", name)); err != nil || !info.IsDir() {
+		return ""
+	}
+	return name
+}
+
+// ActiveProfile returns the name of the active profile
+func ActiveProfile() (string, error) {
+	paths, err := GetPaths()
+	if err != nil {
+		return "", err
+	}
+	if paths.Profile == "" {
+		return DefaultProfile, nil
+	}
+	return paths.Profile, nil
+}
+
+// SetActiveProfile makes a profile the one later commands use
+func SetActiveProfile(name string) error {
+	paths, err := GetPaths()
+	if err != nil {
+		return err
+	}
+
+	if name != DefaultProfile && !ProfileExists(paths, name) {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+
+	if err := os.MkdirAll(paths.Home, 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(paths.Home, ProfileFileName), []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write active profile: %w", err)
+	}
+	return nil
+}
+
+// ProfileExists reports whether a profile has been created
+func ProfileExists(paths *Paths, name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	if ValidateProfileName(name) != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(paths.Profiles, name))
+	return err == nil && info.IsDir()
+}
+
+// ListProfiles returns every profile, the default one first
+func ListProfiles() ([]string, error) {
+	paths, err := GetPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(paths.Profiles)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfileName(entry.Name()) == nil && entry.Name() != DefaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// ProfileConfig is what a profile's config.yaml holds: the settings of its
+// sites. The rest (web server, DNS, ports, ...) is machine-wide, kept in
+// ~/.phppark/config.yaml whichever profile is active, so switching profiles
+// never reconfigures the machine.
+type ProfileConfig struct {
+	DefaultPHP string               `yaml:"default_php,omitempty"`
+	Domain     string               `yaml:"domain,omitempty"`
+	Parked     map[string]ParkedDir `yaml:"parked,omitempty"`
+}
+
+// profileConfigOf returns a config's per-profile settings
+func profileConfigOf(cfg *Config) *ProfileConfig {
+	return &ProfileConfig{
+		DefaultPHP: cfg.DefaultPHP,
+		Domain:     cfg.Domain,
+		Parked:     cfg.Parked,
+	}
+}
+
+// apply sets a config's per-profile settings to the profile's. Settings the
+// profile leaves empty keep the config's.
+func (p *ProfileConfig) apply(cfg *Config) {
+	if p.DefaultPHP != "" {
+		cfg.DefaultPHP = p.DefaultPHP
+	}
+	if p.Domain != "" {
+		cfg.Domain = p.Domain
+	}
+	cfg.Parked = p.Parked
+}
+
+// loadProfileConfig reads a profile's config.yaml. Keys that aren't
+// per-profile (written by versions that copied the whole config) are
+// ignored.
+func loadProfileConfig(path string) (*ProfileConfig, error) {
+	profile := &ProfileConfig{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return profile, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile config: %w", err)
+	}
+	return profile, nil
+}
+
+func saveProfileConfig(path string, profile *ProfileConfig) error {
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// CreateProfile creates a profile with its own settings, the TLD and
+// default PHP version of cfg (an empty registry and certificates directory
+// come with it)
+func CreateProfile(name string, cfg *Config) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+
+	paths, err := GetPaths()
+	if err != nil {
+		return err
+	}
+	if ProfileExists(paths, name) {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+
+	dir := filepath.Join(paths.Profiles, name)
+	if err := os.MkdirAll(filepath.Join(dir, "certificates"), 0755); err != nil {
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	// Parked directories belong to the profile they were parked in
+	return saveProfileConfig(filepath.Join(dir, ConfigFileName), &ProfileConfig{
+		DefaultPHP: cfg.DefaultPHP,
+		Domain:     cfg.Domain,
+	})
+}
+
+// DeleteProfile removes an inactive profile with its registry and
+// certificates
+func DeleteProfile(name string) error {
+	paths, err := GetPaths()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case name == DefaultProfile:
+		return fmt.Errorf("the default profile can't be deleted")
+	case name == paths.Profile:
+		return fmt.Errorf("profile '%s' is active (switch first: phppark profile use default)", name)
+	case !ProfileExists(paths, name):
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+
+	if err := oplog.RemoveAll(filepath.Join(paths.Profiles, name)); err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// MarkSeen records when sites last answered a health check, in seen.json.
+// The registry isn't touched, so the watcher's rounds can't race with
+// commands saving sites.
+func MarkSeen(seen map[string]time.Time) error {
+	if len(seen) == 0 {
+		return nil
+	}
+
+	paths, err := GetPaths()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(paths.Seen, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	times, err := loadSeen(paths.Seen)
+	if err != nil {
+		return err
+	}
+	for name, at := range seen {
+		times[name] = at.Truncate(time.Second)
+	}
+
+	data, err := json.MarshalIndent(times, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(paths.Seen, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", paths.Seen, err)
+	}
+	return nil
+}
+
+// loadSeen reads seen.json; a missing file means no site has been seen
+func loadSeen(path string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return times, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &times); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return times, nil
+}
+
+// addLastSeen sets the sites' LastSeen from seen.json. Without it they're
+// shown as never seen, so a missing or unreadable file isn't an error.
+func addLastSeen(sites []Site) {
+	paths, err := GetPaths()
+	if err != nil {
+		return
+	}
+	times, err := loadSeen(paths.Seen)
+	if err != nil {
+		return
+	}
+	for i := range sites {
+		if at, ok := times[sites[i].Name]; ok {
+			sites[i].LastSeen = &at
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sites (
+	name       TEXT PRIMARY KEY,
+	path       TEXT NOT NULL,
+	data       TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sites_path ON sites (path);
+
+CREATE TABLE IF NOT EXISTS site_history (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	name   TEXT NOT NULL,
+	time   TEXT NOT NULL,
+	action TEXT NOT NULL,
+	data   TEXT
+);
+CREATE INDEX IF NOT EXISTS site_history_name ON site_history (name);
+
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// sqliteStore keeps one row per site in sites.db, so a save only touches
+// the sites that changed. Every change is also kept in site_history.
+type sqliteStore struct {
+	db   *sql.DB
+	path string
+}
+
+func openSQLiteStore(paths *Paths, locked bool) (*sqliteStore, error) {
+	if err := paths.EnsureDirectories(); err != nil {
+		return nil, err
+	}
+
+	// WAL lets readers (e.g., the watcher) run alongside a writer
+	dsn := "file:" + paths.SitesDB + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", paths.SitesDB, err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", paths.SitesDB, err)
+	}
+
+	store := &sqliteStore{db: db, path: paths.SitesDB}
+	if err := store.importJSON(&jsonStore{path: paths.Sites, locked: locked}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// importJSON copies sites.json into the database the first time it's
+// opened, so switching backends keeps existing sites
+func (s *sqliteStore) importJSON(source *jsonStore) error {
+	var done string
+	err := s.db.QueryRow(`SELECT value FROM meta WHERE key = 'imported'`).Scan(&done)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if _, err := os.Stat(source.path); err == nil {
+		registry, err := source.Load()
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", source.path, err)
+		}
+		if err := s.Save(registry); err != nil {
+			return fmt.Errorf("failed to import %s: %w", source.path, err)
+		}
+	}
+
+	_, err = s.db.Exec(`INSERT INTO meta (key, value) VALUES ('imported', ?)`, time.Now().Format(time.RFC3339))
+	return err
+}
+
+func (s *sqliteStore) Load() (*SiteRegistry, error) {
+	rows, err := s.db.Query(`SELECT data FROM sites ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sites: %w", err)
+	}
+	defer rows.Close()
+
+	registry := NewSiteRegistry()
+	for rows.Next() {
+		site, err := scanSite(rows)
+		if err != nil {
+			return nil, err
+		}
+		registry.Sites = append(registry.Sites, *site)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	registry.remember()
+	return registry, nil
+}
+
+// Save writes only the rows of sites that were added, changed or removed
+func (s *sqliteStore) Save(registry *SiteRegistry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stored := make(map[string]string)
+	rows, err := tx.Query(`SELECT name, data FROM sites`)
+	if err != nil {
+		return fmt.Errorf("failed to read sites: %w", err)
+	}
+	for rows.Next() {
+		var name, data string
+		if err := rows.Scan(&name, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		stored[name] = data
+	}
+	rows.Close()
+
+	now := time.Now().Format(time.RFC3339)
+	changed := 0
+
+	for _, site := range registry.Sites {
+		data, err := json.Marshal(site)
+		if err != nil {
+			return fmt.Errorf("failed to marshal site %s: %w", site.Name, err)
+		}
+
+		old, exists := stored[site.Name]
+		delete(stored, site.Name)
+		if exists && old == string(data) {
+			continue
+		}
+
+		if _, err := tx.Exec(`INSERT INTO sites (name, path, data, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET path = excluded.path, data = excluded.data, updated_at = excluded.updated_at`,
+			site.Name, site.Path, string(data), now); err != nil {
+			return fmt.Errorf("failed to save site %s: %w", site.Name, err)
+		}
+		if err := recordHistory(tx, site.Name, now, "save", string(data)); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	// Whatever is left was removed from the registry
+	for name := range stored {
+		if _, err := tx.Exec(`DELETE FROM sites WHERE name = ?`, name); err != nil {
+			return fmt.Errorf("failed to remove site %s: %w", name, err)
+		}
+		if err := recordHistory(tx, name, now, "delete", ""); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save sites: %w", err)
+	}
+
+	if changed > 0 {
+		oplog.Record(oplog.Entry{Op: "write", Target: s.path, Args: []string{fmt.Sprintf("%d site(s)", changed)}})
+	}
+	return nil
+}
+
+func (s *sqliteStore) Find(name string) (*Site, error) {
+	site, err := scanSite(s.db.QueryRow(`SELECT data FROM sites WHERE name = ?`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return site, err
+}
+
+func (s *sqliteStore) Close() error { return s.db.Close() }
+
+// scanSite decodes the data column of a sites row
+func scanSite(row interface{ Scan(...any) error }) (*Site, error) {
+	var data string
+	if err := row.Scan(&data); err != nil {
+		return nil, err
+	}
+
+	var site Site
+	if err := json.Unmarshal([]byte(data), &site); err != nil {
+		return nil, fmt.Errorf("failed to parse site: %w", err)
+	}
+	return &site, nil
+}
+
+func recordHistory(tx *sql.Tx, name, now, action, data string) error {
+	if _, err := tx.Exec(`INSERT INTO site_history (name, time, action, data) VALUES (?, ?, ?, NULLIF(?, ''))`,
+		name, now, action, data); err != nil {
+		return fmt.Errorf("failed to record history for %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Registry backends for the registry setting in config.yaml
+const (
+	RegistryJSON   = "json"
+	RegistrySQLite = "sqlite"
+)
+
+// SiteStore persists the site registry
+type SiteStore interface {
+	// Load returns every registered site
+	Load() (*SiteRegistry, error)
+
+	// Save replaces the stored registry with this one. SaveSites only
+	// hands it the store's own sites with this process's changes applied.
+	Save(registry *SiteRegistry) error
+
+	// Find returns one site by name, or nil if it isn't registered
+	Find(name string) (*Site, error)
+
+	// Close releases the store
+	Close() error
+}
+
+// OpenSiteStore opens the registry backend selected in config.yaml
+func OpenSiteStore() (SiteStore, error) {
+	return openSiteStore(false)
+}
+
+// openSiteStore opens the registry backend. locked means the caller holds
+// the registry's exclusive lock (see withSiteStore), so the store doesn't
+// take it again.
+func openSiteStore(locked bool) (SiteStore, error) {
+	paths, err := GetPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Registry {
+	case "", RegistryJSON:
+		return &jsonStore{path: paths.Sites, locked: locked}, nil
+	case RegistrySQLite:
+		return openSQLiteStore(paths, locked)
+	default:
+		return nil, fmt.Errorf("unknown registry %q (use json or sqlite)", cfg.Registry)
+	}
+}
+
+// jsonStore keeps the registry in sites.json, rewritten on every save
+type jsonStore struct {
+	path   string
+	locked bool // The caller holds the exclusive lock
+}
+
+// lock takes the registry's lock, unless the caller already holds it
+func (s *jsonStore) lock(exclusive bool) (func(), error) {
+	if s.locked {
+		return func() {}, nil
+	}
+	return lockFile(s.path, exclusive)
+}
+
+func (s *jsonStore) Load() (*SiteRegistry, error) {
+	// Read under a shared lock so a concurrent save can't be seen halfway
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path)
+	unlock()
+
+	// If sites file doesn't exist, return empty registry
+	if os.IsNotExist(err) {
+		registry := NewSiteRegistry()
+		registry.remember()
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sites file: %w", err)
+	}
+
+	// Parse JSON
+	var registry SiteRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse sites file: %w", err)
+	}
+	registry.remember()
+
+	return &registry, nil
+}
+
+func (s *jsonStore) Save(registry *SiteRegistry) error {
+	paths, err := GetPaths()
+	if err != nil {
+		return err
+	}
+
+	// Ensure directories exist
+	if err := paths.EnsureDirectories(); err != nil {
+		return err
+	}
+
+	// Convert to pretty JSON
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sites: %w", err)
+	}
+
+	// Write to file (0644 = rw-r--r--) under an exclusive lock
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := writeFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sites file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *jsonStore) Find(name string) (*Site, error) {
+	registry, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	return registry.FindSite(name), nil
+}
+
+func (s *jsonStore) Close() error { return nil }
@@ -1,5 +1,13 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Config represents the main PHPark configuration
 type Config struct {
 	// DefaultPHP is the default PHP version to use (e.g., "8.2", "8.3")
@@ -13,6 +21,180 @@ type Config struct {
 
 	// UseHTTPS indicates if sites should use HTTPS by default
 	UseHTTPS bool `json:"use_https" yaml:"use_https"`
+
+	// FPMStatus exposes the PHP-FPM status and ping pages on every site
+	// (restricted to localhost) for use with `phppark fpm:status`
+	FPMStatus bool `json:"fpm_status" yaml:"fpm_status"`
+
+	// ErrorPages replaces nginx's bare 502/504 on PHP sites with a page
+	// saying which PHP-FPM didn't answer, the end of its log and the
+	// command that restarts it
+	ErrorPages bool `json:"error_pages" yaml:"error_pages"`
+
+	// DumpServer is where var-dump-server listens while `phppark
+	// dump-server start` runs it; sites get it as VAR_DUMPER_SERVER
+	DumpServer string `json:"dump_server,omitempty" yaml:"dump_server,omitempty"`
+
+	// RuntimeStatus is the localhost address (e.g. "127.0.0.1:8089") nginx
+	// serves the status pages `phppark status --runtime` reads on. Empty
+	// leaves them out of the global include.
+	RuntimeStatus string `json:"runtime_status,omitempty" yaml:"runtime_status,omitempty"`
+
+	// Database configures the MySQL/MariaDB server used by the db:* commands
+	Database DatabaseConfig `json:"database" yaml:"database"`
+
+	// WebServer is the backend that serves sites: "nginx" (default), "apache"
+	// or "frankenphp"
+	WebServer string `json:"web_server" yaml:"web_server"`
+
+	// Backend is where nginx and PHP-FPM run: "native" (default, the
+	// distro's packages via systemd) or "docker" (containers via the Docker API)
+	Backend string `json:"backend" yaml:"backend"`
+
+	// Notify is how the health watcher reports failures: "desktop" (default,
+	// notify-send), "off", or a command run with the title and message as
+	// arguments
+	Notify string `json:"notify" yaml:"notify"`
+
+	// Permissions controls how PHPark makes site files readable by the
+	// web server
+	Permissions PermissionsConfig `json:"permissions" yaml:"permissions"`
+
+	// Registry is where sites are stored: "json" (default, sites.json) or
+	// "sqlite" (sites.db, with per-site history; imports sites.json on first use)
+	Registry string `json:"registry" yaml:"registry"`
+
+	// HTTPPort and HTTPSPort are where nginx serves sites (default 80 and
+	// 443). Moving them lets PHPark run next to an Apache that keeps the
+	// standard ports; site URLs then carry the port.
+	HTTPPort  int `json:"http_port" yaml:"http_port"`
+	HTTPSPort int `json:"https_port" yaml:"https_port"`
+
+	// HTTPSRedirect makes secured sites answer plain HTTP with a redirect to
+	// HTTPS (per site, `phppark secure --no-redirect` turns it off)
+	HTTPSRedirect bool `json:"https_redirect" yaml:"https_redirect"`
+
+	// DNS configures how site hostnames resolve
+	DNS DNSConfig `json:"dns" yaml:"dns"`
+
+	// LAN also serves sites as <site>.<hostname>.<suffix> for colleagues
+	// on the same network
+	LAN LANConfig `json:"lan" yaml:"lan"`
+
+	// Parked maps each parked directory to the options it was parked with,
+	// so folders that appear there later (see watch-config) get the same
+	Parked map[string]ParkedDir `json:"parked,omitempty" yaml:"parked,omitempty"`
+
+	// FPMListen maps PHP versions to where their PHP-FPM pool listens when
+	// it isn't the distro's socket: a socket path, host:port, or "tcp" for
+	// 127.0.0.1:90<version> (e.g., "7.4": tcp is 127.0.0.1:9074)
+	FPMListen map[string]string `json:"fpm_listen,omitempty" yaml:"fpm_listen,omitempty"`
+
+	// Nginx points PHPark at a custom nginx install (OpenResty, a build in
+	// /opt). Empty fields keep the Debian/Ubuntu package layout.
+	Nginx NginxConfig `json:"nginx" yaml:"nginx"`
+
+	// MultiUser is for machines several people run PHPark on: sites are
+	// served as <site>.<user>.<domain>, and the nginx configs, logs and
+	// systemd units PHPark puts in shared places carry the user's name so
+	// users with the same site names don't overwrite each other
+	MultiUser bool `json:"multi_user" yaml:"multi_user"`
+
+	// DocRootCandidates are the directories looked for, in order, to find a
+	// site's document root (default: public, public_html, web, htdocs).
+	// A site with none of them is served from its project root.
+	DocRootCandidates []string `json:"docroot_candidates,omitempty" yaml:"docroot_candidates,omitempty"`
+
+	// Locale is the language PHPark prints messages in: "es", "fr", "pt"
+	// or "en" ($PHPPARK_LANG overrides it). Empty follows LANG and friends.
+	Locale string `json:"locale,omitempty" yaml:"locale,omitempty"`
+}
+
+// NginxConfig describes where nginx is installed
+type NginxConfig struct {
+	// Binary is the nginx executable (default: nginx on PATH)
+	Binary string `json:"binary,omitempty" yaml:"binary,omitempty"`
+
+	// Service is the systemd service that runs it (default: nginx)
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+
+	// ConfDir holds nginx.conf (default: /etc/nginx)
+	ConfDir string `json:"conf_dir,omitempty" yaml:"conf_dir,omitempty"`
+
+	// IncludeDir is a directory nginx.conf includes (e.g., conf.d). When
+	// set, site configs are written there instead of
+	// sites-available/sites-enabled.
+	IncludeDir string `json:"include_dir,omitempty" yaml:"include_dir,omitempty"`
+}
+
+// ParkedDir holds the `phppark park` options of a parked directory
+type ParkedDir struct {
+	PHPVersion string   `json:"php,omitempty" yaml:"php,omitempty"`
+	Secure     bool     `json:"secure,omitempty" yaml:"secure,omitempty"`
+	WithDB     bool     `json:"with_db,omitempty" yaml:"with_db,omitempty"`
+	Exclude    []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	Template   string   `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+// DNSConfig holds the DNS settings used by `phppark trust`
+type DNSConfig struct {
+	// Driver is "dnsmasq" (default: dnsmasq on port 53, taking over from the
+	// systemd-resolved stub listener), "resolved" (PHPark's own responder
+	// on Port, with systemd-resolved routing only the site domain to it) or
+	// "dnsmasq-resolved" (the same, with dnsmasq answering on Port)
+	Driver string `json:"driver" yaml:"driver"`
+
+	// Port is where the resolved driver's responder, or the
+	// dnsmasq-resolved driver's dnsmasq, listens on 127.0.0.1
+	Port int `json:"port" yaml:"port"`
+
+	// ListenAddress, Interface and BindInterfaces become dnsmasq's
+	// listen-address, interface and bind-interfaces options, to keep it on
+	// loopback or open it to a LAN interface. Empty means dnsmasq's defaults.
+	ListenAddress  []string `json:"listen_address,omitempty" yaml:"listen_address,omitempty"`
+	Interface      []string `json:"interface,omitempty" yaml:"interface,omitempty"`
+	BindInterfaces bool     `json:"bind_interfaces,omitempty" yaml:"bind_interfaces,omitempty"`
+}
+
+// LANConfig holds the settings for serving sites to the local network
+type LANConfig struct {
+	// Enabled adds <site>.<hostname>.<Suffix> to every site's server
+	// names and certificate, and has PHPark's LAN responder answer for it
+	// on Address
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Suffix is the domain after the hostname (default: "lan")
+	Suffix string `json:"suffix,omitempty" yaml:"suffix,omitempty"`
+
+	// Address is the LAN address names resolve to (default: the address
+	// of the interface with the default route)
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+// PermissionsConfig holds the site permission settings
+type PermissionsConfig struct {
+	// Mode is "chmod" (default: add missing read bits), "acl" (grant the
+	// web server user access with setfacl) or "off"
+	Mode string `json:"mode" yaml:"mode"`
+
+	// Skip lists directory names left untouched anywhere in a site
+	Skip []string `json:"skip" yaml:"skip"`
+}
+
+// DatabaseConfig holds MySQL/MariaDB connection settings
+type DatabaseConfig struct {
+	// Host and Port are what sites connect to (written to .env output)
+	Host string `json:"host" yaml:"host"`
+	Port int    `json:"port" yaml:"port"`
+
+	// AdminUser creates and drops databases. With no AdminPassword PHPark
+	// connects over the unix socket (root via sudo).
+	AdminUser     string `json:"admin_user" yaml:"admin_user"`
+	AdminPassword string `json:"admin_password,omitempty" yaml:"admin_password,omitempty"`
+
+	// Charset and Collation are used for new databases
+	Charset   string `json:"charset" yaml:"charset"`
+	Collation string `json:"collation" yaml:"collation"`
 }
 
 // Site represents a single parked or linked site
@@ -32,11 +214,168 @@ type Site struct {
 
 	// Secured indicates if the site uses HTTPS
 	Secured bool `json:"secured"`
+
+	// NoRedirect keeps a secured site answering plain HTTP instead of
+	// redirecting it to HTTPS
+	NoRedirect bool `json:"no_redirect,omitempty"`
+
+	// Env holds environment variables passed to PHP as fastcgi_param entries
+	Env map[string]string `json:"env,omitempty"`
+
+	// Database is the name of the database created for this site, if any
+	Database string `json:"database,omitempty"`
+
+	// DatabaseUser is the dedicated database user for this site, if any
+	DatabaseUser string `json:"database_user,omitempty"`
+
+	// Proxy is the upstream URL for proxied sites (e.g., "http://127.0.0.1:8025").
+	// When set, nginx proxies requests instead of serving PHP.
+	Proxy string `json:"proxy,omitempty"`
+
+	// Workers are queue workers supervised by systemd for this site
+	Workers []Worker `json:"workers,omitempty"`
+
+	// Scheduler runs `php artisan schedule:run` every minute when enabled
+	Scheduler bool `json:"scheduler,omitempty"`
+
+	// Processes are other long-running commands supervised for this site
+	// (e.g., Horizon, websocket servers, npm run dev)
+	Processes []Process `json:"processes,omitempty"`
+
+	// Octane marks a Laravel Octane site: PHPark supervises the Octane
+	// server on Port and nginx proxies to it instead of PHP-FPM
+	Octane bool `json:"octane,omitempty"`
+
+	// Builtin marks a site served by a supervised `php -S` on Port instead
+	// of the web server (for machines without nginx or root)
+	Builtin bool `json:"builtin,omitempty"`
+
+	// Disabled takes a site offline while keeping it registered, with its
+	// config and certificate installed (`phppark disable`)
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Port is the local port the site's application server listens on
+	Port int `json:"port,omitempty"`
+
+	// Tags group sites (e.g., "client-x") so commands can act on them together
+	Tags []string `json:"tags,omitempty"`
+
+	// Description is a free-text note about the site (e.g., "client X
+	// staging clone"), set with `phppark note`
+	Description string `json:"description,omitempty"`
+
+	// ParkedIn is the parked directory a "park" site was found in
+	ParkedIn string `json:"parked_in,omitempty"`
+
+	// CreatedAt and UpdatedAt are set by SaveSites when the site is first
+	// registered and whenever its settings change
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	// LastSeen is the last time the site answered a health check
+	// (`phppark test` or the watcher). It's kept in seen.json rather than
+	// the registry, which the watcher would otherwise rewrite every round.
+	LastSeen *time.Time `json:"-"`
+
+	// MaxBody is the largest request body the site accepts, in nginx size
+	// syntax (e.g., "512M"). Empty keeps the web server's default.
+	MaxBody string `json:"max_body,omitempty"`
+
+	// Timeout is how many seconds a request may run before the web server
+	// gives up on PHP or the upstream (0 keeps the default)
+	Timeout int `json:"timeout,omitempty"`
+
+	// ThrottleRate caps how fast responses are sent, in nginx size syntax
+	// per second (e.g., "512k"), to mimic a slow connection
+	ThrottleRate string `json:"throttle_rate,omitempty"`
+
+	// ThrottleDelay is how many milliseconds PHP waits before handling each
+	// request, to mimic a slow network's latency
+	ThrottleDelay int `json:"throttle_delay_ms,omitempty"`
+
+	// Profiler is the profiling extension set up for the site ("spx",
+	// "xhprof" or "blackfire"), loaded through its own PHP-FPM when the
+	// shared one can't
+	Profiler string `json:"profiler,omitempty"`
+
+	// Preload is the opcache preload script, relative to Path, that the
+	// site's own PHP-FPM runs at startup (empty for none)
+	Preload string `json:"preload,omitempty"`
+
+	// ExpiresAt marks a temporary site from `phppark serve`, unlinked
+	// automatically once this time passes
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// DocRoot is the document root relative to Path, when it's set rather
+	// than found from the docroot candidates
+	DocRoot string `json:"docroot,omitempty"`
+
+	// DocRootCandidates replaces the configured docroot_candidates for
+	// this site
+	DocRootCandidates []string `json:"docroot_candidates,omitempty"`
+
+	// FPM is a PHP-FPM the site uses instead of the local one for its PHP
+	// version, e.g., in a container: host:port or a socket path
+	FPM string `json:"fpm,omitempty"`
+
+	// FPMRoot is where the site directory is mounted for that PHP-FPM
+	// (e.g., "/var/www/html"), so script paths can be mapped. Empty means
+	// the same path as on the host.
+	FPMRoot string `json:"fpm_root,omitempty"`
+
+	// Template is the framework template the site's web server config uses
+	// (e.g., "wordpress"). Empty means detect it from the site's files.
+	Template string `json:"template,omitempty"`
+
+	// Mounts are other apps served under paths of the site (e.g., a
+	// WordPress blog at /blog), each from its own directory
+	Mounts []Mount `json:"mounts,omitempty"`
+}
+
+// Mount is an app served under a path of a site
+type Mount struct {
+	// Path is the URL path it's served at (e.g., "/blog")
+	Path string `json:"path"`
+
+	// Dir is the app's directory; its document root is found in it the
+	// way a site's is
+	Dir string `json:"dir"`
+
+	// PHPVersion runs the app on another PHP version than the site's
+	PHPVersion string `json:"php_version,omitempty"`
+}
+
+// Process is a long-running command supervised alongside a site
+type Process struct {
+	// Name identifies the process within its site (e.g., "horizon")
+	Name string `json:"name"`
+
+	// Command is run from the site directory through /bin/sh.
+	// A leading "php" is replaced with the site's PHP version.
+	Command string `json:"command"`
+}
+
+// Worker is a long-running queue worker command for a site
+type Worker struct {
+	// Name identifies the worker within its site (e.g., "1", "emails")
+	Name string `json:"name"`
+
+	// Command is run from the site directory (e.g., "php artisan queue:work").
+	// A leading "php" is replaced with the site's PHP version.
+	Command string `json:"command"`
+
+	// Count is the number of identical processes to run
+	Count int `json:"count"`
 }
 
 // SiteRegistry holds all registered sites
 type SiteRegistry struct {
 	Sites []Site `json:"sites"`
+
+	// What the store held when this registry was loaded, and the sites
+	// removed since, so a save only writes what this process changed
+	loaded  map[string]string
+	removed map[string]bool
 }
 
 // DefaultConfig returns a new Config with sensible defaults
@@ -46,7 +385,118 @@ func DefaultConfig() *Config {
 		Domain:          "test",
 		NginxConfigPath: "/etc/nginx/sites-enabled",
 		UseHTTPS:        false,
+		WebServer:       "nginx",
+		Backend:         "native",
+		Notify:          "desktop",
+		Registry:        "json",
+		HTTPPort:        80,
+		HTTPSPort:       443,
+		HTTPSRedirect:   true,
+		DNS: DNSConfig{
+			Driver: "dnsmasq",
+			Port:   5353,
+		},
+		Permissions: PermissionsConfig{
+			Mode: "chmod",
+			Skip: []string{"vendor", "node_modules", ".git"},
+		},
+		Database: DatabaseConfig{
+			Host:      "127.0.0.1",
+			Port:      3306,
+			AdminUser: "root",
+			Charset:   "utf8mb4",
+			Collation: "utf8mb4_unicode_ci",
+		},
+	}
+}
+
+// SitePorts returns the ports sites are served on. Only the nginx backend
+// moves off the standard ports.
+func (c *Config) SitePorts() (http, https int) {
+	if c.WebServer != "" && c.WebServer != "nginx" {
+		return 80, 443
+	}
+
+	http, https = c.HTTPPort, c.HTTPSPort
+	if http == 0 {
+		http = 80
+	}
+	if https == 0 {
+		https = 443
+	}
+	return http, https
+}
+
+// SiteDomain returns the domain sites are served under: Domain, or
+// <user>.<Domain> in multi-user mode
+func (c *Config) SiteDomain() string {
+	if c.MultiUser {
+		if namespace := Namespace(); namespace != "" {
+			return namespace + "." + c.Domain
+		}
+	}
+	return c.Domain
+}
+
+// LANDomain returns the domain sites are also served under on the LAN,
+// <hostname>.<suffix>, or "" when LAN serving is off
+func (c *Config) LANDomain() string {
+	if !c.LAN.Enabled {
+		return ""
+	}
+	suffix := c.LAN.Suffix
+	if suffix == "" {
+		suffix = "lan"
+	}
+	if c.MultiUser {
+		if namespace := Namespace(); namespace != "" {
+			return namespace + "." + Hostname() + "." + suffix
+		}
+	}
+	return Hostname() + "." + suffix
+}
+
+// FPMListenFor returns where a PHP version's PHP-FPM listens according to
+// fpm_listen, or "" for the distro's socket
+func (c *Config) FPMListenFor(phpVersion string) string {
+	listen := c.FPMListen[phpVersion]
+	if listen == "tcp" {
+		// An out-of-range port is refused when the config is loaded
+		address, _ := fpmTCPAddress(phpVersion)
+		return address
+	}
+	return listen
+}
+
+// fpmTCPAddress returns the address fpm_listen's "tcp" stands for: port
+// 90 followed by the version's digits (9074 for 7.4), as long as that's
+// a port at all
+func fpmTCPAddress(phpVersion string) (string, error) {
+	port, err := strconv.Atoi("90" + strings.ReplaceAll(phpVersion, ".", ""))
+	if err != nil || port > 65535 {
+		return "", fmt.Errorf("fpm_listen: \"tcp\" for PHP %s isn't a valid port (give its host:port instead)", phpVersion)
 	}
+	return "127.0.0.1:" + strconv.Itoa(port), nil
+}
+
+// checkFPMListen rejects fpm_listen entries that aren't "tcp", a socket
+// path or a host:port with a port between 1 and 65535
+func (c *Config) checkFPMListen() error {
+	for version, listen := range c.FPMListen {
+		switch {
+		case listen == "tcp":
+			if _, err := fpmTCPAddress(version); err != nil {
+				return err
+			}
+		case strings.HasPrefix(listen, "/"):
+		default:
+			_, port, err := net.SplitHostPort(listen)
+			if n, convErr := strconv.Atoi(port); err != nil || convErr != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("fpm_listen: '%s' for PHP %s isn't tcp, a socket path or a host:port with a port from 1 to 65535", listen, version)
+			}
+		}
+	}
+	return nil
 }
 
 // NewSiteRegistry creates an empty site registry
@@ -78,6 +528,7 @@ func (sr *SiteRegistry) AddSite(site Site) {
 	}
 	// Add new site
 	sr.Sites = append(sr.Sites, site)
+	delete(sr.removed, site.Name)
 }
 
 // RemoveSite removes a site from the registry
@@ -86,12 +537,104 @@ func (sr *SiteRegistry) RemoveSite(name string) bool {
 		if sr.Sites[i].Name == name {
 			// Remove by slicing
 			sr.Sites = append(sr.Sites[:i], sr.Sites[i+1:]...)
+			if sr.removed == nil {
+				sr.removed = make(map[string]bool)
+			}
+			sr.removed[name] = true
+			return true
+		}
+	}
+	return false
+}
+
+// FindWorker returns a site's worker by name
+func (s *Site) FindWorker(name string) *Worker {
+	for i := range s.Workers {
+		if s.Workers[i].Name == name {
+			return &s.Workers[i]
+		}
+	}
+	return nil
+}
+
+// RemoveWorker removes a worker from a site
+func (s *Site) RemoveWorker(name string) bool {
+	for i := range s.Workers {
+		if s.Workers[i].Name == name {
+			s.Workers = append(s.Workers[:i], s.Workers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FindProcess returns a site's process by name
+func (s *Site) FindProcess(name string) *Process {
+	for i := range s.Processes {
+		if s.Processes[i].Name == name {
+			return &s.Processes[i]
+		}
+	}
+	return nil
+}
+
+// RemoveProcess removes a process from a site
+func (s *Site) RemoveProcess(name string) bool {
+	for i := range s.Processes {
+		if s.Processes[i].Name == name {
+			s.Processes = append(s.Processes[:i], s.Processes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FindMount returns a site's mount at a URL path, or nil
+func (s *Site) FindMount(path string) *Mount {
+	for i := range s.Mounts {
+		if s.Mounts[i].Path == path {
+			return &s.Mounts[i]
+		}
+	}
+	return nil
+}
+
+// RemoveMount removes a site's mount at a URL path
+func (s *Site) RemoveMount(path string) bool {
+	for i := range s.Mounts {
+		if s.Mounts[i].Path == path {
+			s.Mounts = append(s.Mounts[:i], s.Mounts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether a site carries a tag
+func (s *Site) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
 			return true
 		}
 	}
 	return false
 }
 
+// Tagged returns the sites carrying a tag, or every site when tag is empty
+func (sr *SiteRegistry) Tagged(tag string) []Site {
+	if tag == "" {
+		return sr.Sites
+	}
+
+	var sites []Site
+	for _, site := range sr.Sites {
+		if site.HasTag(tag) {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
 // ListSites returns all sites
 func (sr *SiteRegistry) ListSites() []Site {
 	return sr.Sites
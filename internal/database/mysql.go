@@ -0,0 +1,229 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// Server describes how PHPark connects to MySQL/MariaDB as an administrator
+type Server struct {
+	Host          string // e.g., "127.0.0.1" or "localhost" (unix socket)
+	Port          int    // e.g., 3306
+	AdminUser     string // e.g., "root"
+	AdminPassword string // empty uses unix socket auth (sudo)
+}
+
+// systemDatabases are never reported as site databases
+var systemDatabases = map[string]bool{
+	"information_schema": true,
+	"mysql":              true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_]`)
+
+var charsetPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// DatabaseName converts a site name into a valid database identifier
+// (e.g., "my-app" → "my_app")
+func DatabaseName(siteName string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(siteName), "_")
+	// MySQL identifiers are limited to 64 characters
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// UserName returns the dedicated user name for a database. MySQL user
+// names are limited to 32 characters.
+func UserName(dbName string) string {
+	if len(dbName) > 32 {
+		return dbName[:32]
+	}
+	return dbName
+}
+
+// GeneratePassword returns a random password suitable for a local database user
+func GeneratePassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// IsInstalled checks if the mysql client is available
+func IsInstalled() bool {
+	_, err := exec.LookPath("mysql")
+	return err == nil
+}
+
+// DefaultCollation returns the collation new databases get for a charset
+// when none is configured
+func DefaultCollation(charset string) string {
+	switch charset {
+	case "binary":
+		return "binary"
+	case "utf8mb4":
+		return "utf8mb4_unicode_ci"
+	}
+	return charset + "_general_ci"
+}
+
+// CheckCollation rejects a collation that doesn't belong to the charset.
+// MySQL names collations after their charset, and the binary charset's
+// only collation is binary.
+func CheckCollation(charset, collation string) error {
+	if !charsetPattern.MatchString(charset) || !charsetPattern.MatchString(collation) {
+		return fmt.Errorf("invalid charset or collation: %s / %s", charset, collation)
+	}
+	charset, collation = strings.ToLower(charset), strings.ToLower(collation)
+	if charset == "binary" || collation == "binary" {
+		if charset != collation {
+			return fmt.Errorf("collation %s doesn't go with charset %s (the binary charset only has the binary collation)", collation, charset)
+		}
+		return nil
+	}
+	// utf8 is an alias of utf8mb3, whose collations go by either name
+	if charset == "utf8" && strings.HasPrefix(collation, "utf8mb3_") {
+		return nil
+	}
+	if !strings.HasPrefix(collation, charset+"_") {
+		return fmt.Errorf("collation %s doesn't belong to charset %s (use a %s_* collation)", collation, charset, charset)
+	}
+	return nil
+}
+
+// CreateDatabase creates a database if it doesn't already exist
+func (s *Server) CreateDatabase(name, charset, collation string) error {
+	if err := CheckCollation(charset, collation); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s CHARACTER SET %s COLLATE %s;",
+		quoteIdent(name), charset, collation)
+
+	if _, err := s.exec(query); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropDatabase drops a database if it exists
+func (s *Server) DropDatabase(name string) error {
+	query := fmt.Sprintf("DROP DATABASE IF EXISTS %s;", quoteIdent(name))
+
+	if _, err := s.exec(query); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// userHosts are the hosts a site user can connect from: the socket and
+// loopback TCP, so it isn't reachable from anywhere else that can reach
+// MySQL
+var userHosts = []string{"localhost", "127.0.0.1"}
+
+// CreateUser creates a user with full privileges on a single database,
+// replacing one a previous version created for any host ('%')
+func (s *Server) CreateUser(user, password, dbName string) error {
+	query := fmt.Sprintf("DROP USER IF EXISTS %s@'%%'; ", quoteString(user))
+	for _, host := range userHosts {
+		account := quoteString(user) + "@" + quoteString(host)
+		query += fmt.Sprintf(
+			"CREATE USER IF NOT EXISTS %s IDENTIFIED BY %s; "+
+				"ALTER USER %s IDENTIFIED BY %s; "+
+				"GRANT ALL PRIVILEGES ON %s.* TO %s; ",
+			account, quoteString(password),
+			account, quoteString(password),
+			quoteIdent(dbName), account)
+	}
+	query += "FLUSH PRIVILEGES;"
+
+	if _, err := s.exec(query); err != nil {
+		return fmt.Errorf("failed to create user %s: %w", user, err)
+	}
+	return nil
+}
+
+// DropUser removes a user if it exists, along with one a previous version
+// created for any host
+func (s *Server) DropUser(user string) error {
+	query := fmt.Sprintf("DROP USER IF EXISTS %s@'%%'", quoteString(user))
+	for _, host := range userHosts {
+		query += fmt.Sprintf(", %s@%s", quoteString(user), quoteString(host))
+	}
+	query += ";"
+
+	if _, err := s.exec(query); err != nil {
+		return fmt.Errorf("failed to drop user %s: %w", user, err)
+	}
+	return nil
+}
+
+// ListDatabases returns all non-system databases
+func (s *Server) ListDatabases() ([]string, error) {
+	output, err := s.exec("SHOW DATABASES;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	var databases []string
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || systemDatabases[name] {
+			continue
+		}
+		databases = append(databases, name)
+	}
+
+	return databases, nil
+}
+
+// exec runs a query through the mysql client and returns its raw output
+func (s *Server) exec(query string) (string, error) {
+	args := []string{"--batch", "--skip-column-names"}
+	if s.Host != "" && s.Host != "localhost" {
+		args = append(args, "--host", s.Host, "--port", fmt.Sprint(s.Port))
+	}
+	if s.AdminUser != "" {
+		args = append(args, "--user", s.AdminUser)
+	}
+	args = append(args, "--execute", query)
+
+	cmd := exec.Command("mysql", args...)
+	if s.AdminPassword != "" {
+		// Keep the password off the process list
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+s.AdminPassword)
+	}
+
+	// Queries may carry passwords, so only the fact that one ran is logged
+	output, err := cmd.CombinedOutput()
+	oplog.Exec("mysql", nil, err)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return string(output), nil
+}
+
+// quoteIdent quotes a MySQL identifier with backticks
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteString quotes a MySQL string literal
+func quoteString(value string) string {
+	escaped := strings.ReplaceAll(value, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `'`, `\'`)
+	return "'" + escaped + "'"
+}
@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	resolvConf   = "/etc/resolv.conf"
+	nsswitchConf = "/etc/nsswitch.conf"
+	queryTimeout = 2 * time.Second
+)
+
+// ResolvedStubAddr and DnsmasqAddr are where the systemd-resolved stub
+// listener and dnsmasq answer queries
+const (
+	ResolvedStubAddr = "127.0.0.53:53"
+	DnsmasqAddr      = "127.0.0.1:53"
+)
+
+// ResolvConf returns where /etc/resolv.conf points (empty if it's a plain
+// file) and the nameservers it lists
+func ResolvConf() (target string, nameservers []string, err error) {
+	if link, err := os.Readlink(resolvConf); err == nil {
+		target = link
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(resolvConf), target)
+		}
+	}
+
+	file, err := os.Open(resolvConf)
+	if err != nil {
+		return target, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			nameservers = append(nameservers, fields[1])
+		}
+	}
+	return target, nameservers, scanner.Err()
+}
+
+// NSSHosts returns the sources on the hosts: line of /etc/nsswitch.conf
+// (e.g., "files mdns4_minimal [NOTFOUND=return] dns")
+func NSSHosts() (string, error) {
+	data, err := os.ReadFile(nsswitchConf)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "hosts:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "hosts:")), nil
+		}
+	}
+	return "", nil
+}
+
+// QueryServer asks one DNS server for a name's addresses, bypassing the
+// system resolver configuration
+func QueryServer(addr, name string) ([]string, error) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: queryTimeout}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	return resolver.LookupHost(ctx, name)
+}
+
+// SystemResolve resolves a name the way applications do, through NSS
+func SystemResolve(name string) ([]string, error) {
+	output, err := exec.Command("getent", "ahostsv4", name).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !seen[fields[0]] {
+			seen[fields[0]] = true
+			addrs = append(addrs, fields[0])
+		}
+	}
+	return addrs, nil
+}
+
+// PortListeners lists the sockets listening on a local port as
+// "address (process)" entries, using ss. Process names need root.
+func PortListeners(port string) ([]string, error) {
+	output, err := exec.Command("ss", "-H", "-lntup", "sport = :"+port).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+
+		entry := fields[0] + " " + fields[4]
+		if process := fields[len(fields)-1]; strings.HasPrefix(process, "users:") {
+			if start := strings.Index(process, "((\""); start >= 0 {
+				name := process[start+3:]
+				if end := strings.Index(name, "\""); end >= 0 {
+					entry += " (" + name[:end] + ")"
+				}
+			}
+		}
+		listeners = append(listeners, entry)
+	}
+	return listeners, nil
+}
@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+const (
+	hostsFile   = "/etc/hosts"
+	hostsMarker = "# phppark"
+)
+
+// AddHostsEntry maps hostname to 127.0.0.1 in /etc/hosts, for machines
+// without dnsmasq. Requires root.
+func AddHostsEntry(hostname string) error {
+	lines, err := readHosts()
+	if err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf("127.0.0.1 %s %s", hostname, hostsMarker)
+	for _, line := range lines {
+		if line == entry {
+			return nil // Already mapped
+		}
+	}
+
+	return writeHosts(append(lines, entry))
+}
+
+// RemoveHostsEntry removes a hostname mapping added by AddHostsEntry
+func RemoveHostsEntry(hostname string) error {
+	lines, err := readHosts()
+	if err != nil {
+		return err
+	}
+
+	entry := fmt.Sprintf("127.0.0.1 %s %s", hostname, hostsMarker)
+	kept := lines[:0]
+	for _, line := range lines {
+		if line != entry {
+			kept = append(kept, line)
+		}
+	}
+
+	if len(kept) == len(lines) {
+		return nil
+	}
+	return writeHosts(kept)
+}
+
+func readHosts() ([]string, error) {
+	data, err := os.ReadFile(hostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", hostsFile, err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+func writeHosts(lines []string) error {
+	content := strings.Join(lines, "\n") + "\n"
+	if err := oplog.WriteFile(hostsFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", hostsFile, err)
+	}
+	return nil
+}
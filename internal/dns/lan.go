@@ -0,0 +1,58 @@
+package dns
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// LANAddress returns the address of the interface with the default route,
+// the one colleagues on the same network reach this machine on. Dialing
+// UDP sends nothing; it only picks the route.
+func LANAddress() (string, error) {
+	conn, err := net.Dial("udp4", "192.0.2.1:53")
+	if err != nil {
+		return "", fmt.Errorf("no default route: %w", err)
+	}
+	defer conn.Close()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || addr.IP.IsLoopback() {
+		return "", fmt.Errorf("no LAN address found")
+	}
+	return addr.IP.String(), nil
+}
+
+// SetupLANDNS has dnsmasq answer <anything>.<domain> with addr, so sites
+// served under the LAN domain resolve for this machine. Colleagues ask the
+// LAN responder instead. Restart dnsmasq to apply (requires sudo).
+func SetupLANDNS(domain, addr string) error {
+	if _, err := exec.LookPath("dnsmasq"); err != nil {
+		return i18n.Errorf(i18n.DnsmasqNotFound)
+	}
+
+	configPath := fmt.Sprintf("/etc/dnsmasq.d/%s", domain)
+	content := fmt.Sprintf("address=/.%s/%s\n", domain, addr)
+
+	cmd := exec.Command("sudo", "tee", configPath)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = io.Discard
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to create dnsmasq config: %w", err)
+	}
+	return nil
+}
+
+// RemoveLANDNS removes the LAN domain's dnsmasq config (requires sudo)
+func RemoveLANDNS(domain string) error {
+	configPath := fmt.Sprintf("/etc/dnsmasq.d/%s", domain)
+	if err := oplog.Run(exec.Command("sudo", "rm", "-f", configPath)); err != nil {
+		return fmt.Errorf("failed to remove dnsmasq config: %w", err)
+	}
+	return nil
+}
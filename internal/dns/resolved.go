@@ -0,0 +1,80 @@
+package dns
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// resolvedDropIn routes the site domain to PHPark's responder, or to
+// dnsmasq on its alternate port
+const resolvedDropIn = "/etc/systemd/resolved.conf.d/phppark.conf"
+
+// IsSystemdResolvedActive reports whether systemd-resolved is running
+func IsSystemdResolvedActive() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", "systemd-resolved").Run() == nil
+}
+
+// SetupResolvedDNS has systemd-resolved send queries for domain (and
+// nothing else) to a server on 127.0.0.1:port. The stub listener and
+// /etc/resolv.conf are left alone, so VPN split DNS keeps working.
+func SetupResolvedDNS(domain string, port int) error {
+	if !IsSystemdResolvedActive() {
+		return fmt.Errorf("systemd-resolved is not running (this DNS driver needs it; use driver: dnsmasq instead)")
+	}
+
+	content := fmt.Sprintf("# Managed by PHPark\n[Resolve]\nDNS=127.0.0.1:%d\nDomains=~%s\n", port, domain)
+
+	if err := oplog.Run(exec.Command("sudo", "mkdir", "-p", filepath.Dir(resolvedDropIn))); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(resolvedDropIn), err)
+	}
+
+	cmd := exec.Command("sudo", "tee", resolvedDropIn)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = io.Discard
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to write %s: %w", resolvedDropIn, err)
+	}
+
+	if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "systemd-resolved")); err != nil {
+		return fmt.Errorf("failed to restart systemd-resolved: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveResolvedDNS removes the drop-in written by SetupResolvedDNS
+func RemoveResolvedDNS() error {
+	if err := oplog.Run(exec.Command("sudo", "rm", "-f", resolvedDropIn)); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", resolvedDropIn, err)
+	}
+
+	if IsSystemdResolvedActive() {
+		if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "systemd-resolved")); err != nil {
+			return fmt.Errorf("failed to restart systemd-resolved: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CheckResolvedDNS reports whether systemd-resolved is set up to route
+// domain to PHPark's responder or dnsmasq
+func CheckResolvedDNS(domain string) bool {
+	data, err := os.ReadFile(resolvedDropIn)
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "Domains=~"+domain {
+			return true
+		}
+	}
+	return false
+}
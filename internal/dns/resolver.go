@@ -6,6 +6,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
 const (
@@ -35,7 +38,7 @@ func CheckDNS(domain string) (bool, error) {
 func setupLinuxDNS(domain string) error {
 	// Check if dnsmasq is installed
 	if _, err := exec.LookPath("dnsmasq"); err != nil {
-		return fmt.Errorf("dnsmasq not installed. Install with: sudo apt install dnsmasq")
+		return i18n.Errorf(i18n.DnsmasqNotFound)
 	}
 
 	// Create dnsmasq domain config
@@ -46,12 +49,12 @@ func setupLinuxDNS(domain string) error {
 	cmd := exec.Command("sudo", "tee", configPath)
 	cmd.Stdin = strings.NewReader(content)
 	cmd.Stdout = io.Discard
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to create dnsmasq config: %w", err)
 	}
 
 	// Restart dnsmasq
-	if err := exec.Command("sudo", "systemctl", "restart", "dnsmasq").Run(); err != nil {
+	if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "dnsmasq")); err != nil {
 		return fmt.Errorf("failed to restart dnsmasq: %w", err)
 	}
 
@@ -61,7 +64,7 @@ func setupLinuxDNS(domain string) error {
 func removeLinuxDNS(domain string) error {
 	configPath := fmt.Sprintf("/etc/dnsmasq.d/%s", domain)
 
-	if err := exec.Command("sudo", "rm", "-f", configPath).Run(); err != nil {
+	if err := oplog.Run(exec.Command("sudo", "rm", "-f", configPath)); err != nil {
 		return fmt.Errorf("failed to remove dnsmasq config: %w", err)
 	}
 
@@ -73,8 +76,11 @@ func removeLinuxDNS(domain string) error {
 		}
 	}
 
+	// Drop PHPark's listen settings too
+	oplog.Run(exec.Command("sudo", "rm", "-f", phpParkDnsmasqConf))
+
 	// Restart dnsmasq if it's running
-	exec.Command("sudo", "systemctl", "restart", "dnsmasq").Run()
+	oplog.Run(exec.Command("sudo", "systemctl", "restart", "dnsmasq"))
 
 	return nil
 }
@@ -100,10 +106,10 @@ func CheckSystemdResolvedConflict() bool {
 }
 
 // IsSystemdResolvedStubDisabled returns true if PHPark has previously disabled
-// the stub listener (indicated by the presence of phppark.conf).
+// the stub listener (indicated by upstream servers in phppark.conf).
 func IsSystemdResolvedStubDisabled() bool {
-	_, err := os.Stat(phpParkDnsmasqConf)
-	return err == nil
+	_, upstream := readPhpparkConf()
+	return len(upstream) > 0
 }
 
 // DisableSystemdResolvedStub disables only the DNS stub listener in systemd-resolved,
@@ -123,18 +129,15 @@ func DisableSystemdResolvedStub() error {
 
 	// 2. Restart (not stop/disable) systemd-resolved so it re-reads the config.
 	//    It continues running and managing upstream DNS for DHCP/VPN/NetworkManager.
-	if err := exec.Command("sudo", "systemctl", "restart", "systemd-resolved").Run(); err != nil {
+	if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "systemd-resolved")); err != nil {
 		return fmt.Errorf("failed to restart systemd-resolved: %w", err)
 	}
 
 	// 3. Write /etc/dnsmasq.d/phppark.conf pointing dnsmasq at systemd-resolved's
 	//    live upstream file. This prevents a loop: without this, dnsmasq would read
 	//    /etc/resolv.conf (which we're about to set to 127.0.0.1) and forward to itself.
-	upstreamConf := buildDnsmasqUpstreamConf()
-	cmd := exec.Command("sudo", "tee", phpParkDnsmasqConf)
-	cmd.Stdin = strings.NewReader(upstreamConf)
-	cmd.Stdout = io.Discard
-	if err := cmd.Run(); err != nil {
+	listen, _ := readPhpparkConf()
+	if err := writePhpparkConf(listen, buildDnsmasqUpstream()); err != nil {
 		return fmt.Errorf("failed to write dnsmasq upstream config: %w", err)
 	}
 
@@ -148,11 +151,11 @@ func DisableSystemdResolvedStub() error {
 			content := "# Managed by PHPark\nnameserver 127.0.0.1\n"
 			// Remove the symlink first — tee follows symlinks, so without this
 			// it would write into the stub file instead of creating a plain file.
-			exec.Command("sudo", "rm", "-f", "/etc/resolv.conf").Run()
-			cmd = exec.Command("sudo", "tee", "/etc/resolv.conf")
+			oplog.Run(exec.Command("sudo", "rm", "-f", "/etc/resolv.conf"))
+			cmd := exec.Command("sudo", "tee", "/etc/resolv.conf")
 			cmd.Stdin = strings.NewReader(content)
 			cmd.Stdout = io.Discard
-			if err := cmd.Run(); err != nil {
+			if err := oplog.Run(cmd); err != nil {
 				return fmt.Errorf("failed to update /etc/resolv.conf: %w", err)
 			}
 		}
@@ -170,31 +173,123 @@ func RevertSystemdResolvedStub() error {
 	}
 
 	// 2. Restart systemd-resolved to re-enable the stub listener on 127.0.0.53:53
-	if err := exec.Command("sudo", "systemctl", "restart", "systemd-resolved").Run(); err != nil {
+	if err := oplog.Run(exec.Command("sudo", "systemctl", "restart", "systemd-resolved")); err != nil {
 		return fmt.Errorf("failed to restart systemd-resolved: %w", err)
 	}
 
-	// 3. Remove PHPark's dnsmasq upstream config
-	exec.Command("sudo", "rm", "-f", phpParkDnsmasqConf).Run()
+	// 3. Remove PHPark's dnsmasq upstream config, keeping listen settings
+	if listen, _ := readPhpparkConf(); len(listen) > 0 {
+		writePhpparkConf(listen, nil)
+	} else {
+		oplog.Run(exec.Command("sudo", "rm", "-f", phpParkDnsmasqConf))
+	}
 
 	// 4. Restore /etc/resolv.conf to the standard systemd stub symlink
-	exec.Command("sudo", "rm", "-f", "/etc/resolv.conf").Run()
-	if err := exec.Command("sudo", "ln", "-sf", resolvedStubSymlink, "/etc/resolv.conf").Run(); err != nil {
+	oplog.Run(exec.Command("sudo", "rm", "-f", "/etc/resolv.conf"))
+	if err := oplog.Run(exec.Command("sudo", "ln", "-sf", resolvedStubSymlink, "/etc/resolv.conf")); err != nil {
 		return fmt.Errorf("failed to restore /etc/resolv.conf: %w", err)
 	}
 
 	return nil
 }
 
-// buildDnsmasqUpstreamConf returns the content for /etc/dnsmasq.d/phppark.conf.
+// buildDnsmasqUpstream returns the upstream lines for /etc/dnsmasq.d/phppark.conf.
 // Uses systemd-resolved's live resolver file as upstream when available so that
 // VPN, DHCP, and NetworkManager DNS changes are automatically picked up.
 // Falls back to public DNS if the file is not yet available.
-func buildDnsmasqUpstreamConf() string {
+func buildDnsmasqUpstream() []string {
 	if _, err := os.Stat(systemdResolveResolvConf); err == nil {
-		return fmt.Sprintf("# Managed by PHPark\nresolv-file=%s\n", systemdResolveResolvConf)
+		return []string{"resolv-file=" + systemdResolveResolvConf}
+	}
+	return []string{"server=8.8.8.8", "server=1.1.1.1"}
+}
+
+// DnsmasqListen controls which addresses, interfaces and port dnsmasq
+// answers on
+type DnsmasqListen struct {
+	Addresses      []string // listen-address entries (e.g., 127.0.0.1)
+	Interfaces     []string // interface entries (e.g., lo, wlan0)
+	BindInterfaces bool     // Bind only those sockets instead of the wildcard
+	Port           int      // Instead of 53, e.g. when systemd-resolved routes to it; 0 keeps 53
+}
+
+// lines renders the options as dnsmasq config lines
+func (l DnsmasqListen) lines() []string {
+	var lines []string
+	if l.Port != 0 {
+		lines = append(lines, fmt.Sprintf("port=%d", l.Port))
+	}
+	for _, addr := range l.Addresses {
+		lines = append(lines, "listen-address="+addr)
+	}
+	for _, iface := range l.Interfaces {
+		lines = append(lines, "interface="+iface)
+	}
+	if l.BindInterfaces {
+		lines = append(lines, "bind-interfaces")
+	}
+	return lines
+}
+
+// ConfigureDnsmasqListen writes the listen settings to phppark.conf, keeping
+// any upstream servers, and reports whether anything changed (dnsmasq needs
+// a restart to pick it up)
+func ConfigureDnsmasqListen(listen DnsmasqListen) (bool, error) {
+	current, upstream := readPhpparkConf()
+	wanted := listen.lines()
+	if strings.Join(current, "\n") == strings.Join(wanted, "\n") {
+		return false, nil
+	}
+
+	if len(wanted) == 0 && len(upstream) == 0 {
+		if err := oplog.Run(exec.Command("sudo", "rm", "-f", phpParkDnsmasqConf)); err != nil {
+			return false, fmt.Errorf("failed to remove %s: %w", phpParkDnsmasqConf, err)
+		}
+		return true, nil
+	}
+
+	if err := writePhpparkConf(wanted, upstream); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// readPhpparkConf splits phppark.conf into listen and upstream lines
+func readPhpparkConf() (listen, upstream []string) {
+	data, err := os.ReadFile(phpParkDnsmasqConf)
+	if err != nil {
+		return nil, nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "port="),
+			strings.HasPrefix(line, "listen-address="),
+			strings.HasPrefix(line, "interface="),
+			line == "bind-interfaces":
+			listen = append(listen, line)
+		case strings.HasPrefix(line, "resolv-file="), strings.HasPrefix(line, "server="):
+			upstream = append(upstream, line)
+		}
 	}
-	return "# Managed by PHPark\nserver=8.8.8.8\nserver=1.1.1.1\n"
+	return listen, upstream
+}
+
+// writePhpparkConf writes /etc/dnsmasq.d/phppark.conf (requires sudo)
+func writePhpparkConf(listen, upstream []string) error {
+	content := "# Managed by PHPark\n"
+	for _, line := range append(listen, upstream...) {
+		content += line + "\n"
+	}
+
+	cmd := exec.Command("sudo", "tee", phpParkDnsmasqConf)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = io.Discard
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to write %s: %w", phpParkDnsmasqConf, err)
+	}
+	return nil
 }
 
 // setDNSStubListener writes or removes the DNSStubListener setting in
@@ -258,7 +353,7 @@ func setDNSStubListener(value string) error {
 	cmd := exec.Command("sudo", "tee", resolvedConf)
 	cmd.Stdin = strings.NewReader(content)
 	cmd.Stdout = io.Discard
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to write %s: %w", resolvedConf, err)
 	}
 	return nil
@@ -277,3 +372,16 @@ func TestDNSResolution(hostname string) (bool, error) {
 	outputStr := string(output)
 	return strings.Contains(outputStr, "127.0.0.1"), nil
 }
+
+// IsDnsmasqRunning reports whether the dnsmasq service is active
+func IsDnsmasqRunning() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", "dnsmasq").Run() == nil
+}
+
+// RestartDnsmasq restarts the dnsmasq service
+func RestartDnsmasq() error {
+	if err := oplog.Run(exec.Command("systemctl", "restart", "dnsmasq")); err != nil {
+		return fmt.Errorf("failed to restart dnsmasq: %w", err)
+	}
+	return nil
+}
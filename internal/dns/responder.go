@@ -0,0 +1,195 @@
+package dns
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	typeA   = 1
+	typeANY = 255
+	classIN = 1
+
+	rcodeFormErr = 1
+	rcodeNotImp  = 4
+	rcodeRefused = 5
+
+	answerTTL = 60
+)
+
+// Responder is a minimal DNS server that answers A queries for every name
+// under Domain with Answer. It backs the "resolved" DNS driver, where
+// systemd-resolved only forwards the site domain to it, and answers the LAN
+// domain for colleagues. Anything else is refused rather than resolved, so
+// it can't be used as an open resolver.
+type Responder struct {
+	Domain string // e.g., "test"
+	Addr   string // e.g., "127.0.0.1:5353"
+	Answer net.IP // Address names resolve to (default: 127.0.0.1)
+}
+
+// ListenAndServe answers queries over UDP and TCP until either listener fails
+func (r *Responder) ListenAndServe() error {
+	udp, err := net.ListenPacket("udp", r.Addr)
+	if err != nil {
+		return err
+	}
+	defer udp.Close()
+
+	tcp, err := net.Listen("tcp", r.Addr)
+	if err != nil {
+		return err
+	}
+	defer tcp.Close()
+
+	errs := make(chan error, 2)
+	go func() { errs <- r.serveUDP(udp) }()
+	go func() { errs <- r.serveTCP(tcp) }()
+	return <-errs
+}
+
+func (r *Responder) serveUDP(conn net.PacketConn) error {
+	// Big enough for queries advertising EDNS buffer sizes
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if reply := r.answer(buf[:n]); reply != nil {
+			conn.WriteTo(reply, addr)
+		}
+	}
+}
+
+func (r *Responder) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go r.handleTCP(conn)
+	}
+}
+
+// handleTCP answers length-prefixed queries until the client goes quiet
+func (r *Responder) handleTCP(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+
+		reply := r.answer(query)
+		if reply == nil {
+			return
+		}
+		binary.BigEndian.PutUint16(size[:], uint16(len(reply)))
+		if _, err := conn.Write(append(size[:], reply...)); err != nil {
+			return
+		}
+	}
+}
+
+// answer builds the reply to a query, or nil for packets that aren't one
+func (r *Responder) answer(query []byte) []byte {
+	// Too short for a header, or a response rather than a query
+	if len(query) < 12 || query[2]&0x80 != 0 {
+		return nil
+	}
+
+	opcode := (query[2] >> 3) & 0x0f
+	rd := query[2] & 0x01
+
+	reply := make([]byte, 12, 512)
+	copy(reply, query[:2])                  // ID
+	reply[2] = 0x80 | opcode<<3 | 0x04 | rd // QR, opcode, AA, RD
+
+	if opcode != 0 {
+		reply[3] = rcodeNotImp
+		return reply
+	}
+
+	name, end, ok := parseQuestion(query)
+	if !ok || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		reply[3] = rcodeFormErr
+		return reply
+	}
+
+	// Echo the question
+	reply = append(reply, query[12:end]...)
+	binary.BigEndian.PutUint16(reply[4:6], 1)
+
+	if !r.inDomain(name) {
+		reply[3] = rcodeRefused
+		return reply
+	}
+
+	// Sites are served on IPv4 only, so other types get an empty answer
+	// and clients fall back to the A record
+	qtype := binary.BigEndian.Uint16(query[end-4 : end-2])
+	qclass := binary.BigEndian.Uint16(query[end-2 : end])
+	if (qtype == typeA || qtype == typeANY) && qclass == classIN {
+		answer := r.Answer.To4()
+		if answer == nil {
+			answer = net.IPv4(127, 0, 0, 1).To4()
+		}
+		reply = append(reply,
+			0xc0, 0x0c, // Name: pointer to the question
+			0, typeA,
+			0, classIN,
+			0, 0, 0, answerTTL,
+			0, 4, // RDLENGTH
+		)
+		reply = append(reply, answer...)
+		binary.BigEndian.PutUint16(reply[6:8], 1)
+	}
+
+	return reply
+}
+
+// parseQuestion reads the first question's name (lowercased, without the
+// trailing dot) and returns the offset just past its type and class
+func parseQuestion(msg []byte) (string, int, bool) {
+	var labels []string
+
+	i := 12
+	for {
+		if i >= len(msg) {
+			return "", 0, false
+		}
+		n := int(msg[i])
+		i++
+		if n == 0 {
+			break
+		}
+		// Compression pointers don't appear in the question of a query
+		if n > 63 || i+n > len(msg) {
+			return "", 0, false
+		}
+		labels = append(labels, strings.ToLower(string(msg[i:i+n])))
+		i += n
+	}
+
+	if i+4 > len(msg) {
+		return "", 0, false
+	}
+	return strings.Join(labels, "."), i + 4, true
+}
+
+// inDomain reports whether name is the responder's domain or under it
+func (r *Responder) inDomain(name string) bool {
+	domain := strings.ToLower(strings.Trim(r.Domain, "."))
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
@@ -0,0 +1,275 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// DefaultSocket is where the Docker daemon listens by default
+const DefaultSocket = "/var/run/docker.sock"
+
+// Client talks to the Docker Engine API over its unix socket
+type Client struct {
+	http *http.Client
+}
+
+// Container is the subset of a container's inspect output PHPark uses
+type Container struct {
+	ID    string `json:"Id"`
+	State struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// ContainerSpec describes a container to create
+type ContainerSpec struct {
+	Name   string
+	Image  string
+	Cmd    []string
+	Labels map[string]string
+	Binds  []string // host:container[:ro]
+}
+
+// NewClient returns a client for the Docker socket
+func NewClient(socket string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+
+	return &Client{http: &http.Client{Transport: transport, Timeout: 10 * time.Minute}}
+}
+
+// Ping checks that the daemon is reachable
+func (c *Client) Ping() error {
+	_, err := c.do(http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return fmt.Errorf("docker is not reachable at %s: %w", DefaultSocket, err)
+	}
+	return nil
+}
+
+// Inspect returns a container, or nil if it doesn't exist
+func (c *Client) Inspect(name string) (*Container, error) {
+	var container Container
+	_, err := c.do(http.MethodGet, "/containers/"+name+"/json", nil, &container)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// Pull downloads an image (e.g., "nginx:stable")
+func (c *Client) Pull(image string) error {
+	ref, tag, ok := strings.Cut(image, ":")
+	if !ok {
+		tag = "latest"
+	}
+
+	query := url.Values{"fromImage": {ref}, "tag": {tag}}
+	_, err := c.do(http.MethodPost, "/images/create?"+query.Encode(), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	return nil
+}
+
+// Create creates a container on the host network that restarts with the
+// daemon, pulling its image first. A failed pull only matters if the image
+// isn't available locally (e.g., working offline).
+func (c *Client) Create(spec *ContainerSpec) error {
+	pullErr := c.Pull(spec.Image)
+
+	body := map[string]any{
+		"Image":  spec.Image,
+		"Labels": spec.Labels,
+		"HostConfig": map[string]any{
+			"Binds":         spec.Binds,
+			"NetworkMode":   "host",
+			"RestartPolicy": map[string]string{"Name": "unless-stopped"},
+		},
+	}
+	if len(spec.Cmd) > 0 {
+		body["Cmd"] = spec.Cmd
+	}
+
+	query := url.Values{"name": {spec.Name}}
+	if _, err := c.do(http.MethodPost, "/containers/create?"+query.Encode(), body, nil); err != nil {
+		if pullErr != nil {
+			return pullErr
+		}
+		return fmt.Errorf("failed to create %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// Start starts a container
+func (c *Client) Start(name string) error {
+	if _, err := c.do(http.MethodPost, "/containers/"+name+"/start", nil, nil); err != nil {
+		return fmt.Errorf("failed to start %s: %w", name, err)
+	}
+	return nil
+}
+
+// Stop stops a container
+func (c *Client) Stop(name string) error {
+	if _, err := c.do(http.MethodPost, "/containers/"+name+"/stop", nil, nil); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", name, err)
+	}
+	return nil
+}
+
+// Remove force-removes a container
+func (c *Client) Remove(name string) error {
+	_, err := c.do(http.MethodDelete, "/containers/"+name+"?force=true", nil, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	return nil
+}
+
+// Signal sends a signal to a container's main process (e.g., "HUP")
+func (c *Client) Signal(name, signal string) error {
+	if _, err := c.do(http.MethodPost, "/containers/"+name+"/kill?signal="+signal, nil, nil); err != nil {
+		return fmt.Errorf("failed to signal %s: %w", name, err)
+	}
+	return nil
+}
+
+// Exec runs a command in a running container and returns its combined
+// output and exit code
+func (c *Client) Exec(name string, cmd ...string) (string, int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	body := map[string]any{"Cmd": cmd, "AttachStdout": true, "AttachStderr": true}
+	if _, err := c.do(http.MethodPost, "/containers/"+name+"/exec", body, &created); err != nil {
+		return "", 0, fmt.Errorf("failed to exec in %s: %w", name, err)
+	}
+
+	raw, err := c.do(http.MethodPost, "/exec/"+created.ID+"/start", map[string]bool{"Detach": false}, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to exec in %s: %w", name, err)
+	}
+
+	var inspect struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if _, err := c.do(http.MethodGet, "/exec/"+created.ID+"/json", nil, &inspect); err != nil {
+		return "", 0, err
+	}
+
+	return demux(raw), inspect.ExitCode, nil
+}
+
+// apiError is a non-2xx response from the daemon
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.message, e.status)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.status == http.StatusNotFound
+}
+
+// do sends a request, decoding a JSON response into out when given, and
+// returns the raw body. Requests that change state are recorded.
+func (c *Client) do(method, path string, in, out any) ([]byte, error) {
+	data, err := c.request(method, path, in, out)
+	if method != http.MethodGet {
+		entry := oplog.Entry{Op: "docker", Target: method + " " + path}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		oplog.Record(entry)
+	}
+	return data, err
+}
+
+func (c *Client) request(method, path string, in, out any) ([]byte, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, "http://docker"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 304 means already started/stopped
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &msg)
+		if msg.Message == "" {
+			msg.Message = resp.Status
+		}
+		return nil, &apiError{status: resp.StatusCode, message: msg.Message}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode docker response: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// demux strips the 8-byte frame headers Docker adds to attached output
+func demux(raw []byte) string {
+	var out bytes.Buffer
+	for len(raw) >= 8 {
+		size := int(raw[4])<<24 | int(raw[5])<<16 | int(raw[6])<<8 | int(raw[7])
+		raw = raw[8:]
+		if size > len(raw) {
+			size = len(raw)
+		}
+		out.Write(raw[:size])
+		raw = raw[size:]
+	}
+	return out.String()
+}
@@ -0,0 +1,148 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+const (
+	// NginxContainer is the container serving every site
+	NginxContainer = "phppark-nginx"
+
+	nginxImage  = "nginx:stable"
+	bindsLabel  = "phppark.binds"
+	socketDir   = "/var/run/php"
+	poolConfDir = "/usr/local/etc/php-fpm.d"
+)
+
+// FPMContainer returns the PHP-FPM container name for a PHP version
+func FPMContainer(version string) string {
+	return "phppark-php" + version
+}
+
+// Stack runs nginx and per-version PHP-FPM in containers. Site paths and
+// certificates are bind-mounted at their host paths so generated nginx
+// configs work unchanged, and FPM sockets are shared through dir/run.
+type Stack struct {
+	Client   *Client
+	Dir      string   // ~/.phppark/docker (sockets and pool configs)
+	NginxDir string   // Generated nginx configs, mounted as conf.d
+	CertDir  string   // SSL certificates
+	Sites    []string // Site paths to mount
+	UID, GID string   // User PHP runs as, so sites can write their files
+}
+
+// EnsureNginx creates or recreates the nginx container as needed and
+// starts it
+func (s *Stack) EnsureNginx() error {
+	binds := append(s.siteBinds(),
+		s.NginxDir+":/etc/nginx/conf.d:ro",
+		s.CertDir+":"+s.CertDir+":ro",
+	)
+
+	return s.ensure(&ContainerSpec{Name: NginxContainer, Image: nginxImage, Binds: binds})
+}
+
+// EnsureFPM creates or recreates the PHP-FPM container for a version as
+// needed and starts it
+func (s *Stack) EnsureFPM(version string) error {
+	poolConf := filepath.Join(s.Dir, fmt.Sprintf("php%s-pool.conf", version))
+	if err := oplog.WriteFile(poolConf, []byte(s.poolConf(version)), 0644); err != nil {
+		return fmt.Errorf("failed to write pool config: %w", err)
+	}
+
+	binds := append(s.siteBinds(), poolConf+":"+poolConfDir+"/zz-phppark.conf:ro")
+
+	return s.ensure(&ContainerSpec{
+		Name:  FPMContainer(version),
+		Image: fmt.Sprintf("php:%s-fpm", version),
+		Binds: binds,
+	})
+}
+
+// TestNginx runs nginx -t inside the nginx container
+func (s *Stack) TestNginx() error {
+	output, code, err := s.Client.Exec(NginxContainer, "nginx", "-t")
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("nginx -t failed: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// ReloadNginx tells nginx to reload its configuration
+func (s *Stack) ReloadNginx() error {
+	return s.Client.Signal(NginxContainer, "HUP")
+}
+
+// Running reports whether a container exists and is running
+func (s *Stack) Running(name string) bool {
+	container, err := s.Client.Inspect(name)
+	return err == nil && container != nil && container.State.Running
+}
+
+// siteBinds mounts the sockets directory and every site at its host path
+func (s *Stack) siteBinds() []string {
+	binds := []string{filepath.Join(s.Dir, "run") + ":" + socketDir}
+	for _, path := range s.Sites {
+		binds = append(binds, path+":"+path)
+	}
+	return binds
+}
+
+// poolConf overrides the image's pool to listen on the shared socket nginx
+// configs expect, with the status page always available
+func (s *Stack) poolConf(version string) string {
+	var b strings.Builder
+	b.WriteString("; Managed by PHPark - do not edit\n")
+	b.WriteString("[www]\n")
+	if s.UID != "" {
+		fmt.Fprintf(&b, "user = %s\n", s.UID)
+		fmt.Fprintf(&b, "group = %s\n", s.GID)
+	}
+	fmt.Fprintf(&b, "listen = %s/php%s-fpm.sock\n", socketDir, version)
+	b.WriteString("listen.mode = 0666\n")
+	b.WriteString("pm.status_path = /fpm-status\n")
+	b.WriteString("ping.path = /fpm-ping\n")
+	b.WriteString("clear_env = no\n")
+	return b.String()
+}
+
+// ensure makes sure a container exists with the spec's mounts and is
+// running. Containers whose mounts changed (e.g., a new site) are recreated.
+func (s *Stack) ensure(spec *ContainerSpec) error {
+	if err := os.MkdirAll(filepath.Join(s.Dir, "run"), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	want := strings.Join(spec.Binds, ",")
+	spec.Labels = map[string]string{bindsLabel: want}
+
+	existing, err := s.Client.Inspect(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && existing.Config.Labels[bindsLabel] != want {
+		if err := s.Client.Remove(spec.Name); err != nil {
+			return err
+		}
+		existing = nil
+	}
+
+	if existing == nil {
+		if err := s.Client.Create(spec); err != nil {
+			return err
+		}
+	} else if existing.State.Running {
+		return nil
+	}
+
+	return s.Client.Start(spec.Name)
+}
@@ -0,0 +1,48 @@
+package frankenphp
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/stevepop/phppark/internal/nginx"
+)
+
+// site is the data passed to the Caddyfile template: the same site
+// settings used for nginx plus where FrankenPHP writes logs
+type site struct {
+	*nginx.SiteConfig
+	LogDir string
+}
+
+// Names is the site's name followed by its aliases, each one an address
+// the site block answers on
+func (s site) Names() []string {
+	return append([]string{s.ServerName}, s.Aliases...)
+}
+
+// GenerateConfig generates a site's Caddyfile entry from a SiteConfig.
+// PHP runs inside FrankenPHP, so the site's PHP-FPM socket is unused.
+func GenerateConfig(cfg *nginx.SiteConfig, logDir string) (string, error) {
+	return render("frankenphp", GetTemplate(), site{SiteConfig: cfg, LogDir: logDir})
+}
+
+// GenerateMainConfig generates the top-level Caddyfile importing all site
+// entries from sitesDir
+func GenerateMainConfig(sitesDir string) (string, error) {
+	return render("caddyfile", mainTemplate, struct{ SitesDir string }{sitesDir})
+}
+
+func render(name, source string, data any) (string, error) {
+	tmpl, err := template.New(name).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,61 @@
+package frankenphp
+
+const caddyTemplate = `{{range $i, $name := .Names}}{{if $i}}, {{end}}{{if $.UseSSL}}{{$name}}{{if not $.RedirectHTTP}}, http://{{$name}}{{end}}{{else}}http://{{$name}}{{end}}{{end}} {
+	# Logging
+	log {
+		output file {{.LogDir}}/{{.SiteName}}.access.log
+	}
+	{{- if .UseSSL}}
+
+	tls {{.CertPath}} {{.KeyPath}}
+	{{- end}}
+	{{- if .Octane}}
+
+	# Serve static files directly, send everything else to Octane
+	root * {{.Root}}
+
+	@static {
+		file
+		not path *.php
+	}
+	handle @static {
+		file_server
+	}
+	handle {
+		reverse_proxy {{.ProxyPass}}
+	}
+	{{- else if .ProxyPass}}
+
+	# Forward everything to the upstream service (websockets included)
+	reverse_proxy {{.ProxyPass}}
+	{{- else}}
+
+	root * {{.Root}}
+	encode zstd br gzip
+
+	# Deny access to hidden files
+	@hidden path */.*
+	respond @hidden 403
+
+	# Laravel/PHP framework friendly (front controller + static files)
+	php_server {
+		{{- range .Env}}
+		env {{.Name}} {{.Value}}
+		{{- end}}
+	}
+	{{- end}}
+}
+`
+
+// mainTemplate is the top-level Caddyfile that imports every site
+const mainTemplate = `{
+	frankenphp
+}
+
+import {{.SitesDir}}/*.caddy
+`
+
+// GetTemplate returns the per-site Caddyfile template
+func GetTemplate() string {
+	return caddyTemplate
+}
@@ -0,0 +1,194 @@
+// Package fswatch reports changes to files in watched directories, using
+// Linux's inotify
+package fswatch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Op is what happened to a file
+type Op int
+
+const (
+	Create Op = iota // Created, or moved into the directory
+	Write            // Written and closed
+	Remove           // Deleted, or moved out of the directory
+)
+
+// Event is a change to a file (or subdirectory) of a watched directory
+type Event struct {
+	Path string
+	Op   Op
+	Dir  bool
+}
+
+// mask covers finished writes and files appearing or going away; editors
+// and git often replace a file by renaming a new one over it
+const mask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_ONLYDIR
+
+// Watcher watches directories (not recursively)
+type Watcher struct {
+	fd int
+
+	mu   sync.Mutex
+	dirs map[int32]string // Watch descriptor -> directory
+}
+
+// New starts an inotify instance
+func New() (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start inotify: %w", err)
+	}
+	return &Watcher{fd: fd, dirs: make(map[int32]string)}, nil
+}
+
+// Add watches a directory. Adding one that's already watched does nothing.
+func (w *Watcher) Add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, mask)
+	if err != nil {
+		if err == syscall.ENOSPC {
+			return fmt.Errorf("failed to watch %s: too many watches (raise fs.inotify.max_user_watches)", dir)
+		}
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	w.mu.Lock()
+	w.dirs[int32(wd)] = dir
+	w.mu.Unlock()
+	return nil
+}
+
+// Remove stops watching a directory
+func (w *Watcher) Remove(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for wd, watched := range w.dirs {
+		if watched == dir {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+// Watching reports whether a directory is watched
+func (w *Watcher) Watching(dir string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, watched := range w.dirs {
+		if watched == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops the watcher
+func (w *Watcher) Close() error {
+	return syscall.Close(w.fd)
+}
+
+// Read blocks until something changes, then returns the changes made within
+// settle of each other, so a burst (a git checkout, an editor's save) comes
+// back as one batch
+func (w *Watcher) Read(settle time.Duration) ([]Event, error) {
+	events, err := w.read(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		more, err := w.read(int(settle / time.Millisecond))
+		if err != nil {
+			return nil, err
+		}
+		if len(more) == 0 {
+			return events, nil
+		}
+		events = append(events, more...)
+	}
+}
+
+// read waits up to timeout milliseconds (forever when negative) for events
+func (w *Watcher) read(timeout int) ([]Event, error) {
+	for {
+		fds := &syscall.FdSet{}
+		fds.Bits[w.fd/64] |= 1 << (uint(w.fd) % 64)
+
+		var tv *syscall.Timeval
+		if timeout >= 0 {
+			t := syscall.NsecToTimeval(int64(timeout) * int64(time.Millisecond))
+			tv = &t
+		}
+
+		n, err := syscall.Select(w.fd+1, fds, nil, nil, tv)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for changes: %w", err)
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		break
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := syscall.Read(w.fd, buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changes: %w", err)
+	}
+	return w.parse(buf[:n]), nil
+}
+
+// parse decodes inotify_event records
+func (w *Watcher) parse(buf []byte) []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []Event
+	for len(buf) >= syscall.SizeofInotifyEvent {
+		wd := int32(binary.NativeEndian.Uint32(buf[0:4]))
+		m := binary.NativeEndian.Uint32(buf[4:8])
+		nameLen := int(binary.NativeEndian.Uint32(buf[12:16]))
+
+		end := syscall.SizeofInotifyEvent + nameLen
+		if end > len(buf) {
+			break
+		}
+		name := string(bytes.TrimRight(buf[syscall.SizeofInotifyEvent:end], "\x00"))
+		buf = buf[end:]
+
+		dir, ok := w.dirs[wd]
+		if m&syscall.IN_IGNORED != 0 {
+			// The directory itself went away
+			delete(w.dirs, wd)
+			continue
+		}
+		if !ok || name == "" {
+			continue
+		}
+
+		event := Event{Path: filepath.Join(dir, name), Dir: m&syscall.IN_ISDIR != 0}
+		switch {
+		case m&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			event.Op = Create
+		case m&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+			event.Op = Remove
+		default:
+			event.Op = Write
+		}
+		events = append(events, event)
+	}
+	return events
+}
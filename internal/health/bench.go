@@ -0,0 +1,133 @@
+package health
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchOptions controls a load run against a target
+type BenchOptions struct {
+	Requests    int           // Total requests to send
+	Concurrency int           // Requests in flight at once
+	Timeout     time.Duration // Per request
+}
+
+// BenchResult summarizes a load run
+type BenchResult struct {
+	Requests    int
+	Elapsed     time.Duration
+	Latencies   []time.Duration // Of requests that got a response, sorted
+	StatusCodes map[int]int
+	Errors      map[string]int // Failed requests by error message
+}
+
+// Failed counts requests that got no response or a server error
+func (r *BenchResult) Failed() int {
+	failed := 0
+	for _, count := range r.Errors {
+		failed += count
+	}
+	for code, count := range r.StatusCodes {
+		if code >= 500 {
+			failed += count
+		}
+	}
+	return failed
+}
+
+// Percentile returns the latency p percent of responses came in under
+func (r *BenchResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(p / 100 * float64(len(r.Latencies)))
+	return r.Latencies[min(i, len(r.Latencies)-1)]
+}
+
+// RequestsPerSecond is the throughput over the whole run
+func (r *BenchResult) RequestsPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Bench sends GET requests to a target from several workers sharing
+// keepalive connections, the way a browser or load balancer would, and
+// reports how long each took. Bodies are read in full so the timing covers
+// the whole response, and redirects are not followed.
+func Bench(target Target, opts BenchOptions) *BenchResult {
+	transport := newTransport(target.Local)
+	transport.MaxIdleConnsPerHost = opts.Concurrency
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	result := &BenchResult{
+		Requests:    opts.Requests,
+		StatusCodes: make(map[int]int),
+		Errors:      make(map[string]int),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan struct{})
+
+	start := time.Now()
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				latency, code, err := benchRequest(client, target.URL)
+
+				mu.Lock()
+				if err != nil {
+					result.Errors[err.Error()]++
+				} else {
+					result.StatusCodes[code]++
+					result.Latencies = append(result.Latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < opts.Requests; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+
+	sort.Slice(result.Latencies, func(i, j int) bool { return result.Latencies[i] < result.Latencies[j] })
+	return result
+}
+
+// benchRequest sends one request and times it until the body is read
+func benchRequest(client *http.Client, url string) (time.Duration, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, 0, err
+	}
+	return time.Since(start), resp.StatusCode, nil
+}
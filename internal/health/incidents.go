@@ -0,0 +1,71 @@
+package health
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Incident is a health problem the watcher noticed, and what it did about it
+type Incident struct {
+	Time      time.Time `json:"time"`
+	Component string    `json:"component"`        // e.g., "nginx", "php8.3-fpm", "blog.test"
+	Message   string    `json:"message"`          // What was wrong (or "recovered")
+	Action    string    `json:"action,omitempty"` // e.g., "restarted", "restart failed: ..."
+}
+
+// RecordIncident appends an incident to the log at path (JSON lines)
+func RecordIncident(path string, incident Incident) error {
+	if incident.Time.IsZero() {
+		incident.Time = time.Now()
+	}
+
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open incident log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write incident log: %w", err)
+	}
+	return nil
+}
+
+// ReadIncidents returns the most recent incidents (oldest first), at most
+// limit of them (0 for all). A missing log means no incidents.
+func ReadIncidents(path string, limit int) ([]Incident, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open incident log: %w", err)
+	}
+	defer f.Close()
+
+	var incidents []Incident
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var incident Incident
+		if err := json.Unmarshal(scanner.Bytes(), &incident); err != nil {
+			continue // Skip corrupt lines
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read incident log: %w", err)
+	}
+
+	if limit > 0 && len(incidents) > limit {
+		incidents = incidents[len(incidents)-limit:]
+	}
+	return incidents, nil
+}
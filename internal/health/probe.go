@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TLS verdicts for HTTPS targets
+const (
+	TLSValid     = "valid"
+	TLSUntrusted = "untrusted" // Valid for the host but not signed by a trusted CA (e.g., self-signed)
+	TLSExpired   = "expired"
+	TLSMismatch  = "hostname mismatch"
+)
+
+// Target is a URL to probe. When Local is set the request is sent to
+// 127.0.0.1 with the URL's host in the Host header and SNI, so sites can be
+// checked without working DNS.
+type Target struct {
+	URL   string // e.g., "https://blog.test"
+	Local bool
+	Head  bool // Send a HEAD request instead of GET, for a quick reachability check
+}
+
+// Result is the outcome of probing a target
+type Result struct {
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	TLS        string // One of the TLS* verdicts, empty for plain HTTP
+	Err        error
+}
+
+// OK reports whether the site answered without a server error and with a
+// certificate that's at least valid for its host
+func (r *Result) OK() bool {
+	if r.Err != nil || r.StatusCode >= 500 {
+		return false
+	}
+	return r.TLS == "" || r.TLS == TLSValid || r.TLS == TLSUntrusted
+}
+
+// Probe sends a GET (or HEAD) request to a target and reports how it answered.
+// Redirects are not followed so an HTTPS redirect shows as a 3xx.
+func Probe(target Target, timeout time.Duration) *Result {
+	result := &Result{URL: target.URL}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	transport := newTransport(target.Local)
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	method := http.MethodGet
+	if target.Head {
+		method = http.MethodHead
+	}
+	req, err := http.NewRequest(method, target.URL, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil {
+		result.TLS = verifyTLS(resp.TLS, u.Hostname())
+	}
+
+	return result
+}
+
+// newTransport builds a transport that skips certificate verification so
+// callers can judge certificates themselves, and with local set dials
+// 127.0.0.1 whatever the URL's host
+func newTransport(local bool) *http.Transport {
+	transport := &http.Transport{
+		// Probe verifies certificates itself so a self-signed one can be
+		// reported rather than failing the request
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	if local {
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, net.JoinHostPort("127.0.0.1", port))
+		}
+	}
+	return transport
+}
+
+// verifyTLS checks a server's certificate chain for a host
+func verifyTLS(state *tls.ConnectionState, host string) string {
+	if len(state.PeerCertificates) == 0 {
+		return TLSUntrusted
+	}
+	leaf := state.PeerCertificates[0]
+
+	if time.Now().After(leaf.NotAfter) {
+		return TLSExpired
+	}
+
+	if err := leaf.VerifyHostname(host); err != nil {
+		return TLSMismatch
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates}); err != nil {
+		return TLSUntrusted
+	}
+
+	return TLSValid
+}
+
+// Summary is a short description of the result for display
+func (r *Result) Summary() string {
+	if r.Err != nil {
+		return r.Err.Error()
+	}
+
+	summary := fmt.Sprintf("%d  %dms", r.StatusCode, r.Duration.Milliseconds())
+	if r.TLS != "" {
+		summary += "  TLS: " + r.TLS
+	}
+	return summary
+}
+
+// Badge is a compact status for site listings: ✅ and the status code for a
+// working site, ⚠️ and the code for an error response (e.g., a 502 from a
+// broken PHP-FPM socket), or ❌ unreachable
+func (r *Result) Badge() string {
+	switch {
+	case r.Err != nil:
+		return "❌ unreachable"
+	case r.StatusCode >= 400:
+		return fmt.Sprintf("⚠️  %d", r.StatusCode)
+	default:
+		return fmt.Sprintf("✅ %d", r.StatusCode)
+	}
+}
+
+// Label is Badge in ASCII, for table columns: text/tabwriter counts an
+// emoji as one cell however wide the terminal draws it
+func (r *Result) Label() string {
+	switch {
+	case r.Err != nil:
+		return "unreachable"
+	case r.StatusCode >= 400:
+		return fmt.Sprintf("error %d", r.StatusCode)
+	default:
+		return fmt.Sprintf("ok %d", r.StatusCode)
+	}
+}
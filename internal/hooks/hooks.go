@@ -0,0 +1,186 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/stevepop/phppark/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Event names a point in a site's lifecycle where hooks run
+type Event string
+
+const (
+	PreLink      Event = "pre-link"
+	PostLink     Event = "post-link"
+	PreUnlink    Event = "pre-unlink"
+	PostUnlink   Event = "post-unlink"
+	PreSecure    Event = "pre-secure"
+	PostSecure   Event = "post-secure"
+	PreUnsecure  Event = "pre-unsecure"
+	PostUnsecure Event = "post-unsecure"
+	PreRebuild   Event = "pre-rebuild"
+	PostRebuild  Event = "post-rebuild"
+)
+
+// Stdout and Stderr are where hook commands print. They're the process's
+// own, taken before the CLI filters or silences os.Stdout and os.Stderr.
+var Stdout, Stderr io.Writer = os.Stdout, os.Stderr
+
+// ProjectFile is the per-project config read from a site's directory
+const ProjectFile = ".phppark.yml"
+
+// Project holds the hooks a project declares in .phppark.yml:
+//
+//	hooks:
+//	  post-link:
+//	    - composer install
+//	    - php artisan migrate --seed
+type Project struct {
+	Hooks map[Event][]string `yaml:"hooks"`
+}
+
+// LoadProject reads .phppark.yml from a site directory. A missing file is
+// not an error.
+func LoadProject(sitePath string) (*Project, error) {
+	path := filepath.Join(sitePath, ProjectFile)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Project{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var project Project
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &project, nil
+}
+
+// Run runs the global hook for an event (an executable named after it in
+// dir), then the project's commands for it, stopping at the first failure.
+// Hooks run in the site directory with the site's metadata in PHPPARK_*
+// variables, as the user who ran sudo when phppark runs as root.
+//
+// A project's commands come from whoever wrote its directory, so they only
+// run once its .phppark.yml is trusted in trustStore; otherwise Run returns
+// an *UntrustedError.
+func Run(dir, trustStore string, event Event, site *config.Site, cfg *config.Config) error {
+	env := append(os.Environ(), Env(event, site, cfg)...)
+
+	global := filepath.Join(dir, string(event))
+	if info, err := os.Stat(global); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+		if err := run(exec.Command(global), site.Path, env); err != nil {
+			return fmt.Errorf("%s hook %s failed: %w", event, global, err)
+		}
+	}
+
+	project, err := LoadProject(site.Path)
+	if err != nil {
+		return err
+	}
+	if len(project.Hooks[event]) == 0 {
+		return nil
+	}
+
+	trusted, err := IsTrusted(trustStore, site.Path)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return &UntrustedError{Path: filepath.Join(site.Path, ProjectFile)}
+	}
+
+	for _, command := range project.Hooks[event] {
+		if err := run(exec.Command("/bin/sh", "-c", command), site.Path, env); err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", event, command, err)
+		}
+	}
+
+	return nil
+}
+
+// Env returns the variables describing a site to its hooks
+func Env(event Event, site *config.Site, cfg *config.Config) []string {
+	phpVersion := site.PHPVersion
+	if phpVersion == "" {
+		phpVersion = cfg.DefaultPHP
+	}
+
+	scheme := "http"
+	if site.Secured {
+		scheme = "https"
+	}
+
+	return []string{
+		"PHPPARK_EVENT=" + string(event),
+		"PHPPARK_SITE=" + site.Name,
+		"PHPPARK_PATH=" + site.Path,
+		"PHPPARK_DOMAIN=" + site.Name + "." + cfg.SiteDomain(),
+		"PHPPARK_URL=" + scheme + "://" + site.Name + "." + cfg.SiteDomain(),
+		"PHPPARK_PHP=" + phpVersion,
+		"PHPPARK_SECURED=" + strconv.FormatBool(site.Secured),
+		"PHPPARK_DATABASE=" + site.Database,
+	}
+}
+
+func run(cmd *exec.Cmd, dir string, env []string) error {
+	// Fall back to the current directory if the site directory is gone
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		cmd.Dir = dir
+	}
+	cmd.Env = env
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
+	if err := dropPrivileges(cmd, dir); err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// dropPrivileges runs a hook as the user who ran sudo, with their HOME,
+// instead of as root. Without sudo (e.g., in watch-config's root unit) it
+// runs as the owner of the site directory.
+func dropPrivileges(cmd *exec.Cmd, dir string) error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+
+	var uid, gid uint64
+	if os.Getenv("SUDO_UID") != "" {
+		var err error
+		if uid, err = strconv.ParseUint(os.Getenv("SUDO_UID"), 10, 32); err != nil {
+			return fmt.Errorf("invalid SUDO_UID: %w", err)
+		}
+		if gid, err = strconv.ParseUint(os.Getenv("SUDO_GID"), 10, 32); err != nil {
+			return fmt.Errorf("invalid SUDO_GID: %w", err)
+		}
+	} else if info, err := os.Stat(dir); err == nil {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		uid, gid = uint64(stat.Uid), uint64(stat.Gid)
+	}
+	if uid == 0 {
+		return nil
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
+	}
+	if u, err := user.LookupId(strconv.FormatUint(uid, 10)); err == nil {
+		cmd.Env = append(cmd.Env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+	}
+	return nil
+}
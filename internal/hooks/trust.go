@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UntrustedError is returned by Run when a project declares hooks for an
+// event but its .phppark.yml hasn't been trusted (or has changed since)
+type UntrustedError struct {
+	Path string // The project's .phppark.yml
+}
+
+func (e *UntrustedError) Error() string {
+	return fmt.Sprintf("%s is not trusted, so its hooks were not run", e.Path)
+}
+
+// Digest returns the SHA-256 of a project's .phppark.yml, or "" if it has
+// none
+func Digest(sitePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(sitePath, ProjectFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// IsTrusted reports whether a project's .phppark.yml is trusted as it is
+// now: its digest matches the one recorded by Trust
+func IsTrusted(storePath, sitePath string) (bool, error) {
+	digest, err := Digest(sitePath)
+	if err != nil || digest == "" {
+		return false, err
+	}
+
+	store, err := loadTrust(storePath)
+	if err != nil {
+		return false, err
+	}
+	return store[trustKey(sitePath)] == digest, nil
+}
+
+// Trust records a project's .phppark.yml as it is now, so its hooks run
+// until the file changes
+func Trust(storePath, sitePath string) error {
+	digest, err := Digest(sitePath)
+	if err != nil {
+		return err
+	}
+	if digest == "" {
+		return fmt.Errorf("%s has no %s", sitePath, ProjectFile)
+	}
+
+	store, err := loadTrust(storePath)
+	if err != nil {
+		return err
+	}
+	store[trustKey(sitePath)] = digest
+	return saveTrust(storePath, store)
+}
+
+// Untrust forgets a project, so its hooks stop running
+func Untrust(storePath, sitePath string) error {
+	store, err := loadTrust(storePath)
+	if err != nil {
+		return err
+	}
+	delete(store, trustKey(sitePath))
+	return saveTrust(storePath, store)
+}
+
+// trustKey is the absolute project directory
+func trustKey(sitePath string) string {
+	if abs, err := filepath.Abs(sitePath); err == nil {
+		return abs
+	}
+	return sitePath
+}
+
+func loadTrust(storePath string) (map[string]string, error) {
+	store := make(map[string]string)
+	data, err := os.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", storePath, err)
+	}
+	return store, nil
+}
+
+func saveTrust(storePath string, store map[string]string) error {
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(storePath), 0755); err != nil {
+		return err
+	}
+	// Owner-only: whoever can write it can run commands as this user
+	return os.WriteFile(storePath, append(data, '\n'), 0600)
+}
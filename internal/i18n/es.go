@@ -0,0 +1,66 @@
+package i18n
+
+// spanish is the Spanish catalog
+var spanish = map[string]string{
+	"ErrorPrefix": "Error: %v\n",
+	"Warning":     "   ⚠️  Aviso: %v\n",
+	"Cancelled":   "Cancelado",
+	"Continue":    "   ¿Continuar? (y/N): ",
+
+	"LoadConfigFailed": "no se pudo cargar la configuración: %w",
+	"SaveConfigFailed": "no se pudo guardar la configuración: %w",
+	"LoadSitesFailed":  "no se pudieron cargar los sitios: %w",
+	"SaveSitesFailed":  "no se pudieron guardar los sitios: %w",
+	"WarnLoadConfig":   "⚠️  No se pudo cargar la configuración: %v\n",
+	"WarnLoadSites":    "⚠️  No se pudieron cargar los sitios: %v\n",
+	"WarnSaveSites":    "   ⚠️  Aviso: no se pudieron guardar los sitios: %v\n",
+
+	"SiteNotFound":  "no se encontró el sitio '%s'",
+	"SiteExists":    "el sitio '%s' ya existe",
+	"SiteNotLinked": "el sitio '%s' no estaba enlazado",
+	"SitesFailed":   "fallaron %d de %d sitio(s)",
+
+	"CurrentDirFailed": "no se pudo obtener el directorio actual: %w",
+	"PathNotExist":     "la ruta no existe: %w",
+	"PathNotDir":       "la ruta no es un directorio: %s",
+
+	"UnknownService": "servicio desconocido '%s' (ver: phppark service list)",
+	"NeedsSudo":      "⚠️  Esto requiere acceso sudo",
+	"ThenRebuild":    "Después ejecuta: sudo phppark rebuild",
+	"EnableInConfig": "\nPara activarlo, añade lo siguiente a ~/.phppark/config.yaml:",
+
+	"SetupNeedsRoot":     "setup debe ejecutarse como root: usa 'sudo phppark setup'",
+	"InstallFailed":      "no se pudo instalar %s: %w",
+	"NoPHPInstalled":     "no se pudo instalar ninguna versión de PHP %s",
+	"StubListenerBusy":   "\n⚠️  El stub listener de systemd-resolved ocupa el puerto 53",
+	"StubListenerManual": "   Para arreglarlo a mano, añade DNSStubListener=no a /etc/systemd/resolved.conf\n   y después ejecuta: sudo systemctl restart systemd-resolved",
+	"SetupComplete":      "✅ ¡Instalación completa!",
+	"TryItOut":           "\n📚 Pruébalo:",
+	"StatusTip":          "\n💡 Consejo: ejecuta 'phppark status' para ver tu configuración",
+
+	"NotInstalled":     "PHPark no está instalado (ejecuta: phppark install)",
+	"InstallSucceeded": "✅ ¡PHPark se instaló correctamente!",
+	"CheckingSystem":   "\n🔧 Comprobando los requisitos del sistema...",
+	"MissingDeps":      "\n⚠️  Faltan dependencias:",
+	"QuickInstall":     "\n💡 Instalación rápida: ejecuta 'sudo phppark setup' para instalarlo todo",
+	"InstallManually":  "   O instálalas a mano: sudo apt install nginx dnsmasq php8.2-fpm",
+	"NextSteps":        "\n📚 Siguientes pasos:",
+	"StepReviewConfig": "  1. Revisa o edita la configuración: cat %s\n",
+	"StepPark":         "  2. Aparca un directorio: phppark park ~/sites",
+	"StepLink":         "  3. Enlaza un sitio: phppark link myapp",
+
+	"PHPNotInstalled":    "PHP %s no está instalado",
+	"FPMNotInstalled":    "PHP-FPM %s no está instalado (no se encontró %s)",
+	"ComposerNotFound":   "no se encontró composer (instálalo desde https://getcomposer.org)",
+	"WPCLINotFound":      "no se encontró wp (instala WP-CLI desde https://wp-cli.org)",
+	"DiffNotFound":       "--diff necesita el comando diff (instala diffutils)",
+	"MySQLNotFound":      "no se encontró el cliente mysql. Instálalo con: sudo apt install mysql-server",
+	"DnsmasqNotFound":    "dnsmasq no está instalado. Instálalo con: sudo apt install dnsmasq",
+	"SetfaclNotFound":    "no se encontró setfacl (instala el paquete acl)",
+	"NotifySendNotFound": "no se encontró notify-send (instala libnotify-bin)",
+
+	"SystemWideUnit": "%s está instalado para todo el sistema; ejecuta phppark con sudo para gestionarlo",
+	"RootUnit":       "%s tiene que ejecutarse como root, y una unidad de usuario no puede (ejecuta con sudo)",
+	"FrankenPHPUnit": "FrankenPHP sirve los puertos 80 y 443, que necesitan una unidad del sistema (ejecuta con sudo)",
+	"RemoveTrySudo":  "no se pudo eliminar %s (prueba con sudo)",
+}
@@ -0,0 +1,66 @@
+package i18n
+
+// french is the French catalog
+var french = map[string]string{
+	"ErrorPrefix": "Erreur : %v\n",
+	"Warning":     "   ⚠️  Attention : %v\n",
+	"Cancelled":   "Annulé",
+	"Continue":    "   Continuer ? (y/N) : ",
+
+	"LoadConfigFailed": "impossible de charger la configuration : %w",
+	"SaveConfigFailed": "impossible d'enregistrer la configuration : %w",
+	"LoadSitesFailed":  "impossible de charger les sites : %w",
+	"SaveSitesFailed":  "impossible d'enregistrer les sites : %w",
+	"WarnLoadConfig":   "⚠️  Impossible de charger la configuration : %v\n",
+	"WarnLoadSites":    "⚠️  Impossible de charger les sites : %v\n",
+	"WarnSaveSites":    "   ⚠️  Attention : impossible d'enregistrer les sites : %v\n",
+
+	"SiteNotFound":  "site '%s' introuvable",
+	"SiteExists":    "le site '%s' existe déjà",
+	"SiteNotLinked": "le site '%s' n'était pas lié",
+	"SitesFailed":   "%d site(s) sur %d en échec",
+
+	"CurrentDirFailed": "impossible d'obtenir le répertoire courant : %w",
+	"PathNotExist":     "le chemin n'existe pas : %w",
+	"PathNotDir":       "le chemin n'est pas un répertoire : %s",
+
+	"UnknownService": "service inconnu '%s' (voir : phppark service list)",
+	"NeedsSudo":      "⚠️  Ceci nécessite un accès sudo",
+	"ThenRebuild":    "Ensuite, lancez : sudo phppark rebuild",
+	"EnableInConfig": "\nPour l'activer, ajoutez ceci à ~/.phppark/config.yaml :",
+
+	"SetupNeedsRoot":     "setup doit être lancé en root : utilisez 'sudo phppark setup'",
+	"InstallFailed":      "impossible d'installer %s : %w",
+	"NoPHPInstalled":     "impossible d'installer une des versions de PHP %s",
+	"StubListenerBusy":   "\n⚠️  Le stub listener de systemd-resolved occupe le port 53",
+	"StubListenerManual": "   Pour corriger à la main, ajoutez DNSStubListener=no à /etc/systemd/resolved.conf\n   puis lancez : sudo systemctl restart systemd-resolved",
+	"SetupComplete":      "✅ Installation terminée !",
+	"TryItOut":           "\n📚 Essayez :",
+	"StatusTip":          "\n💡 Astuce : lancez 'phppark status' pour voir votre configuration",
+
+	"NotInstalled":     "PHPark n'est pas installé (lancez : phppark install)",
+	"InstallSucceeded": "✅ PHPark a été installé !",
+	"CheckingSystem":   "\n🔧 Vérification des prérequis système...",
+	"MissingDeps":      "\n⚠️  Dépendances manquantes :",
+	"QuickInstall":     "\n💡 Installation rapide : lancez 'sudo phppark setup' pour tout installer",
+	"InstallManually":  "   Ou installez-les à la main : sudo apt install nginx dnsmasq php8.2-fpm",
+	"NextSteps":        "\n📚 Étapes suivantes :",
+	"StepReviewConfig": "  1. Relisez/modifiez la configuration : cat %s\n",
+	"StepPark":         "  2. Parquez un répertoire : phppark park ~/sites",
+	"StepLink":         "  3. Liez un site : phppark link myapp",
+
+	"PHPNotInstalled":    "PHP %s n'est pas installé",
+	"FPMNotInstalled":    "PHP-FPM %s n'est pas installé (%s introuvable)",
+	"ComposerNotFound":   "composer introuvable (installez-le depuis https://getcomposer.org)",
+	"WPCLINotFound":      "wp introuvable (installez WP-CLI depuis https://wp-cli.org)",
+	"DiffNotFound":       "--diff a besoin de la commande diff (installez diffutils)",
+	"MySQLNotFound":      "client mysql introuvable. Installez-le avec : sudo apt install mysql-server",
+	"DnsmasqNotFound":    "dnsmasq n'est pas installé. Installez-le avec : sudo apt install dnsmasq",
+	"SetfaclNotFound":    "setfacl introuvable (installez le paquet acl)",
+	"NotifySendNotFound": "notify-send introuvable (installez libnotify-bin)",
+
+	"SystemWideUnit": "%s est installé pour tout le système ; lancez phppark avec sudo pour le gérer",
+	"RootUnit":       "%s doit tourner en root, ce qu'une unité utilisateur ne peut pas (lancez avec sudo)",
+	"FrankenPHPUnit": "FrankenPHP sert les ports 80 et 443, qui demandent une unité système (lancez avec sudo)",
+	"RemoveTrySudo":  "impossible de supprimer %s (essayez avec sudo)",
+}
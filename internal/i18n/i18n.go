@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LangEnv overrides the locale PHPark prints in, ahead of config.yaml and
+// the system's LANG
+const LangEnv = "PHPPARK_LANG"
+
+// Message is a user-facing string. Other is its English text, a fmt format;
+// translations are looked up by ID and take the same arguments in the same
+// order.
+type Message struct {
+	ID    string
+	Other string
+}
+
+// catalogs maps a language (e.g., "es") to its translations by message ID
+var catalogs = map[string]map[string]string{
+	"es": spanish,
+	"pt": portuguese,
+	"fr": french,
+}
+
+// current is the active language's catalog, nil for English
+var current map[string]string
+
+// Languages lists the languages with a catalog, besides English
+func Languages() []string {
+	return []string{"es", "fr", "pt"}
+}
+
+// Detect picks the language to print in: $PHPPARK_LANG, then the locale
+// from config.yaml, then LC_ALL, LC_MESSAGES and LANG. Locales like
+// "pt_BR.UTF-8" count as their language; ones without a catalog mean
+// English.
+func Detect(configured string) string {
+	candidates := []string{os.Getenv(LangEnv), configured, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, locale := range candidates {
+		if locale == "" {
+			continue
+		}
+		// The first one set decides, as with the C library's lookup
+		lang := language(locale)
+		if _, ok := catalogs[lang]; ok {
+			return lang
+		}
+		return "en"
+	}
+	return "en"
+}
+
+// language reduces a locale (e.g., "fr_CA.UTF-8", "pt-BR") to its language
+func language(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// SetLocale switches the language messages are printed in. Unknown ones
+// mean English.
+func SetLocale(locale string) {
+	current = catalogs[language(locale)]
+}
+
+// T returns a message's text in the active language, or in English if it
+// hasn't been translated
+func T(m *Message) string {
+	if text, ok := current[m.ID]; ok {
+		return text
+	}
+	return m.Other
+}
+
+// Sprintf formats a message in the active language
+func Sprintf(m *Message, args ...any) string {
+	return fmt.Sprintf(T(m), args...)
+}
+
+// Printf prints a message in the active language
+func Printf(m *Message, args ...any) {
+	fmt.Printf(T(m), args...)
+}
+
+// Errorf makes an error from a message in the active language; %w wraps
+// as with fmt.Errorf
+func Errorf(m *Message, args ...any) error {
+	return fmt.Errorf(T(m), args...)
+}
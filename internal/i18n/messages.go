@@ -0,0 +1,76 @@
+package i18n
+
+// Messages shared across commands. Emoji and leading indentation are part
+// of the text so translations keep the layout.
+var (
+	ErrorPrefix = &Message{ID: "ErrorPrefix", Other: "Error: %v\n"}
+	Warning     = &Message{ID: "Warning", Other: "   ⚠️  Warning: %v\n"}
+	Cancelled   = &Message{ID: "Cancelled", Other: "Cancelled"}
+	Continue    = &Message{ID: "Continue", Other: "   Continue? (y/N): "}
+
+	LoadConfigFailed = &Message{ID: "LoadConfigFailed", Other: "failed to load config: %w"}
+	SaveConfigFailed = &Message{ID: "SaveConfigFailed", Other: "failed to save config: %w"}
+	LoadSitesFailed  = &Message{ID: "LoadSitesFailed", Other: "failed to load sites: %w"}
+	SaveSitesFailed  = &Message{ID: "SaveSitesFailed", Other: "failed to save sites: %w"}
+	WarnLoadConfig   = &Message{ID: "WarnLoadConfig", Other: "⚠️  Failed to load config: %v\n"}
+	WarnLoadSites    = &Message{ID: "WarnLoadSites", Other: "⚠️  Failed to load sites: %v\n"}
+	WarnSaveSites    = &Message{ID: "WarnSaveSites", Other: "   ⚠️  Warning: failed to save sites: %v\n"}
+
+	SiteNotFound  = &Message{ID: "SiteNotFound", Other: "site '%s' not found"}
+	SiteExists    = &Message{ID: "SiteExists", Other: "site '%s' already exists"}
+	SiteNotLinked = &Message{ID: "SiteNotLinked", Other: "site '%s' was not linked"}
+	SitesFailed   = &Message{ID: "SitesFailed", Other: "%d of %d site(s) failed"}
+
+	CurrentDirFailed = &Message{ID: "CurrentDirFailed", Other: "failed to get current directory: %w"}
+	PathNotExist     = &Message{ID: "PathNotExist", Other: "path does not exist: %w"}
+	PathNotDir       = &Message{ID: "PathNotDir", Other: "path is not a directory: %s"}
+
+	UnknownService = &Message{ID: "UnknownService", Other: "unknown service '%s' (see: phppark service list)"}
+	NeedsSudo      = &Message{ID: "NeedsSudo", Other: "⚠️  This requires sudo access"}
+	ThenRebuild    = &Message{ID: "ThenRebuild", Other: "Then run: sudo phppark rebuild"}
+	EnableInConfig = &Message{ID: "EnableInConfig", Other: "\nTo enable, set the following in ~/.phppark/config.yaml:"}
+)
+
+// Setup and install guidance
+var (
+	SetupNeedsRoot     = &Message{ID: "SetupNeedsRoot", Other: "setup must be run as root: use 'sudo phppark setup'"}
+	InstallFailed      = &Message{ID: "InstallFailed", Other: "failed to install %s: %w"}
+	NoPHPInstalled     = &Message{ID: "NoPHPInstalled", Other: "failed to install any of PHP %s"}
+	StubListenerBusy   = &Message{ID: "StubListenerBusy", Other: "\n⚠️  systemd-resolved stub listener is occupying port 53"}
+	StubListenerManual = &Message{ID: "StubListenerManual", Other: "   To fix manually, add DNSStubListener=no to /etc/systemd/resolved.conf\n   then run: sudo systemctl restart systemd-resolved"}
+	SetupComplete      = &Message{ID: "SetupComplete", Other: "✅ Setup complete!"}
+	TryItOut           = &Message{ID: "TryItOut", Other: "\n📚 Try it out:"}
+	StatusTip          = &Message{ID: "StatusTip", Other: "\n💡 Tip: Run 'phppark status' to see your configuration"}
+
+	NotInstalled     = &Message{ID: "NotInstalled", Other: "PHPark is not installed (run: phppark install)"}
+	InstallSucceeded = &Message{ID: "InstallSucceeded", Other: "✅ PHPark installed successfully!"}
+	CheckingSystem   = &Message{ID: "CheckingSystem", Other: "\n🔧 Checking system requirements..."}
+	MissingDeps      = &Message{ID: "MissingDeps", Other: "\n⚠️  Missing dependencies detected:"}
+	QuickInstall     = &Message{ID: "QuickInstall", Other: "\n💡 Quick install: Run 'sudo phppark setup' to install everything"}
+	InstallManually  = &Message{ID: "InstallManually", Other: "   Or install manually: sudo apt install nginx dnsmasq php8.2-fpm"}
+	NextSteps        = &Message{ID: "NextSteps", Other: "\n📚 Next steps:"}
+	StepReviewConfig = &Message{ID: "StepReviewConfig", Other: "  1. Review/edit config: cat %s\n"}
+	StepPark         = &Message{ID: "StepPark", Other: "  2. Park a directory: phppark park ~/sites"}
+	StepLink         = &Message{ID: "StepLink", Other: "  3. Link a site: phppark link myapp"}
+)
+
+// Missing dependencies
+var (
+	PHPNotInstalled    = &Message{ID: "PHPNotInstalled", Other: "PHP %s is not installed"}
+	FPMNotInstalled    = &Message{ID: "FPMNotInstalled", Other: "PHP-FPM %s is not installed (%s not found)"}
+	ComposerNotFound   = &Message{ID: "ComposerNotFound", Other: "composer not found (install it from https://getcomposer.org)"}
+	WPCLINotFound      = &Message{ID: "WPCLINotFound", Other: "wp not found (install WP-CLI from https://wp-cli.org)"}
+	DiffNotFound       = &Message{ID: "DiffNotFound", Other: "--diff needs the diff command (install diffutils)"}
+	MySQLNotFound      = &Message{ID: "MySQLNotFound", Other: "mysql client not found. Install with: sudo apt install mysql-server"}
+	DnsmasqNotFound    = &Message{ID: "DnsmasqNotFound", Other: "dnsmasq not installed. Install with: sudo apt install dnsmasq"}
+	SetfaclNotFound    = &Message{ID: "SetfaclNotFound", Other: "setfacl not found (install the acl package)"}
+	NotifySendNotFound = &Message{ID: "NotifySendNotFound", Other: "notify-send not found (install libnotify-bin)"}
+)
+
+// Running with or without sudo
+var (
+	SystemWideUnit = &Message{ID: "SystemWideUnit", Other: "%s is installed system-wide; run phppark with sudo to manage it"}
+	RootUnit       = &Message{ID: "RootUnit", Other: "%s has to run as root, which a user unit can't (run with sudo)"}
+	FrankenPHPUnit = &Message{ID: "FrankenPHPUnit", Other: "FrankenPHP serves ports 80 and 443, which need a system unit (run with sudo)"}
+	RemoveTrySudo  = &Message{ID: "RemoveTrySudo", Other: "failed to remove %s (try with sudo)"}
+)
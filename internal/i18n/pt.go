@@ -0,0 +1,66 @@
+package i18n
+
+// portuguese is the Portuguese catalog
+var portuguese = map[string]string{
+	"ErrorPrefix": "Erro: %v\n",
+	"Warning":     "   ⚠️  Aviso: %v\n",
+	"Cancelled":   "Cancelado",
+	"Continue":    "   Continuar? (y/N): ",
+
+	"LoadConfigFailed": "não foi possível carregar a configuração: %w",
+	"SaveConfigFailed": "não foi possível salvar a configuração: %w",
+	"LoadSitesFailed":  "não foi possível carregar os sites: %w",
+	"SaveSitesFailed":  "não foi possível salvar os sites: %w",
+	"WarnLoadConfig":   "⚠️  Não foi possível carregar a configuração: %v\n",
+	"WarnLoadSites":    "⚠️  Não foi possível carregar os sites: %v\n",
+	"WarnSaveSites":    "   ⚠️  Aviso: não foi possível salvar os sites: %v\n",
+
+	"SiteNotFound":  "site '%s' não encontrado",
+	"SiteExists":    "o site '%s' já existe",
+	"SiteNotLinked": "o site '%s' não estava vinculado",
+	"SitesFailed":   "%d de %d site(s) falharam",
+
+	"CurrentDirFailed": "não foi possível obter o diretório atual: %w",
+	"PathNotExist":     "o caminho não existe: %w",
+	"PathNotDir":       "o caminho não é um diretório: %s",
+
+	"UnknownService": "serviço desconhecido '%s' (veja: phppark service list)",
+	"NeedsSudo":      "⚠️  Isto requer acesso sudo",
+	"ThenRebuild":    "Depois execute: sudo phppark rebuild",
+	"EnableInConfig": "\nPara ativar, defina o seguinte em ~/.phppark/config.yaml:",
+
+	"SetupNeedsRoot":     "setup precisa ser executado como root: use 'sudo phppark setup'",
+	"InstallFailed":      "não foi possível instalar %s: %w",
+	"NoPHPInstalled":     "não foi possível instalar nenhuma versão do PHP %s",
+	"StubListenerBusy":   "\n⚠️  O stub listener do systemd-resolved está ocupando a porta 53",
+	"StubListenerManual": "   Para corrigir manualmente, adicione DNSStubListener=no a /etc/systemd/resolved.conf\n   e depois execute: sudo systemctl restart systemd-resolved",
+	"SetupComplete":      "✅ Instalação concluída!",
+	"TryItOut":           "\n📚 Experimente:",
+	"StatusTip":          "\n💡 Dica: execute 'phppark status' para ver sua configuração",
+
+	"NotInstalled":     "O PHPark não está instalado (execute: phppark install)",
+	"InstallSucceeded": "✅ PHPark instalado com sucesso!",
+	"CheckingSystem":   "\n🔧 Verificando os requisitos do sistema...",
+	"MissingDeps":      "\n⚠️  Dependências ausentes:",
+	"QuickInstall":     "\n💡 Instalação rápida: execute 'sudo phppark setup' para instalar tudo",
+	"InstallManually":  "   Ou instale manualmente: sudo apt install nginx dnsmasq php8.2-fpm",
+	"NextSteps":        "\n📚 Próximos passos:",
+	"StepReviewConfig": "  1. Revise/edite a configuração: cat %s\n",
+	"StepPark":         "  2. Estacione um diretório: phppark park ~/sites",
+	"StepLink":         "  3. Vincule um site: phppark link myapp",
+
+	"PHPNotInstalled":    "o PHP %s não está instalado",
+	"FPMNotInstalled":    "o PHP-FPM %s não está instalado (%s não encontrado)",
+	"ComposerNotFound":   "composer não encontrado (instale-o a partir de https://getcomposer.org)",
+	"WPCLINotFound":      "wp não encontrado (instale o WP-CLI a partir de https://wp-cli.org)",
+	"DiffNotFound":       "--diff precisa do comando diff (instale o diffutils)",
+	"MySQLNotFound":      "cliente mysql não encontrado. Instale com: sudo apt install mysql-server",
+	"DnsmasqNotFound":    "o dnsmasq não está instalado. Instale com: sudo apt install dnsmasq",
+	"SetfaclNotFound":    "setfacl não encontrado (instale o pacote acl)",
+	"NotifySendNotFound": "notify-send não encontrado (instale o libnotify-bin)",
+
+	"SystemWideUnit": "%s está instalado para todo o sistema; execute o phppark com sudo para gerenciá-lo",
+	"RootUnit":       "%s precisa rodar como root, o que uma unidade de usuário não pode (execute com sudo)",
+	"FrankenPHPUnit": "O FrankenPHP serve as portas 80 e 443, que precisam de uma unidade do sistema (execute com sudo)",
+	"RemoveTrySudo":  "não foi possível remover %s (tente com sudo)",
+}
@@ -0,0 +1,41 @@
+package nginx
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AccessEntry is one request from a JSONLogFormat access log
+type AccessEntry struct {
+	Time         time.Time `json:"time"`
+	RemoteAddr   string    `json:"remote_addr"`
+	Method       string    `json:"method"`
+	URI          string    `json:"uri"`
+	Status       int       `json:"status"`
+	Bytes        int64     `json:"bytes"`
+	RequestTime  float64   `json:"request_time"`  // Seconds, including sending the response
+	UpstreamTime string    `json:"upstream_time"` // Seconds PHP (or the upstream) took, "" for static files
+	Referer      string    `json:"referer"`
+	UserAgent    string    `json:"user_agent"`
+}
+
+// ParseAccessEntry parses a line of a JSONLogFormat access log
+func ParseAccessEntry(line []byte) (*AccessEntry, error) {
+	var entry AccessEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Path is the request's URI without its query string
+func (e *AccessEntry) Path() string {
+	path, _, _ := strings.Cut(e.URI, "?")
+	return path
+}
+
+// Duration is how long the request took
+func (e *AccessEntry) Duration() time.Duration {
+	return time.Duration(e.RequestTime * float64(time.Second))
+}
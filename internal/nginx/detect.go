@@ -0,0 +1,118 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Built-in templates. The generic (Laravel-style) template has no name of
+// its own on a SiteConfig.
+const (
+	TemplateLaravel   = "laravel"
+	TemplateWordPress = "wordpress"
+	TemplateDrupal    = "drupal"
+	TemplateMagento   = "magento"
+	TemplateSymfony   = "symfony"
+	TemplateStatic    = "static"
+	TemplateSPA       = "spa"
+	TemplateProxy     = "proxy"
+)
+
+// TemplateInfo describes a built-in template for listings
+type TemplateInfo struct {
+	Name        string
+	Description string
+	Detected    string // What auto-detection looks for (empty if never detected)
+}
+
+// BuiltinTemplates lists the built-in templates in the order they're shown
+var BuiltinTemplates = []TemplateInfo{
+	{TemplateLaravel, "PHP front controller, any file can run (default)", ""},
+	{TemplateSymfony, "Symfony front controller, profiler and toolbar routes", "bin/console"},
+	{TemplateWordPress, "Permalinks, multisite rewrites, 64M uploads", "wp-load.php"},
+	{TemplateDrupal, "Clean URLs, update.php, image styles, private files", "core/lib/Drupal.php"},
+	{TemplateMagento, "pub/ docroot, static and media rewrites, 600s timeout", "bin/magento"},
+	{TemplateStatic, "Plain files, no PHP", ""},
+	{TemplateSPA, "Plain files, unknown paths fall back to index.html", ""},
+	{TemplateProxy, "Forward to an upstream URL (needs --proxy)", ""},
+}
+
+// WordPressMaxBody is the upload limit WordPress sites get by default; 1M
+// rejects most theme and plugin uploads
+const WordPressMaxBody = "64M"
+
+// MagentoTimeout is the request timeout in seconds Magento sites get by
+// default, since cold caches and admin actions run far past nginx's 60s
+const MagentoTimeout = 600
+
+var multisitePattern = regexp.MustCompile(`define\(\s*['"]MULTISITE['"]\s*,\s*true\s*\)`)
+
+// IsTemplate reports whether name is a built-in template
+func IsTemplate(name string) bool {
+	for _, info := range BuiltinTemplates {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectTemplate guesses a site's framework template from its files,
+// returning "" for the generic one
+func DetectTemplate(sitePath string) string {
+	if WordPressRoot(sitePath) != "" {
+		return TemplateWordPress
+	}
+	if fileExists(filepath.Join(GetDocumentRoot(sitePath), "core", "lib", "Drupal.php")) {
+		return TemplateDrupal
+	}
+	if fileExists(filepath.Join(sitePath, "bin", "magento")) {
+		return TemplateMagento
+	}
+	if fileExists(filepath.Join(sitePath, "bin", "console")) {
+		return TemplateSymfony
+	}
+	return ""
+}
+
+// WordPressRoot returns the directory holding WordPress core (wp-load.php)
+// for a site, or "" if it isn't a WordPress site
+func WordPressRoot(sitePath string) string {
+	for _, dir := range []string{GetDocumentRoot(sitePath), sitePath} {
+		if fileExists(filepath.Join(dir, "wp-load.php")) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// IsWordPressMultisite reports whether a WordPress site's wp-config.php
+// turns on multisite. wp-config.php may also sit one level above core.
+func IsWordPressMultisite(sitePath string) bool {
+	root := WordPressRoot(sitePath)
+	if root == "" {
+		return false
+	}
+
+	for _, dir := range []string{root, filepath.Dir(root)} {
+		if data, err := os.ReadFile(filepath.Join(dir, "wp-config.php")); err == nil {
+			return multisitePattern.Match(data)
+		}
+	}
+	return false
+}
+
+// TemplateDocumentRoot returns the document root a template requires, or ""
+// if the usual guess (GetDocumentRoot) applies
+func TemplateDocumentRoot(template, sitePath string) string {
+	if template == TemplateMagento {
+		return filepath.Join(sitePath, "pub")
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,24 @@
+package nginx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// commentPattern matches nginx comments, to the end of the line
+var commentPattern = regexp.MustCompile(`#[^\n]*`)
+
+// Directives returns the arguments of every occurrence of a simple
+// directive (e.g., "root" or "fastcgi_pass") in a config, in order. It's a
+// line-oriented reading meant for PHPark's own generated configs, not a
+// full nginx parser.
+func Directives(content []byte, name string) [][]string {
+	pattern := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(name) + `\s+([^;{}]+);`)
+	text := commentPattern.ReplaceAllString(string(content), "")
+
+	var found [][]string
+	for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, strings.Fields(match[1]))
+	}
+	return found
+}
@@ -0,0 +1,54 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateEnv checks that a variable can be safely emitted as a fastcgi_param
+func ValidateEnv(name, value string) error {
+	if !envNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid variable name '%s' (use letters, digits and underscores)", name)
+	}
+
+	// nginx expands $ in parameter values and has no escape for it
+	if strings.Contains(value, "$") {
+		return fmt.Errorf("value for %s cannot contain '$'", name)
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("value for %s cannot contain newlines", name)
+	}
+
+	return nil
+}
+
+// EnvParams converts site environment variables to sorted, quoted fastcgi params
+func EnvParams(env map[string]string) []FastCGIParam {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]FastCGIParam, 0, len(names))
+	for _, name := range names {
+		params = append(params, FastCGIParam{
+			Name:  name,
+			Value: quoteValue(env[name]),
+		})
+	}
+
+	return params
+}
+
+// quoteValue wraps a value in double quotes, escaping quotes and backslashes
+func quoteValue(value string) string {
+	escaped := strings.ReplaceAll(value, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	return `"` + escaped + `"`
+}
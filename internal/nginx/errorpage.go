@@ -0,0 +1,84 @@
+package nginx
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// ErrorDiagnosis is what a site's diagnostic error page says about the
+// PHP-FPM that didn't answer
+type ErrorDiagnosis struct {
+	ServerName string    // e.g., "myapp.test"
+	PHPVersion string    // e.g., "8.2"
+	Service    string    // systemd service, e.g., "php8.2-fpm" (empty when PHPark doesn't run it)
+	Listen     string    // Socket or host:port nginx passes PHP requests to
+	LogPath    string    // PHP-FPM's error log (empty when it's elsewhere, e.g., in a container)
+	LogTail    []string  // Its last lines when the page was written
+	LogError   string    // Why the log couldn't be read, if it couldn't
+	Fixes      []string  // Commands that usually bring PHP-FPM back
+	Written    time.Time // When the page was written
+	LogHidden  bool      // The log is left out: the page is for clients on other machines
+}
+
+const errorPageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>PHP-FPM isn't answering · {{.ServerName}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 52rem; margin: 3rem auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.5rem; }
+dt { font-weight: 600; margin-top: .5rem; }
+pre { background: #f4f4f4; padding: 1rem; overflow-x: auto; font-size: .85rem; }
+footer { color: #777; font-size: .8rem; margin-top: 2rem; }
+</style>
+</head>
+<body>
+<h1>❌ {{.ServerName}}: PHP-FPM isn't answering</h1>
+<p>nginx couldn't get a response from PHP, so it returned a 502/504. This page comes from PHPark, not from your application.</p>
+<dl>
+<dt>PHP version</dt><dd>{{.PHPVersion}}</dd>
+{{- if .Service}}
+<dt>Service</dt><dd><code>{{.Service}}</code></dd>
+{{- end}}
+<dt>Listening on</dt><dd><code>{{.Listen}}</code></dd>
+</dl>
+{{- if .Fixes}}
+<h2>To fix it</h2>
+<pre>{{range .Fixes}}{{.}}
+{{end}}</pre>
+{{- end}}
+{{- if .LogHidden}}
+<p>Open the site from this machine to see the end of PHP-FPM's log.</p>
+{{- else if .LogPath}}
+<h2>{{.LogPath}}</h2>
+{{- if .LogError}}
+<p>Couldn't read the log: {{.LogError}}</p>
+{{- else if .LogTail}}
+<pre>{{range .LogTail}}{{.}}
+{{end}}</pre>
+{{- else}}
+<p>The log is empty.</p>
+{{- end}}
+{{- end}}
+<footer>Written by PHPark at {{.Written.Format "2006-01-02 15:04:05"}}. Turn this page off with <code>error_pages: false</code> in config.yaml.</footer>
+</body>
+</html>
+`
+
+// GenerateErrorPage renders the diagnostic page nginx serves for a site
+// when PHP-FPM doesn't answer
+func GenerateErrorPage(d *ErrorDiagnosis) (string, error) {
+	tmpl, err := template.New("errorpage").Parse(errorPageTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse error page template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return "", fmt.Errorf("failed to execute error page template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
+
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
 // GetPHPSocket returns the PHP-FPM socket path for a given PHP version
@@ -16,13 +19,47 @@ func GetPHPSocket(phpVersion string) string {
 	return fmt.Sprintf("/var/run/php/php%s-fpm.sock", phpVersion)
 }
 
+// FastCGITarget turns where PHP-FPM listens (a socket path or host:port)
+// into a fastcgi_pass target
+func FastCGITarget(listen string) string {
+	if strings.HasPrefix(listen, "/") {
+		return "unix:" + listen
+	}
+	return listen
+}
+
+// ScriptRoot returns the document root PHP-FPM resolves scripts against:
+// the remote path when PHP runs elsewhere, otherwise nginx's own
+func (c *SiteConfig) ScriptRoot() string {
+	if c.RemoteRoot != "" {
+		return c.RemoteRoot
+	}
+	return "$realpath_root"
+}
+
+// ForMount returns the site's settings as its mounts' PHP gets them: the
+// same limits and environment, but no remote document root, since mounts
+// run on the local PHP-FPM
+func (c *SiteConfig) ForMount() *SiteConfig {
+	local := *c
+	local.RemoteRoot = ""
+	return &local
+}
+
+// DocRootCandidates are the directories GetDocumentRoot looks for, in order
+// (common Laravel/Symfony/modern PHP structure)
+var DocRootCandidates = []string{"public", "public_html", "web", "htdocs"}
+
 // GetDocumentRoot determines the document root for a site
 // Looks for common directories: public, public_html, web, or uses site path
 func GetDocumentRoot(sitePath string) string {
-	// Common Laravel/Symfony/modern PHP structure
-	publicDirs := []string{"public", "public_html", "web", "htdocs"}
+	return FindDocumentRoot(sitePath, DocRootCandidates)
+}
 
-	for _, dir := range publicDirs {
+// FindDocumentRoot returns the first of the candidate directories that
+// exists in the site, or the site path itself if none does
+func FindDocumentRoot(sitePath string, candidates []string) string {
+	for _, dir := range candidates {
 		fullPath := filepath.Join(sitePath, dir)
 		if info, err := os.Stat(fullPath); err == nil && info.IsDir() {
 			return fullPath
@@ -35,12 +72,37 @@ func GetDocumentRoot(sitePath string) string {
 
 // GenerateConfig generates nginx configuration from a SiteConfig
 func GenerateConfig(cfg *SiteConfig) (string, error) {
-	tmpl, err := template.New("nginx").Parse(GetTemplate())
+	source := GetTemplate()
+	switch {
+	case cfg.TemplateSource != "":
+		source = cfg.TemplateSource
+	case cfg.Octane:
+		source = GetOctaneTemplate()
+	case cfg.ProxyPass != "":
+		source = GetProxyTemplate()
+	case cfg.Template == TemplateWordPress:
+		source = GetWordPressTemplate()
+	case cfg.Template == TemplateDrupal:
+		source = GetDrupalTemplate()
+	case cfg.Template == TemplateMagento:
+		source = GetMagentoTemplate()
+	case cfg.Template == TemplateSymfony:
+		source = GetSymfonyTemplate()
+	case cfg.Template == TemplateStatic, cfg.Template == TemplateSPA:
+		source = GetStaticTemplate()
+	}
+	if cfg.UseSSL && cfg.RedirectHTTP {
+		source = GetRedirectTemplate() + source
+	}
+
+	tmpl, err := template.New("nginx").Parse(fastcgiTemplate + source)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
 
+	// Tag the config so stale ones can be told apart from hand-written ones
 	var buf bytes.Buffer
+	buf.WriteString(GeneratedMarker + cfg.SiteName + "\n")
 	if err := tmpl.Execute(&buf, cfg); err != nil {
 		return "", fmt.Errorf("failed to execute template: %w", err)
 	}
@@ -48,6 +110,20 @@ func GenerateConfig(cfg *SiteConfig) (string, error) {
 	return buf.String(), nil
 }
 
+// GeneratedMarker starts the first line of every site config PHPark
+// generates, followed by the site's name
+const GeneratedMarker = "# Generated by PHPark for site "
+
+// GeneratedFor returns the site a config was generated for, or "" if
+// PHPark didn't generate it
+func GeneratedFor(content []byte) string {
+	line, _, _ := strings.Cut(string(content), "\n")
+	if !strings.HasPrefix(line, GeneratedMarker) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, GeneratedMarker))
+}
+
 // CreateSiteConfig creates a SiteConfig from basic site information
 func CreateSiteConfig(siteName, sitePath, domain, phpVersion string, useSSL bool) *SiteConfig {
 	if phpVersion == "" {
@@ -59,21 +135,23 @@ func CreateSiteConfig(siteName, sitePath, domain, phpVersion string, useSSL bool
 	phpSocket := GetPHPSocket(phpVersion)
 
 	cfg := &SiteConfig{
-		SiteName:   siteName,
-		Domain:     domain,
-		ServerName: serverName,
-		Root:       documentRoot,
-		SitePath:   sitePath,
-		PHPVersion: phpVersion,
-		PHPSocket:  phpSocket,
-		UseSSL:     useSSL,
-		ListenPort: 80,
+		SiteName:    siteName,
+		Domain:      domain,
+		ServerName:  serverName,
+		Root:        documentRoot,
+		SitePath:    sitePath,
+		PHPVersion:  phpVersion,
+		PHPSocket:   phpSocket,
+		FastCGIPass: FastCGITarget(phpSocket),
+		UseSSL:      useSSL,
+		ListenPort:  80,
+		SSLPort:     443,
 	}
 
 	if useSSL {
 		certDir := fmt.Sprintf("/home/%s/.phppark/certificates", os.Getenv("USER"))
 		cfg.CertPath = filepath.Join(certDir, fmt.Sprintf("%s.crt", siteName))
-		cfg.KeyPath = filepath.Join(certDir, fmt.Sprintf("%s.key", siteName))
+		cfg.KeyPath = filepath.Join(certDir, "private", fmt.Sprintf("%s.key", siteName))
 	}
 
 	return cfg
@@ -88,7 +166,7 @@ func WriteConfigFile(configPath string, content string) error {
 	}
 
 	// Write file
-	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+	if err := oplog.WriteFile(configPath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -0,0 +1,183 @@
+package nginx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// LogFormat is the access log format the global include defines for sites
+const LogFormat = "phppark"
+
+// JSONLogFormat is the JSON access log format the global include defines,
+// read back by `phppark traffic`
+const JSONLogFormat = "phppark_json"
+
+// CacheZone is the FastCGI cache zone the global include defines, for
+// templates that opt in with `fastcgi_cache phppark;`
+const CacheZone = "phppark"
+
+// UpgradeVariable is the variable the global include maps $http_upgrade
+// to: "upgrade" for websocket requests, "close" otherwise. It's prefixed so
+// it can't clash with the $connection_upgrade many configs define.
+const UpgradeVariable = "phppark_connection_upgrade"
+
+// GlobalConfig is the http-level config PHPark shares between sites
+type GlobalConfig struct {
+	CacheDir  string     // fastcgi_cache_path for the shared cache zone
+	Upstreams []Upstream // PHP-FPM upstreams sites pass requests to
+
+	// StatusAddr is where nginx's stub_status and each upstream's PHP-FPM
+	// status page are served, to localhost only, for `phppark status
+	// --runtime`. Empty leaves the status server out.
+	StatusAddr string
+}
+
+// Upstream is a named PHP-FPM upstream
+type Upstream struct {
+	Name   string // e.g., "phppark_php83", or "phppark_alice_php83" in multi-user mode
+	Server string // e.g., "unix:/var/run/php/php8.3-fpm.sock"
+}
+
+// UpstreamName returns the shared upstream for a PHP version. In
+// multi-user mode each user's upstreams are prefixed with their namespace,
+// since their fpm_listen settings can differ.
+func UpstreamName(namespace, phpVersion string) string {
+	version := strings.ReplaceAll(phpVersion, ".", "")
+	if namespace == "" {
+		return "phppark_php" + version
+	}
+	return "phppark_" + strings.ReplaceAll(namespace, "-", "_") + "_php" + version
+}
+
+// PHPUpstreams returns an upstream for each PHP version, in version order,
+// given where each version's PHP-FPM listens (a socket path or host:port)
+func PHPUpstreams(namespace string, listen map[string]string) []Upstream {
+	var versions []string
+	for version := range listen {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var upstreams []Upstream
+	for _, version := range versions {
+		upstreams = append(upstreams, Upstream{
+			Name:   UpstreamName(namespace, version),
+			Server: FastCGITarget(listen[version]),
+		})
+	}
+	return upstreams
+}
+
+// upstreamPattern matches the upstreams in a generated global include
+var upstreamPattern = regexp.MustCompile(`(?m)^upstream (\S+) \{\s*server ([^;]+);`)
+
+// ParseUpstreams returns the upstreams defined in a global include
+func ParseUpstreams(content string) []Upstream {
+	var upstreams []Upstream
+	for _, match := range upstreamPattern.FindAllStringSubmatch(content, -1) {
+		upstreams = append(upstreams, Upstream{Name: match[1], Server: match[2]})
+	}
+	return upstreams
+}
+
+// MergeUpstreams adds the upstreams from others that ours doesn't define,
+// sorted by name. Ours win where both define one.
+func MergeUpstreams(ours, others []Upstream) []Upstream {
+	seen := make(map[string]bool)
+	merged := append([]Upstream(nil), ours...)
+	for _, upstream := range ours {
+		seen[upstream.Name] = true
+	}
+	for _, upstream := range others {
+		if !seen[upstream.Name] {
+			seen[upstream.Name] = true
+			merged = append(merged, upstream)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return merged
+}
+
+const globalTemplate = `# Managed by PHPark: rewritten whenever sites are deployed, so edits are lost
+
+# Upgrade websocket connections, let the rest close (for templates:
+# proxy_set_header Connection ${{.UpgradeVariable}};)
+map $http_upgrade ${{.UpgradeVariable}} {
+    default upgrade;
+    ''      close;
+}
+
+# Access log format for sites, with PHP and upstream timings
+log_format {{.LogFormat}} '$remote_addr - $remote_user [$time_local] "$request" '
+                   '$status $body_bytes_sent "$http_referer" "$http_user_agent" '
+                   'rt=$request_time urt=$upstream_response_time';
+
+# The same as JSON, one object per line, for phppark traffic
+log_format {{.JSONLogFormat}} escape=json '{"time":"$time_iso8601","remote_addr":"$remote_addr",'
+                   '"method":"$request_method","uri":"$request_uri","status":$status,'
+                   '"bytes":$body_bytes_sent,"request_time":$request_time,'
+                   '"upstream_time":"$upstream_response_time","referer":"$http_referer",'
+                   '"user_agent":"$http_user_agent"}';
+
+# Shared FastCGI cache for templates that opt in (fastcgi_cache {{.CacheZone}};
+# with their own fastcgi_cache_key, so the http level's stays the user's)
+fastcgi_cache_path {{.CacheDir}} levels=1:2 keys_zone={{.CacheZone}}:10m max_size=256m inactive=60m;
+{{range .Upstreams}}
+upstream {{.Name}} {
+    server {{.Server}};
+    keepalive 8;
+}
+{{end}}
+{{- if .StatusAddr}}
+# Runtime metrics for phppark status --runtime (localhost only)
+server {
+    listen {{.StatusAddr}};
+    access_log off;
+    allow 127.0.0.1;
+    deny all;
+
+    location = /nginx-status {
+        stub_status;
+    }
+{{- if .Upstreams}}
+
+    # Each upstream's PHP-FPM status page, once fpm_status enables it
+{{- end}}
+{{- range .Upstreams}}
+    location = /fpm-status/{{.Name}} {
+        fastcgi_pass {{.Name}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_NAME /fpm-status;
+        fastcgi_param SCRIPT_FILENAME /fpm-status;
+    }
+{{- end}}
+}
+{{- end}}
+`
+
+// GenerateGlobalConfig renders the global include
+func GenerateGlobalConfig(cfg *GlobalConfig) (string, error) {
+	tmpl, err := template.New("global").Parse(globalTemplate)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	data := struct {
+		*GlobalConfig
+		LogFormat       string
+		JSONLogFormat   string
+		CacheZone       string
+		UpgradeVariable string
+	}{cfg, LogFormat, JSONLogFormat, CacheZone, UpgradeVariable}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,85 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var sizePattern = regexp.MustCompile(`^([0-9]+)([kKmMgG]?)$`)
+
+// ValidateSize checks a body size in the syntax nginx and php.ini share
+// (e.g., "512M", "2g", "100k")
+func ValidateSize(size string) error {
+	if !sizePattern.MatchString(size) {
+		return fmt.Errorf("invalid size '%s' (use a number with an optional K, M or G suffix, e.g., 512M)", size)
+	}
+	return nil
+}
+
+// SizeBytes converts a size accepted by ValidateSize to bytes
+func SizeBytes(size string) int64 {
+	match := sizePattern.FindStringSubmatch(size)
+	if match == nil {
+		return 0
+	}
+
+	n, _ := strconv.ParseInt(match[1], 10, 64)
+	switch strings.ToUpper(match[2]) {
+	case "K":
+		n <<= 10
+	case "M":
+		n <<= 20
+	case "G":
+		n <<= 30
+	}
+	return n
+}
+
+// BodySize returns the site's client_max_body_size: its own limit, or the
+// template's default (empty for nginx's)
+func (c *SiteConfig) BodySize() string {
+	if c.MaxBodySize == "" && c.Template == TemplateWordPress {
+		return WordPressMaxBody
+	}
+	return c.MaxBodySize
+}
+
+// RequestTimeout returns the seconds a request may run: the site's own
+// timeout, or the template's default (0 for the web server's)
+func (c *SiteConfig) RequestTimeout() int {
+	if c.Timeout == 0 && c.Template == TemplateMagento {
+		return MagentoTimeout
+	}
+	return c.Timeout
+}
+
+// DelayScriptSource is the PHP file behind `phppark throttle --delay`. nginx
+// can't pause a response by itself, so every PHP request starts with this
+// sleep. It's prepended by the site's own PHP-FPM (a PHP_VALUE would stick
+// to a shared pool's worker and slow down the sites it serves next), and
+// the delay comes from a per-request param.
+const DelayScriptSource = `<?php
+// Written by PHPark for phppark throttle --delay
+if (!empty($_SERVER['PHPPARK_DELAY_MS'])) {
+    usleep((int) $_SERVER['PHPPARK_DELAY_MS'] * 1000);
+}
+`
+
+// PHPLimits returns the php.ini overrides that match a site's own limits,
+// for the site's own PHP-FPM. Without them PHP would still reject uploads
+// (or stop scripts) at its own defaults. They aren't passed as PHP_VALUE:
+// that sticks to a shared pool's worker and applies to the sites it serves
+// next.
+func PHPLimits(maxBody string, timeout int) map[string]string {
+	settings := make(map[string]string)
+	if maxBody != "" {
+		settings["upload_max_filesize"] = maxBody
+		settings["post_max_size"] = maxBody
+	}
+	if timeout > 0 {
+		settings["max_execution_time"] = strconv.Itoa(timeout)
+	}
+	return settings
+}
@@ -1,9 +1,94 @@
 package nginx
 
+// fastcgiTemplate holds the per-site fastcgi settings shared by every
+// template that runs PHP: limits and environment. It also defines
+// "throttle", the server-level speed limit every template includes,
+// "errorpage", PHPark's diagnostic page for when PHP-FPM
+// doesn't answer, and "mounts", the apps served under paths of the site.
+const fastcgiTemplate = `{{define "fastcgi"}}
+        {{- if .RemoteRoot}}
+        fastcgi_param DOCUMENT_ROOT {{.RemoteRoot}};
+        {{- end}}
+        {{- if .Shared}}
+        fastcgi_keep_conn on;
+        {{- end}}
+        {{- if .RequestTimeout}}
+        fastcgi_read_timeout {{.RequestTimeout}}s;
+        {{- end}}
+        {{- if .Delay}}
+        fastcgi_param PHPPARK_DELAY_MS {{.Delay}};
+        {{- end}}
+        {{- range .Env}}
+        fastcgi_param {{.Name}} {{.Value}};
+        {{- end}}
+{{- end}}
+
+{{- define "throttle"}}
+    {{- if .LimitRate}}
+    limit_rate {{.LimitRate}};
+    {{- end}}
+{{- end}}
+
+{{- define "errorpage"}}
+    {{- if .ErrorPage}}
+
+    # PHPark's diagnostic page when PHP-FPM doesn't answer, with the end
+    # of its log for clients on this machine only
+    error_page 502 504 /__phppark/fpm-error.html;
+    location = /__phppark/fpm-error.html {
+        alias {{.ErrorPage}};
+        internal;
+        {{- if .LocalErrorPage}}
+        if ($remote_addr ~ "^(127\.|::1$)") {
+            rewrite ^ /__phppark/fpm-error-local.html last;
+        }
+        {{- end}}
+    }
+    {{- if .LocalErrorPage}}
+    location = /__phppark/fpm-error-local.html {
+        alias {{.LocalErrorPage}};
+        internal;
+    }
+    {{- end}}
+    {{- end}}
+{{- end}}
+
+{{- define "mounts"}}
+    {{- range .Mounts}}
+
+    # {{.Path}} is another app (see: phppark mount)
+    location = {{.Path}} {
+        return 301 {{.Path}}/$is_args$args;
+    }
+
+    location ^~ {{.Path}}/ {
+        alias {{.Root}}/;
+        index index.php index.html index.htm;
+        try_files $uri $uri/ @mount_{{.Label}};
+
+        location ~ /\. {
+            deny all;
+        }
+
+        location ~ \.php$ {
+            fastcgi_pass {{.FastCGIPass}};
+            fastcgi_index index.php;
+            fastcgi_param SCRIPT_FILENAME $request_filename;
+            include fastcgi_params;
+            {{- template "fastcgi" $.ForMount}}
+        }
+    }
+
+    location @mount_{{.Label}} {
+        rewrite ^ {{.Path}}/index.php last;
+    }
+    {{- end}}
+{{- end}}`
+
 const nginxTemplate = `server {
-    listen {{.ListenPort}};
-    {{if .UseSSL}}listen 443 ssl http2;{{end}}
-    server_name {{.ServerName}};
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
     root {{.Root}};
 
     {{if .UseSSL}}
@@ -12,23 +97,223 @@ const nginxTemplate = `server {
     {{end}}
 
     index index.php index.html index.htm;
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
 
     # Logging
-    access_log /var/log/nginx/{{.SiteName}}.access.log;
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
     error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
 
     # Laravel/PHP framework friendly
     location / {
         try_files $uri $uri/ /index.php?$query_string;
     }
 
+    {{if .FPMStatus}}
+    # PHP-FPM status and ping pages (localhost only)
+    location ~ ^/(fpm-status|fpm-ping)$ {
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+        access_log off;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+    }
+    {{end}}
+
+    # PHP-FPM configuration
+    location ~ \.php$ {
+        fastcgi_pass {{.FastCGIPass}};
+        fastcgi_index index.php;
+        fastcgi_param SCRIPT_FILENAME {{.ScriptRoot}}$fastcgi_script_name;
+        include fastcgi_params;
+        {{- template "fastcgi" .}}
+    }
+    {{- template "errorpage" .}}
+
+    # Deny access to hidden files
+    location ~ /\. {
+        deny all;
+    }
+}
+`
+
+const proxyTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    # Don't cap uploads to local services (e.g., S3 objects)
+    client_max_body_size {{if .MaxBodySize}}{{.MaxBodySize}}{{else}}0{{end}};
+
+    # Forward everything to the upstream service (websockets included)
+    location / {
+        proxy_pass {{.ProxyPass}};
+        proxy_http_version 1.1;
+        {{- if .Timeout}}
+        proxy_read_timeout {{.Timeout}}s;
+        {{- end}}
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+        proxy_set_header Upgrade $http_upgrade;
+        proxy_set_header Connection $http_connection;
+    }
+}
+`
+
+const octaneTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    index index.php;
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    # Serve static files directly, send everything else to Octane
+    location /index.php {
+        try_files /not_exists @octane;
+    }
+
+    location / {
+        try_files $uri $uri/ @octane;
+    }
+
+    location @octane {
+        set $suffix "";
+
+        if ($uri = /index.php) {
+            set $suffix ?$query_string;
+        }
+
+        proxy_pass {{.ProxyPass}}$suffix;
+        proxy_http_version 1.1;
+        {{- if .Timeout}}
+        proxy_read_timeout {{.Timeout}}s;
+        {{- end}}
+        proxy_set_header Host $http_host;
+        proxy_set_header Scheme $scheme;
+        proxy_set_header SERVER_PORT $server_port;
+        proxy_set_header REMOTE_ADDR $remote_addr;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+        proxy_set_header Upgrade $http_upgrade;
+        proxy_set_header Connection $http_connection;
+    }
+
+    # Deny access to hidden files
+    location ~ /\. {
+        deny all;
+    }
+}
+`
+
+// wordpressTemplate serves WordPress: permalinks through index.php, no PHP
+// from uploads, and room for media uploads
+const wordpressTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    index index.php index.html;
+    client_max_body_size {{.BodySize}};
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+    {{- if .Multisite}}
+
+    # Multisite: map /<site>/wp-* back to the shared core files
+    if (!-e $request_filename) {
+        rewrite /wp-admin$ $scheme://$host$uri/ permanent;
+        rewrite ^(/[^/]+)?(/wp-.*) $2 last;
+        rewrite ^(/[^/]+)?(/.*\.php) $2 last;
+    }
+    {{- end}}
+
+    # Pretty permalinks
+    location / {
+        try_files $uri $uri/ /index.php?$args;
+    }
+
+    # Uploads are never code
+    location ~* /wp-content/uploads/.*\.php$ {
+        deny all;
+    }
+
+    {{if .FPMStatus}}
+    # PHP-FPM status and ping pages (localhost only)
+    location ~ ^/(fpm-status|fpm-ping)$ {
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+        access_log off;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+    }
+    {{end}}
+
     # PHP-FPM configuration
     location ~ \.php$ {
-        fastcgi_pass unix:{{.PHPSocket}};
+        try_files $uri =404;
+        fastcgi_pass {{.FastCGIPass}};
         fastcgi_index index.php;
-        fastcgi_param SCRIPT_FILENAME $realpath_root$fastcgi_script_name;
+        fastcgi_param SCRIPT_FILENAME {{.ScriptRoot}}$fastcgi_script_name;
         include fastcgi_params;
+        {{- template "fastcgi" .}}
     }
+    {{- template "errorpage" .}}
 
     # Deny access to hidden files
     location ~ /\. {
@@ -37,7 +322,376 @@ const nginxTemplate = `server {
 }
 `
 
+// drupalTemplate serves Drupal: clean URLs and update.php through the front
+// controller, generated image styles, and no access to private files or
+// stray PHP
+const drupalTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    index index.php;
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    # Clean URLs
+    location / {
+        try_files $uri /index.php?$query_string;
+    }
+
+    location @rewrite {
+        rewrite ^ /index.php;
+    }
+
+    # Private files are served by Drupal (with access checks), never directly
+    location ~ ^/sites/.*/files/private/ {
+        return 403;
+    }
+
+    location ~ ^(/[a-z\-]+)?/system/files/ {
+        try_files $uri /index.php?$query_string;
+    }
+
+    # Image styles are generated on first request
+    location ~ ^/sites/.*/files/styles/ {
+        try_files $uri @rewrite;
+    }
+
+    # Only the front controller and update.php run
+    location ~ \..*/.*\.php$ {
+        return 403;
+    }
+
+    location ~ ^/sites/[^/]+/files/.*\.php$ {
+        deny all;
+    }
+
+    location ~ /vendor/.*\.php$ {
+        deny all;
+    }
+
+    {{if .FPMStatus}}
+    # PHP-FPM status and ping pages (localhost only)
+    location ~ ^/(fpm-status|fpm-ping)$ {
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+        access_log off;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+    }
+    {{end}}
+
+    # PHP-FPM configuration (update.php takes a path, e.g., /update.php/selection)
+    location ~ '\.php$|^/update.php' {
+        fastcgi_split_path_info ^(.+?\.php)(|/.*)$;
+        try_files $fastcgi_script_name =404;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME {{.ScriptRoot}}$fastcgi_script_name;
+        fastcgi_param PATH_INFO $fastcgi_path_info;
+        fastcgi_param QUERY_STRING $query_string;
+        fastcgi_param HTTP_PROXY "";
+        {{- template "fastcgi" .}}
+    }
+    {{- template "errorpage" .}}
+
+    # Deny access to hidden files (but allow .well-known)
+    location ~ (^|/)\.(?!well-known/) {
+        return 403;
+    }
+}
+`
+
+// magentoTemplate serves Magento 2 from pub/: versioned static files and
+// media through static.php and get.php, and only Magento's entry points run
+const magentoTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    index index.php;
+    autoindex off;
+    charset UTF-8;
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    location / {
+        try_files $uri $uri/ /index.php$is_args$args;
+    }
+
+    # Static files: strip the deploy version, generate missing ones
+    location /static/ {
+        location ~ ^/static/version\d*/ {
+            rewrite ^/static/version\d*/(.*)$ /static/$1 last;
+        }
+
+        location ~* \.(ico|jpg|jpeg|png|gif|svg|svgz|webp|avif|js|css|eot|ttf|otf|woff|woff2|html|json|webmanifest)$ {
+            add_header Cache-Control "public";
+            expires +1y;
+
+            if (!-f $request_filename) {
+                rewrite ^/static/(version\d*/)?(.*)$ /static.php?resource=$2 last;
+            }
+        }
+
+        if (!-f $request_filename) {
+            rewrite ^/static/(version\d*/)?(.*)$ /static.php?resource=$2 last;
+        }
+    }
+
+    # Media: resize and serve from the database through get.php
+    location /media/ {
+        try_files $uri $uri/ /get.php$is_args$args;
+
+        location ~ ^/media/theme_customization/.*\.xml {
+            deny all;
+        }
+    }
+
+    location ~ ^/media/(customer|downloadable|import|custom_options)/ {
+        deny all;
+    }
+
+    location /errors/ {
+        location ~* \.xml$ {
+            deny all;
+        }
+    }
+
+    {{if .FPMStatus}}
+    # PHP-FPM status and ping pages (localhost only)
+    location ~ ^/(fpm-status|fpm-ping)$ {
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+        access_log off;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+    }
+    {{end}}
+
+    # PHP-FPM configuration (Magento's entry points only)
+    location ~ ^/(index|get|static|errors/report|errors/404|errors/503|health_check)\.php$ {
+        try_files $uri =404;
+        fastcgi_pass {{.FastCGIPass}};
+        fastcgi_buffers 16 16k;
+        fastcgi_buffer_size 32k;
+        fastcgi_index index.php;
+        fastcgi_param SCRIPT_FILENAME {{.ScriptRoot}}$fastcgi_script_name;
+        include fastcgi_params;
+        {{- template "fastcgi" .}}
+    }
+    {{- template "errorpage" .}}
+
+    # Deny everything else that isn't meant to be served
+    location ~* (\.php$|\.phtml$|\.htaccess$|\.htpasswd$|\.git) {
+        deny all;
+    }
+}
+`
+
+// symfonyTemplate serves Symfony through its front controller only. The
+// profiler, web debug toolbar and fragments are routes, not files.
+const symfonyTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    location / {
+        try_files $uri /index.php$is_args$args;
+    }
+
+    # Profiler, toolbar and fragment URLs can look like files (e.g., .js)
+    location ~ ^/(_profiler|_wdt|_fragment)(/|$) {
+        rewrite ^ /index.php last;
+    }
+
+    {{if .FPMStatus}}
+    # PHP-FPM status and ping pages (localhost only)
+    location ~ ^/(fpm-status|fpm-ping)$ {
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+        access_log off;
+        fastcgi_pass {{.FastCGIPass}};
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+    }
+    {{end}}
+
+    # PHP-FPM configuration (front controller only, not reachable directly)
+    location ~ ^/index\.php(/|$) {
+        fastcgi_pass {{.FastCGIPass}};
+        fastcgi_split_path_info ^(.+\.php)(/.*)$;
+        include fastcgi_params;
+        fastcgi_param SCRIPT_FILENAME {{.ScriptRoot}}$fastcgi_script_name;
+        {{- if not .RemoteRoot}}
+        fastcgi_param DOCUMENT_ROOT $realpath_root;
+        {{- end}}
+        # Room for the profiler's debug headers
+        fastcgi_buffer_size 128k;
+        fastcgi_buffers 4 256k;
+        fastcgi_busy_buffers_size 256k;
+        {{- template "fastcgi" .}}
+        internal;
+    }
+    {{- template "errorpage" .}}
+
+    # No other PHP file runs
+    location ~ \.php$ {
+        return 404;
+    }
+
+    # Deny access to hidden files
+    location ~ /\. {
+        deny all;
+    }
+}
+`
+
+// staticTemplate serves plain files without PHP; single-page apps get
+// index.html for paths that aren't files so client-side routing works
+const staticTemplate = `server {
+    {{if not (and .UseSSL .RedirectHTTP)}}listen {{.ListenPort}};{{end}}
+    {{if .UseSSL}}listen {{.SSLPort}} ssl http2;{{end}}
+    server_name {{.ServerNames}};
+    root {{.Root}};
+
+    {{if .UseSSL}}
+    ssl_certificate {{.CertPath}};
+    ssl_certificate_key {{.KeyPath}};
+    {{end}}
+
+    index index.html index.htm;
+    {{- if .MaxBodySize}}
+    client_max_body_size {{.MaxBodySize}};
+    {{- end}}
+    {{- template "throttle" .}}
+
+    # Logging
+    access_log /var/log/nginx/{{.SiteName}}.access.log{{if .Shared}} phppark{{end}};
+    {{- if .Shared}}
+    access_log /var/log/nginx/{{.SiteName}}.access.json phppark_json;
+    {{- end}}
+    error_log /var/log/nginx/{{.SiteName}}.error.log;
+    {{- template "mounts" .}}
+
+    location / {
+        try_files $uri $uri/ {{if eq .Template "spa"}}/index.html{{else}}=404{{end}};
+    }
+
+    # Deny access to hidden files
+    location ~ /\. {
+        deny all;
+    }
+}
+`
+
+// redirectTemplate answers plain HTTP for a secured site with a redirect
+const redirectTemplate = `server {
+    listen {{.ListenPort}};
+    server_name {{.ServerNames}};
+    return 301 https://$host{{if ne .SSLPort 443}}:{{.SSLPort}}{{end}}$request_uri;
+}
+
+`
+
 // GetTemplate returns the nginx configuration template
 func GetTemplate() string {
 	return nginxTemplate
 }
+
+// GetProxyTemplate returns the nginx reverse-proxy template
+func GetProxyTemplate() string {
+	return proxyTemplate
+}
+
+// GetRedirectTemplate returns the nginx HTTP to HTTPS redirect block
+func GetRedirectTemplate() string {
+	return redirectTemplate
+}
+
+// GetWordPressTemplate returns the nginx template for WordPress sites
+func GetWordPressTemplate() string {
+	return wordpressTemplate
+}
+
+// GetDrupalTemplate returns the nginx template for Drupal sites
+func GetDrupalTemplate() string {
+	return drupalTemplate
+}
+
+// GetMagentoTemplate returns the nginx template for Magento sites
+func GetMagentoTemplate() string {
+	return magentoTemplate
+}
+
+// GetSymfonyTemplate returns the nginx template for Symfony sites
+func GetSymfonyTemplate() string {
+	return symfonyTemplate
+}
+
+// GetStaticTemplate returns the nginx template for static sites and
+// single-page apps
+func GetStaticTemplate() string {
+	return staticTemplate
+}
+
+// GetOctaneTemplate returns the nginx template for Laravel Octane sites
+func GetOctaneTemplate() string {
+	return octaneTemplate
+}
@@ -1,27 +1,101 @@
 package nginx
 
+import (
+	"fmt"
+	"strings"
+)
+
 // SiteConfig represents nginx configuration for a site
 type SiteConfig struct {
 	// Site information
-	SiteName   string // e.g., "myapp"
-	Domain     string // e.g., "test"
-	ServerName string // e.g., "myapp.test"
+	SiteName   string   // e.g., "myapp"
+	Domain     string   // e.g., "test"
+	ServerName string   // e.g., "myapp.test"
+	Aliases    []string // Other names the site answers to, e.g., "myapp.laptop.lan"
 
 	// Paths
 	Root     string // Document root (e.g., /Users/steve/sites/myapp/public)
 	SitePath string // Full site path
 
 	// PHP configuration
-	PHPVersion string // e.g., "8.2"
-	PHPSocket  string // e.g., "/var/run/php/php8.2-fpm.sock"
+	PHPVersion  string // e.g., "8.2"
+	PHPSocket   string // Where PHP-FPM listens: a socket (e.g., "/var/run/php/php8.2-fpm.sock") or host:port (e.g., "127.0.0.1:9000")
+	FastCGIPass string // nginx fastcgi_pass target, e.g., "unix:/var/run/php/php8.2-fpm.sock" or "php:9000"
+	Env         []FastCGIParam
+	RemoteRoot  string // Document root as a remote or containerized PHP-FPM sees it (empty when it shares nginx's filesystem)
+
+	// Proxy configuration
+	ProxyPass string // e.g., "http://127.0.0.1:8025" (empty for PHP sites)
+	Octane    bool   // Serve static files from Root, proxy the rest to ProxyPass
+
+	// Framework
+	Template       string // Built-in or user template name, e.g., "wordpress" (empty for the generic one)
+	TemplateSource string // Contents of a user template, used instead of the built-in ones
+	Multisite      bool   // WordPress multisite (subdirectory) rewrites
 
 	// SSL
-	UseSSL   bool
-	CertPath string
-	KeyPath  string
+	UseSSL       bool
+	CertPath     string
+	KeyPath      string
+	RedirectHTTP bool // Answer plain HTTP with a 301 to HTTPS instead of serving it
+
+	// Limits
+	MaxBodySize string // client_max_body_size (e.g., "512M", empty for the default)
+	Timeout     int    // Seconds before giving up on PHP or the upstream (0 for the default)
+
+	// Throttling
+	LimitRate string // limit_rate, e.g., "512k" per second (empty for full speed)
+	Delay     int    // Milliseconds PHP sleeps before each request (0 for none)
+
+	// Errors
+	ErrorPage      string // Diagnostic page served for 502/504 when PHP-FPM doesn't answer (empty for nginx's own)
+	LocalErrorPage string // The same with the end of PHP-FPM's log, served to clients on this machine
+
+	// Other apps served under paths of the site
+	Mounts []Mount
 
 	// Additional
-	ListenPort int // 80 or 443
+	ListenPort int  // HTTP port, usually 80
+	SSLPort    int  // HTTPS port, usually 443
+	FPMStatus  bool // Expose /fpm-status and /fpm-ping to localhost
+	Shared     bool // The global include is deployed (FastCGIPass names its upstream, its log format exists)
+}
+
+// ServerNames is the site's name followed by its aliases, as nginx's
+// server_name takes them
+func (c *SiteConfig) ServerNames() string {
+	return strings.Join(append([]string{c.ServerName}, c.Aliases...), " ")
+}
+
+// Mount is an app served under a path of a site from its own directory
+type Mount struct {
+	Path        string // URL path without a trailing slash, e.g., "/blog"
+	Root        string // The app's document root
+	PHPSocket   string // Where its PHP-FPM listens
+	FastCGIPass string // nginx fastcgi_pass target for it
+}
+
+// Label names the mount in nginx (e.g., "blog" for /blog), for its
+// named location. Characters other than letters, digits and "-" are
+// escaped as "_" and their hex code ("/a/b" is "a_2fb", "/a_b" is
+// "a_5fb"), so no two paths share a label.
+func (m Mount) Label() string {
+	var b strings.Builder
+	for _, c := range []byte(strings.Trim(m.Path, "/")) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
+
+// FastCGIParam is a single fastcgi_param entry with its value already quoted
+type FastCGIParam struct {
+	Name  string // e.g., "APP_ENV"
+	Value string // e.g., "\"local\""
 }
 
 // NginxConfig holds all nginx-related paths
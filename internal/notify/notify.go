@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+
+	"github.com/stevepop/phppark/internal/i18n"
+)
+
+// Send delivers a notification. mode is "desktop" (notify-send), "off", or
+// a command that receives the title and message as arguments.
+func Send(mode, title, message string) error {
+	switch mode {
+	case "off":
+		return nil
+	case "", "desktop":
+		return desktop(title, message)
+	default:
+		return exec.Command(mode, title, message).Run()
+	}
+}
+
+// desktop sends through notify-send. A root process (the watcher unit) has
+// no session bus of its own, so it notifies every logged-in user's session.
+func desktop(title, message string) error {
+	notifySend, err := exec.LookPath("notify-send")
+	if err != nil {
+		return i18n.Errorf(i18n.NotifySendNotFound)
+	}
+
+	args := []string{"--app-name=PHPark", "--urgency=critical", title, message}
+
+	if os.Geteuid() != 0 {
+		return exec.Command(notifySend, args...).Run()
+	}
+
+	buses, _ := filepath.Glob("/run/user/*/bus")
+	for _, bus := range buses {
+		uid := filepath.Base(filepath.Dir(bus))
+		if uid == "0" {
+			continue
+		}
+		u, err := user.LookupId(uid)
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command("setpriv", append([]string{
+			"--reuid=" + u.Uid, "--regid=" + u.Gid, "--init-groups", notifySend,
+		}, args...)...)
+		cmd.Env = append(os.Environ(), "DBUS_SESSION_BUS_ADDRESS=unix:path="+bus)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to notify user %s: %w", uid, err)
+		}
+	}
+	return nil
+}
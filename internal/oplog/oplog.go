@@ -0,0 +1,360 @@
+package oplog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one change PHPark made to the system
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Invocation string    `json:"invocation,omitempty"` // The phppark command that made the change
+	Op         string    `json:"op"`                   // write, remove, symlink, exec, docker
+	Target     string    `json:"target"`               // File path, program or container
+	Args       []string  `json:"args,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+var (
+	mu         sync.Mutex
+	logFile    string
+	invocation string
+	verbose    bool
+)
+
+// SetVerbose makes Run and CombinedOutput echo each command and its
+// output to stderr as it runs
+func SetVerbose(v bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	verbose = v
+}
+
+func isVerbose() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return verbose
+}
+
+// Open starts recording to path, tagging entries with the invocation that
+// made them. Until Open is called nothing is recorded.
+func Open(path, command string) {
+	mu.Lock()
+	defer mu.Unlock()
+	logFile = path
+	invocation = command
+}
+
+// Record appends an entry to the log. Logging is best effort: a failure to
+// record never fails the operation itself.
+func Record(entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile == "" {
+		return
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.Invocation == "" {
+		entry.Invocation = invocation
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	f.Write(append(data, '\n'))
+}
+
+// WriteFile is os.WriteFile, recorded
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	err := os.WriteFile(path, data, perm)
+	Record(Entry{Op: "write", Target: path, Error: errString(err)})
+	return err
+}
+
+// Rename is os.Rename, recorded as removing oldpath and writing newpath
+func Rename(oldpath, newpath string) error {
+	err := os.Rename(oldpath, newpath)
+	Record(Entry{Op: "remove", Target: oldpath, Error: errString(err)})
+	Record(Entry{Op: "write", Target: newpath, Error: errString(err)})
+	return err
+}
+
+// Remove is os.Remove, recorded when something was there to remove
+func Remove(path string) error {
+	err := os.Remove(path)
+	if !os.IsNotExist(err) {
+		Record(Entry{Op: "remove", Target: path, Error: errString(err)})
+	}
+	return err
+}
+
+// RemoveAll is os.RemoveAll, recorded
+func RemoveAll(path string) error {
+	err := os.RemoveAll(path)
+	Record(Entry{Op: "remove", Target: path, Error: errString(err)})
+	return err
+}
+
+// Symlink is os.Symlink, recorded
+func Symlink(oldname, newname string) error {
+	err := os.Symlink(oldname, newname)
+	Record(Entry{Op: "symlink", Target: newname, Args: []string{oldname}, Error: errString(err)})
+	return err
+}
+
+// Run runs a command that changes the system, recording it and its exit
+// code. Output the caller doesn't consume is captured, and a failure is
+// returned as a *CommandError carrying it, so errors say what went wrong
+// rather than just "exit status 1".
+func Run(cmd *exec.Cmd) error {
+	var output bytes.Buffer
+	capture := io.Writer(&output)
+	if isVerbose() {
+		echo(cmd)
+		capture = io.MultiWriter(&output, os.Stderr)
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = capture
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = capture
+	}
+
+	err := cmd.Run()
+	recordExec(cmd, err)
+	if err != nil {
+		return &CommandError{Command: cmd.Args, Output: output.String(), Err: err}
+	}
+	return nil
+}
+
+// Stream is Run for long commands such as package installs: while the
+// command runs, its latest output line is shown in place on a terminal (or
+// every line, indented, when stdout isn't one), so it's clear what it's
+// doing. A failure's *CommandError carries all of the output.
+func Stream(cmd *exec.Cmd) error {
+	if isVerbose() {
+		// Run already echoes everything
+		return Run(cmd)
+	}
+
+	var output bytes.Buffer
+	progress := newProgress(os.Stdout)
+	capture := io.MultiWriter(&output, progress)
+	cmd.Stdout = capture
+	cmd.Stderr = capture
+
+	err := cmd.Run()
+	progress.done()
+	recordExec(cmd, err)
+	if err != nil {
+		return &CommandError{Command: cmd.Args, Output: output.String(), Err: err}
+	}
+	return nil
+}
+
+// progress shows a command's output lines as they complete
+type progress struct {
+	mu       sync.Mutex
+	out      *os.File
+	terminal bool
+	width    int
+	partial  []byte
+	shown    bool
+}
+
+func newProgress(out *os.File) *progress {
+	p := &progress{out: out, width: 76}
+	if info, err := out.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb" {
+		p.terminal = true
+	}
+	return p
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+	for {
+		// apt redraws its progress with carriage returns
+		i := bytes.IndexAny(p.partial, "\r\n")
+		if i < 0 {
+			break
+		}
+		p.show(string(p.partial[:i]))
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *progress) show(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	if !p.terminal {
+		fmt.Fprintf(p.out, "   │ %s\n", line)
+		return
+	}
+
+	if runes := []rune(line); len(runes) > p.width {
+		line = string(runes[:p.width-1]) + "…"
+	}
+	fmt.Fprintf(p.out, "\r\033[K   │ %s", line)
+	p.shown = true
+}
+
+// done shows what's left and clears the in-place line
+func (p *progress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.partial) > 0 {
+		p.show(string(p.partial))
+		p.partial = nil
+	}
+	if p.shown {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.shown = false
+	}
+}
+
+// CombinedOutput is cmd.CombinedOutput, recorded like Run. Callers get the
+// output themselves, so errors aren't wrapped.
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	if isVerbose() {
+		echo(cmd)
+	}
+
+	output, err := cmd.CombinedOutput()
+	recordExec(cmd, err)
+
+	if isVerbose() {
+		os.Stderr.Write(output)
+	}
+	return output, err
+}
+
+// echo prints a command line before it runs (verbose mode)
+func echo(cmd *exec.Cmd) {
+	fmt.Fprintf(os.Stderr, "   $ %s\n", strings.Join(cmd.Args, " "))
+}
+
+// CommandError is a failed command with the output it printed
+type CommandError struct {
+	Command []string
+	Output  string
+	Err     error
+}
+
+// maxErrorLines is how much of a failed command's output goes into its error
+const maxErrorLines = 10
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s: %v", strings.Join(e.Command, " "), e.Err)
+
+	output := strings.TrimSpace(e.Output)
+	if output == "" {
+		return msg
+	}
+
+	// The end of the output is where tools explain why they failed
+	lines := strings.Split(output, "\n")
+	if len(lines) > maxErrorLines {
+		lines = lines[len(lines)-maxErrorLines:]
+	}
+	return msg + ": " + strings.Join(lines, "\n")
+}
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// Exec records a command run outside Run, such as one whose arguments
+// must not be logged
+func Exec(program string, args []string, err error) {
+	entry := Entry{Op: "exec", Target: program, Args: args, Error: errString(err)}
+	entry.ExitCode = exitCode(err)
+	Record(entry)
+}
+
+func recordExec(cmd *exec.Cmd, err error) {
+	var args []string
+	if len(cmd.Args) > 1 {
+		args = cmd.Args[1:]
+	}
+	Exec(cmd.Path, args, err)
+}
+
+// exitCode returns the exit code of a finished command, or nil if it never ran
+func exitCode(err error) *int {
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil
+		}
+		code = exitErr.ExitCode()
+	}
+	return &code
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Read returns the last limit entries of a log (all of them if limit is 0)
+func Read(path string, limit int) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip partial or corrupt lines
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
@@ -1,6 +1,7 @@
 package php
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,22 +10,67 @@ import (
 	"strings"
 )
 
+// searchPaths are the directories PHP binaries are looked for in
+var searchPaths = []string{
+	"/usr/bin",
+	"/usr/local/bin",
+}
+
 // DetectPHPVersions finds all installed PHP versions
 func DetectPHPVersions() ([]PHPVersion, error) {
 	return detectLinuxPHP()
 }
 
+// DetectPHPVersionsCached is DetectPHPVersions for hot paths like shell
+// completion, which can't run every PHP binary on each keypress. The result
+// is kept in cachePath and reused until a search directory changes (a PHP
+// version is installed or removed).
+func DetectPHPVersionsCached(cachePath string) ([]PHPVersion, error) {
+	if versions, ok := loadVersionCache(cachePath); ok {
+		return versions, nil
+	}
+
+	versions, err := DetectPHPVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	// Best effort: without a cache the next call detects again
+	if data, err := json.Marshal(versions); err == nil {
+		os.WriteFile(cachePath, data, 0644)
+	}
+	return versions, nil
+}
+
+// loadVersionCache reads the cached versions, if they're newer than every
+// search directory
+func loadVersionCache(cachePath string) ([]PHPVersion, bool) {
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	for _, dir := range searchPaths {
+		if dirInfo, err := os.Stat(dir); err == nil && dirInfo.ModTime().After(info.ModTime()) {
+			return nil, false
+		}
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+	var versions []PHPVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, false
+	}
+	return versions, true
+}
+
 // detectLinuxPHP finds PHP versions on Linux (Debian/Ubuntu)
 func detectLinuxPHP() ([]PHPVersion, error) {
 	var versions []PHPVersion
 	versionMap := make(map[string]bool) // Deduplicate
 
-	// Common Linux locations
-	searchPaths := []string{
-		"/usr/bin",
-		"/usr/local/bin",
-	}
-
 	for _, searchPath := range searchPaths {
 		entries, err := os.ReadDir(searchPath)
 		if err != nil {
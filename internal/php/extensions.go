@@ -0,0 +1,140 @@
+package php
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// SPXVersion is the SPX release BuildSPX builds, and SPXSHA256 the SHA-256
+// of its source archive; update both together. The download is refused
+// until the digest is set.
+const (
+	SPXVersion = "0.4.18"
+	SPXSHA256  = ""
+)
+
+// ExtensionInstalled reports whether a PHP version has an extension's
+// shared object in its extension directory
+func ExtensionInstalled(version, name string) bool {
+	binary := BinaryPath(version)
+	if binary == "" {
+		return false
+	}
+
+	out, err := exec.Command(binary, "-n", "-r", "echo PHP_EXTENSION_DIR;").Output()
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(strings.TrimSpace(string(out)), name+".so"))
+	return err == nil
+}
+
+// InstallExtensionPackage installs a PHP version's package for an extension
+// (e.g., php8.2-xhprof). The package enables it everywhere; it's disabled
+// again for PHP-FPM so only the sites that load it themselves get it.
+func InstallExtensionPackage(version, name string) error {
+	packageName := fmt.Sprintf("php%s-%s", version, name)
+
+	fmt.Printf("   Installing %s...\n", packageName)
+	if out, err := oplog.CombinedOutput(exec.Command("apt-get", "install", "-y", packageName)); err != nil {
+		return fmt.Errorf("failed to install %s: %w\n   %s", packageName, err, strings.TrimSpace(string(out)))
+	}
+
+	if err := oplog.Run(exec.Command("phpdismod", "-v", version, "-s", "fpm", name)); err != nil {
+		return fmt.Errorf("failed to disable %s for PHP-FPM %s: %w", name, version, err)
+	}
+	return nil
+}
+
+// BuildSPX builds and installs the SPX profiler for a PHP version from
+// source, as no distro packages it
+func BuildSPX(version string) error {
+	fmt.Println("   Installing build dependencies...")
+	deps := []string{"install", "-y", fmt.Sprintf("php%s-dev", version), "build-essential", "zlib1g-dev"}
+	if out, err := oplog.CombinedOutput(exec.Command("apt-get", deps...)); err != nil {
+		return fmt.Errorf("failed to install build dependencies: %w\n   %s", err, strings.TrimSpace(string(out)))
+	}
+
+	dir, err := os.MkdirTemp("", "phppark-spx-*")
+	if err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("   Downloading SPX %s...\n", SPXVersion)
+	archive := filepath.Join(dir, "spx.zip")
+	download := &services.Download{
+		URL:    fmt.Sprintf("https://github.com/NoiseByNorthwest/php-spx/archive/refs/tags/v%s.zip", SPXVersion),
+		SHA256: SPXSHA256,
+	}
+	if err := services.DownloadVerified(download, archive, 0644); err != nil {
+		return err
+	}
+	src := filepath.Join(dir, "src")
+	if err := services.ExtractZip(archive, src, 1); err != nil {
+		return fmt.Errorf("failed to extract SPX: %w", err)
+	}
+
+	fmt.Printf("   Building SPX %s for PHP %s...\n", SPXVersion, version)
+	steps := [][]string{
+		{"phpize" + version},
+		{"./configure", "--with-php-config=/usr/bin/php-config" + version},
+		{"make"},
+		{"make", "install"},
+	}
+	for _, step := range steps {
+		cmd := exec.Command(step[0], step[1:]...)
+		cmd.Dir = src
+		if out, err := oplog.CombinedOutput(cmd); err != nil {
+			return fmt.Errorf("failed to build SPX (%s): %w\n   %s", strings.Join(step, " "), err, lastLines(string(out), 10))
+		}
+	}
+	return nil
+}
+
+// InstallBlackfire installs the Blackfire agent and PHP probe from
+// Blackfire's apt repository. The probe's package enables it for every
+// installed PHP version.
+func InstallBlackfire() error {
+	fmt.Println("   Adding the Blackfire repository...")
+	if err := os.MkdirAll("/etc/apt/keyrings", 0755); err != nil {
+		return fmt.Errorf("failed to create keyrings directory: %w", err)
+	}
+
+	keyCmd := exec.Command("sh", "-c",
+		`wget -qO- https://packages.blackfire.io/gpg.key | gpg --dearmor --yes -o /etc/apt/keyrings/blackfire.gpg`)
+	if out, err := oplog.CombinedOutput(keyCmd); err != nil {
+		return fmt.Errorf("failed to fetch Blackfire signing key: %w\n   %s", err, strings.TrimSpace(string(out)))
+	}
+
+	source := "deb [signed-by=/etc/apt/keyrings/blackfire.gpg] http://packages.blackfire.io/debian any main\n"
+	if err := oplog.WriteFile("/etc/apt/sources.list.d/blackfire.list", []byte(source), 0644); err != nil {
+		return fmt.Errorf("failed to write apt source file: %w", err)
+	}
+
+	if err := oplog.Run(exec.Command("apt-get", "update")); err != nil {
+		return fmt.Errorf("failed to update packages: %w", err)
+	}
+
+	fmt.Println("   Installing blackfire and blackfire-php...")
+	if out, err := oplog.CombinedOutput(exec.Command("apt-get", "install", "-y", "blackfire", "blackfire-php")); err != nil {
+		return fmt.Errorf("failed to install Blackfire: %w\n   %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// lastLines returns the end of a command's output, where build errors are
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n   ")
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
 // InstallPHP installs a PHP version with FPM
@@ -17,7 +19,7 @@ func InstallPHP(version string) error {
 	// Ubuntu 24.04 ships PHP 8.3; this avoids any PPA setup on those systems.
 	fmt.Println("   Trying default repositories...")
 	cmd := exec.Command("apt-get", "install", "-y", packageName)
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Stream(cmd); err != nil {
 		// Not in default repos — add the ondrej/php repository manually.
 		// We bypass add-apt-repository (which contacts api.launchpad.net via
 		// Python's httplib2) and add the repo directly from packages.sury.org.
@@ -30,15 +32,15 @@ func InstallPHP(version string) error {
 		// Update package list after adding repo
 		fmt.Println("   Updating package list...")
 		cmd = exec.Command("apt-get", "update")
-		if err := cmd.Run(); err != nil {
+		if err := oplog.Stream(cmd); err != nil {
 			return fmt.Errorf("failed to update packages: %w", err)
 		}
 
 		// Retry install from the new repo
 		fmt.Printf("   Installing %s...\n", packageName)
 		cmd = exec.Command("apt-get", "install", "-y", packageName)
-		if out, err := cmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to install PHP %s: %w\n   %s", version, err, strings.TrimSpace(string(out)))
+		if err := oplog.Stream(cmd); err != nil {
+			return fmt.Errorf("failed to install PHP %s: %w", version, err)
 		}
 	}
 
@@ -56,7 +58,10 @@ func InstallPHP(version string) error {
 
 	for _, ext := range extensions {
 		cmd = exec.Command("apt-get", "install", "-y", ext)
-		cmd.Run() // Non-fatal if individual extensions fail
+		// Non-fatal if individual extensions fail
+		if err := oplog.Stream(cmd); err != nil {
+			fmt.Printf("   ⚠️  Warning: %s not installed: %v\n", ext, err)
+		}
 	}
 
 	fmt.Printf("\n✅ PHP %s installed successfully!\n", version)
@@ -76,7 +81,7 @@ func addSuryPHPRepo() error {
 	codename := strings.TrimSpace(string(out))
 
 	// Ensure gnupg and wget are available for key import
-	exec.Command("apt-get", "install", "-y", "--no-install-recommends", "gnupg", "wget").Run()
+	oplog.Run(exec.Command("apt-get", "install", "-y", "--no-install-recommends", "gnupg", "wget"))
 
 	// Create keyrings directory
 	if err := os.MkdirAll("/etc/apt/keyrings", 0755); err != nil {
@@ -86,7 +91,7 @@ func addSuryPHPRepo() error {
 	// Download and store the signing key
 	keyCmd := exec.Command("sh", "-c",
 		`wget -qO- https://packages.sury.org/php/apt.gpg > /etc/apt/keyrings/sury-php.gpg`)
-	if out, err := keyCmd.CombinedOutput(); err != nil {
+	if out, err := oplog.CombinedOutput(keyCmd); err != nil {
 		return fmt.Errorf("failed to fetch PHP repo signing key: %w\n   %s", err, strings.TrimSpace(string(out)))
 	}
 
@@ -94,7 +99,7 @@ func addSuryPHPRepo() error {
 	source := fmt.Sprintf(
 		"deb [signed-by=/etc/apt/keyrings/sury-php.gpg] https://packages.sury.org/php/ %s main\n",
 		codename)
-	if err := os.WriteFile("/etc/apt/sources.list.d/sury-php.list", []byte(source), 0644); err != nil {
+	if err := oplog.WriteFile("/etc/apt/sources.list.d/sury-php.list", []byte(source), 0644); err != nil {
 		return fmt.Errorf("failed to write apt source file: %w", err)
 	}
 
@@ -0,0 +1,109 @@
+package php
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// shimTemplate dispatches php to the version PHPark resolves for the
+// current directory, falling back to the system php if that fails
+const shimTemplate = `#!/bin/sh
+# Managed by PHPark - do not edit
+PHP_BIN="$(%s php:which 2>/dev/null)"
+if [ -z "$PHP_BIN" ]; then
+    PHP_BIN=/usr/bin/php
+fi
+exec "$PHP_BIN" "$@"
+`
+
+// WriteShims writes the php shim into binDir. phpparkBin is the absolute
+// path of the phppark executable the shim calls back into.
+func WriteShims(binDir, phpparkBin string) error {
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return fmt.Errorf("failed to create shim directory: %w", err)
+	}
+
+	shimPath := filepath.Join(binDir, "php")
+	content := fmt.Sprintf(shimTemplate, ShellQuote(phpparkBin))
+
+	if err := oplog.WriteFile(shimPath, []byte(content), 0755); err != nil {
+		return fmt.Errorf("failed to write php shim: %w", err)
+	}
+
+	return nil
+}
+
+// ShimsOnPath reports whether binDir is on PATH ahead of the system php
+func ShimsOnPath(binDir string) bool {
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(dir) == filepath.Clean(binDir) {
+			return true
+		}
+		// A system php found first would shadow the shim
+		if dir == "/usr/bin" || dir == "/usr/local/bin" {
+			return false
+		}
+	}
+	return false
+}
+
+// BinaryPath returns the php CLI binary for a version (e.g., /usr/bin/php8.2)
+func BinaryPath(version string) string {
+	for _, dir := range []string{"/usr/bin", "/usr/local/bin"} {
+		candidate := filepath.Join(dir, "php"+version)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// ShellProfile returns the rc file that should receive the PATH export
+// for the user's login shell
+func ShellProfile(homeDir string) string {
+	if strings.HasSuffix(os.Getenv("SHELL"), "zsh") {
+		return filepath.Join(homeDir, ".zshrc")
+	}
+	return filepath.Join(homeDir, ".bashrc")
+}
+
+// AddShimsToProfile appends a PATH export for binDir to the given rc file
+// unless it is already present
+func AddShimsToProfile(profilePath, binDir string) error {
+	existing, err := os.ReadFile(profilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", profilePath, err)
+	}
+	if strings.Contains(string(existing), binDir) {
+		return nil
+	}
+
+	f, err := os.OpenFile(profilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", profilePath, err)
+	}
+	defer f.Close()
+
+	line := fmt.Sprintf("\n# Added by PHPark\n%s\n", PathExport(binDir))
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("failed to update %s: %w", profilePath, err)
+	}
+
+	return nil
+}
+
+// PathExport returns the shell line that puts binDir ahead of the rest of
+// PATH
+func PathExport(binDir string) string {
+	return fmt.Sprintf(`export PATH=%s:"$PATH"`, ShellQuote(binDir))
+}
+
+// ShellQuote quotes a word for sh, so none of it is expanded: it's put in
+// single quotes, with any single quote in it closed, escaped and reopened
+func ShellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// ApacheLayout describes where a distro's Apache keeps its vhosts
+type ApacheLayout struct {
+	Service string // systemd service: "apache2" (Debian/Ubuntu) or "httpd" (RHEL/Fedora/Arch)
+	ConfDir string // Directory vhost files are written to
+	LogDir  string // Directory Apache writes logs to
+	Debian  bool   // Uses sites-available/sites-enabled with a2ensite
+}
+
+// apacheModules are the modules PHPark vhosts rely on
+var apacheModules = []string{"proxy", "proxy_fcgi", "proxy_http", "setenvif", "rewrite", "ssl", "headers"}
+
+// DetectApacheLayout returns the Apache layout for this machine
+func DetectApacheLayout() *ApacheLayout {
+	if info, err := os.Stat("/etc/apache2"); err == nil && info.IsDir() {
+		return &ApacheLayout{
+			Service: "apache2",
+			ConfDir: "/etc/apache2/sites-available",
+			LogDir:  "/var/log/apache2",
+			Debian:  true,
+		}
+	}
+
+	return &ApacheLayout{
+		Service: "httpd",
+		ConfDir: "/etc/httpd/conf.d",
+		LogDir:  "/var/log/httpd",
+	}
+}
+
+// confName returns the vhost file name for a site. On conf.d layouts
+// the prefix keeps PHPark's files apart from the distro's own.
+func (l *ApacheLayout) confName(siteName string) string {
+	if l.Debian {
+		return siteName + ".conf"
+	}
+	return "phppark-" + siteName + ".conf"
+}
+
+// ConfigPath is where a site's vhost is installed
+func (l *ApacheLayout) ConfigPath(siteName string) string {
+	return filepath.Join(l.ConfDir, l.confName(siteName))
+}
+
+// DeployApacheConfig copies a vhost to Apache, enables it and reloads,
+// restoring the previous vhost if the config test fails
+func DeployApacheConfig(siteName, configPath string) error {
+	rollback, err := StageApacheConfig(siteName, configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := TestApacheConfig(); err != nil {
+		return undo(rollback, fmt.Errorf("apache config test failed: %w", err))
+	}
+
+	if err := ReloadApache(); err != nil {
+		return fmt.Errorf("failed to reload apache: %w", err)
+	}
+
+	return nil
+}
+
+// StageApacheConfig copies and enables a vhost without testing or
+// reloading. The returned Rollback restores what was there before.
+func StageApacheConfig(siteName, configPath string) (Rollback, error) {
+	layout := DetectApacheLayout()
+	name := layout.confName(siteName)
+	target := layout.ConfigPath(siteName)
+
+	paths := []string{target}
+	if layout.Debian {
+		paths = append(paths, filepath.Join("/etc/apache2/sites-enabled", name))
+	}
+	rollback, err := snapshotPaths(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := copyFile(configPath, target); err != nil {
+		return nil, undo(rollback, fmt.Errorf("failed to copy config: %w", err))
+	}
+
+	if layout.Debian {
+		enableApacheModules()
+
+		if err := enableApacheSite(layout, siteName); err != nil {
+			return nil, undo(rollback, fmt.Errorf("failed to enable site: %w", err))
+		}
+	}
+
+	return rollback, nil
+}
+
+// RemoveApacheConfig disables and removes a site's vhost, then reloads
+func RemoveApacheConfig(siteName string) error {
+	if err := UnstageApacheConfig(siteName); err != nil {
+		return err
+	}
+
+	if err := TestApacheConfig(); err != nil {
+		return fmt.Errorf("apache config test failed: %w", err)
+	}
+
+	if err := ReloadApache(); err != nil {
+		return fmt.Errorf("failed to reload apache: %w", err)
+	}
+
+	return nil
+}
+
+// UnstageApacheConfig disables and removes a site's vhost without testing
+// or reloading
+func UnstageApacheConfig(siteName string) error {
+	layout := DetectApacheLayout()
+	name := layout.confName(siteName)
+
+	if layout.Debian {
+		if _, err := exec.LookPath("a2dissite"); err == nil {
+			oplog.Run(exec.Command("a2dissite", "-q", siteName)) // Non-fatal, removed below
+		}
+
+		enabledPath := filepath.Join("/etc/apache2/sites-enabled", name)
+		if err := oplog.Remove(enabledPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove enabled config: %w", err)
+		}
+	}
+
+	if err := oplog.Remove(filepath.Join(layout.ConfDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove config: %w", err)
+	}
+
+	return nil
+}
+
+// DisableApacheConfig takes a site offline without testing or reloading.
+// On Debian the vhost is disabled with a2dissite and stays in
+// sites-available; in conf.d it's set aside.
+func DisableApacheConfig(siteName string) error {
+	layout := DetectApacheLayout()
+	if !layout.Debian {
+		return SetAsideConfig(layout.ConfigPath(siteName))
+	}
+
+	if _, err := exec.LookPath("a2dissite"); err == nil {
+		oplog.Run(exec.Command("a2dissite", "-q", siteName)) // Non-fatal, removed below
+	}
+
+	enabledPath := filepath.Join("/etc/apache2/sites-enabled", layout.confName(siteName))
+	if err := oplog.Remove(enabledPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove enabled config: %w", err)
+	}
+	return nil
+}
+
+// EnableApacheConfig puts back what DisableApacheConfig took away, without
+// testing or reloading
+func EnableApacheConfig(siteName string) error {
+	layout := DetectApacheLayout()
+	if !layout.Debian {
+		return RestoreConfig(layout.ConfigPath(siteName))
+	}
+
+	if _, err := os.Stat(layout.ConfigPath(siteName)); err != nil {
+		return fmt.Errorf("no installed config for %s: %w", siteName, err)
+	}
+	if err := enableApacheSite(layout, siteName); err != nil {
+		return fmt.Errorf("failed to enable site: %w", err)
+	}
+	return nil
+}
+
+// enableApacheSite enables a vhost with a2ensite, or by symlinking it
+// into sites-enabled when a2ensite isn't available
+func enableApacheSite(layout *ApacheLayout, siteName string) error {
+	if _, err := exec.LookPath("a2ensite"); err == nil {
+		if output, err := oplog.CombinedOutput(exec.Command("a2ensite", "-q", siteName)); err != nil {
+			return fmt.Errorf("a2ensite failed: %s", string(output))
+		}
+		return nil
+	}
+
+	name := layout.confName(siteName)
+	return createSymlink(filepath.Join(layout.ConfDir, name), filepath.Join("/etc/apache2/sites-enabled", name))
+}
+
+// enableApacheModules turns on the modules PHPark vhosts need (Debian only;
+// other distros load them from conf.modules.d by default)
+func enableApacheModules() {
+	if _, err := exec.LookPath("a2enmod"); err != nil {
+		return
+	}
+
+	args := append([]string{"-q"}, apacheModules...)
+	oplog.Run(exec.Command("a2enmod", args...)) // Non-fatal, configtest reports what's missing
+}
+
+// TestApacheConfig tests Apache configuration. On failure it returns a
+// *ConfigTestError with Apache's explanation and the offending file.
+func TestApacheConfig() error {
+	output, err := exec.Command("apachectl", "configtest").CombinedOutput()
+	if err != nil {
+		return newConfigTestError("apachectl configtest", output)
+	}
+	return nil
+}
+
+// ReloadApache gracefully reloads Apache
+func ReloadApache() error {
+	layout := DetectApacheLayout()
+
+	cmd := exec.Command("systemctl", "reload", layout.Service)
+	if err := oplog.Run(cmd); err != nil {
+		// Try alternative reload method
+		cmd = exec.Command("apachectl", "graceful")
+		if err := oplog.Run(cmd); err != nil {
+			return fmt.Errorf("failed to reload apache: %w", err)
+		}
+	}
+	return nil
+}
+
+// StartApache starts Apache if not running
+func StartApache() error {
+	layout := DetectApacheLayout()
+
+	cmd := exec.Command("systemctl", "is-active", layout.Service)
+	if err := cmd.Run(); err == nil {
+		return nil // Already running
+	}
+
+	cmd = exec.Command("systemctl", "start", layout.Service)
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to start %s: %w", layout.Service, err)
+	}
+
+	// Enable on boot
+	oplog.Run(exec.Command("systemctl", "enable", layout.Service)) // Non-fatal
+
+	return nil
+}
+
+// IsApacheRunning reports whether the Apache service is active
+func IsApacheRunning() bool {
+	return IsServiceActive(DetectApacheLayout().Service)
+}
+
+// StopApache stops Apache
+func StopApache() error {
+	layout := DetectApacheLayout()
+
+	cmd := exec.Command("systemctl", "stop", layout.Service)
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", layout.Service, err)
+	}
+	return nil
+}
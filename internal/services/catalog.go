@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// ServiceProxy exposes one of a service's ports as <Site>.<domain>
+type ServiceProxy struct {
+	Site string // e.g., "search" for search.test
+	Port int    // Local port to proxy to
+}
+
+// ManagedService describes an optional service PHPark can install and supervise
+type ManagedService struct {
+	Name        string         // e.g., "meilisearch"
+	Description string         // Shown in `service list`
+	Proxies     []ServiceProxy // HTTP ports that can be exposed as sites
+	Secrets     []string       // Env vars generated once and kept in <dir>/service.env
+
+	binary        string                    // Binary filename inside the service dir
+	download      func() (*Download, error) // Release file for this architecture, with its published SHA-256
+	archiveMember string                    // File to extract if the download is a .tar.gz
+	args          func(dir string) []string
+	envHints      func(secrets map[string]string) []string
+}
+
+// catalog lists every service PHPark knows how to install
+var catalog = map[string]*ManagedService{
+	"mailpit": {
+		Name:        "mailpit",
+		Description: "Local mail capture (SMTP 127.0.0.1:1025)",
+		Proxies:     []ServiceProxy{{Site: "mail", Port: 8025}},
+		binary:      "mailpit",
+		download: func() (*Download, error) {
+			return GitHubAsset("axllent/mailpit",
+				regexp.MustCompile(`^mailpit-linux-`+runtime.GOARCH+`\.tar\.gz$`))
+		},
+		archiveMember: "mailpit",
+		args: func(dir string) []string {
+			return []string{
+				"--smtp", "127.0.0.1:1025",
+				"--listen", "127.0.0.1:8025",
+				"--database", filepath.Join(dir, "mailpit.db"),
+			}
+		},
+		envHints: func(secrets map[string]string) []string {
+			return []string{
+				"MAIL_MAILER=smtp",
+				"MAIL_HOST=127.0.0.1",
+				"MAIL_PORT=1025",
+				"MAIL_USERNAME=null",
+				"MAIL_PASSWORD=null",
+				"MAIL_ENCRYPTION=null",
+			}
+		},
+	},
+	"meilisearch": {
+		Name:        "meilisearch",
+		Description: "Search engine for Laravel Scout (127.0.0.1:7700)",
+		Proxies:     []ServiceProxy{{Site: "search", Port: 7700}},
+		Secrets:     []string{"MEILI_MASTER_KEY"},
+		binary:      "meilisearch",
+		download: func() (*Download, error) {
+			arch := "amd64"
+			if runtime.GOARCH == "arm64" {
+				arch = "aarch64"
+			}
+			return GitHubAsset("meilisearch/meilisearch",
+				regexp.MustCompile("^meilisearch-linux-"+arch+"$"))
+		},
+		args: func(dir string) []string {
+			return []string{
+				"--http-addr", "127.0.0.1:7700",
+				"--db-path", filepath.Join(dir, "data.ms"),
+				"--env", "development",
+			}
+		},
+		envHints: func(secrets map[string]string) []string {
+			return []string{
+				"SCOUT_DRIVER=meilisearch",
+				"MEILISEARCH_HOST=http://127.0.0.1:7700",
+				"MEILISEARCH_KEY=" + secrets["MEILI_MASTER_KEY"],
+			}
+		},
+	},
+	"minio": {
+		Name:        "minio",
+		Description: "S3-compatible object storage (API 127.0.0.1:9000, console 9001)",
+		Proxies: []ServiceProxy{
+			{Site: "minio", Port: 9001},
+			{Site: "s3", Port: 9000},
+		},
+		Secrets: []string{"MINIO_ROOT_USER", "MINIO_ROOT_PASSWORD"},
+		binary:  "minio",
+		download: func() (*Download, error) {
+			url := fmt.Sprintf("https://dl.min.io/server/minio/release/linux-%s/minio", runtime.GOARCH)
+			return PublishedSHA256(url, url+".sha256sum")
+		},
+		args: func(dir string) []string {
+			return []string{
+				"server", filepath.Join(dir, "data"),
+				"--address", "127.0.0.1:9000",
+				"--console-address", "127.0.0.1:9001",
+			}
+		},
+		envHints: func(secrets map[string]string) []string {
+			return []string{
+				"FILESYSTEM_DISK=s3",
+				"AWS_ACCESS_KEY_ID=" + secrets["MINIO_ROOT_USER"],
+				"AWS_SECRET_ACCESS_KEY=" + secrets["MINIO_ROOT_PASSWORD"],
+				"AWS_DEFAULT_REGION=us-east-1",
+				"AWS_BUCKET=local",
+				"AWS_ENDPOINT=http://127.0.0.1:9000",
+				"AWS_USE_PATH_STYLE_ENDPOINT=true",
+			}
+		},
+	},
+}
+
+// GetService looks up a managed service by name
+func GetService(name string) (*ManagedService, bool) {
+	svc, ok := catalog[name]
+	return svc, ok
+}
+
+// ListServices returns all managed services sorted by name
+func ListServices() []*ManagedService {
+	list := make([]*ManagedService, 0, len(catalog))
+	for _, svc := range catalog {
+		list = append(list, svc)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// UnitName returns the systemd unit name for the service
+func (s *ManagedService) UnitName() string {
+	return "phppark-" + s.Name
+}
+
+// IsRunning reports whether the service's unit is active
+func (s *ManagedService) IsRunning() bool {
+	return IsUnitActive(s.UnitName())
+}
+
+// EnvHints returns .env lines applications need to use the service
+func (s *ManagedService) EnvHints(secrets map[string]string) []string {
+	if s.envHints == nil {
+		return nil
+	}
+	return s.envHints(secrets)
+}
+
+// fetch downloads the release file into dest, verified against its
+// published SHA-256
+func (s *ManagedService) fetch(dest string, mode os.FileMode) error {
+	d, err := s.download()
+	if err != nil {
+		return err
+	}
+	return DownloadVerified(d, dest, mode)
+}
+
+// Install downloads the service into dir, generates its secrets, and runs it
+// under systemd. It returns the service's secrets for display.
+func (s *ManagedService) Install(dir string) (map[string]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	binary := filepath.Join(dir, s.binary)
+	if s.archiveMember != "" {
+		archive := binary + ".tar.gz"
+		if err := s.fetch(archive, 0644); err != nil {
+			return nil, err
+		}
+		defer os.Remove(archive)
+
+		if err := ExtractTarGzFile(archive, s.archiveMember, binary, 0755); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.fetch(binary, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	secrets, err := loadOrCreateSecrets(dir, s.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unit runs as the invoking user, who has to own its data
+	if err := ChownToInvokingUser(dir); err != nil {
+		return nil, err
+	}
+
+	unit := &Unit{
+		Name:        s.UnitName(),
+		Description: fmt.Sprintf("PHPark %s", s.Name),
+		ExecStart:   append([]string{binary}, s.args(dir)...),
+		WorkingDir:  dir,
+	}
+	if len(s.Secrets) > 0 {
+		unit.EnvironmentFile = secretsPath(dir)
+	}
+
+	if err := InstallUnit(unit); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// Uninstall stops the service and removes its unit and files (including data)
+func (s *ManagedService) Uninstall(dir string) error {
+	if err := RemoveUnit(s.UnitName()); err != nil {
+		return err
+	}
+
+	if err := oplog.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", dir, err)
+	}
+
+	return nil
+}
+
+// secretsPath is the env file holding a service's generated secrets
+func secretsPath(dir string) string {
+	return filepath.Join(dir, "service.env")
+}
+
+// loadOrCreateSecrets reads existing secrets so reinstalling keeps the same
+// keys, generating any that are missing
+func loadOrCreateSecrets(dir string, names []string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if len(names) == 0 {
+		return secrets, nil
+	}
+
+	path := secretsPath(dir)
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if name, value, ok := strings.Cut(scanner.Text(), "="); ok {
+				secrets[name] = value
+			}
+		}
+		f.Close()
+	}
+
+	var b strings.Builder
+	for _, name := range names {
+		if secrets[name] == "" {
+			value, err := randomSecret()
+			if err != nil {
+				return nil, err
+			}
+			secrets[name] = value
+		}
+		fmt.Fprintf(&b, "%s=%s\n", name, secrets[name])
+	}
+
+	// Secrets are readable by the owner only
+	if err := oplog.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return secrets, nil
+}
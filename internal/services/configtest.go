@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConfigTestError is a failed web server config test, with the file and
+// line the server complained about when its output names one
+type ConfigTestError struct {
+	Command string // e.g., "nginx -t"
+	Output  string // The server's own explanation
+	File    string
+	Line    int
+	Source  string // The offending line, read before any rollback replaces the file
+}
+
+// Locations in config test output:
+//
+//	nginx: [emerg] unknown directive "foo" in /etc/nginx/sites-enabled/blog.conf:12
+//	AH00526: Syntax error on line 12 of /etc/apache2/sites-enabled/blog.conf:
+var (
+	nginxLocation  = regexp.MustCompile(`in (/\S+):(\d+)`)
+	apacheLocation = regexp.MustCompile(`on line (\d+) of (/[^\s:]+)`)
+)
+
+func newConfigTestError(command string, output []byte) *ConfigTestError {
+	e := &ConfigTestError{Command: command, Output: strings.TrimSpace(string(output))}
+
+	if m := nginxLocation.FindStringSubmatch(e.Output); m != nil {
+		e.File = m[1]
+		e.Line, _ = strconv.Atoi(m[2])
+	} else if m := apacheLocation.FindStringSubmatch(e.Output); m != nil {
+		e.File = m[2]
+		e.Line, _ = strconv.Atoi(m[1])
+	}
+	e.Source = readLine(e.File, e.Line)
+
+	return e
+}
+
+func (e *ConfigTestError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Command, e.Message())
+}
+
+// Message is the line of the output that explains the failure, without
+// the summary lines around it
+func (e *ConfigTestError) Message() string {
+	for _, line := range strings.Split(e.Output, "\n") {
+		if strings.Contains(line, "[emerg]") || strings.Contains(line, "Syntax error") {
+			return strings.TrimSpace(line)
+		}
+	}
+	return e.Output
+}
+
+// readLine returns one line of a file, or "" if it can't be read
+func readLine(path string, line int) string {
+	if path == "" || line < 1 {
+		return ""
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		if n == line {
+			return strings.TrimSpace(scanner.Text())
+		}
+	}
+	return ""
+}
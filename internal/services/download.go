@@ -0,0 +1,345 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// Download is a release file and the SHA-256 its publisher lists for it
+type Download struct {
+	URL    string
+	SHA256 string // Hex digest
+}
+
+// GitHubAsset finds the file in a repository's latest release whose name
+// matches pattern, with the SHA-256 digest GitHub publishes for it
+func GitHubAsset(repo string, pattern *regexp.Regexp) (*Download, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/repos/"+repo+"/releases/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the latest %s release: %w", repo, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to look up the latest %s release: %s", repo, resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name   string `json:"name"`
+			URL    string `json:"browser_download_url"`
+			Digest string `json:"digest"` // e.g., "sha256:<hex>"
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to read the latest %s release: %w", repo, err)
+	}
+
+	for _, asset := range release.Assets {
+		if !pattern.MatchString(asset.Name) {
+			continue
+		}
+		sum, ok := strings.CutPrefix(asset.Digest, "sha256:")
+		if !ok {
+			return nil, fmt.Errorf("%s %s publishes no SHA-256 for %s", repo, release.TagName, asset.Name)
+		}
+		return &Download{URL: asset.URL, SHA256: sum}, nil
+	}
+	return nil, fmt.Errorf("%s %s has no file matching %s", repo, release.TagName, pattern)
+}
+
+// PublishedSHA256 pairs a URL with the SHA-256 listed in the checksum file
+// published next to it (sha256sum format: "<hex>  <file>" per line)
+func PublishedSHA256(url, sumURL string) (*Download, error) {
+	sum, err := publishedDigest(url, sumURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Download{URL: url, SHA256: sum}, nil
+}
+
+// DownloadPublishedSHA1 fetches a URL into dest like DownloadFile, then
+// removes it again unless it matches the SHA-1 in the checksum file
+// published next to it, for publishers that list no SHA-256
+func DownloadPublishedSHA1(url, sumURL, dest string, mode os.FileMode) error {
+	sum, err := publishedDigest(url, sumURL)
+	if err != nil {
+		return err
+	}
+	if err := DownloadFile(url, dest, mode); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		return err
+	}
+	if got := fmt.Sprintf("%x", sha1.Sum(data)); got != strings.ToLower(sum) {
+		oplog.Remove(dest)
+		return fmt.Errorf("%s failed verification: SHA-1 is %s, the published one is %s", url, got, sum)
+	}
+	return nil
+}
+
+// publishedDigest reads the digest listed for url in the checksum file at
+// sumURL
+func publishedDigest(url, sumURL string) (string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(sumURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", sumURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", sumURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", sumURL, err)
+	}
+
+	// A file with one line is about the download, whatever name it gives;
+	// otherwise the line naming it is
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(lines) == 1 || (len(fields) > 1 && strings.TrimPrefix(fields[1], "*") == path.Base(url)) {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no SHA-256 for %s", sumURL, path.Base(url))
+}
+
+// DownloadFile fetches a URL into dest with the given permissions
+func DownloadFile(url, dest string, mode os.FileMode) error {
+	return download(url, "", dest, mode)
+}
+
+// DownloadVerified fetches a download into dest with the given
+// permissions, refusing it unless it matches the published SHA-256
+func DownloadVerified(d *Download, dest string, mode os.FileMode) error {
+	if d.SHA256 == "" {
+		return fmt.Errorf("no published SHA-256 for %s", d.URL)
+	}
+	return download(d.URL, strings.ToLower(d.SHA256), dest, mode)
+}
+
+// download fetches url into dest, checking its SHA-256 first when sum
+// isn't empty
+func download(url, sum, dest string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	// Write to a temp file first so a failed (or tampered) download never
+	// replaces a working binary
+	tmp := dest + ".download"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), resp.Body); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	out.Close()
+
+	if got := hex.EncodeToString(hash.Sum(nil)); sum != "" && got != sum {
+		os.Remove(tmp)
+		return fmt.Errorf("%s failed verification: SHA-256 is %s, the published one is %s", url, got, sum)
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+
+	oplog.Record(oplog.Entry{Op: "write", Target: dest, Args: []string{url}})
+	return nil
+}
+
+// ChownToInvokingUser hands paths (recursively) to the user behind sudo,
+// so what PHPark creates for them under sudo, such as a service's data
+// directory its unit writes as that user, isn't left owned by root
+func ChownToInvokingUser(paths ...string) error {
+	name := os.Getenv("SUDO_USER")
+	if name == "" || os.Geteuid() != 0 {
+		return nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return err
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(file string, _ fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Lchown(file, uid, gid)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to hand %s to %s: %w", root, name, err)
+		}
+	}
+	return nil
+}
+
+// ExtractTarGzFile extracts a single named file from a .tar.gz archive
+func ExtractTarGzFile(archive, member, dest string, mode os.FileMode) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in archive", member)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if filepath.Base(header.Name) != member || header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", dest, err)
+		}
+		defer out.Close()
+
+		if _, err := io.Copy(out, tr); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", member, err)
+		}
+		oplog.Record(oplog.Entry{Op: "write", Target: dest, Args: []string{archive}})
+		return nil
+	}
+}
+
+// randomSecret returns a random hex string for generated service credentials
+func randomSecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ExtractZip extracts a .zip archive into dest, dropping the first
+// stripComponents path elements of every entry (like tar --strip-components)
+func ExtractZip(archive, dest string, stripComponents int) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		parts := strings.Split(strings.TrimSuffix(f.Name, "/"), "/")
+		if len(parts) <= stripComponents {
+			continue
+		}
+		relPath := filepath.Join(parts[stripComponents:]...)
+
+		// Guard against entries escaping the destination
+		target := filepath.Join(dest, relPath)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path in archive: %s", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+
+	oplog.Record(oplog.Entry{Op: "write", Target: dest, Args: []string{archive}})
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	in, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	return nil
+}
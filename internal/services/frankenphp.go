@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// FrankenPHPUnit is the systemd unit running FrankenPHP
+const FrankenPHPUnit = "phppark-frankenphp"
+
+// FrankenPHPBinary returns the path of the FrankenPHP binary in dir
+func FrankenPHPBinary(dir string) string {
+	return filepath.Join(dir, "frankenphp")
+}
+
+// FrankenPHPCaddyfile returns the path of the top-level Caddyfile in dir
+func FrankenPHPCaddyfile(dir string) string {
+	return filepath.Join(dir, "Caddyfile")
+}
+
+// frankenPHPDownload returns the latest release binary for this
+// architecture, with its published SHA-256
+func frankenPHPDownload() (*Download, error) {
+	arch := "x86_64"
+	if runtime.GOARCH == "arm64" {
+		arch = "aarch64"
+	}
+	return GitHubAsset("php/frankenphp", regexp.MustCompile("^frankenphp-linux-"+arch+"$"))
+}
+
+// InstallFrankenPHP downloads FrankenPHP into dir (once), writes its
+// top-level Caddyfile and runs it under systemd
+func InstallFrankenPHP(dir, caddyfile string) error {
+	binary := FrankenPHPBinary(dir)
+	if _, err := os.Stat(binary); os.IsNotExist(err) {
+		fmt.Println("   📥 Downloading FrankenPHP...")
+		d, err := frankenPHPDownload()
+		if err != nil {
+			return err
+		}
+		if err := DownloadVerified(d, binary, 0755); err != nil {
+			return err
+		}
+	}
+
+	if err := oplog.WriteFile(FrankenPHPCaddyfile(dir), []byte(caddyfile), 0644); err != nil {
+		return fmt.Errorf("failed to write Caddyfile: %w", err)
+	}
+	// The unit runs as the invoking user
+	if err := ChownToInvokingUser(dir); err != nil {
+		return err
+	}
+
+	if IsUnitActive(FrankenPHPUnit) {
+		return nil
+	}
+
+	// A user unit can't be granted the capability below
+	if UserScope() {
+		return i18n.Errorf(i18n.FrankenPHPUnit)
+	}
+
+	return InstallUnit(&Unit{
+		Name:        FrankenPHPUnit,
+		Description: "PHPark FrankenPHP",
+		ExecStart:   []string{binary, "run", "--config", FrankenPHPCaddyfile(dir)},
+		WorkingDir:  dir,
+		// It runs as the invoking user, who can't bind 80 and 443 otherwise
+		Capabilities: []string{"CAP_NET_BIND_SERVICE"},
+	})
+}
+
+// ReloadFrankenPHP applies Caddyfile changes without dropping connections
+func ReloadFrankenPHP(dir string) error {
+	if !IsUnitActive(FrankenPHPUnit) {
+		return StartUnit(FrankenPHPUnit)
+	}
+
+	output, err := oplog.CombinedOutput(exec.Command(FrankenPHPBinary(dir), "reload", "--config", FrankenPHPCaddyfile(dir)))
+	if err != nil {
+		return fmt.Errorf("frankenphp reload failed: %s", string(output))
+	}
+	return nil
+}
+
+// ValidateFrankenPHPConfig checks the Caddyfile and every imported site
+func ValidateFrankenPHPConfig(dir string) error {
+	output, err := exec.Command(FrankenPHPBinary(dir), "validate", "--config", FrankenPHPCaddyfile(dir)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("frankenphp validate failed: %s", string(output))
+	}
+	return nil
+}
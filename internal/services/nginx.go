@@ -2,46 +2,330 @@ package services
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
-// DeployNginxConfig copies config to nginx and reloads
+// NginxLayout describes where nginx is installed and reads site configs
+// from. The zero value is the Debian/Ubuntu package layout.
+type NginxLayout struct {
+	Binary     string // Executable (default: nginx on PATH)
+	Service    string // systemd service (default: nginx)
+	ConfDir    string // Main config directory (default: /etc/nginx)
+	IncludeDir string // Directory nginx.conf includes site configs from; when set, configs are written there directly instead of sites-available/sites-enabled
+}
+
+// nginxLayout is the layout every nginx function uses
+var nginxLayout NginxLayout
+
+// SetNginxLayout points PHPark at a custom nginx install (OpenResty, a
+// build in /opt). Empty fields keep the defaults.
+func SetNginxLayout(layout NginxLayout) {
+	nginxLayout = layout
+}
+
+// NginxBinary returns the nginx executable
+func NginxBinary() string {
+	if nginxLayout.Binary != "" {
+		return nginxLayout.Binary
+	}
+	return "nginx"
+}
+
+// NginxService returns the systemd service that runs nginx
+func NginxService() string {
+	if nginxLayout.Service != "" {
+		return nginxLayout.Service
+	}
+	return "nginx"
+}
+
+// nginxConfDir returns nginx's main config directory
+func nginxConfDir() string {
+	if nginxLayout.ConfDir != "" {
+		return nginxLayout.ConfDir
+	}
+	return "/etc/nginx"
+}
+
+// nginxCommand builds an nginx invocation. A configured conf dir is passed
+// with -c, since a custom binary may have been built with another default.
+func nginxCommand(args ...string) *exec.Cmd {
+	if nginxLayout.ConfDir != "" {
+		args = append([]string{"-c", filepath.Join(nginxLayout.ConfDir, "nginx.conf")}, args...)
+	}
+	return exec.Command(NginxBinary(), args...)
+}
+
+// sitesEnabledDir returns where enabled site configs live. With an include
+// dir there's no sites-available/sites-enabled split, so it's both.
+func sitesEnabledDir() string {
+	if nginxLayout.IncludeDir != "" {
+		return nginxLayout.IncludeDir
+	}
+	return filepath.Join(nginxConfDir(), "sites-enabled")
+}
+
+// sitesAvailableDir returns where site configs are installed
+func sitesAvailableDir() string {
+	if nginxLayout.IncludeDir != "" {
+		return nginxLayout.IncludeDir
+	}
+	return filepath.Join(nginxConfDir(), "sites-available")
+}
+
+// NginxGlobalConfigPath is where PHPark's http-level include goes: conf.d,
+// or the include dir next to the sites
+func NginxGlobalConfigPath() string {
+	if nginxLayout.IncludeDir != "" {
+		return filepath.Join(nginxLayout.IncludeDir, "_phppark.conf")
+	}
+	return filepath.Join(nginxConfDir(), "conf.d", "phppark.conf")
+}
+
+// NginxCacheDir is where the global include keeps its FastCGI cache. nginx
+// creates it (owned by its worker user) if the parent exists.
+const NginxCacheDir = "/var/cache/phppark/fastcgi"
+
+// StageNginxGlobalConfig writes PHPark's http-level include, without
+// testing or reloading. It returns nil when the content is unchanged, and
+// otherwise a Rollback that restores the previous include.
+func StageNginxGlobalConfig(content string) (Rollback, error) {
+	path := NginxGlobalConfigPath()
+	if current, err := os.ReadFile(path); err == nil && string(current) == content {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(NginxCacheDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	rollback, err := snapshotPaths(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := oplog.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, undo(rollback, fmt.Errorf("failed to write %s: %w", path, err))
+	}
+	return rollback, nil
+}
+
+// nginxLogrotatePath is PHPark's logrotate entry. The distro's own nginx
+// entry only rotates *.log, which leaves the sites' JSON access logs out.
+const nginxLogrotatePath = "/etc/logrotate.d/phppark-nginx"
+
+// nginxLogrotate rotates the JSON access logs daily, or sooner once one
+// passes 50 MB, and has nginx reopen them afterwards
+const nginxLogrotate = `# Managed by PHPark
+/var/log/nginx/*.access.json {
+	daily
+	maxsize 50M
+	rotate 7
+	missingok
+	notifempty
+	compress
+	delaycompress
+	sharedscripts
+	postrotate
+		[ ! -s /run/nginx.pid ] || kill -USR1 "$(cat /run/nginx.pid)"
+	endscript
+}
+`
+
+// InstallNginxLogrotate writes the logrotate entry for the sites' JSON
+// access logs. Without logrotate there's nothing to write it for.
+func InstallNginxLogrotate() error {
+	if _, err := os.Stat(filepath.Dir(nginxLogrotatePath)); os.IsNotExist(err) {
+		return nil
+	}
+	if current, err := os.ReadFile(nginxLogrotatePath); err == nil && string(current) == nginxLogrotate {
+		return nil
+	}
+	if err := oplog.WriteFile(nginxLogrotatePath, []byte(nginxLogrotate), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", nginxLogrotatePath, err)
+	}
+	return nil
+}
+
+// DeployNginxConfig copies config to nginx and reloads. If nginx rejects
+// the result, the site's previous config and symlink are restored so a bad
+// file never stays enabled.
 func DeployNginxConfig(siteName, configPath string) error {
+	rollback, err := StageNginxConfig(siteName, configPath)
+	if err != nil {
+		return err
+	}
+
+	// Test nginx config
+	if err := TestNginxConfig(); err != nil {
+		return undo(rollback, fmt.Errorf("nginx config test failed: %w", err))
+	}
+
+	// Reload nginx
+	if err := ReloadNginx(); err != nil {
+		return fmt.Errorf("failed to reload nginx: %w", err)
+	}
+
+	return nil
+}
+
+// NginxConfigPath is where a site's config is installed in sites-available
+func NginxConfigPath(siteName string) string {
+	return filepath.Join(sitesAvailableDir(), siteName+".conf")
+}
+
+// StageNginxConfig copies a site config into sites-available and enables
+// it, without testing or reloading, so many sites can be applied with one
+// reload. The returned Rollback restores what was there before.
+func StageNginxConfig(siteName, configPath string) (Rollback, error) {
 	// Paths
-	sitesAvailable := "/etc/nginx/sites-available"
-	sitesEnabled := "/etc/nginx/sites-enabled"
+	sitesEnabled := sitesEnabledDir()
 	defaultSite := filepath.Join(sitesEnabled, "default")
 
 	// Target paths
-	availablePath := filepath.Join(sitesAvailable, siteName+".conf")
+	availablePath := NginxConfigPath(siteName)
 	enabledPath := filepath.Join(sitesEnabled, siteName+".conf")
 
+	// An include dir holds the config itself, with nothing to link
+	if nginxLayout.IncludeDir != "" {
+		rollback, err := snapshotPaths(availablePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := copyFile(configPath, availablePath); err != nil {
+			return nil, undo(rollback, fmt.Errorf("failed to copy config: %w", err))
+		}
+		return rollback, nil
+	}
+
+	// Remember what's there now to roll back to
+	rollback, err := snapshotPaths(availablePath, enabledPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Copy to sites-available
 	if err := copyFile(configPath, availablePath); err != nil {
-		return fmt.Errorf("failed to copy config: %w", err)
+		return nil, undo(rollback, fmt.Errorf("failed to copy config: %w", err))
 	}
 
 	// Create symlink in sites-enabled
 	if err := createSymlink(availablePath, enabledPath); err != nil {
-		return fmt.Errorf("failed to create symlink: %w", err)
+		return nil, undo(rollback, fmt.Errorf("failed to create symlink: %w", err))
 	}
 
 	// Remove default site (first time only)
 	if _, err := os.Stat(defaultSite); err == nil {
-		if err := os.Remove(defaultSite); err != nil {
+		if err := oplog.Remove(defaultSite); err != nil {
 			// Non-fatal, just warn
 			fmt.Printf("   ⚠️  Could not remove default site: %v\n", err)
 		}
 	}
 
-	// Test nginx config
+	return rollback, nil
+}
+
+// Rollback undoes a staged config change
+type Rollback func() error
+
+// undo rolls back after a failure, reporting whether it worked
+func undo(rollback Rollback, cause error) error {
+	if err := rollback(); err != nil {
+		return fmt.Errorf("%w (rollback failed: %v)", cause, err)
+	}
+	return fmt.Errorf("%w (previous config restored)", cause)
+}
+
+// snapshotPaths records the current state of paths, returning a Rollback
+// that puts them back
+func snapshotPaths(paths ...string) (Rollback, error) {
+	var snapshots []*fileSnapshot
+	for _, path := range paths {
+		snapshot, err := takeSnapshot(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return func() error {
+		for _, snapshot := range snapshots {
+			if err := snapshot.restore(); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", snapshot.path, err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// fileSnapshot records a file or symlink (or its absence) so it can be put
+// back after a failed change
+type fileSnapshot struct {
+	path   string
+	exists bool
+	link   string // Symlink target, when the path was a symlink
+	data   []byte
+	mode   os.FileMode
+}
+
+func takeSnapshot(path string) (*fileSnapshot, error) {
+	snapshot := &fileSnapshot{path: path}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return snapshot, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot.exists = true
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		snapshot.link, err = os.Readlink(path)
+		return snapshot, err
+	}
+
+	snapshot.mode = info.Mode().Perm()
+	snapshot.data, err = os.ReadFile(path)
+	return snapshot, err
+}
+
+// restore returns the path to its recorded state
+func (s *fileSnapshot) restore() error {
+	if err := oplog.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch {
+	case !s.exists:
+		return nil
+	case s.link != "":
+		return oplog.Symlink(s.link, s.path)
+	default:
+		return oplog.WriteFile(s.path, s.data, s.mode)
+	}
+}
+
+// RemoveNginxConfig removes config from nginx and reloads
+func RemoveNginxConfig(siteName string) error {
+	if err := UnstageNginxConfig(siteName); err != nil {
+		return err
+	}
+
+	// Test and reload
 	if err := TestNginxConfig(); err != nil {
 		return fmt.Errorf("nginx config test failed: %w", err)
 	}
 
-	// Reload nginx
 	if err := ReloadNginx(); err != nil {
 		return fmt.Errorf("failed to reload nginx: %w", err)
 	}
@@ -49,52 +333,111 @@ func DeployNginxConfig(siteName, configPath string) error {
 	return nil
 }
 
-// RemoveNginxConfig removes config from nginx and reloads
-func RemoveNginxConfig(siteName string) error {
-	sitesAvailable := "/etc/nginx/sites-available"
-	sitesEnabled := "/etc/nginx/sites-enabled"
-
-	availablePath := filepath.Join(sitesAvailable, siteName+".conf")
-	enabledPath := filepath.Join(sitesEnabled, siteName+".conf")
+// UnstageNginxConfig disables and removes a site's config without testing
+// or reloading, so many sites can be removed with one reload
+func UnstageNginxConfig(siteName string) error {
+	availablePath := NginxConfigPath(siteName)
+	enabledPath := filepath.Join(sitesEnabledDir(), siteName+".conf")
 
 	// Remove symlink
-	if err := os.Remove(enabledPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove enabled config: %w", err)
+	if enabledPath != availablePath {
+		if err := oplog.Remove(enabledPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove enabled config: %w", err)
+		}
 	}
 
 	// Remove from sites-available
-	if err := os.Remove(availablePath); err != nil && !os.IsNotExist(err) {
+	if err := oplog.Remove(availablePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove available config: %w", err)
 	}
 
-	// Test and reload
-	if err := TestNginxConfig(); err != nil {
-		return fmt.Errorf("nginx config test failed: %w", err)
-	}
+	return nil
+}
 
-	if err := ReloadNginx(); err != nil {
-		return fmt.Errorf("failed to reload nginx: %w", err)
+// DisableNginxConfig takes a site offline without testing or reloading:
+// its sites-enabled link goes and the config stays in sites-available.
+// With an include dir there's no link, so the config is set aside instead.
+func DisableNginxConfig(siteName string) error {
+	availablePath := NginxConfigPath(siteName)
+	enabledPath := filepath.Join(sitesEnabledDir(), siteName+".conf")
+
+	if enabledPath == availablePath {
+		return SetAsideConfig(availablePath)
 	}
+	if err := oplog.Remove(enabledPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove enabled config: %w", err)
+	}
+	return nil
+}
+
+// EnableNginxConfig puts back what DisableNginxConfig took away, without
+// testing or reloading
+func EnableNginxConfig(siteName string) error {
+	availablePath := NginxConfigPath(siteName)
+	enabledPath := filepath.Join(sitesEnabledDir(), siteName+".conf")
 
+	if enabledPath == availablePath {
+		return RestoreConfig(availablePath)
+	}
+	if _, err := os.Stat(availablePath); err != nil {
+		return fmt.Errorf("no installed config for %s: %w", siteName, err)
+	}
+	if err := createSymlink(availablePath, enabledPath); err != nil {
+		return fmt.Errorf("failed to enable config: %w", err)
+	}
 	return nil
 }
 
-// TestNginxConfig tests nginx configuration
+// NginxSiteConfigs lists the *.conf files in sites-available and
+// sites-enabled (or the include dir), for finding stale ones
+func NginxSiteConfigs() []string {
+	dirs := []string{sitesAvailableDir()}
+	if enabled := sitesEnabledDir(); enabled != dirs[0] {
+		dirs = append(dirs, enabled)
+	}
+
+	var files []string
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// NginxEnabledConfigs lists every file nginx loads sites from: those in
+// sites-enabled (or the include dir), PHPark's or not
+func NginxEnabledConfigs() []string {
+	entries, err := os.ReadDir(sitesEnabledDir())
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(sitesEnabledDir(), entry.Name()))
+		}
+	}
+	return files
+}
+
+// TestNginxConfig tests nginx configuration. On failure it returns a
+// *ConfigTestError with nginx's own explanation and the offending file.
 func TestNginxConfig() error {
-	cmd := exec.Command("nginx", "-t")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("nginx -t failed: %w", err)
+	output, err := nginxCommand("-t").CombinedOutput()
+	if err != nil {
+		return newConfigTestError("nginx -t", output)
 	}
 	return nil
 }
 
 // ReloadNginx reloads nginx service
 func ReloadNginx() error {
-	cmd := exec.Command("systemctl", "reload", "nginx")
-	if err := cmd.Run(); err != nil {
+	cmd := exec.Command("systemctl", "reload", NginxService())
+	if err := oplog.Run(cmd); err != nil {
 		// Try alternative reload method
-		cmd = exec.Command("nginx", "-s", "reload")
-		if err := cmd.Run(); err != nil {
+		cmd = nginxCommand("-s", "reload")
+		if err := oplog.Run(cmd); err != nil {
 			return fmt.Errorf("failed to reload nginx: %w", err)
 		}
 	}
@@ -104,24 +447,82 @@ func ReloadNginx() error {
 // StartNginx starts nginx if not running
 func StartNginx() error {
 	// Check if running
-	cmd := exec.Command("systemctl", "is-active", "nginx")
+	cmd := exec.Command("systemctl", "is-active", NginxService())
 	if err := cmd.Run(); err == nil {
 		return nil // Already running
 	}
 
 	// Start nginx
-	cmd = exec.Command("systemctl", "start", "nginx")
-	if err := cmd.Run(); err != nil {
+	cmd = exec.Command("systemctl", "start", NginxService())
+	if err := oplog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to start nginx: %w", err)
 	}
 
 	// Enable on boot
-	cmd = exec.Command("systemctl", "enable", "nginx")
-	cmd.Run() // Non-fatal
+	cmd = exec.Command("systemctl", "enable", NginxService())
+	oplog.Run(cmd) // Non-fatal
 
 	return nil
 }
 
+// IsNginxRunning reports whether the nginx service is active
+func IsNginxRunning() bool {
+	return IsServiceActive(NginxService())
+}
+
+// StopNginx stops nginx
+func StopNginx() error {
+	cmd := exec.Command("systemctl", "stop", NginxService())
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to stop nginx: %w", err)
+	}
+	return nil
+}
+
+// NginxStatus holds the counters from nginx's stub_status page
+type NginxStatus struct {
+	Active   int64 // Open client connections, including idle keepalives
+	Accepts  int64
+	Handled  int64
+	Requests int64 // Requests served since nginx started
+	Reading  int64
+	Writing  int64
+	Waiting  int64
+}
+
+// FetchNginxStatus reads the stub_status page the global include serves at
+// addr
+func FetchNginxStatus(addr string) (*NginxStatus, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + addr + "/nginx-status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status page returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status page: %w", err)
+	}
+
+	// Active connections: 2
+	// server accepts handled requests
+	//  16 16 31
+	// Reading: 0 Writing: 1 Waiting: 1
+	var status NginxStatus
+	if _, err := fmt.Sscanf(string(body),
+		"Active connections: %d\nserver accepts handled requests\n %d %d %d\nReading: %d Writing: %d Waiting: %d",
+		&status.Active, &status.Accepts, &status.Handled, &status.Requests,
+		&status.Reading, &status.Writing, &status.Waiting); err != nil {
+		return nil, fmt.Errorf("failed to parse status page: %w", err)
+	}
+	return &status, nil
+}
+
 // Helper: Copy file
 func copyFile(src, dst string) error {
 	input, err := os.ReadFile(src)
@@ -135,13 +536,42 @@ func copyFile(src, dst string) error {
 		return err
 	}
 
-	return os.WriteFile(dst, input, 0644)
+	return oplog.WriteFile(dst, input, 0644)
+}
+
+// DisabledSuffix is added to a config a server reads from a directory of
+// its own (e.g., *.conf) to take a site offline without deleting it
+const DisabledSuffix = ".disabled"
+
+// SetAsideConfig renames a config to <path>.disabled so the server's
+// include glob no longer matches it
+func SetAsideConfig(path string) error {
+	if err := oplog.Rename(path, path+DisabledSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to set config aside: %w", err)
+	}
+	return nil
+}
+
+// RestoreConfig undoes SetAsideConfig. If the config has been written
+// again since, the set-aside copy is just dropped.
+func RestoreConfig(path string) error {
+	disabled := path + DisabledSuffix
+	if _, err := os.Stat(disabled); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return oplog.Remove(disabled)
+	}
+	if err := oplog.Rename(disabled, path); err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+	return nil
 }
 
 // Helper: Create symlink
 func createSymlink(src, dst string) error {
 	// Remove existing symlink if it exists
-	os.Remove(dst)
+	oplog.Remove(dst)
 
-	return os.Symlink(src, dst)
+	return oplog.Symlink(src, dst)
 }
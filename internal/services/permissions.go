@@ -2,32 +2,60 @@ package services
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
+	"os/user"
 	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// Permission modes for FixSitePermissions
+const (
+	PermissionsChmod = "chmod"
+	PermissionsACL   = "acl"
+	PermissionsOff   = "off"
 )
 
-// FixSitePermissions fixes permissions for a site directory
-func FixSitePermissions(sitePath string) error {
+// FixSitePermissions makes a site directory readable by the web server.
+// Directories named in skip (e.g., vendor, node_modules) are left alone.
+func FixSitePermissions(sitePath, mode string, skip []string) error {
+	if mode == PermissionsOff {
+		return nil
+	}
+
 	// Get absolute path
 	absPath, err := filepath.Abs(sitePath)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
+	if mode == PermissionsACL {
+		return fixACLPermissions(absPath, skip)
+	}
+	if mode != "" && mode != PermissionsChmod {
+		return fmt.Errorf("unknown permissions mode %q (use chmod, acl or off)", mode)
+	}
+
 	// Fix permissions on parent directories up to home
 	if err := fixParentPermissions(absPath); err != nil {
 		return fmt.Errorf("failed to fix parent permissions: %w", err)
 	}
 
 	// Fix permissions on site directory and contents
-	if err := fixDirectoryPermissions(absPath); err != nil {
+	if err := fixDirectoryPermissions(absPath, skip); err != nil {
 		return fmt.Errorf("failed to fix directory permissions: %w", err)
 	}
 
 	return nil
 }
 
-// fixParentPermissions fixes permissions on parent directories
+// fixParentPermissions lets the web server traverse the directories above
+// a site, up to home
 func fixParentPermissions(path string) error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -35,10 +63,10 @@ func fixParentPermissions(path string) error {
 	}
 
 	// Walk up to home directory
-	current := path
+	current := filepath.Dir(path)
 	for {
-		// Set directory to 755 (readable/executable by all)
-		if err := os.Chmod(current, 0755); err != nil {
+		// Traversal only needs the execute bits
+		if err := ensureMode(current, 0111); err != nil {
 			return err
 		}
 
@@ -58,19 +86,166 @@ func fixParentPermissions(path string) error {
 	return nil
 }
 
-// fixDirectoryPermissions recursively fixes permissions in a directory
-func fixDirectoryPermissions(path string) error {
-	return filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+// fixDirectoryPermissions makes directories at least 755 and files at
+// least 644, keeping existing bits (e.g., executable scripts). Paths are
+// checked by a pool of workers and only changed when bits are missing.
+func fixDirectoryPermissions(root string, skip []string) error {
+	paths := make(chan walkedPath)
+	var firstErr error
+	var once sync.Once
+	fail := func(err error) { once.Do(func() { firstErr = err }) }
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				want := fs.FileMode(0644)
+				if p.dir {
+					want = 0755
+				}
+				if err := ensureMode(p.path, want); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	walkErr := walkSite(root, skip, func(path string, d fs.DirEntry) {
+		paths <- walkedPath{path: path, dir: d.IsDir()}
+	})
+	close(paths)
+	wg.Wait()
+
+	if walkErr != nil {
+		return walkErr
+	}
+	return firstErr
+}
+
+// walkedPath is a path found by walkSite
+type walkedPath struct {
+	path string
+	dir  bool
+}
+
+// walkSite calls fn for every directory and regular file under root,
+// skipping symlinks and directories named in skip
+func walkSite(root string, skip []string, fn func(path string, d fs.DirEntry)) error {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
-			// Directories: 755
-			return os.Chmod(filePath, 0755)
+		if d.IsDir() && path != root && skipped[d.Name()] {
+			return filepath.SkipDir
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		fn(path, d)
+		return nil
+	})
+}
+
+// ensureMode adds any of the want bits a path is missing
+func ensureMode(path string, want fs.FileMode) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	mode := info.Mode().Perm()
+	if mode&want == want {
+		return nil
+	}
+	return os.Chmod(path, mode|want)
+}
+
+// fixACLPermissions grants the web server user read access to a site (and
+// traversal of its parents) with POSIX ACLs, leaving modes untouched.
+// Default ACLs on directories cover files created later.
+func fixACLPermissions(root string, skip []string) error {
+	if _, err := exec.LookPath("setfacl"); err != nil {
+		return i18n.Errorf(i18n.SetfaclNotFound)
+	}
+
+	webUser := webServerUser()
+	if webUser == "" {
+		return fmt.Errorf("no web server user found (www-data, nginx, http or apache)")
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	for current := filepath.Dir(root); ; current = filepath.Dir(current) {
+		if err := setfacl("u:"+webUser+":x", current); err != nil {
+			return err
+		}
+		if current == homeDir || current == filepath.Dir(current) {
+			break
+		}
+	}
+
+	// Batch paths to keep the number of setfacl runs low
+	var dirs, files []string
+	err := walkSite(root, skip, func(path string, d fs.DirEntry) {
+		if d.IsDir() {
+			dirs = append(dirs, path)
 		} else {
-			// Files: 644
-			return os.Chmod(filePath, 0644)
+			files = append(files, path)
 		}
 	})
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range chunk(dirs, 500) {
+		if err := setfacl("u:"+webUser+":rx,d:u:"+webUser+":rX", batch...); err != nil {
+			return err
+		}
+	}
+	for _, batch := range chunk(files, 500) {
+		if err := setfacl("u:"+webUser+":r", batch...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setfacl(spec string, paths ...string) error {
+	args := append([]string{"-m", spec, "--"}, paths...)
+	if output, err := oplog.CombinedOutput(exec.Command("setfacl", args...)); err != nil {
+		return fmt.Errorf("setfacl failed: %s", output)
+	}
+	return nil
+}
+
+// webServerUser returns the account the distro's web server runs as
+func webServerUser() string {
+	for _, name := range []string{"www-data", "nginx", "http", "apache"} {
+		if _, err := user.Lookup(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// chunk splits items into slices of at most size
+func chunk(items []string, size int) [][]string {
+	var chunks [][]string
+	for len(items) > size {
+		chunks = append(chunks, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
 }
@@ -1,14 +1,28 @@
 package services
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
+// PHPFPMService returns the distro's systemd service for a PHP version
+func PHPFPMService(version string) string {
+	return fmt.Sprintf("php%s-fpm", version)
+}
+
 // StartPHPFPM starts PHP-FPM service for a given version
 func StartPHPFPM(version string) error {
-	serviceName := fmt.Sprintf("php%s-fpm", version)
+	serviceName := PHPFPMService(version)
 
 	// Check if running
 	cmd := exec.Command("systemctl", "is-active", serviceName)
@@ -18,17 +32,33 @@ func StartPHPFPM(version string) error {
 
 	// Start service
 	cmd = exec.Command("systemctl", "start", serviceName)
-	if err := cmd.Run(); err != nil {
+	if err := oplog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to start %s: %w", serviceName, err)
 	}
 
 	// Enable on boot
 	cmd = exec.Command("systemctl", "enable", serviceName)
-	cmd.Run() // Non-fatal
+	oplog.Run(cmd) // Non-fatal
 
 	return nil
 }
 
+// IsPHPFPMRunning reports whether PHP-FPM for a version is active
+func IsPHPFPMRunning(version string) bool {
+	return IsServiceActive(PHPFPMService(version))
+}
+
+// StopPHPFPM stops the PHP-FPM service for a given version
+func StopPHPFPM(version string) error {
+	serviceName := PHPFPMService(version)
+
+	cmd := exec.Command("systemctl", "stop", serviceName)
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", serviceName, err)
+	}
+	return nil
+}
+
 // EnsurePHPFPMRunning ensures all detected PHP-FPM versions are running
 func EnsurePHPFPMRunning(versions []string) error {
 	var errors []string
@@ -51,3 +81,129 @@ func EnsurePHPFPMRunning(versions []string) error {
 
 	return nil
 }
+
+// FPMStatus holds the fields PHPark reports from the PHP-FPM status page
+type FPMStatus struct {
+	Pool               string `json:"pool"`
+	ProcessManager     string `json:"process manager"`
+	StartSince         int64  `json:"start since"`
+	AcceptedConn       int64  `json:"accepted conn"`
+	ListenQueue        int64  `json:"listen queue"`
+	MaxListenQueue     int64  `json:"max listen queue"`
+	IdleProcesses      int64  `json:"idle processes"`
+	ActiveProcesses    int64  `json:"active processes"`
+	TotalProcesses     int64  `json:"total processes"`
+	MaxActiveProcesses int64  `json:"max active processes"`
+	MaxChildrenReached int64  `json:"max children reached"`
+	SlowRequests       int64  `json:"slow requests"`
+}
+
+// fpmStatusPoolConf returns the pool drop-in path for a PHP version.
+// The zz- prefix makes it load after www.conf so it extends the same pool.
+func fpmStatusPoolConf(version string) string {
+	return fmt.Sprintf("/etc/php/%s/fpm/pool.d/zz-phppark-status.conf", version)
+}
+
+// EnableFPMStatus turns on the status and ping pages for a PHP version's
+// default pool and reloads PHP-FPM if the setting changed
+func EnableFPMStatus(version string) error {
+	content := "; Managed by PHPark\n" +
+		"[www]\n" +
+		"pm.status_path = /fpm-status\n" +
+		"ping.path = /fpm-ping\n" +
+		"ping.response = pong\n"
+
+	confPath := fpmStatusPoolConf(version)
+
+	// Nothing to do if already enabled
+	if existing, err := os.ReadFile(confPath); err == nil && string(existing) == content {
+		return nil
+	}
+
+	if err := oplog.WriteFile(confPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write FPM status config: %w", err)
+	}
+
+	return ReloadPHPFPM(version)
+}
+
+// DisableFPMStatus removes the status pool drop-in for a PHP version
+func DisableFPMStatus(version string) error {
+	confPath := fpmStatusPoolConf(version)
+
+	if err := oplog.Remove(confPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove FPM status config: %w", err)
+	}
+
+	return ReloadPHPFPM(version)
+}
+
+// ReloadPHPFPM gracefully reloads PHP-FPM for a given version
+func ReloadPHPFPM(version string) error {
+	serviceName := PHPFPMService(version)
+
+	cmd := exec.Command("systemctl", "reload", serviceName)
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to reload %s: %w", serviceName, err)
+	}
+	return nil
+}
+
+// FetchFPMStatus queries a site's status page through the web server, at
+// the site's URL (its scheme, port and hostname, e.g.
+// https://blog.test:8443). It connects to 127.0.0.1 whatever the hostname
+// resolves to, since the page only answers localhost.
+func FetchFPMStatus(siteURL string) (*FPMStatus, error) {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(siteURL, "/")+"/fpm-status?json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		// The site's own certificate, which may be self-signed; the
+		// connection never leaves the machine
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, net.JoinHostPort("127.0.0.1", port))
+		},
+	}
+	return fetchFPMStatus(&http.Client{Timeout: 5 * time.Second, Transport: transport}, req, req.URL.Host)
+}
+
+// FetchUpstreamFPMStatus queries an upstream's status page through the
+// global include's localhost-only status server at addr
+func FetchUpstreamFPMStatus(addr, upstream string) (*FPMStatus, error) {
+	req, err := http.NewRequest("GET", "http://"+addr+"/fpm-status/"+upstream+"?json", nil)
+	if err != nil {
+		return nil, err
+	}
+	return fetchFPMStatus(&http.Client{Timeout: 5 * time.Second}, req, addr)
+}
+
+// fetchFPMStatus sends a status page request and decodes the answer
+func fetchFPMStatus(client *http.Client, req *http.Request, name string) (*FPMStatus, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status page returned %s", resp.Status)
+	}
+
+	var status FPMStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to parse status page: %w", err)
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+// SiteFPM is a PHP-FPM instance PHPark runs for a single site, for php.ini
+// settings that PHP-FPM only reads when its master starts (extensions,
+// opcache preloading) and that a pool shared with other sites can't have
+type SiteFPM struct {
+	Name    string            // Unit-safe site name, e.g., "myapp"
+	Version string            // PHP version, e.g., "8.2"
+	INI     map[string]string // php.ini settings, passed with -d
+}
+
+// SiteFPMUnitName is the systemd unit running a site's own PHP-FPM
+func SiteFPMUnitName(name string) string {
+	return "phppark-fpm-" + name
+}
+
+// SiteFPMSocket is where a site's own PHP-FPM listens
+func SiteFPMSocket(name string) string {
+	return fmt.Sprintf("/var/run/php/phppark-%s.sock", name)
+}
+
+// SiteFPMLogFile is where a site's own PHP-FPM writes its log
+func SiteFPMLogFile(logDir, name string) string {
+	return filepath.Join(logDir, "fpm-"+name+".log")
+}
+
+// siteFPMConfigPath is the php-fpm.conf of a site's own PHP-FPM
+func siteFPMConfigPath(configDir, name string) string {
+	return filepath.Join(configDir, name+".conf")
+}
+
+// Config returns the instance's php-fpm.conf: one on-demand pool, run as
+// the same user as the distro's www pool
+func (f *SiteFPM) Config(logFile string) string {
+	var b strings.Builder
+
+	b.WriteString("; Managed by PHPark - do not edit\n")
+	b.WriteString("[global]\n")
+	fmt.Fprintf(&b, "pid = /var/run/php/phppark-%s.pid\n", f.Name)
+	fmt.Fprintf(&b, "error_log = %s\n\n", logFile)
+
+	fmt.Fprintf(&b, "[%s]\n", f.Name)
+	b.WriteString("user = www-data\n")
+	b.WriteString("group = www-data\n")
+	fmt.Fprintf(&b, "listen = %s\n", SiteFPMSocket(f.Name))
+	b.WriteString("listen.owner = www-data\n")
+	b.WriteString("listen.group = www-data\n")
+	b.WriteString("pm = ondemand\n")
+	b.WriteString("pm.max_children = 5\n")
+	b.WriteString("pm.process_idle_timeout = 60s\n")
+
+	return b.String()
+}
+
+// InstallSiteFPM writes a site's PHP-FPM config and (re)starts it under
+// systemd, so changed settings take effect
+func InstallSiteFPM(f *SiteFPM, configDir, logDir string) error {
+	binary := fmt.Sprintf("/usr/sbin/php-fpm%s", f.Version)
+	if _, err := os.Stat(binary); err != nil {
+		return i18n.Errorf(i18n.FPMNotInstalled, f.Version, binary)
+	}
+
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", configDir, err)
+	}
+
+	confPath := siteFPMConfigPath(configDir, f.Name)
+	if err := oplog.WriteFile(confPath, []byte(f.Config(SiteFPMLogFile(logDir, f.Name))), 0644); err != nil {
+		return fmt.Errorf("failed to write PHP-FPM config: %w", err)
+	}
+
+	names := make([]string, 0, len(f.INI))
+	for name := range f.INI {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []string{binary, "--nodaemonize", "--fpm-config", confPath}
+	for _, name := range names {
+		args = append(args, "-d", name+"="+f.INI[name])
+	}
+
+	unit := &Unit{
+		Name:        SiteFPMUnitName(f.Name),
+		Description: fmt.Sprintf("PHPark PHP-FPM %s for %s", f.Version, f.Name),
+		ExecStart:   args,
+		// The master switches its workers to www-data
+		User: "root",
+	}
+	if err := InstallUnit(unit); err != nil {
+		return err
+	}
+
+	// enable --now leaves an instance that was already running on its old
+	// settings
+	return RestartUnit(unit.Name)
+}
+
+// RemoveSiteFPM stops a site's own PHP-FPM and removes its config
+func RemoveSiteFPM(name, configDir string) error {
+	if err := RemoveUnit(SiteFPMUnitName(name)); err != nil {
+		return err
+	}
+
+	if err := oplog.Remove(siteFPMConfigPath(configDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove PHP-FPM config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/i18n"
+	"github.com/stevepop/phppark/internal/oplog"
+)
+
+const systemdUnitDir = "/etc/systemd/system"
+
+// UserScope reports whether PHPark manages units in the invoking user's
+// systemd instance (`systemctl --user`) rather than the system one. That's
+// the case whenever phppark runs without root, which can't write system units.
+func UserScope() bool {
+	return os.Geteuid() != 0
+}
+
+// unitDir returns where unit files are written for the current scope
+func unitDir() (string, error) {
+	dir, err := scopeUnitDir(UserScope())
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// scopeUnitDir returns the unit directory of the system instance, or of
+// the invoking user's instance (SUDO_USER's under sudo)
+func scopeUnitDir(userScope bool) (string, error) {
+	if !userScope {
+		return systemdUnitDir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && !UserScope() {
+		var u *user.User
+		if u, err = user.Lookup(sudoUser); err == nil {
+			home = u.HomeDir
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// installedScope reports which instance a unit file (e.g., "x.service")
+// is installed in, true for the user's. The scope is where the unit was
+// installed, not where this run would install it: a unit installed with
+// sudo can be managed later without it, and the other way round. A unit in
+// neither gets the current scope.
+func installedScope(file string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(systemdUnitDir, file)); err == nil {
+		if UserScope() {
+			return false, i18n.Errorf(i18n.SystemWideUnit, file)
+		}
+		return false, nil
+	}
+	if dir, err := scopeUnitDir(true); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return true, nil
+		}
+	}
+	return UserScope(), nil
+}
+
+// systemctl builds a systemctl command for the current scope
+func systemctl(args ...string) *exec.Cmd {
+	return scopedSystemctl(UserScope(), args...)
+}
+
+// scopedSystemctl builds a systemctl command for the system instance or
+// the user's. Under sudo, the user's instance is SUDO_USER's.
+func scopedSystemctl(userScope bool, args ...string) *exec.Cmd {
+	if userScope {
+		prefix := []string{"--user"}
+		if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && !UserScope() {
+			prefix = append(prefix, "--machine="+sudoUser+"@")
+		}
+		args = append(prefix, args...)
+	}
+	return exec.Command("systemctl", args...)
+}
+
+// installedSystemctl builds a systemctl command for the instance a unit
+// file is installed in
+func installedSystemctl(file string, args ...string) (*exec.Cmd, error) {
+	userScope, err := installedScope(file)
+	if err != nil {
+		return nil, err
+	}
+	return scopedSystemctl(userScope, args...), nil
+}
+
+// Unit describes a systemd service unit managed by PHPark
+type Unit struct {
+	Name        string   // e.g., "phppark-mailpit" (without .service)
+	Description string   // e.g., "PHPark Mailpit"
+	ExecStart   []string // Command and arguments
+	WorkingDir  string   // Optional working directory
+	User        string   // User to run as (default: invoking user)
+	// Capabilities are granted to a system unit's non-root user, e.g.,
+	// CAP_NET_BIND_SERVICE to bind ports below 1024
+	Capabilities []string
+	Environment  []string // KEY=VALUE entries
+	// EnvironmentFile is an optional file of KEY=VALUE entries (kept out of
+	// the world-readable unit file, e.g., for secrets)
+	EnvironmentFile string
+	// OneShot marks a unit that runs to completion (e.g., triggered by a timer)
+	OneShot bool
+	// LogFile captures stdout and stderr instead of the journal
+	LogFile string
+	// Persistent makes the unit's timer catch up at boot on a run missed
+	// while the machine was off
+	Persistent bool
+}
+
+// Render returns the unit file contents
+func (u *Unit) Render() string {
+	var b strings.Builder
+
+	b.WriteString("# Managed by PHPark - do not edit\n")
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", escapeSpecifiers(u.Description))
+	b.WriteString("After=network.target\n\n")
+
+	b.WriteString("[Service]\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", quoteExec(u.ExecStart))
+	if u.WorkingDir != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", escapeSpecifiers(u.WorkingDir))
+	}
+	if u.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", u.User)
+	}
+	if len(u.Capabilities) > 0 && u.User != "root" {
+		fmt.Fprintf(&b, "AmbientCapabilities=%s\n", strings.Join(u.Capabilities, " "))
+	}
+	for _, env := range u.Environment {
+		fmt.Fprintf(&b, "Environment=%q\n", escapeSpecifiers(env))
+	}
+	if u.EnvironmentFile != "" {
+		fmt.Fprintf(&b, "EnvironmentFile=%s\n", escapeSpecifiers(u.EnvironmentFile))
+	}
+	if u.LogFile != "" {
+		fmt.Fprintf(&b, "StandardOutput=append:%s\n", escapeSpecifiers(u.LogFile))
+		fmt.Fprintf(&b, "StandardError=append:%s\n", escapeSpecifiers(u.LogFile))
+	}
+
+	// One-shot units are started by their timer, not at boot
+	if u.OneShot {
+		b.WriteString("Type=oneshot\n")
+		return b.String()
+	}
+
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=2\n\n")
+
+	b.WriteString("[Install]\n")
+	if UserScope() {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+
+	return b.String()
+}
+
+// renderTimer returns a timer unit that triggers the named service
+func renderTimer(name, description, onCalendar string, persistent bool) string {
+	var b strings.Builder
+
+	b.WriteString("# Managed by PHPark - do not edit\n")
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n\n", escapeSpecifiers(description))
+
+	b.WriteString("[Timer]\n")
+	fmt.Fprintf(&b, "OnCalendar=%s\n", onCalendar)
+	fmt.Fprintf(&b, "Unit=%s.service\n", name)
+	b.WriteString("AccuracySec=1s\n")
+	if persistent {
+		b.WriteString("Persistent=true\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=timers.target\n")
+
+	return b.String()
+}
+
+// InstallUnit writes a unit file, reloads systemd, and enables + starts it
+func InstallUnit(u *Unit) error {
+	if err := prepareUnit(u); err != nil {
+		return err
+	}
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+
+	unitPath := filepath.Join(dir, u.Name+".service")
+	if err := oplog.WriteFile(unitPath, []byte(u.Render()), 0644); err != nil {
+		return fmt.Errorf("failed to write unit %s: %w", unitPath, err)
+	}
+
+	if err := oplog.Run(systemctl("daemon-reload")); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+
+	if err := oplog.Run(systemctl("enable", "--now", u.Name)); err != nil {
+		return fmt.Errorf("failed to start %s: %w", u.Name, err)
+	}
+
+	return nil
+}
+
+// InstallTimer writes a one-shot service plus a timer that runs it on the
+// given OnCalendar schedule, and enables the timer
+func InstallTimer(u *Unit, onCalendar string) error {
+	if err := prepareUnit(u); err != nil {
+		return err
+	}
+	dir, err := unitDir()
+	if err != nil {
+		return err
+	}
+	u.OneShot = true
+
+	servicePath := filepath.Join(dir, u.Name+".service")
+	if err := oplog.WriteFile(servicePath, []byte(u.Render()), 0644); err != nil {
+		return fmt.Errorf("failed to write unit %s: %w", servicePath, err)
+	}
+
+	timerPath := filepath.Join(dir, u.Name+".timer")
+	timer := renderTimer(u.Name, u.Description, onCalendar, u.Persistent)
+	if err := oplog.WriteFile(timerPath, []byte(timer), 0644); err != nil {
+		return fmt.Errorf("failed to write timer %s: %w", timerPath, err)
+	}
+
+	if err := oplog.Run(systemctl("daemon-reload")); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+
+	if err := oplog.Run(systemctl("enable", "--now", u.Name+".timer")); err != nil {
+		return fmt.Errorf("failed to start %s.timer: %w", u.Name, err)
+	}
+
+	return nil
+}
+
+// RemoveTimer stops and deletes a timer and the service it triggers
+func RemoveTimer(name string) error {
+	userScope, err := installedScope(name + ".timer")
+	if err != nil {
+		return err
+	}
+	dir, err := scopeUnitDir(userScope)
+	if err != nil {
+		return err
+	}
+
+	oplog.Run(scopedSystemctl(userScope, "disable", "--now", name+".timer")) // Non-fatal
+
+	for _, suffix := range []string{".timer", ".service"} {
+		path := filepath.Join(dir, name+suffix)
+		if err := oplog.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	oplog.Run(scopedSystemctl(userScope, "daemon-reload"))
+	return nil
+}
+
+// RemoveUnit stops, disables, and deletes a unit file
+func RemoveUnit(name string) error {
+	userScope, err := installedScope(name + ".service")
+	if err != nil {
+		return err
+	}
+	dir, err := scopeUnitDir(userScope)
+	if err != nil {
+		return err
+	}
+
+	oplog.Run(scopedSystemctl(userScope, "disable", "--now", name)) // Non-fatal
+
+	unitPath := filepath.Join(dir, name+".service")
+	if err := oplog.Remove(unitPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove unit %s: %w", unitPath, err)
+	}
+
+	oplog.Run(scopedSystemctl(userScope, "daemon-reload"))
+	return nil
+}
+
+// StartUnit starts a systemd unit
+func StartUnit(name string) error {
+	return runUnitCommand("start", name)
+}
+
+// StopUnit stops a systemd unit
+func StopUnit(name string) error {
+	return runUnitCommand("stop", name)
+}
+
+// RestartUnit restarts a systemd unit
+func RestartUnit(name string) error {
+	return runUnitCommand("restart", name)
+}
+
+// runUnitCommand runs a systemctl action on a unit in the instance it's
+// installed in
+func runUnitCommand(action, name string) error {
+	cmd, err := installedSystemctl(unitFile(name), action, name)
+	if err != nil {
+		return err
+	}
+	if err := oplog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to %s %s: %w", action, name, err)
+	}
+	return nil
+}
+
+// IsUnitActive reports whether a systemd unit is running
+func IsUnitActive(name string) bool {
+	userScope, err := installedScope(unitFile(name))
+	if err != nil {
+		// Installed system-wide: anyone may read its state
+		userScope = false
+	}
+	return scopedSystemctl(userScope, "is-active", "--quiet", name).Run() == nil
+}
+
+// unitFile returns the file name of a unit, defaulting to a .service
+func unitFile(name string) string {
+	for _, suffix := range []string{".service", ".timer", ".socket", ".target"} {
+		if strings.HasSuffix(name, suffix) {
+			return name
+		}
+	}
+	return name + ".service"
+}
+
+// prepareUnit fills in what every unit PHPark installs gets: its user, and
+// the PHPark home this run uses, so units running phppark look for its state
+// where this run did
+func prepareUnit(u *Unit) error {
+	if err := setUnitUser(u); err != nil {
+		return err
+	}
+	if home := os.Getenv(config.HomeEnv); home != "" {
+		u.Environment = append(u.Environment, config.HomeEnv+"="+home)
+	}
+	return nil
+}
+
+// setUnitUser defaults a system unit to the invoking user. User units
+// always run as their owner and may not set User=, so a unit that needs
+// root is refused rather than installed to fail at runtime.
+func setUnitUser(u *Unit) error {
+	if !UserScope() {
+		if u.User == "" {
+			u.User = InvokingUser()
+		}
+		return nil
+	}
+	if u.User == "root" {
+		return i18n.Errorf(i18n.RootUnit, u.Name)
+	}
+	u.User = ""
+	return nil
+}
+
+// IsServiceActive reports whether a system service (e.g., a distro's
+// nginx or dnsmasq) is running, regardless of PHPark's unit scope
+func IsServiceActive(name string) bool {
+	return exec.Command("systemctl", "is-active", "--quiet", name).Run() == nil
+}
+
+// ServiceState is what systemd knows about a system service
+type ServiceState struct {
+	Loaded     bool   // False when no such unit is installed
+	Active     string // ActiveState: active, failed, inactive, activating, ...
+	Sub        string // SubState: running, dead, exited, ...
+	Result     string // How the last run ended: success, exit-code, signal, ...
+	ExitStatus string // Exit code (or signal number) of the last main process
+	Since      string // When it entered its current state
+	UserScope  bool   // Read from the user's systemd instance
+}
+
+// ServiceStatus reads a system service's state from `systemctl show`
+func ServiceStatus(name string) (*ServiceState, error) {
+	return scopedServiceStatus(false, name)
+}
+
+// UnitStatus reads the state of a unit PHPark installed, from the system
+// instance or the user's, whichever it was installed in
+func UnitStatus(name string) (*ServiceState, error) {
+	userScope, err := installedScope(unitFile(name))
+	if err != nil {
+		// Installed system-wide: anyone may read its state
+		userScope = false
+	}
+	return scopedServiceStatus(userScope, name)
+}
+
+func scopedServiceStatus(userScope bool, name string) (*ServiceState, error) {
+	output, err := scopedSystemctl(userScope, "show", name,
+		"--property=LoadState,ActiveState,SubState,Result,ExecMainStatus,StateChangeTimestamp").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s state: %w", name, err)
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			props[key] = value
+		}
+	}
+
+	return &ServiceState{
+		Loaded:     props["LoadState"] == "loaded",
+		Active:     props["ActiveState"],
+		Sub:        props["SubState"],
+		Result:     props["Result"],
+		ExitStatus: props["ExecMainStatus"],
+		Since:      props["StateChangeTimestamp"],
+		UserScope:  userScope,
+	}, nil
+}
+
+// InvokingUser returns the user who ran phppark, looking through sudo
+func InvokingUser() string {
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		return sudoUser
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// UnitSafe replaces characters systemd does not allow in unit names, so
+// site names (which come from directory names) can be embedded safely
+func UnitSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// escapeSpecifiers doubles % so systemd doesn't read a site path or name
+// as a specifier (e.g., %h)
+func escapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// quoteExec joins a command for ExecStart, quoting arguments with spaces.
+// % and $ are doubled so systemd passes them through rather than
+// expanding them as specifiers and variables before the command sees them.
+func quoteExec(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(escapeSpecifiers(arg), "$", "$$")
+		if strings.ContainsAny(arg, " \t\"'") {
+			quoted[i] = fmt.Sprintf("%q", arg)
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
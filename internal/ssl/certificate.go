@@ -1,6 +1,10 @@
 package ssl
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -10,17 +14,113 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/stevepop/phppark/internal/oplog"
 )
 
+// KeyDirName is the subdirectory of the certificates directory holding
+// private keys, readable by their owner only
+const KeyDirName = "private"
+
 // CertificatePaths holds paths to certificate files
 type CertificatePaths struct {
 	CertFile string // .crt file
 	KeyFile  string // .key file
 }
 
-// GenerateSelfSignedCert generates a self-signed SSL certificate
-func GenerateSelfSignedCert(siteName, domain, certDir string) (*CertificatePaths, error) {
+// CertPath is where a site's certificate lives
+func CertPath(siteName, certDir string) string {
+	return filepath.Join(certDir, siteName+".crt")
+}
+
+// KeyPath is where a site's private key lives. Keys are kept apart from
+// certificates so the one directory holding them can be locked down, and
+// left out of backups that sweep up ~/.phppark.
+func KeyPath(siteName, certDir string) string {
+	return filepath.Join(certDir, KeyDirName, siteName+".key")
+}
+
+// legacyKeyPath is where keys lived before they got their own directory
+func legacyKeyPath(siteName, certDir string) string {
+	return filepath.Join(certDir, siteName+".key")
+}
+
+// ensureKeyDir creates the private key directory, and tightens it if it
+// already exists with looser permissions
+func ensureKeyDir(certDir string) error {
+	dir := filepath.Join(certDir, KeyDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0700)
+}
+
+// legacyKey reports whether a site's key is still a file beside its
+// certificate, rather than a link to the private key directory
+func legacyKey(siteName, certDir string) bool {
+	info, err := os.Lstat(legacyKeyPath(siteName, certDir))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// MigrateKey moves a site's private key from beside its certificate into
+// the private key directory, readable by its owner only. A link is left
+// in its place so web server configs deployed before the move keep
+// working until they're rebuilt. It does nothing if the key has already
+// moved, or there's no key.
+func MigrateKey(siteName, certDir string) error {
+	if !legacyKey(siteName, certDir) {
+		return nil
+	}
+	legacy := legacyKeyPath(siteName, certDir)
+
+	if err := ensureKeyDir(certDir); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.Chmod(legacy, 0600); err != nil {
+		return fmt.Errorf("failed to protect key: %w", err)
+	}
+
+	keyPath := KeyPath(siteName, certDir)
+	if err := os.Rename(legacy, keyPath); err != nil {
+		return fmt.Errorf("failed to move key: %w", err)
+	}
+	oplog.Record(oplog.Entry{Op: "write", Target: keyPath})
+
+	// Relative, so the link also resolves where the directory is mounted
+	// elsewhere (e.g., in the docker backend's containers)
+	if err := oplog.Symlink(filepath.Join(KeyDirName, siteName+".key"), legacy); err != nil {
+		return fmt.Errorf("failed to link moved key: %w", err)
+	}
+	return nil
+}
+
+// MigrateKeys moves every key still beside its certificate into the
+// private key directory, returning the sites whose key moved
+func MigrateKeys(certDir string) ([]string, error) {
+	candidates, err := filepath.Glob(filepath.Join(certDir, "*.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []string
+	for _, path := range candidates {
+		siteName := strings.TrimSuffix(filepath.Base(path), ".key")
+		if !legacyKey(siteName, certDir) {
+			continue
+		}
+		if err := MigrateKey(siteName, certDir); err != nil {
+			return moved, fmt.Errorf("%s: %w", siteName, err)
+		}
+		moved = append(moved, siteName)
+	}
+	return moved, nil
+}
+
+// GenerateSelfSignedCert generates a self-signed SSL certificate for
+// <siteName>.<domain>, also valid for any aliases the site answers to
+func GenerateSelfSignedCert(siteName, domain, certDir string, aliases ...string) (*CertificatePaths, error) {
 	// Ensure certificate directory exists
 	if err := os.MkdirAll(certDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create certificate directory: %w", err)
@@ -53,7 +153,7 @@ func GenerateSelfSignedCert(siteName, domain, certDir string) (*CertificatePaths
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
-		DNSNames:              []string{serverName, "localhost"},
+		DNSNames:              append([]string{serverName, "localhost"}, aliases...),
 		IPAddresses:           nil,
 	}
 
@@ -63,69 +163,264 @@ func GenerateSelfSignedCert(siteName, domain, certDir string) (*CertificatePaths
 		return nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
 
-	// Certificate file path
-	certPath := filepath.Join(certDir, siteName+".crt")
-	certFile, err := os.Create(certPath)
+	return writeCertificate(siteName, certDir, certBytes, privateKey)
+}
+
+// RenewCertificate replaces a site's self-signed certificate with a new one
+// for the same names (SANs), with a fresh key of the same type and size,
+// valid for as long as the old one was
+func RenewCertificate(siteName, certDir string) (*CertificatePaths, error) {
+	old, err := loadCertificate(siteName, certDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create certificate file: %w", err)
+		return nil, err
+	}
+	if !bytes.Equal(old.RawIssuer, old.RawSubject) {
+		return nil, fmt.Errorf("the certificate for %s isn't self-signed, so it has to be renewed by whoever issued it", siteName)
 	}
-	defer certFile.Close()
 
-	// Write certificate
-	if err := pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: certBytes}); err != nil {
-		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	if err := MigrateKey(siteName, certDir); err != nil {
+		return nil, err
+	}
+	keyData, err := os.ReadFile(KeyPath(siteName, certDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	oldKey, err := parsePrivateKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := newKeyLike(oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	// Private key file path
-	keyPath := filepath.Join(certDir, siteName+".key")
-	keyFile, err := os.Create(keyPath)
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create key file: %w", err)
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
 	}
-	defer keyFile.Close()
 
-	// Write private key
-	privateKeyBytes := x509.MarshalPKCS1PrivateKey(privateKey)
-	if err := pem.Encode(keyFile, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: privateKeyBytes}); err != nil {
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               old.Subject,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(old.NotAfter.Sub(old.NotBefore)),
+		KeyUsage:              old.KeyUsage,
+		ExtKeyUsage:           old.ExtKeyUsage,
+		BasicConstraintsValid: old.BasicConstraintsValid,
+		IsCA:                  old.IsCA,
+		DNSNames:              old.DNSNames,
+		IPAddresses:           old.IPAddresses,
+		EmailAddresses:        old.EmailAddresses,
+		URIs:                  old.URIs,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, privateKey.Public(), privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	return writeCertificate(siteName, certDir, certBytes, privateKey)
+}
+
+// writeCertificate writes a site's certificate and private key. Each file is
+// written to a temporary name and renamed into place, so the web server
+// never reads a half-written one.
+func writeCertificate(siteName, certDir string, certBytes []byte, privateKey crypto.Signer) (*CertificatePaths, error) {
+	keyBlock, err := marshalPrivateKey(privateKey)
+	if err != nil {
 		return nil, fmt.Errorf("failed to encode private key: %w", err)
 	}
 
-	// Set permissions (private key should be read-only by owner)
-	if err := os.Chmod(keyPath, 0600); err != nil {
-		return nil, fmt.Errorf("failed to set key permissions: %w", err)
+	certPath := CertPath(siteName, certDir)
+	keyPath := KeyPath(siteName, certDir)
+
+	// Private key should be read-only by owner, in a directory only they
+	// can list. An old key beside the certificate moves there first, so
+	// the link left in its place points at the new key.
+	if err := ensureKeyDir(certDir); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := MigrateKey(siteName, certDir); err != nil {
+		return nil, err
+	}
+	if err := writePEM(keyPath, keyBlock, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := writePEM(certPath, &pem.Block{Type: "CERTIFICATE", Bytes: certBytes}, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write certificate file: %w", err)
 	}
 
+	oplog.Record(oplog.Entry{Op: "write", Target: certPath})
+	oplog.Record(oplog.Entry{Op: "write", Target: keyPath})
+
 	return &CertificatePaths{
 		CertFile: certPath,
 		KeyFile:  keyPath,
 	}, nil
 }
 
-// CertificateExists checks if certificates exist for a site
-func CertificateExists(siteName, certDir string) bool {
-	certPath := filepath.Join(certDir, siteName+".crt")
-	keyPath := filepath.Join(certDir, siteName+".key")
+// writePEM atomically writes a PEM block to a file
+func writePEM(path string, block *pem.Block, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := pem.Encode(tmp, block); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parsePrivateKey reads a PEM private key in any of the usual encodings
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no private key found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// newKeyLike generates a key of the same type and size as another
+func newKeyLike(key crypto.Signer) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return rsa.GenerateKey(rand.Reader, k.N.BitLen())
+	case *ecdsa.PrivateKey:
+		return ecdsa.GenerateKey(k.Curve, rand.Reader)
+	case ed25519.PrivateKey:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// marshalPrivateKey encodes a key the way OpenSSL would write it
+func marshalPrivateKey(key crypto.Signer) (*pem.Block, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+	}
+}
 
-	_, certErr := os.Stat(certPath)
-	_, keyErr := os.Stat(keyPath)
+// CertificateExists checks if certificates exist for a site, with the key
+// in either the private key directory or, not yet migrated, beside the
+// certificate
+func CertificateExists(siteName, certDir string) bool {
+	_, certErr := os.Stat(CertPath(siteName, certDir))
+	_, keyErr := os.Stat(KeyPath(siteName, certDir))
+	if os.IsNotExist(keyErr) {
+		_, keyErr = os.Stat(legacyKeyPath(siteName, certDir))
+	}
 
 	return certErr == nil && keyErr == nil
 }
 
 // RemoveCertificate removes certificate files for a site
 func RemoveCertificate(siteName, certDir string) error {
-	certPath := filepath.Join(certDir, siteName+".crt")
-	keyPath := filepath.Join(certDir, siteName+".key")
-
 	// Remove certificate file
-	if err := os.Remove(certPath); err != nil && !os.IsNotExist(err) {
+	if err := oplog.Remove(CertPath(siteName, certDir)); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove certificate: %w", err)
 	}
 
-	// Remove key file
-	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove key: %w", err)
+	// Remove key file, and the old one or the link left in its place
+	for _, keyPath := range []string{KeyPath(siteName, certDir), legacyKeyPath(siteName, certDir)} {
+		if err := oplog.Remove(keyPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove key: %w", err)
+		}
 	}
 
 	return nil
 }
+
+// CertificateExpiry returns when a site's certificate stops being valid
+func CertificateExpiry(siteName, certDir string) (time.Time, error) {
+	cert, err := loadCertificate(siteName, certDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// loadCertificate reads and parses a site's certificate
+func loadCertificate(siteName, certDir string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(CertPath(siteName, certDir))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no certificate found for %s", siteName)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// CoversNames reports whether a site's certificate is valid for all of
+// names, so one made before a name was added can be replaced
+func CoversNames(siteName, certDir string, names []string) bool {
+	cert, err := loadCertificate(siteName, certDir)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// SelfSigned reports whether a site's certificate signs itself, rather than
+// being issued by a certificate authority browsers could trust
+func SelfSigned(siteName, certDir string) (bool, error) {
+	cert, err := loadCertificate(siteName, certDir)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil, nil
+}
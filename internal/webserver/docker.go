@@ -0,0 +1,139 @@
+package webserver
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"sort"
+
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/docker"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// newDockerStack builds the container stack for the registered sites
+func newDockerStack() (*docker.Stack, error) {
+	paths, err := config.GetPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := config.LoadSites()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sites: %w", err)
+	}
+
+	// Sorted so the mount list (and so the container) only changes when
+	// sites do
+	seen := make(map[string]bool)
+	var sitePaths []string
+	for _, site := range sites.ListSites() {
+		dirs := []string{site.Path}
+		for _, mount := range site.Mounts {
+			dirs = append(dirs, mount.Dir)
+		}
+		for _, dir := range dirs {
+			if !seen[dir] {
+				seen[dir] = true
+				sitePaths = append(sitePaths, dir)
+			}
+		}
+	}
+	sort.Strings(sitePaths)
+
+	stack := &docker.Stack{
+		Client:   docker.NewClient(docker.DefaultSocket),
+		Dir:      paths.Docker,
+		NginxDir: paths.Nginx,
+		CertDir:  paths.Certificates,
+		Sites:    sitePaths,
+	}
+
+	// Run PHP as the invoking user so sites can write their own files
+	if u, err := user.Lookup(services.InvokingUser()); err == nil {
+		stack.UID, stack.GID = u.Uid, u.Gid
+	}
+
+	if err := stack.Client.Ping(); err != nil {
+		return nil, err
+	}
+
+	return stack, nil
+}
+
+// dockerServer serves sites with nginx running in a container, managed
+// through the Docker API
+type dockerServer struct {
+	stack *docker.Stack
+}
+
+func (dockerServer) Name() string { return "docker" }
+
+func (dockerServer) ConfigPath(paths *config.Paths, siteName string) string {
+	return filepath.Join(paths.Nginx, siteName+".conf")
+}
+
+// DeployedPath is ConfigPath: the config directory is mounted into the container
+func (s dockerServer) DeployedPath(paths *config.Paths, siteName string) string {
+	return s.ConfigPath(paths, siteName)
+}
+
+func (dockerServer) Generate(cfg *nginx.SiteConfig) (string, error) {
+	return nginx.GenerateConfig(cfg)
+}
+
+// Deploy needs no copy: the config directory is mounted into the container
+func (s dockerServer) Deploy(siteName, configPath string) error {
+	if _, err := s.Stage(siteName, configPath); err != nil {
+		return err
+	}
+
+	if err := s.Test(); err != nil {
+		return err
+	}
+
+	return s.stack.ReloadNginx()
+}
+
+func (s dockerServer) Stage(siteName, configPath string) (services.Rollback, error) {
+	if err := s.stack.EnsureNginx(); err != nil {
+		return nil, err
+	}
+	return removeOnRollback(configPath), nil
+}
+
+func (s dockerServer) Test() error {
+	if err := s.stack.TestNginx(); err != nil {
+		return fmt.Errorf("nginx config test failed: %w", err)
+	}
+	return nil
+}
+
+// Unstage has nothing to do: removing the site's file from the mounted
+// config directory already takes it out of nginx
+func (dockerServer) Unstage(siteName string) error { return nil }
+
+// Disable sets the site's config aside in the mounted directory, out of
+// conf.d/*.conf
+func (s dockerServer) Disable(siteName string) error {
+	return services.SetAsideConfig(filepath.Join(s.stack.NginxDir, siteName+".conf"))
+}
+
+func (s dockerServer) Enable(siteName string) error {
+	return services.RestoreConfig(filepath.Join(s.stack.NginxDir, siteName+".conf"))
+}
+
+func (s dockerServer) Remove(siteName string) error {
+	if err := s.stack.TestNginx(); err != nil {
+		return fmt.Errorf("nginx config test failed: %w", err)
+	}
+
+	return s.stack.ReloadNginx()
+}
+
+func (s dockerServer) Start() error  { return s.stack.EnsureNginx() }
+func (s dockerServer) Stop() error   { return s.stack.Client.Stop(docker.NginxContainer) }
+func (s dockerServer) Reload() error { return s.stack.ReloadNginx() }
+func (dockerServer) EmbedsPHP() bool { return false }
+func (s dockerServer) Running() bool { return s.stack.Running(docker.NginxContainer) }
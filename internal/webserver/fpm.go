@@ -0,0 +1,71 @@
+package webserver
+
+import (
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/docker"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// FPM manages the PHP-FPM pools that serve sites
+type FPM interface {
+	// Start starts PHP-FPM for a version if it isn't running
+	Start(version string) error
+
+	// Stop stops PHP-FPM for a version
+	Stop(version string) error
+
+	// EnableStatus exposes the pool's status and ping pages
+	EnableStatus(version string) error
+
+	// DisableStatus hides them again
+	DisableStatus(version string) error
+
+	// Running reports whether PHP-FPM for a version is up
+	Running(version string) bool
+}
+
+// NewFPM returns the PHP-FPM manager for the config's backend: the
+// distro's systemd services, or containers with the docker backend
+func NewFPM(cfg *config.Config) (FPM, error) {
+	if cfg.Backend != "docker" {
+		return systemdFPM{}, nil
+	}
+
+	stack, err := newDockerStack()
+	if err != nil {
+		return nil, err
+	}
+	return dockerFPM{stack: stack}, nil
+}
+
+// systemdFPM manages the distro's php<version>-fpm services
+type systemdFPM struct{}
+
+func (systemdFPM) Start(version string) error         { return services.StartPHPFPM(version) }
+func (systemdFPM) Stop(version string) error          { return services.StopPHPFPM(version) }
+func (systemdFPM) EnableStatus(version string) error  { return services.EnableFPMStatus(version) }
+func (systemdFPM) DisableStatus(version string) error { return services.DisableFPMStatus(version) }
+func (systemdFPM) Running(version string) bool        { return services.IsPHPFPMRunning(version) }
+
+// dockerFPM runs each PHP version from the official php:<version>-fpm image
+type dockerFPM struct {
+	stack *docker.Stack
+}
+
+func (f dockerFPM) Start(version string) error {
+	return f.stack.EnsureFPM(version)
+}
+
+func (f dockerFPM) Stop(version string) error {
+	return f.stack.Client.Stop(docker.FPMContainer(version))
+}
+
+func (f dockerFPM) Running(version string) bool {
+	return f.stack.Running(docker.FPMContainer(version))
+}
+
+// EnableStatus is a no-op: container pools always serve their status page
+func (dockerFPM) EnableStatus(version string) error { return nil }
+
+// DisableStatus is a no-op: only the web server config exposes the page
+func (dockerFPM) DisableStatus(version string) error { return nil }
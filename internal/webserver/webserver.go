@@ -0,0 +1,280 @@
+package webserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stevepop/phppark/internal/apache"
+	"github.com/stevepop/phppark/internal/config"
+	"github.com/stevepop/phppark/internal/frankenphp"
+	"github.com/stevepop/phppark/internal/nginx"
+	"github.com/stevepop/phppark/internal/oplog"
+	"github.com/stevepop/phppark/internal/services"
+)
+
+// Server is a web server backend that serves PHPark sites
+type Server interface {
+	// Name is the backend name used in config.yaml (e.g., "nginx")
+	Name() string
+
+	// ConfigPath is where PHPark writes the generated config for a site
+	ConfigPath(paths *config.Paths, siteName string) string
+
+	// DeployedPath is the config file the server actually reads for a
+	// site (the same as ConfigPath for servers that read it in place)
+	DeployedPath(paths *config.Paths, siteName string) string
+
+	// Generate renders a site's config
+	Generate(cfg *nginx.SiteConfig) (string, error)
+
+	// Deploy installs a generated config, tests it and reloads the server
+	Deploy(siteName, configPath string) error
+
+	// Stage installs a generated config without testing or reloading, so
+	// many sites can share one Test and Reload. The returned Rollback
+	// undoes it.
+	Stage(siteName, configPath string) (services.Rollback, error)
+
+	// Test checks the installed configuration
+	Test() error
+
+	// Remove uninstalls a site's config and reloads the server
+	Remove(siteName string) error
+
+	// Unstage uninstalls a site's config without testing or reloading, so
+	// many sites can be removed with one Test and Reload
+	Unstage(siteName string) error
+
+	// Disable takes a site offline but leaves its config installed, without
+	// testing or reloading
+	Disable(siteName string) error
+
+	// Enable puts a disabled site's config back in service, without testing
+	// or reloading
+	Enable(siteName string) error
+
+	// Start starts the server if it isn't running
+	Start() error
+
+	// Stop stops the server
+	Stop() error
+
+	// Reload reloads the server configuration
+	Reload() error
+
+	// Running reports whether the server is up
+	Running() bool
+
+	// EmbedsPHP reports whether the server runs PHP itself, so sites
+	// don't need PHP-FPM
+	EmbedsPHP() bool
+}
+
+// New returns the Server selected by the config's web_server and backend
+// settings. An empty web server means nginx.
+func New(cfg *config.Config) (Server, error) {
+	if cfg.Backend == "docker" {
+		if cfg.WebServer != "" && cfg.WebServer != "nginx" {
+			return nil, fmt.Errorf("the docker backend only supports nginx (web_server: %s)", cfg.WebServer)
+		}
+
+		stack, err := newDockerStack()
+		if err != nil {
+			return nil, err
+		}
+		return dockerServer{stack: stack}, nil
+	}
+
+	switch cfg.WebServer {
+	case "", "nginx":
+		return nginxServer{}, nil
+	case "apache":
+		return apacheServer{layout: services.DetectApacheLayout()}, nil
+	case "frankenphp":
+		paths, err := config.GetPaths()
+		if err != nil {
+			return nil, err
+		}
+		return frankenphpServer{dir: paths.FrankenPHP, logDir: paths.Logs}, nil
+	default:
+		return nil, fmt.Errorf("unknown web server %q (use nginx, apache or frankenphp)", cfg.WebServer)
+	}
+}
+
+// nginxServer serves sites with nginx
+type nginxServer struct{}
+
+func (nginxServer) Name() string { return "nginx" }
+
+func (nginxServer) ConfigPath(paths *config.Paths, siteName string) string {
+	return filepath.Join(paths.Nginx, siteName+".conf")
+}
+
+func (nginxServer) DeployedPath(paths *config.Paths, siteName string) string {
+	return services.NginxConfigPath(siteName)
+}
+
+func (nginxServer) Generate(cfg *nginx.SiteConfig) (string, error) {
+	return nginx.GenerateConfig(cfg)
+}
+
+func (nginxServer) Deploy(siteName, configPath string) error {
+	return services.DeployNginxConfig(siteName, configPath)
+}
+
+func (nginxServer) Stage(siteName, configPath string) (services.Rollback, error) {
+	return services.StageNginxConfig(siteName, configPath)
+}
+
+func (nginxServer) Test() error                   { return services.TestNginxConfig() }
+func (nginxServer) Remove(siteName string) error  { return services.RemoveNginxConfig(siteName) }
+func (nginxServer) Unstage(siteName string) error { return services.UnstageNginxConfig(siteName) }
+func (nginxServer) Disable(siteName string) error { return services.DisableNginxConfig(siteName) }
+func (nginxServer) Enable(siteName string) error  { return services.EnableNginxConfig(siteName) }
+func (nginxServer) Start() error                  { return services.StartNginx() }
+func (nginxServer) Stop() error                   { return services.StopNginx() }
+func (nginxServer) Reload() error                 { return services.ReloadNginx() }
+func (nginxServer) EmbedsPHP() bool               { return false }
+func (nginxServer) Running() bool                 { return services.IsNginxRunning() }
+
+// apacheServer serves sites with Apache httpd and mod_proxy_fcgi
+type apacheServer struct {
+	layout *services.ApacheLayout
+}
+
+func (apacheServer) Name() string { return "apache" }
+
+func (apacheServer) ConfigPath(paths *config.Paths, siteName string) string {
+	return filepath.Join(paths.Apache, siteName+".conf")
+}
+
+func (s apacheServer) DeployedPath(paths *config.Paths, siteName string) string {
+	return s.layout.ConfigPath(siteName)
+}
+
+func (s apacheServer) Generate(cfg *nginx.SiteConfig) (string, error) {
+	return apache.GenerateConfig(cfg, s.layout.LogDir)
+}
+
+func (apacheServer) Deploy(siteName, configPath string) error {
+	return services.DeployApacheConfig(siteName, configPath)
+}
+
+func (apacheServer) Stage(siteName, configPath string) (services.Rollback, error) {
+	return services.StageApacheConfig(siteName, configPath)
+}
+
+func (apacheServer) Test() error                   { return services.TestApacheConfig() }
+func (apacheServer) Remove(siteName string) error  { return services.RemoveApacheConfig(siteName) }
+func (apacheServer) Unstage(siteName string) error { return services.UnstageApacheConfig(siteName) }
+func (apacheServer) Disable(siteName string) error { return services.DisableApacheConfig(siteName) }
+func (apacheServer) Enable(siteName string) error  { return services.EnableApacheConfig(siteName) }
+func (apacheServer) Start() error                  { return services.StartApache() }
+func (apacheServer) Stop() error                   { return services.StopApache() }
+func (apacheServer) Reload() error                 { return services.ReloadApache() }
+func (apacheServer) EmbedsPHP() bool               { return false }
+func (apacheServer) Running() bool                 { return services.IsApacheRunning() }
+
+// frankenphpServer serves sites with a PHPark-managed FrankenPHP process.
+// Each site is a Caddyfile entry in dir/sites imported by dir/Caddyfile.
+type frankenphpServer struct {
+	dir    string
+	logDir string
+}
+
+func (frankenphpServer) Name() string { return "frankenphp" }
+
+func (s frankenphpServer) sitesDir() string {
+	return filepath.Join(s.dir, "sites")
+}
+
+func (s frankenphpServer) ConfigPath(paths *config.Paths, siteName string) string {
+	return filepath.Join(s.sitesDir(), siteName+".caddy")
+}
+
+func (s frankenphpServer) DeployedPath(paths *config.Paths, siteName string) string {
+	return s.ConfigPath(paths, siteName)
+}
+
+func (s frankenphpServer) Generate(cfg *nginx.SiteConfig) (string, error) {
+	return frankenphp.GenerateConfig(cfg, s.logDir)
+}
+
+func (s frankenphpServer) Deploy(siteName, configPath string) error {
+	if _, err := s.Stage(siteName, configPath); err != nil {
+		return err
+	}
+
+	if err := s.Test(); err != nil {
+		return err
+	}
+
+	return services.ReloadFrankenPHP(s.dir)
+}
+
+// Stage makes sure the main Caddyfile imports the sites directory. The site
+// entry is already live there, so rolling back takes the site offline
+// rather than letting it break every other site.
+func (s frankenphpServer) Stage(siteName, configPath string) (services.Rollback, error) {
+	caddyfile, err := frankenphp.GenerateMainConfig(s.sitesDir())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := services.InstallFrankenPHP(s.dir, caddyfile); err != nil {
+		return nil, fmt.Errorf("failed to install frankenphp: %w", err)
+	}
+
+	return removeOnRollback(configPath), nil
+}
+
+func (s frankenphpServer) Test() error { return services.ValidateFrankenPHPConfig(s.dir) }
+
+func (s frankenphpServer) Remove(siteName string) error {
+	if err := s.Unstage(siteName); err != nil {
+		return err
+	}
+
+	return services.ReloadFrankenPHP(s.dir)
+}
+
+func (s frankenphpServer) Unstage(siteName string) error {
+	path := filepath.Join(s.sitesDir(), siteName+".caddy")
+	if err := oplog.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove config: %w", err)
+	}
+	return nil
+}
+
+// Disable sets the site's entry aside, out of the import glob
+func (s frankenphpServer) Disable(siteName string) error {
+	return services.SetAsideConfig(filepath.Join(s.sitesDir(), siteName+".caddy"))
+}
+
+func (s frankenphpServer) Enable(siteName string) error {
+	return services.RestoreConfig(filepath.Join(s.sitesDir(), siteName+".caddy"))
+}
+
+func (frankenphpServer) Start() error {
+	if services.IsUnitActive(services.FrankenPHPUnit) {
+		return nil
+	}
+	return services.StartUnit(services.FrankenPHPUnit)
+}
+
+func (frankenphpServer) Stop() error     { return services.StopUnit(services.FrankenPHPUnit) }
+func (s frankenphpServer) Reload() error { return services.ReloadFrankenPHP(s.dir) }
+func (frankenphpServer) EmbedsPHP() bool { return true }
+func (frankenphpServer) Running() bool   { return services.IsUnitActive(services.FrankenPHPUnit) }
+
+// removeOnRollback is the Rollback for servers that read PHPark's generated
+// config directly: the only way to back out a bad site is to remove it
+func removeOnRollback(configPath string) services.Rollback {
+	return func() error {
+		if err := oplog.Remove(configPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+}